@@ -85,6 +85,15 @@ func newRunCmd() *cobra.Command {
 	// Labels flags.
 	cmd.Flags().StringVar(&opts.Label, "label", "",
 		"Label attached to the agent for scheduling constraints")
+	cmd.Flags().StringVar(&opts.Topology, "topology", "",
+		"Network topology domain of the agent, from the widest to the narrowest level "+
+			"separated by slashes (e.g., zone-a/rack-3)")
+
+	// Reconnection flags.
+	cmd.Flags().IntVar(&opts.ReconnectAttempts, "reconnect-attempts", 12,
+		"Number of times to try to reconnect to the master before shutting down")
+	cmd.Flags().IntVar(&opts.ReconnectBackoff, "reconnect-backoff", 5,
+		"Seconds to wait between attempts to reconnect to the master")
 
 	// ResourcePool flags.
 	cmd.Flags().StringVar(&opts.ResourcePool, "resource-pool", "",
@@ -147,6 +156,8 @@ func newRunCmd() *cobra.Command {
 		"Docker image to use for the managed Fluent Bit daemon")
 	cmd.Flags().IntVar(&opts.Fluent.Port, "fluent-port", 24224,
 		"TCP port for the Fluent Bit daemon to listen on")
+	cmd.Flags().IntVar(&opts.Fluent.MetricsPort, "fluent-metrics-port", 2020,
+		"TCP port for the monitoring API of the Fluent Bit daemon to listen on")
 
 	return cmd
 }
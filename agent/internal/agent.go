@@ -13,18 +13,21 @@ import (
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/actor/api"
 	proto "github.com/determined-ai/determined/master/pkg/agent"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
 	"github.com/determined-ai/determined/master/pkg/device"
 	"github.com/determined-ai/determined/master/pkg/logger"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -47,8 +50,25 @@ type agent struct {
 
 	masterProto  string
 	masterClient *http.Client
+
+	masterConnected bool
+	// containers holds the latest state change of each container, which the agent reports to the
+	// master when it reconnects. Terminated containers are removed once the master is told.
+	containers        map[cproto.ID]proto.ContainerStateChanged
+	reconnectAttempts int
+	// heldLogs holds the container logs that were written while the agent was disconnected from
+	// the master, up to maxHeldLogs, which are sent once the agent reconnects.
+	heldLogs    []proto.ContainerLog
+	droppedLogs int
 }
 
+// maxHeldLogs is the most container logs that the agent holds while it is disconnected from the
+// master; older logs are dropped first.
+const maxHeldLogs = 10000
+
+// reconnectToMaster asks the agent to try to reconnect to the master.
+type reconnectToMaster struct{}
+
 func (a *agent) addProxy(config *container.Config) {
 	addVars := map[string]string{
 		"HTTP_PROXY":  a.Options.HTTPProxy,
@@ -93,22 +113,54 @@ func (a *agent) Receive(ctx *actor.Context) error {
 		}
 
 	case proto.ContainerStateChanged:
-		if a.socket != nil {
+		a.containers[msg.Container.ID] = msg
+		switch {
+		case a.socket != nil:
 			ctx.Ask(a.socket, api.WriteMessage{Message: proto.MasterMessage{ContainerStateChanged: &msg}})
-		} else {
+		case a.MasterHost != "":
+			ctx.Log().Warnf("holding container state change until the agent reconnects: %s %s",
+				msg.Container.ID, msg.Container.State)
+			return nil
+		default:
 			ctx.Log().Warnf("Not sending container state change to the master: %+v", msg)
 		}
+		if msg.Container.State == cproto.Terminated {
+			delete(a.containers, msg.Container.ID)
+		}
 	case proto.ContainerLog:
-		if a.socket != nil {
+		switch {
+		case a.socket != nil:
 			ctx.Ask(a.socket, api.WriteMessage{Message: proto.MasterMessage{ContainerLog: &msg}})
+		case a.MasterHost != "":
+			a.holdLog(msg)
 		}
 
 	case model.TrialLog:
 		return a.postTrialLog(msg)
 
+	case getMasterConnected:
+		ctx.Respond(a.masterConnected)
+
+	case reconnectToMaster:
+		a.reconnectAttempts++
+		if err := a.connectToMaster(ctx); err != nil {
+			if a.reconnectAttempts >= a.ReconnectAttempts {
+				return errors.Wrapf(err, "failed to reconnect to master after %d attempts",
+					a.reconnectAttempts)
+			}
+			ctx.Log().WithError(err).Warnf("failed to reconnect to master (attempt %d of %d)",
+				a.reconnectAttempts, a.ReconnectAttempts)
+			actors.NotifyAfter(ctx, a.reconnectBackoff(), reconnectToMaster{})
+			return nil
+		}
+		masterReconnects.Inc()
+
 	case actor.ChildFailed:
 		switch msg.Child {
 		case a.socket:
+			if a.reconnect(ctx, msg.Error) {
+				return nil
+			}
 			ctx.Log().Warn("master socket disconnected, shutting down agent...")
 		case a.cm:
 			ctx.Log().Warn("container manager failed, shutting down agent...")
@@ -116,6 +168,9 @@ func (a *agent) Receive(ctx *actor.Context) error {
 		return errors.Wrapf(msg.Error, "unexpected child failure: %s", msg.Child.Address())
 
 	case actor.ChildStopped:
+		if msg.Child == a.socket && a.reconnect(ctx, nil) {
+			return nil
+		}
 		return errors.Errorf("unexpected child stopped: %s", msg.Child.Address())
 
 	case os.Signal:
@@ -262,11 +317,18 @@ func (a *agent) getMasterInfo() error {
 }
 
 func (a *agent) setup(ctx *actor.Context) error {
+	a.containers = make(map[cproto.ID]proto.ContainerStateChanged)
+
 	fluentActor, err := newFluentActor(ctx, a.Options)
 	if err != nil {
 		return errors.Wrap(err, "failed to start Fluent daemon")
 	}
 	a.fluent, _ = ctx.ActorOf("fluent", fluentActor)
+	agentMetrics.MustRegister(&fluentCollector{
+		docker:      fluentActor.docker,
+		containerID: fluentActor.containerID,
+		metricsPort: a.Fluent.MetricsPort,
+	})
 
 	ctx.Log().Infof("Determined agent %s (built with %s)", a.Version, runtime.Version())
 	actors.NotifyOnSignal(ctx, syscall.SIGINT, syscall.SIGTERM)
@@ -278,6 +340,7 @@ func (a *agent) setup(ctx *actor.Context) error {
 	for _, d := range a.Devices {
 		ctx.Log().Infof("\t%s", d.String())
 	}
+	agentMetrics.MustRegister(&deviceCollector{devices: a.Devices})
 
 	v, err := getNvidiaVersion()
 	if err != nil {
@@ -346,14 +409,69 @@ func (a *agent) connectToMaster(ctx *actor.Context) error {
 	}
 	ctx.Log().Infof("successfully connected to master")
 
+	a.setMasterConnected(true)
 	a.socket, _ = ctx.ActorOf("websocket", api.WrapSocket(conn, proto.AgentMessage{}, true))
 
+	// Report the containers that the agent has, so that a master that the agent reconnects to can
+	// reconcile them with the containers it expects.
 	started := proto.MasterMessage{AgentStarted: &proto.AgentStarted{
-		Version: a.Version, Devices: a.Devices, Label: a.Label}}
+		Version: a.Version, Devices: a.Devices, Label: a.Label, Topology: a.Topology}}
+	for id, sc := range a.containers {
+		started.AgentStarted.Containers = append(started.AgentStarted.Containers, sc)
+		if sc.Container.State == cproto.Terminated {
+			delete(a.containers, id)
+		}
+	}
 	ctx.Ask(a.socket, api.WriteMessage{Message: started})
+
+	if a.droppedLogs > 0 {
+		ctx.Log().Warnf("dropped %d container logs while disconnected from the master", a.droppedLogs)
+	}
+	for i := range a.heldLogs {
+		ctx.Ask(a.socket, api.WriteMessage{Message: proto.MasterMessage{ContainerLog: &a.heldLogs[i]}})
+	}
+	a.heldLogs, a.droppedLogs = nil, 0
 	return nil
 }
 
+// holdLog holds a container log until the agent reconnects to the master, dropping the oldest held
+// log if too many are held.
+func (a *agent) holdLog(log proto.ContainerLog) {
+	if len(a.heldLogs) >= maxHeldLogs {
+		a.heldLogs = a.heldLogs[1:]
+		a.droppedLogs++
+	}
+	a.heldLogs = append(a.heldLogs, log)
+}
+
+// reconnect handles the socket to the master being closed. It schedules attempts to reconnect and
+// returns true, unless reconnection is disabled.
+func (a *agent) reconnect(ctx *actor.Context, err error) bool {
+	a.setMasterConnected(false)
+	if a.ReconnectAttempts == 0 {
+		return false
+	}
+	ctx.Log().WithError(err).Warn("master socket disconnected, reconnecting...")
+	a.socket = nil
+	a.reconnectAttempts = 0
+	actors.NotifyAfter(ctx, a.reconnectBackoff(), reconnectToMaster{})
+	return true
+}
+
+func (a *agent) reconnectBackoff() time.Duration {
+	return time.Duration(a.ReconnectBackoff) * time.Second
+}
+
+// setMasterConnected records whether the agent is connected to the master.
+func (a *agent) setMasterConnected(connected bool) {
+	a.masterConnected = connected
+	value := 0.0
+	if connected {
+		value = 1
+	}
+	masterConnected.Set(value)
+}
+
 func (a *agent) postTrialLog(log model.TrialLog) error {
 	j, err := json.Marshal([]model.TrialLog{log})
 	if err != nil {
@@ -383,6 +501,9 @@ func runAPIServer(options Options, system *actor.System) error {
 	server.Pre(middleware.RemoveTrailingSlash())
 
 	server.Any("/*", api.Route(system, nil))
+	server.GET("/health", healthHandler(options, system))
+	server.GET("/prom/metrics",
+		echo.WrapHandler(promhttp.HandlerFor(agentMetrics, promhttp.HandlerOpts{})))
 	server.Any("/debug/pprof/*", echo.WrapHandler(http.HandlerFunc(pprof.Index)))
 	server.Any("/debug/pprof/cmdline", echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)))
 	server.Any("/debug/pprof/profile", echo.WrapHandler(http.HandlerFunc(pprof.Profile)))
@@ -1,10 +1,13 @@
 package internal
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/golang-collections/collections/set"
+
+	proto "github.com/determined-ai/determined/master/pkg/agent"
 )
 
 func TestNoAddProxy(t *testing.T) {
@@ -73,6 +76,25 @@ func TestAlreadyAddedProxy(t *testing.T) {
 	}
 }
 
+func TestHoldLog(t *testing.T) {
+	testAgent := agent{}
+
+	for i := 0; i < maxHeldLogs+2; i++ {
+		msg := fmt.Sprintf("%d", i)
+		testAgent.holdLog(proto.ContainerLog{AuxMessage: &msg})
+	}
+
+	if len(testAgent.heldLogs) != maxHeldLogs {
+		t.Errorf("Expected %d held logs but got: %d", maxHeldLogs, len(testAgent.heldLogs))
+	}
+	if testAgent.droppedLogs != 2 {
+		t.Errorf("Expected 2 dropped logs but got: %d", testAgent.droppedLogs)
+	}
+	if first := *testAgent.heldLogs[0].AuxMessage; first != "2" {
+		t.Errorf("Expected the oldest logs to be dropped but the first held log is: %s", first)
+	}
+}
+
 func compareSlices(env []string, ans []string) bool {
 	output := set.New()
 	correct := set.New()
@@ -38,6 +38,7 @@ type containerManager struct {
 
 	fluentPort int
 	docker     *client.Client
+	states     map[cproto.ID]cproto.State
 }
 
 func newContainerManager(a *agent, fluentPort int) (*containerManager, error) {
@@ -46,6 +47,7 @@ func newContainerManager(a *agent, fluentPort int) (*containerManager, error) {
 		Options:    a.Options,
 		Devices:    a.Devices,
 		fluentPort: fluentPort,
+		states:     make(map[cproto.ID]cproto.State),
 	}, nil
 }
 
@@ -94,7 +96,11 @@ func (c *containerManager) Receive(ctx *actor.Context) error {
 			dockerMasterLabel:        c.MasterInfo.MasterID,
 		}
 
-	case proto.ContainerLog, proto.ContainerStateChanged, model.TrialLog:
+	case proto.ContainerStateChanged:
+		c.updateState(msg.Container)
+		ctx.Tell(ctx.Self().Parent(), msg)
+
+	case proto.ContainerLog, model.TrialLog:
 		ctx.Tell(ctx.Self().Parent(), msg)
 
 	case proto.StartContainer:
@@ -128,6 +134,18 @@ func (c *containerManager) Receive(ctx *actor.Context) error {
 	return nil
 }
 
+// updateState records the state of the container and exports the number of containers in each
+// state.
+func (c *containerManager) updateState(cont cproto.Container) {
+	containerTransitions.WithLabelValues(string(cont.State)).Inc()
+	if cont.State == cproto.Terminated {
+		delete(c.states, cont.ID)
+	} else {
+		c.states[cont.ID] = cont.State
+	}
+	updateContainerMetrics(c.states)
+}
+
 func (c *containerManager) handleAPIRequest(ctx *actor.Context, apiCtx echo.Context) {
 	switch apiCtx.Request().Method {
 	case echo.GET:
@@ -17,13 +17,16 @@ import (
 	"github.com/determined-ai/determined/master/pkg/device"
 )
 
+// artificialBrand is the brand of the artificial devices that the agent exposes for testing.
+const artificialBrand = "Artificial"
+
 func (a *agent) detect() error {
 	switch {
 	case a.ArtificialSlots > 0:
 		for i := 0; i < a.ArtificialSlots; i++ {
 			id := uuid.New().String()
 			a.Devices = append(a.Devices, device.Device{
-				ID: i, Brand: "Artificial", UUID: id, Type: device.CPU})
+				ID: i, Brand: artificialBrand, UUID: id, Type: device.CPU})
 		}
 	case a.SlotType == "none":
 		a.Devices = []device.Device{}
@@ -86,6 +86,9 @@ func (d *dockerActor) Receive(ctx *actor.Context) error {
 }
 
 func (d *dockerActor) pullImage(ctx *actor.Context, msg pullImage) {
+	outcome := imagePullFailed
+	defer func(start time.Time) { observeImagePull(outcome, start) }(time.Now())
+
 	ref, err := reference.ParseNormalizedNamed(msg.Name)
 	if err != nil {
 		sendErr(ctx, errors.Wrapf(err, "error parsing image name: %s", msg.Name))
@@ -113,6 +116,7 @@ func (d *dockerActor) pullImage(ctx *actor.Context, msg pullImage) {
 		}
 	case err == nil:
 		d.sendAuxLog(ctx, fmt.Sprintf("image already found, skipping pull phase: %s", ref.String()))
+		outcome = imagePullCached
 		ctx.Tell(ctx.Sender(), imagePulled{})
 		return
 	case client.IsErrNotFound(err):
@@ -162,10 +166,12 @@ func (d *dockerActor) pullImage(ctx *actor.Context, msg pullImage) {
 		sendErr(ctx, errors.Wrap(err, "error closing log stream"))
 		return
 	}
+	outcome = imagePullPulled
 	ctx.Tell(ctx.Sender(), imagePulled{})
 }
 
 func (d *dockerActor) runContainer(ctx *actor.Context, msg container.RunSpec) {
+	start := time.Now()
 	response, err := d.ContainerCreate(
 		context.Background(), &msg.ContainerConfig, &msg.HostConfig, &msg.NetworkingConfig, "")
 	if err != nil {
@@ -212,6 +218,7 @@ func (d *dockerActor) runContainer(ctx *actor.Context, msg container.RunSpec) {
 		sendErr(ctx, errors.Wrap(err, "error inspecting container"))
 		return
 	}
+	containerStartSeconds.Observe(time.Since(start).Seconds())
 
 	ctx.Tell(
 		ctx.Sender(),
@@ -84,10 +84,14 @@ end
   # Flush every .05 seconds to reduce latency for users.
   Flush .05
   Parsers_File %s
+  # Serve the monitoring API, which the agent reads the health of log forwarding from.
+  HTTP_Server On
+  HTTP_Listen 127.0.0.1
+  HTTP_Port %d
 
 [INPUT]
   Name forward
-`, parserConfigPath)
+`, parserConfigPath, opts.Fluent.MetricsPort)
 
 	filterConfig := fmt.Sprintf(`
 # Attempt to parse the rank ID and log level out of output lines.
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/labstack/echo"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// healthCheckTimeout bounds how long the health endpoint waits on the agent and on Docker.
+const healthCheckTimeout = 5 * time.Second
+
+// getMasterConnected asks the agent whether it is connected to the master.
+type getMasterConnected struct{}
+
+// agentHealth is the health of the agent that is reported by the /health endpoint. The agent is
+// healthy if it is connected to the master, unless it runs in standalone mode, and if it can reach
+// the Docker daemon.
+type agentHealth struct {
+	Healthy         bool   `json:"healthy"`
+	MasterConnected bool   `json:"master_connected"`
+	DockerReachable bool   `json:"docker_reachable"`
+	Error           string `json:"error,omitempty"`
+}
+
+// healthHandler returns the handler of the /health endpoint, which responds with 503 Service
+// Unavailable when the agent is unhealthy so that monitors can drain it.
+func healthHandler(options Options, system *actor.System) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var health agentHealth
+		resp := system.AskAt(actor.Addr("agent"), getMasterConnected{})
+		if connected, ok := resp.GetOrTimeout(healthCheckTimeout); ok {
+			health.MasterConnected, _ = connected.(bool)
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request().Context(), healthCheckTimeout)
+		defer cancel()
+		if err := pingDocker(ctx); err != nil {
+			health.Error = err.Error()
+		} else {
+			health.DockerReachable = true
+		}
+
+		health.Healthy = (health.MasterConnected || options.MasterHost == "") &&
+			health.DockerReachable
+		if !health.Healthy {
+			return c.JSON(http.StatusServiceUnavailable, health)
+		}
+		return c.JSON(http.StatusOK, health)
+	}
+}
+
+func pingDocker(ctx context.Context) error {
+	docker, err := client.NewClientWithOpts(client.FromEnv)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = docker.Close()
+	}()
+	_, err = docker.Ping(ctx)
+	return err
+}
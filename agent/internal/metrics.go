@@ -0,0 +1,266 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shirou/gopsutil/cpu"
+	log "github.com/sirupsen/logrus"
+
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+// scrapeTimeout bounds how long collectors wait on other processes when the metrics are scraped.
+const scrapeTimeout = 5 * time.Second
+
+// Outcomes of pulling images.
+const (
+	imagePullCached = "cached"
+	imagePullPulled = "pulled"
+	imagePullFailed = "failed"
+)
+
+var (
+	containersByState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "det_agent_containers",
+		Help: "Number of containers of the agent, by state.",
+	}, []string{"state"})
+
+	containerTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "det_agent_container_transitions_total",
+		Help: "Number of times that containers of the agent entered a state, by state.",
+	}, []string{"state"})
+
+	imagePullSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "det_agent_image_pull_seconds",
+		Help:    "Time taken to make the images of containers available, by outcome.",
+		Buckets: []float64{.1, 1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800},
+	}, []string{"outcome"})
+
+	containerStartSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "det_agent_container_start_seconds",
+		Help:    "Time taken to create and start containers once their images are available.",
+		Buckets: []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120},
+	})
+
+	masterConnected = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "det_agent_master_connected",
+		Help: "Whether the agent is connected to the master.",
+	})
+
+	masterReconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "det_agent_master_reconnects_total",
+		Help: "Number of times that the agent reconnected to the master.",
+	})
+
+	fluentUpDesc = prometheus.NewDesc("det_agent_fluent_up",
+		"Whether the Fluent Bit daemon is running.", nil, nil)
+	fluentRecordsDesc = prometheus.NewDesc("det_agent_fluent_output_records_total",
+		"Number of log records forwarded by Fluent Bit, by output.", []string{"output"}, nil)
+	fluentErrorsDesc = prometheus.NewDesc("det_agent_fluent_output_errors_total",
+		"Number of errors forwarding log records by Fluent Bit, by output.",
+		[]string{"output"}, nil)
+	fluentRetriesDesc = prometheus.NewDesc("det_agent_fluent_output_retries_total",
+		"Number of retries forwarding log records by Fluent Bit, by output.",
+		[]string{"output"}, nil)
+	fluentRetriesFailedDesc = prometheus.NewDesc("det_agent_fluent_output_retries_failed_total",
+		"Number of log records that Fluent Bit dropped after retrying, by output.",
+		[]string{"output"}, nil)
+
+	deviceUtilizationDesc = prometheus.NewDesc("det_agent_device_utilization_ratio",
+		"Utilization of the device, between 0 and 1.",
+		[]string{"device_id", "device_type", "uuid"}, nil)
+	gpuMemoryUsedDesc = prometheus.NewDesc("det_agent_gpu_memory_used_bytes",
+		"Bytes of memory of the GPU that are in use.", []string{"device_id", "uuid"}, nil)
+	gpuMemoryTotalDesc = prometheus.NewDesc("det_agent_gpu_memory_total_bytes",
+		"Bytes of memory of the GPU.", []string{"device_id", "uuid"}, nil)
+)
+
+// agentMetrics holds the collectors whose metrics the agent exports. A dedicated registry is used
+// rather than the default one so that only the metrics of the agent are exported.
+var agentMetrics = prometheus.NewRegistry()
+
+func init() {
+	agentMetrics.MustRegister(
+		containersByState,
+		containerTransitions,
+		imagePullSeconds,
+		containerStartSeconds,
+		masterConnected,
+		masterReconnects,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+}
+
+// activeContainerStates are the states of containers that are counted by det_agent_containers;
+// terminated containers are removed by the agent and are counted only by their transitions.
+var activeContainerStates = []cproto.State{
+	cproto.Assigned, cproto.Pulling, cproto.Starting, cproto.Running,
+}
+
+// updateContainerMetrics exports the number of containers in each state.
+func updateContainerMetrics(states map[cproto.ID]cproto.State) {
+	counts := make(map[cproto.State]int)
+	for _, state := range states {
+		counts[state]++
+	}
+	for _, state := range activeContainerStates {
+		containersByState.WithLabelValues(string(state)).Set(float64(counts[state]))
+	}
+}
+
+func observeImagePull(outcome string, start time.Time) {
+	imagePullSeconds.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+}
+
+// fluentCollector collects the health of the Fluent Bit daemon that forwards the logs of
+// containers to the master when the metrics are scraped.
+type fluentCollector struct {
+	docker      *client.Client
+	containerID string
+	metricsPort int
+}
+
+// fluentOutputMetrics are the metrics of an output plugin in the response of the monitoring API of
+// Fluent Bit.
+type fluentOutputMetrics struct {
+	ProcRecords   float64 `json:"proc_records"`
+	Errors        float64 `json:"errors"`
+	Retries       float64 `json:"retries"`
+	RetriesFailed float64 `json:"retries_failed"`
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *fluentCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- fluentUpDesc
+	ch <- fluentRecordsDesc
+	ch <- fluentErrorsDesc
+	ch <- fluentRetriesDesc
+	ch <- fluentRetriesFailedDesc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *fluentCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	up := 0.0
+	if info, err := c.docker.ContainerInspect(ctx, c.containerID); err != nil {
+		log.WithError(err).Warn("failed to inspect Fluent Bit container")
+	} else if info.State != nil && info.State.Running {
+		up = 1
+	}
+	ch <- prometheus.MustNewConstMetric(fluentUpDesc, prometheus.GaugeValue, up)
+	if up == 0 {
+		return
+	}
+
+	outputs, err := c.outputMetrics(ctx)
+	if err != nil {
+		log.WithError(err).Warn("failed to read the metrics of Fluent Bit")
+		return
+	}
+	for output, m := range outputs {
+		for desc, value := range map[*prometheus.Desc]float64{
+			fluentRecordsDesc:       m.ProcRecords,
+			fluentErrorsDesc:        m.Errors,
+			fluentRetriesDesc:       m.Retries,
+			fluentRetriesFailedDesc: m.RetriesFailed,
+		} {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value, output)
+		}
+	}
+}
+
+// outputMetrics reads the metrics of the output plugins from the monitoring API of Fluent Bit.
+func (c *fluentCollector) outputMetrics(
+	ctx context.Context,
+) (map[string]fluentOutputMetrics, error) {
+	req, err := http.NewRequest(http.MethodGet,
+		fmt.Sprintf("http://%s:%d/api/v1/metrics", localhost, c.metricsPort), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.WithError(err).Warn("failed to close the response of Fluent Bit")
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status from Fluent Bit: %s", resp.Status)
+	}
+	var body struct {
+		Output map[string]fluentOutputMetrics `json:"output"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body.Output, nil
+}
+
+// deviceCollector collects the utilization of the devices of the agent when the metrics are
+// scraped. Utilization is reported for GPUs through nvidia-smi and for CPUs through the operating
+// system; artificial devices are skipped.
+type deviceCollector struct {
+	devices []device.Device
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *deviceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- deviceUtilizationDesc
+	ch <- gpuMemoryUsedDesc
+	ch <- gpuMemoryTotalDesc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *deviceCollector) Collect(ch chan<- prometheus.Metric) {
+	gauge := func(desc *prometheus.Desc, value float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+	}
+
+	var gpus map[int]gpuUtilization
+	for _, d := range c.devices {
+		id := strconv.Itoa(d.ID)
+		switch d.Type {
+		case device.GPU:
+			if gpus == nil {
+				var err error
+				if gpus, err = getGPUUtilization(); err != nil {
+					log.WithError(err).Warn("failed to read the utilization of GPUs")
+					gpus = map[int]gpuUtilization{}
+				}
+			}
+			u, ok := gpus[d.ID]
+			if !ok {
+				continue
+			}
+			gauge(deviceUtilizationDesc, u.utilization, id, string(d.Type), d.UUID)
+			gauge(gpuMemoryUsedDesc, u.memoryUsed, id, d.UUID)
+			gauge(gpuMemoryTotalDesc, u.memoryTotal, id, d.UUID)
+		case device.CPU:
+			if d.Brand == artificialBrand {
+				continue
+			}
+			// All the cores of the agent are a single CPU device, so report their total usage since
+			// the previous scrape.
+			percents, err := cpu.Percent(0, false)
+			if err != nil || len(percents) == 0 {
+				log.WithError(err).Warn("failed to read the utilization of CPUs")
+				continue
+			}
+			gauge(deviceUtilizationDesc, percents[0]/100, id, string(d.Type), d.UUID)
+		}
+	}
+}
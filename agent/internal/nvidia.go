@@ -1,9 +1,11 @@
 package internal
 
 import (
+	"context"
 	"encoding/csv"
 	"io"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -39,3 +41,61 @@ func getNvidiaVersion() (string, error) {
 	}
 	return record[0], nil
 }
+
+// gpuUtilization is the utilization of a GPU as reported by nvidia-smi.
+type gpuUtilization struct {
+	// utilization is the fraction of time over the past sample period during which a kernel was
+	// running on the GPU.
+	utilization float64
+	memoryUsed  float64
+	memoryTotal float64
+}
+
+var gpuUtilizationArgs = []string{
+	"nvidia-smi",
+	"--query-gpu=index,utilization.gpu,memory.used,memory.total",
+	"--format=csv,noheader,nounits",
+}
+
+// getGPUUtilization returns the utilization of the Nvidia GPUs by their index.
+func getGPUUtilization() (map[int]gpuUtilization, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	// #nosec G204
+	out, err := exec.CommandContext(ctx, gpuUtilizationArgs[0], gpuUtilizationArgs[1:]...).Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "error while executing nvidia-smi")
+	}
+	return parseGPUUtilization(string(out))
+}
+
+func parseGPUUtilization(out string) (map[int]gpuUtilization, error) {
+	const mebibyte = 1 << 20
+
+	gpus := make(map[int]gpuUtilization)
+	r := csv.NewReader(strings.NewReader(out))
+	for {
+		record, err := r.Read()
+		switch {
+		case err == io.EOF:
+			return gpus, nil
+		case err != nil:
+			return nil, errors.Wrap(err, "error parsing output of nvidia-smi as csv")
+		case len(record) != 4:
+			return nil, errors.New(
+				"error parsing output of nvidia-smi; GPU record should have exactly 4 fields")
+		}
+
+		var values [4]float64
+		for i, field := range record {
+			// Fields that a GPU does not support are reported as "[Not Supported]" and left as zero.
+			values[i], _ = strconv.ParseFloat(strings.TrimSpace(field), 64)
+		}
+		gpus[int(values[0])] = gpuUtilization{
+			utilization: values[1] / 100,
+			memoryUsed:  values[2] * mebibyte,
+			memoryTotal: values[3] * mebibyte,
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestParseGPUUtilization(t *testing.T) {
+	out := "0, 45, 1024, 16160\n1, [Not Supported], 0, 16160\n"
+
+	gpus, err := parseGPUUtilization(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[int]gpuUtilization{
+		0: {utilization: .45, memoryUsed: 1024 << 20, memoryTotal: 16160 << 20},
+		1: {utilization: 0, memoryUsed: 0, memoryTotal: 16160 << 20},
+	}
+	if len(gpus) != len(expected) {
+		t.Fatalf("Expected: %v But got: %v", expected, gpus)
+	}
+	for index, gpu := range expected {
+		if gpus[index] != gpu {
+			t.Errorf("Expected: %v But got: %v", gpu, gpus[index])
+		}
+	}
+}
+
+func TestParseGPUUtilizationMalformed(t *testing.T) {
+	if _, err := parseGPUUtilization("0, 45\n"); err == nil {
+		t.Error("Expected an error for a record with missing fields")
+	}
+}
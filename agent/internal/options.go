@@ -22,8 +22,14 @@ type Options struct {
 	ContainerMasterPort int    `json:"container_master_port"`
 
 	Label        string `json:"label"`
+	Topology     string `json:"topology"`
 	ResourcePool string `json:"resource_pool"`
 
+	// ReconnectAttempts is how many times the agent tries to reconnect to the master after its
+	// connection drops before shutting down, waiting ReconnectBackoff seconds between attempts.
+	ReconnectAttempts int `json:"reconnect_attempts"`
+	ReconnectBackoff  int `json:"reconnect_backoff"`
+
 	APIEnabled bool   `json:"api_enabled"`
 	BindIP     string `json:"bind_ip"`
 	BindPort   int    `json:"bind_port"`
@@ -49,6 +55,8 @@ func (o Options) Validate() []error {
 	return []error{
 		o.validateTLS(),
 		check.In(o.SlotType, []string{"gpu", "auto", "none"}),
+		check.GreaterThanOrEqualTo(o.ReconnectAttempts, 0, "reconnect_attempts must be >= 0"),
+		check.GreaterThanOrEqualTo(o.ReconnectBackoff, 0, "reconnect_backoff must be >= 0"),
 	}
 }
 
@@ -98,6 +106,7 @@ func (t TLSOptions) Validate() []error {
 
 // FluentOptions stores configurable Fluent Bit-related options.
 type FluentOptions struct {
-	Image string `json:"image"`
-	Port  int    `json:"port"`
+	Image       string `json:"image"`
+	Port        int    `json:"port"`
+	MetricsPort int    `json:"metrics_port"`
 }
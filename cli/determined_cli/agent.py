@@ -2,6 +2,7 @@ import argparse
 import json
 import os
 import sys
+import time
 from collections import OrderedDict
 from typing import Any, Callable, List
 
@@ -117,6 +118,38 @@ def patch_agent(enabled: bool) -> Callable[[argparse.Namespace], None]:
     return patch
 
 
+@authentication_required
+def drain_agent(args: argparse.Namespace) -> None:
+    check_false(args.all and args.agent_id)
+
+    if not (args.all or args.agent_id):
+        print("Error: must specify exactly one of `--all` or agent_id")
+        sys.exit(1)
+
+    if args.agent_id:
+        agent_ids = [args.agent_id]
+    else:
+        r = api.get(args.master, "agents")
+        agent_ids = sorted(local_id(a) for a in r.json().keys())
+
+    for agent_id in agent_ids:
+        api.post(args.master, "api/v1/agents/{}/drain".format(agent_id))
+        print("Draining agent {}".format(agent_id))
+
+    if not args.wait:
+        return
+
+    pending = set(agent_ids)
+    while pending:
+        for agent_id in sorted(pending):
+            agent = api.get(args.master, "api/v1/agents/{}".format(agent_id)).json()["agent"]
+            if agent["drained"]:
+                print("Drained agent {}".format(agent_id))
+                pending.remove(agent_id)
+        if pending:
+            time.sleep(5)
+
+
 def patch_slot(enabled: bool) -> Callable[[argparse.Namespace], None]:
     @authentication_required
     def patch(args: argparse.Namespace) -> None:
@@ -158,6 +191,14 @@ args_description = [
                 Arg("--all", action="store_true", help="disable all agents"),
             )
         ]),
+        Cmd("drain", drain_agent, "stop scheduling on agent and move its trials to other agents", [
+            Group(
+                Arg("agent_id", help="agent ID", nargs="?", completer=agent_id_completer),
+                Arg("--all", action="store_true", help="drain all agents"),
+            ),
+            Arg("--wait", action="store_true",
+                help="wait until no containers are left on the agents"),
+        ]),
     ]),
     Cmd("s|lot", None, "manage slots", [
         Cmd("list", list_slots, "list slots in cluster", [
@@ -19,6 +19,10 @@ FullUser = namedtuple(
     ["username", "admin", "active", "agent_uid", "agent_gid", "agent_user", "agent_group"],
 )
 
+APIToken = namedtuple(
+    "APIToken", ["id", "name", "created_at", "expiry", "last_used", "read_only", "workspace"]
+)
+
 
 def authentication_optional(func: Callable[[Namespace], Any]) -> Callable[[Namespace], Any]:
     @wraps(func)
@@ -199,6 +203,34 @@ def whoami(parsed_args: Namespace) -> None:
     print("You are logged in as user '{}'".format(user["username"]))
 
 
+@authentication_required
+def list_tokens(parsed_args: Namespace) -> None:
+    tokens = api.get(parsed_args.master, "api-tokens").json()
+    render.render_objects(APIToken, [render.unmarshal(APIToken, t) for t in tokens])
+
+
+@authentication_required
+def create_token(parsed_args: Namespace) -> None:
+    request = {
+        "name": parsed_args.name,
+        "read_only": parsed_args.read_only,
+    }  # type: Dict[str, Any]
+    if parsed_args.expiry is not None:
+        request["expiry"] = parsed_args.expiry
+    if parsed_args.workspace is not None:
+        request["workspace"] = parsed_args.workspace
+
+    token = api.post(parsed_args.master, "api-tokens", body=request).json()
+    print("Created API token {} with ID {}.".format(token["name"], token["id"]))
+    print("Set DET_API_TOKEN to the following token to use it; it will not be shown again:")
+    print(token["token"])
+
+
+@authentication_required
+def revoke_token(parsed_args: Namespace) -> None:
+    api.delete(parsed_args.master, "api-tokens/{}".format(parsed_args.token_id))
+
+
 # fmt: off
 
 args_description = [
@@ -232,7 +264,22 @@ args_description = [
             Arg("--agent-gid", type=int, help="GID on agent to run tasks as"),
             Arg("--agent-group", help="group on the agent to run tasks as"),
         ]),
-        Cmd("whoami", whoami, "print the active user", [])
+        Cmd("whoami", whoami, "print the active user", []),
+        Cmd("token", None, "manage API tokens of the active user", [
+            Cmd("list", list_tokens, "list API tokens", [], is_default=True),
+            Cmd("create", create_token, "create API token", [
+                Arg("name", help="name of the token, e.g., the pipeline that uses it"),
+                Arg("--expiry", help="time at which the token expires, e.g., "
+                    "2021-01-01T00:00:00Z; tokens do not expire by default"),
+                Arg("--read-only", action="store_true",
+                    help="only allow the token to be used for viewing"),
+                Arg("--workspace", help="only allow the token to access experiments in "
+                    "this workspace"),
+            ]),
+            Cmd("revoke", revoke_token, "revoke API token", [
+                Arg("token_id", type=int, help="ID of the token to revoke"),
+            ]),
+        ]),
     ])
 ]  # type: List[Any]
 
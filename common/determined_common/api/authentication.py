@@ -213,6 +213,15 @@ def initialize_session(
 ) -> None:
     auth = Authentication.instance()
 
+    # An API token, e.g., of a CI pipeline, takes the place of a session of its user.
+    api_token = os.environ.get("DET_API_TOKEN")
+    if api_token:
+        user = _token_user(master_address, api_token)
+        if user is None:
+            raise api.errors.UnauthenticatedException(username=requested_user or "")
+        auth.session = api.Session(user, api_token)
+        return
+
     session_user = (
         requested_user or auth.token_store.get_active_user() or constants.DEFAULT_DETERMINED_USER
     )
@@ -257,13 +266,23 @@ def _is_token_valid(master_address: str, token: str) -> bool:
     Find out whether the given token is valid by attempting to use it
     on the "/users/me" endpoint.
     """
+    return _token_user(master_address, token) is not None
+
+
+def _token_user(master_address: str, token: str) -> Optional[str]:
+    """
+    Return the username of the user that the given token belongs to, or None if
+    the token is not valid.
+    """
     headers = {"Authorization": "Bearer {}".format(token)}
     try:
         r = api.get(master_address, "users/me", headers=headers, authenticated=False)
     except (api.errors.UnauthenticatedException, api.errors.APIException):
-        return False
+        return None
 
-    return r.status_code == 200
+    if r.status_code != 200:
+        return None
+    return cast(str, r.json()["username"])
 
 
 def do_login(master_address: str, auth: Authentication, username: str, password: str) -> str:
@@ -1,15 +1,22 @@
 import logging
 import socket
 import ssl
+import time
 from typing import Any, Optional
 
 import lomond
+import lomond.errors
 import lomond.session
 import simplejson
 
 import determined as det
 from determined import layers, util, workload
 
+# How long the harness keeps trying to reconnect to a master that restarted, and how long it waits
+# between attempts.
+RECONNECT_TIMEOUT = 10 * 60
+RECONNECT_INTERVAL = 5
+
 
 class CustomSSLWebsocketSession(lomond.session.WebsocketSession):  # type: ignore
     """
@@ -40,8 +47,13 @@ class SocketManager(workload.Source):
 
     def __init__(self, env: det.EnvContext) -> None:
         self.env = env
+        self.closed = False
+
+        # The last response that the master did not answer with a workload yet. It is sent again
+        # after reconnecting, in case the master did not record it before it restarted.
+        self.last_response = None  # type: Optional[str]
 
-        url = "{}://{}:{}/ws/trial/{}/{}/{}".format(
+        self.url = "{}://{}:{}/ws/trial/{}/{}/{}".format(
             "wss" if self.env.use_tls else "ws",
             self.env.master_addr,
             self.env.master_port,
@@ -50,13 +62,7 @@ class SocketManager(workload.Source):
             self.env.container_id,
         )
 
-        # Disable reading proxy configuration because we shouldn't proxy our
-        # own connection to the master.
-        self.socket = lomond.WebSocket(url, proxies={})
-
-        self.ws_events = self.socket.connect(
-            ping_rate=0, session_class=lambda socket: CustomSSLWebsocketSession(socket, env)
-        )
+        self.connect()
 
         # Handle the messages up to and including the rendezvous message.
         for ws_event in self.ws_events:
@@ -72,9 +78,12 @@ class SocketManager(workload.Source):
         # Always yield the initial workload first.
         yield from self.yield_workload(self.env.initial_workload)
 
-        # Then pass workloads which arrive on the websocket.
-        for ws_event in self.ws_events:
-            yield from self.handle_event(ws_event)
+        # Then pass workloads which arrive on the websocket, reconnecting if the master restarts.
+        while True:
+            for ws_event in self.ws_events:
+                yield from self.handle_event(ws_event)
+            if self.closed or not self.reconnect():
+                return
 
     def __enter__(self) -> "SocketManager":
         return self
@@ -82,7 +91,47 @@ class SocketManager(workload.Source):
     def __exit__(self, *_: Any) -> None:
         self.close()
 
+    def connect(self) -> None:
+        # Disable reading proxy configuration because we shouldn't proxy our
+        # own connection to the master.
+        self.socket = lomond.WebSocket(self.url, proxies={})
+
+        self.ws_events = self.socket.connect(
+            ping_rate=0, session_class=lambda socket: CustomSSLWebsocketSession(socket, self.env)
+        )
+
+    def reconnect(self) -> bool:
+        """
+        Reconnect to a master that restarted, and return whether it succeeded.
+
+        The master sends the rendezvous information again once it reattaches to this container;
+        then the last response is sent again in case the master did not record it.
+        """
+
+        deadline = time.time() + RECONNECT_TIMEOUT
+        while time.time() < deadline:
+            time.sleep(RECONNECT_INTERVAL)
+            logging.info("Reconnecting to master")
+            self.connect()
+            for ws_event in self.ws_events:
+                if self.check_for_rendezvous_info(ws_event) is None:
+                    continue
+                if self.last_response is not None:
+                    self.send(self.last_response)
+                return True
+
+        logging.error("Failed to reconnect to master within %d seconds", RECONNECT_TIMEOUT)
+        return False
+
+    def send(self, text: str) -> None:
+        try:
+            self.socket.send_text(text)
+        except lomond.errors.WebSocketError as e:
+            # The response is sent again once the harness reconnects.
+            logging.warning("Failed to send response to master: %s", e)
+
     def close(self) -> None:
+        self.closed = True
         self.socket.close()
 
         # Empty the websocket.
@@ -168,8 +217,13 @@ class SocketManager(workload.Source):
         elif isinstance(event, lomond.events.Text):
             msg = simplejson.loads(event.text)
             if msg["type"] == "RUN_WORKLOAD":
+                self.last_response = None
                 wkld = workload.Workload.from_json(msg["workload"])
                 yield from self.yield_workload(wkld)
+            elif msg["type"] == "RENDEZVOUS_INFO":
+                # The master sends the rendezvous information again when it reattaches to the
+                # containers of the trial; the containers are already set up.
+                logging.info("Ignoring repeated rendezvous information")
             else:
                 raise NotImplementedError(f"Unrecognized message: {msg}")
         else:
@@ -197,7 +251,8 @@ class SocketManager(workload.Source):
             duration = metrics["end_time"] - metrics["start_time"]
             logging.info(f"Workload completed: {metrics['workload']} (duration {duration})")
 
-            self.socket.send_text(util.json_encode(metrics))
+            self.last_response = util.json_encode(metrics)
+            self.send(self.last_response)
 
         yield wkld, [], respond
 
@@ -17,6 +17,7 @@ func init() {
 	rootCmd.Version = version.Version
 
 	registerConfig()
+	rootCmd.AddCommand(newSimulateSchedulerCmd())
 }
 
 type configKey []string
@@ -105,6 +106,12 @@ func registerConfig() {
 	registerString(flags, name("root"),
 		defaults.Root, "static file root directory")
 
+	registerBool(flags, name("high-availability", "enabled"),
+		defaults.HighAvailability.Enabled, "run on standby while another master leads the cluster")
+	registerInt(flags, name("high-availability", "election-interval"),
+		defaults.HighAvailability.ElectionInterval,
+		"seconds between attempts to become the leader of the cluster")
+
 	registerBool(flags, name("telemetry", "enabled"),
 		defaults.Telemetry.Enabled, "enable telemetry")
 	registerString(flags, name("telemetry", "segment-master-key"),
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+)
+
+type simulateSchedulerOptions struct {
+	schedulers      []string
+	fittingPolicies []string
+	preemption      bool
+	json            bool
+}
+
+func newSimulateSchedulerCmd() *cobra.Command {
+	opts := simulateSchedulerOptions{}
+	cmd := &cobra.Command{
+		Use:   "simulate-scheduler TRACE_FILE",
+		Short: "replay a workload trace through the schedulers in virtual time",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The resource pools log every scheduling decision they cannot make.
+			log.SetLevel(log.WarnLevel)
+			return runSimulateScheduler(cmd.OutOrStdout(), args[0], opts)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringSliceVar(&opts.schedulers, "scheduler",
+		[]string{"fair_share", "priority", "round_robin", "backfill"}, "the scheduler types to simulate")
+	flags.StringSliceVar(&opts.fittingPolicies, "fitting-policy",
+		[]string{"best"}, "the fitting policies to simulate")
+	flags.BoolVar(&opts.preemption, "preemption", false,
+		"enable preemption for the priority scheduler")
+	flags.BoolVar(&opts.json, "json", false, "print the reports as JSON")
+	return cmd
+}
+
+func runSimulateScheduler(out io.Writer, path string, opts simulateSchedulerOptions) error {
+	bs, err := ioutil.ReadFile(path) // #nosec G304
+	if err != nil {
+		return errors.Wrapf(err, "error reading trace file %s", path)
+	}
+	var trace resourcemanagers.SimulationTrace
+	if err = yaml.Unmarshal(bs, &trace, yaml.DisallowUnknownFields); err != nil {
+		return errors.Wrapf(err, "error parsing trace file %s", path)
+	}
+
+	var reports []*resourcemanagers.SimulationReport
+	for _, scheduler := range opts.schedulers {
+		for _, fittingPolicy := range opts.fittingPolicies {
+			config := &resourcemanagers.SchedulerConfig{FittingPolicy: fittingPolicy}
+			switch scheduler {
+			case "fair_share":
+				config.FairShare = &resourcemanagers.FairShareSchedulerConfig{}
+			case "priority":
+				config.Priority = &resourcemanagers.PrioritySchedulerConfig{
+					Preemption: opts.preemption,
+				}
+			case "round_robin":
+				config.RoundRobin = &resourcemanagers.RoundRobinSchedulerConfig{}
+			case "backfill":
+				config.Backfill = &resourcemanagers.BackfillSchedulerConfig{}
+			default:
+				return errors.Errorf("unknown scheduler: %s", scheduler)
+			}
+
+			report, err := resourcemanagers.Simulate(trace, config)
+			if err != nil {
+				return errors.Wrapf(err, "error simulating %s scheduler with %s fitting policy",
+					scheduler, fittingPolicy)
+			}
+			reports = append(reports, report)
+		}
+	}
+
+	if opts.json {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	}
+	return printSimulationReports(out, reports)
+}
+
+func printSimulationReports(out io.Writer, reports []*resourcemanagers.SimulationReport) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCHEDULER\tFIT\tCOMPLETED\tUNSCHEDULED\tPREEMPTIONS\tMAKESPAN\t"+
+		"MEAN WAIT\tP95 WAIT\tMAX WAIT\tUTILIZATION\tFAIRNESS")
+	for _, r := range reports {
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%d\t%d\t%s\t%s\t%s\t%s\t%.1f%%\t%.3f\n",
+			r.Scheduler, r.FittingPolicy, r.CompletedTasks, r.Tasks, r.UnscheduledTasks,
+			r.Preemptions, r.Makespan.Round(time.Second), r.MeanWait.Round(time.Second),
+			r.P95Wait.Round(time.Second), r.MaxWait.Round(time.Second), 100*r.Utilization,
+			r.Fairness)
+	}
+	return w.Flush()
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/internal/telemetry"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	ws "github.com/determined-ai/determined/master/pkg/actor/api"
 	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	"github.com/determined-ai/determined/master/pkg/check"
@@ -30,6 +31,28 @@ type agent struct {
 	containers       map[container.ID]*actor.Ref
 	resourcePoolName string
 	label            string
+	topology         string
+
+	// containerStates holds the latest state of each container on the agent that the master knows.
+	containerStates map[container.ID]container.Container
+	// started is true once the agent has registered its devices.
+	started bool
+	// reconnectWait is how long the agent has to reconnect after its websocket drops before its
+	// containers are considered lost. While the agent is disconnected, messages to it are held in
+	// pending and sent once it reconnects.
+	reconnectWait time.Duration
+	disconnects   int
+	pending       []aproto.AgentMessage
+	// draining is true from a drain request until the agent is enabled again. No tasks are
+	// scheduled on a draining agent and its trials are asked to release their resources, so that
+	// they are rescheduled on other agents; the agent is drained at drainedTime, once none of its
+	// containers are left.
+	draining    bool
+	drainedTime *time.Time
+	// unclaimed holds the containers that the agent kept running across a master restart and
+	// that no task has claimed yet, along with the logs they wrote since. They are killed if they
+	// are not claimed within reconnectWait.
+	unclaimed map[container.ID]*unclaimedContainer
 
 	// uuid is an anonymous ID that is used when reporting telemetry
 	// information to allow agent connection and disconnection events
@@ -37,6 +60,26 @@ type agent struct {
 	uuid uuid.UUID
 }
 
+// unclaimedContainer is a container that the agent reported when it registered, which the master
+// does not know the task of.
+type unclaimedContainer struct {
+	container container.Container
+	started   *aproto.ContainerStarted
+	logs      []aproto.ContainerLog
+}
+
+// maxUnclaimedLogs is the most logs held for each unclaimed container; older logs are dropped.
+const maxUnclaimedLogs = 1000
+
+// claimTimeout notifies the agent that the time for tasks to claim its containers has elapsed.
+type claimTimeout struct{}
+
+// reconnectTimeout notifies the agent that the time to reconnect after its websocket dropped for
+// the given time has elapsed.
+type reconnectTimeout struct {
+	disconnects int
+}
+
 // AgentSummary summarizes the state on an agent.
 type AgentSummary struct {
 	ID             string       `json:"id"`
@@ -45,6 +88,10 @@ type AgentSummary struct {
 	NumContainers  int          `json:"num_containers"`
 	ResourcePool   string       `json:"resource_pool"`
 	Label          string       `json:"label"`
+	Topology       string       `json:"topology"`
+	Draining       bool         `json:"draining"`
+	Drained        bool         `json:"drained"`
+	DrainedTime    *time.Time   `json:"drained_time"`
 }
 
 func (a *agent) Receive(ctx *actor.Context) error {
@@ -53,10 +100,15 @@ func (a *agent) Receive(ctx *actor.Context) error {
 		a.uuid = uuid.New()
 		a.slots, _ = ctx.ActorOf("slots", &slots{resourcePool: a.resourcePool})
 		a.containers = make(map[container.ID]*actor.Ref)
+		a.containerStates = make(map[container.ID]container.Container)
+		a.unclaimed = make(map[container.ID]*unclaimedContainer)
 	case AgentSummary:
 		ctx.Respond(a.summarize(ctx))
 	case ws.WebSocketConnected:
-		check.Panic(check.True(a.socket == nil, "websocket already connected"))
+		if a.socket != nil {
+			ctx.Respond(errors.Errorf("agent already connected: %s", ctx.Self().Address().Local()))
+			return nil
+		}
 		socket, ok := msg.Accept(ctx, aproto.MasterMessage{}, true)
 		check.Panic(check.True(ok, "failed to accept websocket connection"))
 		a.socket = socket
@@ -71,18 +123,30 @@ func (a *agent) Receive(ctx *actor.Context) error {
 		killMsg := aproto.SignalContainer{
 			ContainerID: msg.ContainerID, Signal: syscall.SIGKILL,
 		}
-		ctx.Ask(a.socket, ws.WriteMessage{Message: aproto.AgentMessage{SignalContainer: &killMsg}})
+		a.send(ctx, aproto.AgentMessage{SignalContainer: &killMsg})
 	case aproto.SignalContainer:
-		ctx.Ask(a.socket, ws.WriteMessage{Message: aproto.AgentMessage{SignalContainer: &msg}})
+		a.send(ctx, aproto.AgentMessage{SignalContainer: &msg})
 	case sproto.StartTaskContainer:
-		start := ws.WriteMessage{Message: aproto.AgentMessage{StartContainer: &msg.StartContainer}}
 		ctx.Log().Infof("starting container id: %s slots: %d task handler: %s",
 			msg.StartContainer.Container.ID, len(msg.StartContainer.Container.Devices),
 			msg.TaskActor.Address())
 
-		ctx.Ask(a.socket, start)
+		a.send(ctx, aproto.AgentMessage{StartContainer: &msg.StartContainer})
 		ctx.Tell(a.slots, msg.StartContainer)
 		a.containers[msg.Container.ID] = msg.TaskActor
+		a.containerStates[msg.Container.ID] = msg.Container
+	case sproto.ClaimTaskContainer:
+		ctx.Respond(a.claimContainer(ctx, msg))
+	case sproto.ReportTaskContainers:
+		for _, uc := range a.unclaimed {
+			if uc.container.Parent == msg.TaskActor.Address() {
+				ctx.Tell(msg.TaskActor, sproto.TaskContainerReported{
+					Agent: ctx.Self(), Container: uc.container,
+				})
+			}
+		}
+	case claimTimeout:
+		a.killUnclaimedContainers(ctx)
 	case aproto.MasterMessage:
 		a.handleIncomingWSMessage(ctx, msg)
 	case *proto.GetAgentRequest:
@@ -96,16 +160,47 @@ func (a *agent) Receive(ctx *actor.Context) error {
 		ctx.Respond(&proto.GetSlotsResponse{Slots: slots})
 	case *proto.EnableAgentRequest:
 		ctx.Tell(a.slots, patchSlot{Enabled: true})
+		a.stopDraining(ctx)
 		ctx.Respond(&proto.EnableAgentResponse{Agent: ToProtoAgent(a.summarize(ctx))})
 	case *proto.DisableAgentRequest:
 		ctx.Tell(a.slots, patchSlot{Enabled: false})
 		ctx.Respond(&proto.DisableAgentResponse{Agent: ToProtoAgent(a.summarize(ctx))})
+	case *proto.DrainAgentRequest:
+		if !a.draining {
+			ctx.Log().Infof("draining agent with %d containers", a.numContainers())
+			a.draining = true
+		}
+		ctx.Tell(a.resourcePool, sproto.DrainAgent{Agent: ctx.Self()})
+		a.checkDrained(ctx)
+		ctx.Respond(&proto.DrainAgentResponse{Agent: ToProtoAgent(a.summarize(ctx))})
+	case patchSlot:
+		// The slots of the agent were patched through the REST API.
+		if msg.Enabled {
+			a.stopDraining(ctx)
+		}
 	case echo.Context:
 		a.handleAPIRequest(ctx, msg)
 	case actor.ChildFailed:
+		if msg.Child == a.socket && a.awaitReconnect(ctx, msg.Error) {
+			return nil
+		}
 		telemetry.ReportAgentDisconnected(ctx.Self().System(), a.uuid)
 
 		return errors.Wrapf(msg.Error, "child failed: %s", msg.Child.Address())
+	case actor.ChildStopped:
+		if msg.Child == a.socket && a.awaitReconnect(ctx, nil) {
+			return nil
+		}
+		telemetry.ReportAgentDisconnected(ctx.Self().System(), a.uuid)
+
+		return errors.Errorf("child stopped: %s", msg.Child.Address())
+	case reconnectTimeout:
+		if a.socket != nil || msg.disconnects != a.disconnects {
+			return nil
+		}
+		telemetry.ReportAgentDisconnected(ctx.Self().System(), a.uuid)
+
+		return errors.Errorf("agent did not reconnect within %s", a.reconnectWait)
 	case actor.PostStop:
 		ctx.Log().Infof("agent disconnected")
 		for cid := range a.containers {
@@ -137,35 +232,232 @@ func (a *agent) handleAPIRequest(ctx *actor.Context, apiCtx echo.Context) {
 
 func (a *agent) handleIncomingWSMessage(ctx *actor.Context, msg aproto.MasterMessage) {
 	switch {
+	case msg.AgentStarted != nil && a.started:
+		ctx.Log().Infof("agent reconnected ip: %v resource pool: %s containers: %d",
+			a.address, a.resourcePoolName, len(msg.AgentStarted.Containers))
+		a.reconcileContainers(ctx, msg.AgentStarted.Containers)
 	case msg.AgentStarted != nil:
+		a.started = true
 		telemetry.ReportAgentConnected(ctx.Self().System(), a.uuid, msg.AgentStarted.Devices)
 		ctx.Log().Infof("agent connected ip: %v resource pool: %s slots: %d",
 			a.address, a.resourcePoolName, len(msg.AgentStarted.Devices))
 
-		ctx.Tell(a.resourcePool, sproto.AddAgent{Agent: ctx.Self(), Label: msg.AgentStarted.Label})
+		ctx.Tell(a.resourcePool, sproto.AddAgent{
+			Agent:    ctx.Self(),
+			Label:    msg.AgentStarted.Label,
+			Topology: msg.AgentStarted.Topology,
+		})
 		ctx.Tell(a.slots, *msg.AgentStarted)
 		a.label = msg.AgentStarted.Label
+		a.topology = msg.AgentStarted.Topology
+		a.holdUnclaimedContainers(ctx, msg.AgentStarted.Containers)
 	case msg.ContainerStateChanged != nil:
 		a.containerStateChanged(ctx, *msg.ContainerStateChanged)
 	case msg.ContainerLog != nil:
 		ref, ok := a.containers[msg.ContainerLog.Container.ID]
-		check.Panic(check.True(ok,
-			"container not allocated to agent: container %s", msg.ContainerLog.Container.ID))
-		ctx.Tell(ref, sproto.ContainerLog{
-			Container:   msg.ContainerLog.Container,
-			Timestamp:   msg.ContainerLog.Timestamp,
-			PullMessage: msg.ContainerLog.PullMessage,
-			RunMessage:  msg.ContainerLog.RunMessage,
-			AuxMessage:  msg.ContainerLog.AuxMessage,
-		})
+		if uc, unclaimed := a.unclaimed[msg.ContainerLog.Container.ID]; unclaimed {
+			if len(uc.logs) >= maxUnclaimedLogs {
+				uc.logs = uc.logs[1:]
+			}
+			uc.logs = append(uc.logs, *msg.ContainerLog)
+			return
+		} else if !ok {
+			ctx.Log().Debugf("ignoring log of unknown container %s", msg.ContainerLog.Container.ID)
+			return
+		}
+		tellContainerLog(ctx, ref, *msg.ContainerLog)
 	default:
 		check.Panic(errors.Errorf("error parsing incoming message"))
 	}
 }
 
+func tellContainerLog(ctx *actor.Context, taskActor *actor.Ref, log aproto.ContainerLog) {
+	ctx.Tell(taskActor, sproto.ContainerLog{
+		Container:   log.Container,
+		Timestamp:   log.Timestamp,
+		PullMessage: log.PullMessage,
+		RunMessage:  log.RunMessage,
+		AuxMessage:  log.AuxMessage,
+	})
+}
+
+// holdUnclaimedContainers holds the containers that the agent reports when it first registers,
+// which it kept running across a master restart, so that their tasks can claim them. Their slots
+// stay in use until they exit, and they are killed if they are not claimed within reconnectWait.
+// The tasks that were restored already are told about their containers.
+func (a *agent) holdUnclaimedContainers(
+	ctx *actor.Context, inventory []aproto.ContainerStateChanged,
+) {
+	for _, sc := range inventory {
+		if sc.Container.State == container.Terminated {
+			continue
+		}
+		ctx.Log().Infof("holding container id: %s of task handler: %s until it is claimed",
+			sc.Container.ID, sc.Container.Parent)
+		a.unclaimed[sc.Container.ID] = &unclaimedContainer{
+			container: sc.Container, started: sc.ContainerStarted,
+		}
+		if ref := ctx.Self().System().Get(sc.Container.Parent); ref != nil {
+			ctx.Tell(ref, sproto.TaskContainerReported{Agent: ctx.Self(), Container: sc.Container})
+		}
+	}
+	if len(a.unclaimed) == 0 {
+		return
+	}
+	if a.reconnectWait == 0 {
+		a.killUnclaimedContainers(ctx)
+		return
+	}
+	actors.NotifyAfter(ctx, a.reconnectWait, claimTimeout{})
+}
+
+// claimContainer hands an unclaimed container to the task that claims it, if the task's handler
+// is the one that started the container.
+func (a *agent) claimContainer(ctx *actor.Context, msg sproto.ClaimTaskContainer) bool {
+	uc, ok := a.unclaimed[msg.ContainerID]
+	if !ok || uc.container.Parent != msg.TaskActor.Address() {
+		return false
+	}
+	ctx.Log().Infof("container id: %s was claimed by task handler: %s",
+		msg.ContainerID, msg.TaskActor.Address())
+	delete(a.unclaimed, msg.ContainerID)
+	a.containers[msg.ContainerID] = msg.TaskActor
+	a.containerStates[msg.ContainerID] = uc.container
+
+	rsc := sproto.TaskContainerStateChanged{Container: uc.container}
+	if uc.started != nil {
+		if uc.started.ProxyAddress == "" {
+			uc.started.ProxyAddress = a.address
+		}
+		rsc.ContainerStarted = &sproto.TaskContainerStarted{Addresses: uc.started.Addresses()}
+	}
+	ctx.Tell(msg.TaskActor, rsc)
+	for _, log := range uc.logs {
+		tellContainerLog(ctx, msg.TaskActor, log)
+	}
+	return true
+}
+
+// killUnclaimedContainers kills the containers that no task claimed. They stay unclaimed until
+// the agent reports that they terminated, which frees their slots.
+func (a *agent) killUnclaimedContainers(ctx *actor.Context) {
+	for cid := range a.unclaimed {
+		ctx.Log().Warnf("killing unclaimed container id: %s", cid)
+		killMsg := aproto.SignalContainer{ContainerID: cid, Signal: syscall.SIGKILL}
+		a.send(ctx, aproto.AgentMessage{SignalContainer: &killMsg})
+	}
+}
+
+// awaitReconnect handles the websocket of the agent being closed. It gives the agent time to
+// reconnect and returns true, unless the agent has not registered yet or reconnection is disabled.
+func (a *agent) awaitReconnect(ctx *actor.Context, err error) bool {
+	if !a.started || a.reconnectWait == 0 {
+		return false
+	}
+	a.socket = nil
+	a.disconnects++
+	ctx.Log().WithError(err).Warnf("agent disconnected, waiting %s for it to reconnect",
+		a.reconnectWait)
+	actors.NotifyAfter(ctx, a.reconnectWait, reconnectTimeout{disconnects: a.disconnects})
+	return true
+}
+
+// send sends the message to the agent, or holds it until the agent reconnects if it is
+// disconnected.
+func (a *agent) send(ctx *actor.Context, msg aproto.AgentMessage) {
+	if a.socket == nil {
+		a.pending = append(a.pending, msg)
+		return
+	}
+	ctx.Ask(a.socket, ws.WriteMessage{Message: msg})
+}
+
+// reconcileContainers reconciles the containers that the master knows are on the agent with the
+// inventory of containers that the agent reports when it reconnects. Containers that changed
+// state while the agent was disconnected are updated, containers that the agent no longer has are
+// lost, and containers that the master does not know and that are not waiting to be claimed are
+// killed. Messages that were held while the agent was disconnected are then sent.
+func (a *agent) reconcileContainers(ctx *actor.Context, inventory []aproto.ContainerStateChanged) {
+	pendingStarts := make(map[container.ID]bool)
+	for _, msg := range a.pending {
+		if msg.StartContainer != nil {
+			pendingStarts[msg.StartContainer.Container.ID] = true
+		}
+	}
+
+	reported := make(map[container.ID]bool)
+	for _, sc := range inventory {
+		reported[sc.Container.ID] = true
+		known, ok := a.containerStates[sc.Container.ID]
+		uc, unclaimed := a.unclaimed[sc.Container.ID]
+		switch {
+		case unclaimed && uc.container.State != sc.Container.State:
+			a.containerStateChanged(ctx, sc)
+		case unclaimed:
+		case !ok && sc.Container.State != container.Terminated:
+			ctx.Log().Warnf("killing unknown container id: %s", sc.Container.ID)
+			killMsg := aproto.SignalContainer{ContainerID: sc.Container.ID, Signal: syscall.SIGKILL}
+			a.send(ctx, aproto.AgentMessage{SignalContainer: &killMsg})
+		case ok && known.State != sc.Container.State:
+			a.containerStateChanged(ctx, sc)
+		}
+	}
+
+	for cid, known := range a.containerStates {
+		if reported[cid] || pendingStarts[cid] {
+			continue
+		}
+		ctx.Log().Warnf("container id: %s was lost while the agent was disconnected", cid)
+		stopped := aproto.ContainerError(
+			aproto.AgentFailed, errors.New("container was lost while the agent was disconnected"))
+		known.State = container.Terminated
+		a.containerStateChanged(ctx, aproto.ContainerStateChanged{
+			Container: known, ContainerStopped: &stopped,
+		})
+	}
+
+	for cid, uc := range a.unclaimed {
+		if reported[cid] {
+			continue
+		}
+		stopped := aproto.ContainerError(
+			aproto.AgentFailed, errors.New("container was lost while the agent was disconnected"))
+		uc.container.State = container.Terminated
+		a.containerStateChanged(ctx, aproto.ContainerStateChanged{
+			Container: uc.container, ContainerStopped: &stopped,
+		})
+	}
+
+	pending := a.pending
+	a.pending = nil
+	for _, msg := range pending {
+		a.send(ctx, msg)
+	}
+}
+
 func (a *agent) containerStateChanged(ctx *actor.Context, sc aproto.ContainerStateChanged) {
+	if uc, ok := a.unclaimed[sc.Container.ID]; ok {
+		uc.container = sc.Container
+		if sc.ContainerStarted != nil {
+			uc.started = sc.ContainerStarted
+		}
+		if sc.Container.State == container.Terminated {
+			ctx.Log().Infof("stopped unclaimed container id: %s", sc.Container.ID)
+			delete(a.unclaimed, sc.Container.ID)
+		}
+		ctx.Tell(a.slots, sc)
+		a.checkDrained(ctx)
+		return
+	}
+
 	taskActor, ok := a.containers[sc.Container.ID]
-	check.Panic(check.True(ok, "container not allocated to agent: container %s", sc.Container.ID))
+	if !ok {
+		// The agent reports containers that the master killed because it did not know them after
+		// the agent reconnected.
+		ctx.Log().Debugf("ignoring state change of unknown container %s", sc.Container.ID)
+		return
+	}
+	a.containerStates[sc.Container.ID] = sc.Container
 
 	rsc := sproto.TaskContainerStateChanged{Container: sc.Container}
 	switch sc.Container.State {
@@ -179,6 +471,7 @@ func (a *agent) containerStateChanged(ctx *actor.Context, sc aproto.ContainerSta
 	case container.Terminated:
 		ctx.Log().Infof("stopped container id: %s", sc.Container.ID)
 		delete(a.containers, sc.Container.ID)
+		delete(a.containerStates, sc.Container.ID)
 		rsc.ContainerStopped = &sproto.TaskContainerStopped{
 			ContainerStopped: *sc.ContainerStopped,
 		}
@@ -186,6 +479,32 @@ func (a *agent) containerStateChanged(ctx *actor.Context, sc aproto.ContainerSta
 
 	ctx.Tell(taskActor, rsc)
 	ctx.Tell(a.slots, sc)
+	a.checkDrained(ctx)
+}
+
+func (a *agent) numContainers() int {
+	return len(a.containers) + len(a.unclaimed)
+}
+
+// checkDrained records when a draining agent has no containers left and reports that it is
+// drained.
+func (a *agent) checkDrained(ctx *actor.Context) {
+	if !a.draining || a.drainedTime != nil || a.numContainers() > 0 {
+		return
+	}
+	now := time.Now().UTC()
+	a.drainedTime = &now
+	ctx.Log().Info("agent is drained")
+	telemetry.ReportAgentDrained(ctx.Self().System(), a.uuid)
+}
+
+// stopDraining schedules tasks on a draining agent again.
+func (a *agent) stopDraining(ctx *actor.Context) {
+	if !a.draining {
+		return
+	}
+	a.draining, a.drainedTime = false, nil
+	ctx.Tell(a.resourcePool, sproto.StopDrainingAgent{Agent: ctx.Self()})
 }
 
 func (a *agent) summarize(ctx *actor.Context) AgentSummary {
@@ -193,8 +512,12 @@ func (a *agent) summarize(ctx *actor.Context) AgentSummary {
 		ID:             ctx.Self().Address().Local(),
 		RegisteredTime: ctx.Self().RegisteredTime(),
 		Slots:          ctx.Ask(a.slots, SlotsSummary{}).Get().(SlotsSummary),
-		NumContainers:  len(a.containers),
+		NumContainers:  a.numContainers(),
 		ResourcePool:   a.resourcePoolName,
 		Label:          a.label,
+		Topology:       a.topology,
+		Draining:       a.draining,
+		Drained:        a.drainedTime != nil,
+		DrainedTime:    a.drainedTime,
 	}
 }
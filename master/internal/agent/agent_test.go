@@ -0,0 +1,195 @@
+package agent
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	ws "github.com/determined-ai/determined/master/pkg/actor/api"
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/device"
+	proto "github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+type recorder struct {
+	messages []actor.Message
+}
+
+type flush struct{}
+
+func (r *recorder) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart, actor.PostStop, flush:
+	case ws.WriteMessage:
+		r.messages = append(r.messages, msg.Message)
+		ctx.Respond(ws.WriteResponse{})
+	default:
+		r.messages = append(r.messages, msg)
+	}
+	return nil
+}
+
+// received returns the messages that the recorder received once it handled the ones already sent.
+func received(system *actor.System, ref *actor.Ref, r *recorder) []actor.Message {
+	system.Ask(ref, flush{}).Get()
+	return r.messages
+}
+
+func TestMasterRestart(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	pool, socket, task := &recorder{}, &recorder{}, &recorder{}
+	poolRef := system.MustActorOf(actor.Addr("pool"), pool)
+	socketRef := system.MustActorOf(actor.Addr("socket"), socket)
+	taskRef := system.MustActorOf(actor.Addr("task"), task)
+
+	gpu0 := device.Device{ID: 0, Type: device.GPU}
+	gpu1 := device.Device{ID: 1, Type: device.GPU}
+	claimed := container.Container{
+		Parent: taskRef.Address(), ID: "claimed", State: container.Running,
+		Devices: []device.Device{gpu0},
+	}
+	orphan := container.Container{
+		Parent: actor.Addr("lost-task"), ID: "orphan", State: container.Running,
+		Devices: []device.Device{gpu1},
+	}
+
+	// The agent registers with a master that restarted while its containers kept running.
+	ref := system.MustActorOf(actor.Addr("agent"), &agent{
+		resourcePool: poolRef, socket: socketRef, reconnectWait: time.Hour,
+	})
+	system.Ask(ref, aproto.MasterMessage{AgentStarted: &aproto.AgentStarted{
+		Devices: []device.Device{gpu0, gpu1},
+		Containers: []aproto.ContainerStateChanged{
+			{Container: claimed}, {Container: orphan},
+		},
+	}}).Get()
+	summary := system.Ask(ref, AgentSummary{}).Get().(AgentSummary)
+	assert.Equal(t, summary.NumContainers, 2)
+
+	// The slots of the containers stay in use, and nothing is killed yet.
+	inUse := make(map[device.Device]container.ID)
+	for _, msg := range received(system, poolRef, pool) {
+		if add, ok := msg.(sproto.AddDevice); ok {
+			assert.Assert(t, add.ContainerID != nil, "device %s added as free", add.Device)
+			inUse[add.Device] = *add.ContainerID
+		}
+	}
+	assert.DeepEqual(t, inUse, map[device.Device]container.ID{gpu0: "claimed", gpu1: "orphan"})
+	assert.Equal(t, len(received(system, socketRef, socket)), 0)
+
+	// The task is told about its container when the agent registers and whenever it asks.
+	system.Ask(ref, sproto.ReportTaskContainers{TaskActor: taskRef}).Get()
+	reports := received(system, taskRef, task)
+	assert.Equal(t, len(reports), 2)
+	for _, msg := range reports {
+		report := msg.(sproto.TaskContainerReported)
+		assert.Equal(t, report.Agent, ref)
+		assert.Equal(t, report.Container.ID, container.ID("claimed"))
+	}
+	task.messages = nil
+
+	// The task claims its container and receives its state and the logs it wrote in the meantime.
+	line := "still training"
+	system.Ask(ref, aproto.MasterMessage{ContainerLog: &aproto.ContainerLog{
+		Container: claimed, AuxMessage: &line,
+	}}).Get()
+	ok := system.Ask(ref, sproto.ClaimTaskContainer{TaskActor: taskRef, ContainerID: "orphan"})
+	assert.Equal(t, ok.Get(), false)
+	ok = system.Ask(ref, sproto.ClaimTaskContainer{TaskActor: taskRef, ContainerID: "claimed"})
+	assert.Equal(t, ok.Get(), true)
+	replayed := received(system, taskRef, task)
+	assert.Equal(t, len(replayed), 2)
+	assert.Equal(t, replayed[0].(sproto.TaskContainerStateChanged).Container.State,
+		container.Running)
+	assert.Equal(t, *replayed[1].(sproto.ContainerLog).AuxMessage, line)
+
+	// Only the container that was not claimed is killed.
+	system.Ask(ref, claimTimeout{}).Get()
+	sent := received(system, socketRef, socket)
+	assert.Equal(t, len(sent), 1)
+	assert.DeepEqual(t, *sent[0].(aproto.AgentMessage).SignalContainer,
+		aproto.SignalContainer{ContainerID: "orphan", Signal: syscall.SIGKILL})
+
+	// The slot of the killed container is freed once it exits, and the claimed container reports
+	// to its task.
+	stopped := aproto.ContainerError(aproto.TaskError, errors.New("killed"))
+	for _, c := range []container.Container{orphan, claimed} {
+		c.State = container.Terminated
+		system.Ask(ref, aproto.MasterMessage{ContainerStateChanged: &aproto.ContainerStateChanged{
+			Container: c, ContainerStopped: &stopped,
+		}}).Get()
+	}
+	summary = system.Ask(ref, AgentSummary{}).Get().(AgentSummary)
+	assert.Equal(t, summary.NumContainers, 0)
+
+	var freed []device.Device
+	for _, msg := range received(system, poolRef, pool) {
+		if free, ok := msg.(sproto.FreeDevice); ok {
+			freed = append(freed, free.Device)
+		}
+	}
+	assert.DeepEqual(t, freed, []device.Device{gpu1, gpu0})
+	msgs := received(system, taskRef, task)
+	assert.Equal(t, msgs[len(msgs)-1].(sproto.TaskContainerStateChanged).Container.ID,
+		container.ID("claimed"))
+}
+
+func TestDrainedAfterContainersExit(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	pool, socket, task := &recorder{}, &recorder{}, &recorder{}
+	poolRef := system.MustActorOf(actor.Addr("pool"), pool)
+	socketRef := system.MustActorOf(actor.Addr("socket"), socket)
+	taskRef := system.MustActorOf(actor.Addr("task"), task)
+
+	gpu := device.Device{ID: 0, Type: device.GPU}
+	ref := system.MustActorOf(actor.Addr("agent"), &agent{
+		resourcePool: poolRef, socket: socketRef, reconnectWait: time.Hour,
+	})
+	system.Ask(ref, aproto.MasterMessage{AgentStarted: &aproto.AgentStarted{
+		Devices: []device.Device{gpu},
+	}}).Get()
+	c := container.Container{
+		Parent: taskRef.Address(), ID: "container", State: container.Assigned,
+		Devices: []device.Device{gpu},
+	}
+	system.Ask(ref, sproto.StartTaskContainer{
+		TaskActor: taskRef, StartContainer: aproto.StartContainer{Container: c},
+	}).Get()
+
+	// The agent is draining, but not drained, while its container runs.
+	resp := system.Ask(ref, &proto.DrainAgentRequest{}).Get().(*proto.DrainAgentResponse)
+	assert.Assert(t, resp.Agent.Draining)
+	assert.Assert(t, !resp.Agent.Drained)
+	assert.Assert(t, resp.Agent.DrainedTime == nil)
+	var drains int
+	for _, msg := range received(system, poolRef, pool) {
+		if _, ok := msg.(sproto.DrainAgent); ok {
+			drains++
+		}
+	}
+	assert.Equal(t, drains, 1)
+
+	// It is drained once the container exits.
+	c.State = container.Terminated
+	stopped := aproto.ContainerError(aproto.TaskError, errors.New("released"))
+	system.Ask(ref, aproto.MasterMessage{ContainerStateChanged: &aproto.ContainerStateChanged{
+		Container: c, ContainerStopped: &stopped,
+	}}).Get()
+	summary := system.Ask(ref, AgentSummary{}).Get().(AgentSummary)
+	assert.Assert(t, summary.Drained)
+	assert.Assert(t, summary.DrainedTime != nil)
+
+	// Enabling the agent schedules tasks on it again.
+	system.Ask(ref, &proto.EnableAgentRequest{}).Get()
+	summary = system.Ask(ref, AgentSummary{}).Get().(AgentSummary)
+	assert.Assert(t, !summary.Draining && !summary.Drained)
+	msgs := received(system, poolRef, pool)
+	_, ok := msgs[len(msgs)-1].(sproto.StopDrainingAgent)
+	assert.Assert(t, ok)
+}
@@ -2,26 +2,30 @@ package agent
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo"
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/api"
 	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 )
 
-// Initialize creates a new global agent actor.
-func Initialize(system *actor.System, e *echo.Echo, c *actor.Ref) {
-	_, ok := system.ActorOf(actor.Addr("agents"), &agents{cluster: c})
+// Initialize creates a new global agent actor. Agents whose connection drops have reconnectWait to
+// reconnect before their containers are considered lost.
+func Initialize(system *actor.System, e *echo.Echo, c *actor.Ref, reconnectWait time.Duration) {
+	_, ok := system.ActorOf(sproto.AgentsAddr, &agents{cluster: c, reconnectWait: reconnectWait})
 	check.Panic(check.True(ok, "agents address already taken"))
 	// Route /agents and /agents/<agent id>/slots to the agents actor and slots actors.
 	e.Any("/agents*", api.Route(system, nil))
 }
 
 type agents struct {
-	cluster *actor.Ref
+	cluster       *actor.Ref
+	reconnectWait time.Duration
 }
 
 type agentsSummary map[string]AgentSummary
@@ -30,11 +34,17 @@ func (a *agents) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case api.WebSocketConnected:
 		id, resourcePool := msg.Ctx.QueryParam("id"), msg.Ctx.QueryParam("resource_pool")
-		if ref, err := a.createAgentActor(ctx, id, resourcePool); err != nil {
+		if ref := ctx.Child(id); ref != nil {
+			// The agent is reconnecting; its actor accepts the connection if the previous one
+			// dropped.
+			ctx.Respond(ctx.Ask(ref, msg).Get())
+		} else if ref, err := a.createAgentActor(ctx, id, resourcePool); err != nil {
 			ctx.Respond(err)
 		} else {
 			ctx.Respond(ctx.Ask(ref, msg).Get())
 		}
+	case sproto.ReportTaskContainers:
+		ctx.TellAll(msg, ctx.Children()...)
 	case *apiv1.GetAgentsRequest:
 		response := &apiv1.GetAgentsResponse{}
 		for _, a := range a.summarize(ctx) {
@@ -63,6 +73,7 @@ func (a *agents) createAgentActor(ctx *actor.Context, id, resourcePool string) (
 	ref, ok := ctx.ActorOf(id, &agent{
 		resourcePool:     a.cluster.Child(resourcePool),
 		resourcePoolName: resourcePool,
+		reconnectWait:    a.reconnectWait,
 	})
 	if !ok {
 		return nil, errors.Errorf("agent already connected: %s", id)
@@ -11,14 +11,21 @@ func ToProtoAgent(a AgentSummary) *proto.Agent {
 	for _, s := range a.Slots {
 		slots[s.ID] = toProtoSlot(s)
 	}
-	return &proto.Agent{
+	agent := &proto.Agent{
 		Id:             a.ID,
 		RegisteredTime: protoutils.ToTimestamp(a.RegisteredTime),
 		Slots:          slots,
 		Containers:     nil,
 		Label:          a.Label,
 		ResourcePool:   a.ResourcePool,
+		Topology:       a.Topology,
+		Draining:       a.Draining,
+		Drained:        a.Drained,
 	}
+	if a.DrainedTime != nil {
+		agent.DrainedTime = protoutils.ToTimestamp(*a.DrainedTime)
+	}
+	return agent
 }
 
 func toProtoSlot(s SlotSummary) *proto.Slot {
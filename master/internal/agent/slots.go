@@ -27,12 +27,23 @@ func (s *slots) Receive(ctx *actor.Context) error {
 	case SlotsSummary:
 		ctx.Respond(s.summarize(ctx))
 	case aproto.AgentStarted:
+		// Containers that the agent kept running across a master restart keep using their devices.
+		inUse := make(map[device.Device]*container.Container)
+		for _, sc := range msg.Containers {
+			if c := sc.Container; c.State != container.Terminated {
+				for _, d := range c.Devices {
+					inUse[d] = &c
+				}
+			}
+		}
 		for _, d := range msg.Devices {
 			enabled := slotEnabled{
 				agentEnabled: true,
 				userEnabled:  true,
 			}
-			_, ok := ctx.ActorOf(d.ID, &slot{resourcePool: s.resourcePool, enabled: enabled, device: d})
+			_, ok := ctx.ActorOf(d.ID, &slot{
+				resourcePool: s.resourcePool, enabled: enabled, device: d, container: inUse[d],
+			})
 			check.Panic(check.True(ok, "error registering slot, slot %s already created", d.ID))
 		}
 	case aproto.StartContainer:
@@ -65,6 +76,7 @@ func (s *slots) handleAPIRequest(ctx *actor.Context, apiCtx echo.Context) {
 		for _, child := range ctx.Children() {
 			ctx.Tell(child, patch)
 		}
+		ctx.Tell(ctx.Self().Parent(), patch)
 		ctx.Respond(apiCtx.NoContent(http.StatusNoContent))
 	default:
 		ctx.Respond(echo.ErrMethodNotAllowed)
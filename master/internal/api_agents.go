@@ -7,7 +7,9 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/proto/pkg/agentv1"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 )
 
@@ -30,9 +32,35 @@ func (a *apiServer) GetAgents(
 		return req.Label == "" || v.Label == req.Label
 	})
 	a.sort(resp.Agents, req.OrderBy, req.SortBy, apiv1.GetAgentsRequest_SORT_BY_ID)
+	if resp.Quotas, err = a.getQuotas(); err != nil {
+		return nil, err
+	}
 	return resp, a.paginate(&resp.Pagination, &resp.Agents, req.Offset, req.Limit)
 }
 
+func (a *apiServer) getQuotas() ([]*agentv1.SlotQuota, error) {
+	resp := a.m.system.Ask(a.m.rm, resourcemanagers.GetQuotaSummaries{})
+	if err := resp.Error(); err != nil {
+		return nil, err
+	}
+	summaries, ok := resp.Get().([]resourcemanagers.QuotaSummary)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "unexpected quota summaries: %T", resp.Get())
+	}
+	quotas := make([]*agentv1.SlotQuota, 0, len(summaries))
+	for _, s := range summaries {
+		quotas = append(quotas, &agentv1.SlotQuota{
+			ResourcePool: s.ResourcePool,
+			Kind:         s.Kind,
+			Name:         s.Name,
+			UsedSlots:    int32(s.UsedSlots),
+			MaxSlots:     int32(s.MaxSlots),
+			BurstSlots:   int32(s.BurstSlots),
+		})
+	}
+	return quotas, nil
+}
+
 func (a *apiServer) GetAgent(
 	_ context.Context, req *apiv1.GetAgentRequest) (resp *apiv1.GetAgentResponse, err error) {
 	err = a.actorRequest(fmt.Sprintf("/agents/%s", req.AgentId), req, &resp)
@@ -63,6 +91,12 @@ func (a *apiServer) DisableAgent(
 	return resp, err
 }
 
+func (a *apiServer) DrainAgent(
+	_ context.Context, req *apiv1.DrainAgentRequest) (resp *apiv1.DrainAgentResponse, err error) {
+	err = a.actorRequest(fmt.Sprintf("/agents/%s", req.AgentId), req, &resp)
+	return resp, err
+}
+
 func (a *apiServer) EnableSlot(
 	_ context.Context, req *apiv1.EnableSlotRequest) (resp *apiv1.EnableSlotResponse, err error) {
 	err = a.actorRequest(fmt.Sprintf("/agents/%s/slots/%s", req.AgentId, req.SlotId), req, &resp)
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func toProtoAuditEntry(entry model.AuditEntry) (*apiv1.AuditEntry, error) {
+	t, err := ptypes.TimestampProto(entry.Time)
+	if err != nil {
+		return nil, err
+	}
+	pEntry := &apiv1.AuditEntry{
+		Id:            entry.ID,
+		Time:          t,
+		Username:      entry.Username,
+		Transport:     entry.Transport,
+		Action:        entry.Action,
+		ResourceType:  entry.ResourceType,
+		ResourceId:    entry.ResourceID,
+		PayloadDigest: entry.PayloadDigest,
+		SourceIp:      entry.SourceIP,
+		Outcome:       entry.Outcome,
+		Status:        entry.Status,
+	}
+	if entry.APITokenID != nil {
+		pEntry.ApiTokenId = int32(*entry.APITokenID)
+	}
+	return pEntry, nil
+}
+
+func (a *apiServer) GetAuditLog(
+	_ context.Context, req *apiv1.GetAuditLogRequest,
+) (*apiv1.GetAuditLogResponse, error) {
+	filter := model.AuditFilter{
+		Username:     req.Username,
+		Action:       req.Action,
+		ResourceType: req.ResourceType,
+		ResourceID:   req.ResourceId,
+		Outcome:      req.Outcome,
+	}
+	if req.Since != nil {
+		since, err := ptypes.Timestamp(req.Since)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		filter.Since = &since
+	}
+	if req.Until != nil {
+		until, err := ptypes.Timestamp(req.Until)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		filter.Until = &until
+	}
+
+	// The audit log may be large, so it is paginated in the database rather than in memory.
+	total, err := a.m.db.CountAuditEntries(filter)
+	if err != nil {
+		return nil, err
+	}
+	pagination, err := api.Paginate(total, int(req.Offset), int(req.Limit))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	entries, err := a.m.db.AuditEntries(filter, req.OrderBy == apiv1.OrderBy_ORDER_BY_DESC,
+		pagination.StartIndex, pagination.EndIndex-pagination.StartIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &apiv1.GetAuditLogResponse{
+		Pagination: &apiv1.Pagination{
+			Offset:     req.Offset,
+			Limit:      req.Limit,
+			StartIndex: int32(pagination.StartIndex),
+			EndIndex:   int32(pagination.EndIndex),
+			Total:      int32(total),
+		},
+	}
+	for _, entry := range entries {
+		pEntry, err := toProtoAuditEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		resp.Entries = append(resp.Entries, pEntry)
+	}
+	return resp, nil
+}
@@ -5,6 +5,9 @@ import (
 	"crypto/sha512"
 	"fmt"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/grpc"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
@@ -67,6 +70,10 @@ func (a *apiServer) Logout(
 	if err != nil {
 		return nil, err
 	}
+	if userSession == nil {
+		return nil, status.Error(
+			codes.InvalidArgument, "cannot log out of an API token; revoke the token instead")
+	}
 	err = a.m.db.DeleteSessionByID(userSession.ID)
 	return &apiv1.LogoutResponse{}, err
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/grpc"
 	"github.com/determined-ai/determined/master/internal/lttb"
+	"github.com/determined-ai/determined/master/internal/rbac"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -98,13 +99,24 @@ func (a *apiServer) GetExperiment(
 }
 
 func (a *apiServer) GetExperiments(
-	_ context.Context, req *apiv1.GetExperimentsRequest) (*apiv1.GetExperimentsResponse, error) {
+	ctx context.Context, req *apiv1.GetExperimentsRequest) (*apiv1.GetExperimentsResponse, error) {
+	user, token, err := grpc.GetUserAndAPIToken(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := a.m.authz.VisibleExperiments(*user, token)
+	if err != nil {
+		return nil, err
+	}
 	resp := &apiv1.GetExperimentsResponse{}
-	if err := a.m.db.QueryProto("get_experiments", &resp.Experiments); err != nil {
+	if err = a.m.db.QueryProto("get_experiments", &resp.Experiments); err != nil {
 		return nil, err
 	}
 	a.filter(&resp.Experiments, func(i int) bool {
 		v := resp.Experiments[i]
+		if !visible(int(v.Id)) {
+			return false
+		}
 		if req.Archived != nil && req.Archived.Value != v.Archived {
 			return false
 		}
@@ -139,12 +151,19 @@ func (a *apiServer) GetExperiments(
 	return resp, a.paginate(&resp.Pagination, &resp.Experiments, req.Offset, req.Limit)
 }
 
-func (a *apiServer) GetExperimentLabels(_ context.Context,
+func (a *apiServer) GetExperimentLabels(ctx context.Context,
 	req *apiv1.GetExperimentLabelsRequest) (*apiv1.GetExperimentLabelsResponse, error) {
 	resp := &apiv1.GetExperimentLabelsResponse{}
 
-	var err error
-	labelUsage, err := a.m.db.ExperimentLabelUsage()
+	user, token, err := grpc.GetUserAndAPIToken(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := a.m.authz.VisibleExperiments(*user, token)
+	if err != nil {
+		return nil, err
+	}
+	labelUsage, err := a.m.db.ExperimentLabelUsage(visible)
 	if err != nil {
 		return nil, err
 	}
@@ -193,6 +212,10 @@ func (a *apiServer) PreviewHPSearch(
 		return nil, status.Errorf(codes.InvalidArgument, "invalid experiment config: %s", err)
 	}
 
+	if config.Searcher.CustomConfig != nil {
+		return nil, status.Error(codes.InvalidArgument, errCustomSearcherPreview.Error())
+	}
+
 	sm := searcher.NewSearchMethod(config.Searcher)
 	s := searcher.NewSearcher(req.Seed, sm, config.Hyperparameters)
 	sim, err := searcher.Simulate(s, nil, searcher.RandomValidation, true, config.Searcher.Metric)
@@ -515,11 +538,34 @@ func (a *apiServer) CreateExperiment(
 		return &apiv1.CreateExperimentResponse{}, nil
 	}
 
-	user, _, err := grpc.GetUser(ctx, a.m.db)
+	protoExp, err := a.startExperiment(ctx, dbExp)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.CreateExperimentResponse{
+		Experiment: protoExp, Config: protoutils.ToStruct(dbExp.Config),
+	}, nil
+}
+
+// startExperiment saves a new experiment, owned by the current user, and starts its actor.
+func (a *apiServer) startExperiment(
+	ctx context.Context, dbExp *model.Experiment,
+) (*experimentv1.Experiment, error) {
+	user, token, err := grpc.GetUserAndAPIToken(ctx, a.m.db)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get the user: %s", err)
 	}
 
+	switch err = a.m.assignWorkspace(*user, token, dbExp); {
+	case errors.Cause(err) == db.ErrNotFound, err == rbac.ErrNotVisible:
+		return nil, status.Errorf(
+			codes.InvalidArgument, "workspace not found: %s", dbExp.Config.Workspace)
+	case err == rbac.ErrPermissionDenied:
+		return nil, grpc.ErrPermissionDenied
+	case err != nil:
+		return nil, err
+	}
+
 	dbExp.OwnerID = &user.ID
 	e, err := newExperiment(a.m, dbExp)
 	if err != nil {
@@ -527,15 +573,111 @@ func (a *apiServer) CreateExperiment(
 	}
 	a.m.system.ActorOf(actor.Addr("experiments", e.ID), e)
 
-	protoExp, err := a.getExperiment(e.ID)
+	return a.getExperiment(e.ID)
+}
+
+func (a *apiServer) ForkExperiment(
+	ctx context.Context, req *apiv1.ForkExperimentRequest,
+) (*apiv1.ForkExperimentResponse, error) {
+	if err := a.checkExperimentExists(int(req.Id)); err != nil {
+		return nil, err
+	}
+
+	dbExp, err := a.m.parseForkExperiment(int(req.Id), req.Config)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid experiment: %s", err)
+	}
+
+	if req.ValidateOnly {
+		return &apiv1.ForkExperimentResponse{}, nil
+	}
+
+	protoExp, err := a.startExperiment(ctx, dbExp)
 	if err != nil {
 		return nil, err
 	}
-	return &apiv1.CreateExperimentResponse{
-		Experiment: protoExp, Config: protoutils.ToStruct(e.Config),
+	return &apiv1.ForkExperimentResponse{
+		Experiment: protoExp, Config: protoutils.ToStruct(dbExp.Config),
 	}, nil
 }
 
+func (a *apiServer) ContinueExperiment(
+	ctx context.Context, req *apiv1.ContinueExperimentRequest,
+) (*apiv1.ContinueExperimentResponse, error) {
+	if err := a.checkExperimentExists(int(req.Id)); err != nil {
+		return nil, err
+	}
+	if len(req.TrialIds) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one trial must be specified")
+	}
+	var maxLength model.Length
+	switch {
+	case req.MaxLength == nil:
+		return nil, status.Error(codes.InvalidArgument, "max_length must be specified")
+	case req.MaxLength.Unit == experimentv1.Unit_UNIT_RECORDS:
+		maxLength = model.NewLengthInRecords(int(req.MaxLength.Count))
+	case req.MaxLength.Unit == experimentv1.Unit_UNIT_BATCHES:
+		maxLength = model.NewLengthInBatches(int(req.MaxLength.Count))
+	case req.MaxLength.Unit == experimentv1.Unit_UNIT_EPOCHS:
+		maxLength = model.NewLengthInEpochs(int(req.MaxLength.Count))
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid max_length unit: %s",
+			req.MaxLength.Unit)
+	}
+
+	forked, err := a.m.parseForkExperiment(int(req.Id), req.Config)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid experiment: %s", err)
+	}
+
+	// Check all of the trials before creating any experiments, so that a bad trial does not leave
+	// the request half done.
+	var dbExps []*model.Experiment
+	for _, trialID := range req.TrialIds {
+		trial, tErr := a.m.db.TrialByID(int(trialID))
+		switch {
+		case errors.Cause(tErr) == db.ErrNotFound ||
+			(tErr == nil && trial.ExperimentID != int(req.Id)):
+			return nil, status.Errorf(
+				codes.NotFound, "trial %d not found in experiment %d", trialID, req.Id)
+		case tErr != nil:
+			return nil, tErr
+		}
+
+		checkpoint, cErr := a.m.db.LatestCheckpointForTrial(trial.ID)
+		switch {
+		case cErr != nil:
+			return nil, cErr
+		case checkpoint == nil:
+			return nil, status.Errorf(
+				codes.FailedPrecondition, "trial %d has no completed checkpoints", trial.ID)
+		}
+		step, cErr := a.m.db.StepByID(trial.ID, checkpoint.StepID)
+		if cErr != nil {
+			return nil, cErr
+		}
+
+		config, cErr := continueTrialConfig(
+			forked.Config, trial, maxLength, step.PriorBatchesProcessed+step.NumBatches)
+		if cErr != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid experiment: %s", cErr)
+		}
+		dbExp := *forked
+		dbExp.Config = config
+		dbExps = append(dbExps, &dbExp)
+	}
+
+	resp := &apiv1.ContinueExperimentResponse{}
+	for _, dbExp := range dbExps {
+		protoExp, sErr := a.startExperiment(ctx, dbExp)
+		if sErr != nil {
+			return nil, sErr
+		}
+		resp.Experiments = append(resp.Experiments, protoExp)
+	}
+	return resp, nil
+}
+
 var metricsStreamPeriod = 30 * time.Second
 
 func (a *apiServer) MetricNames(req *apiv1.MetricNamesRequest,
@@ -725,6 +867,10 @@ func (a *apiServer) topTrials(experimentID int, maxTrials int, s model.SearcherC
 		ranking = ByMetricOfInterest
 	case s.GridConfig != nil:
 		ranking = ByMetricOfInterest
+	case s.TPEConfig != nil:
+		ranking = ByMetricOfInterest
+	case s.CustomConfig != nil:
+		ranking = ByMetricOfInterest
 	case s.SyncHalvingConfig != nil:
 		ranking = ByTrainingLength
 	case s.AdaptiveConfig != nil:
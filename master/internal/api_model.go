@@ -13,6 +13,7 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpc"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/checkpointv1"
 	"github.com/determined-ai/determined/proto/pkg/modelv1"
@@ -132,10 +133,25 @@ func (a *apiServer) GetModelVersions(
 		return nil, err
 	}
 
+	user, token, err := grpc.GetUserAndAPIToken(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := a.m.authz.VisibleExperiments(*user, token)
+	if err != nil {
+		return nil, err
+	}
+
 	resp := &apiv1.GetModelVersionsResponse{Model: getResp.Model}
-	if err := a.m.db.QueryProto("get_model_versions", &resp.ModelVersions, req.ModelName); err != nil {
+	var versions []*modelv1.ModelVersion
+	if err = a.m.db.QueryProto("get_model_versions", &versions, req.ModelName); err != nil {
 		return nil, err
 	}
+	for _, version := range versions {
+		if version.Checkpoint == nil || visible(int(version.Checkpoint.ExperimentId)) {
+			resp.ModelVersions = append(resp.ModelVersions, version)
+		}
+	}
 
 	a.sort(resp.ModelVersions, req.OrderBy, req.SortBy, apiv1.GetModelVersionsRequest_SORT_BY_VERSION)
 	return resp, a.paginate(&resp.Pagination, &resp.ModelVersions, req.Offset, req.Limit)
@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/grpc"
+	"github.com/determined-ai/determined/master/internal/rbac"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+// AuthorizeRequest implements the grpc.RequestAuthorizer interface. Requests that refer to an
+// experiment or a trial require the viewer role for the experiment to read it and the editor role
+// to change it; killing a command requires owning it or the editor role in the cluster; changing
+// models and templates and launching commands require the editor role in the cluster, and
+// TensorBoards also the viewer role for their experiments; checkpoints and model versions require
+// the role for the experiment of the checkpoint; other requests that read require the viewer role
+// in the cluster; and changing agents and users and reading the audit log and usage require the
+// admin role in the cluster. Requests that are not listed are denied. Read-only API tokens may
+// only make requests that read, and API tokens scoped to a workspace may only make requests about
+// the experiments and trials in it.
+func (a *apiServer) AuthorizeRequest(
+	ctx context.Context, user *model.User, token *model.APIToken, req interface{},
+) error {
+	var err error
+	switch req := req.(type) {
+	case *apiv1.GetExperimentRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.ExperimentId), model.RoleViewer)
+	case *apiv1.GetExperimentValidationHistoryRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.ExperimentId), model.RoleViewer)
+	case *apiv1.GetExperimentCheckpointsRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.Id), model.RoleViewer)
+	case *apiv1.GetExperimentTrialsRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.ExperimentId), model.RoleViewer)
+	case *apiv1.MetricNamesRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.ExperimentId), model.RoleViewer)
+	case *apiv1.MetricBatchesRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.ExperimentId), model.RoleViewer)
+	case *apiv1.TrialsSnapshotRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.ExperimentId), model.RoleViewer)
+	case *apiv1.TrialsSampleRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.ExperimentId), model.RoleViewer)
+	case *apiv1.ForkExperimentRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.Id), model.RoleViewer)
+	case *apiv1.ContinueExperimentRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.Id), model.RoleViewer)
+
+	case *apiv1.ActivateExperimentRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.Id), model.RoleEditor)
+	case *apiv1.PauseExperimentRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.Id), model.RoleEditor)
+	case *apiv1.CancelExperimentRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.Id), model.RoleEditor)
+	case *apiv1.KillExperimentRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.Id), model.RoleEditor)
+	case *apiv1.ArchiveExperimentRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.Id), model.RoleEditor)
+	case *apiv1.UnarchiveExperimentRequest:
+		err = a.m.authz.CheckExperiment(*user, token, int(req.Id), model.RoleEditor)
+	case *apiv1.PatchExperimentRequest:
+		if req.Experiment != nil {
+			err = a.m.authz.CheckExperiment(*user, token, int(req.Experiment.Id), model.RoleEditor)
+		}
+
+	case *apiv1.GetTrialRequest:
+		err = a.m.authz.CheckTrial(*user, token, int(req.TrialId), model.RoleViewer)
+	case *apiv1.TrialLogsRequest:
+		err = a.m.authz.CheckTrial(*user, token, int(req.TrialId), model.RoleViewer)
+	case *apiv1.TrialLogsFieldsRequest:
+		err = a.m.authz.CheckTrial(*user, token, int(req.TrialId), model.RoleViewer)
+	case *apiv1.GetTrialCheckpointsRequest:
+		err = a.m.authz.CheckTrial(*user, token, int(req.Id), model.RoleViewer)
+	case *apiv1.KillTrialRequest:
+		err = a.m.authz.CheckTrial(*user, token, int(req.Id), model.RoleEditor)
+
+	case *apiv1.KillNotebookRequest:
+		var resp *apiv1.GetNotebookResponse
+		addr := fmt.Sprintf("/notebooks/%s", req.NotebookId)
+		getReq := &apiv1.GetNotebookRequest{NotebookId: req.NotebookId}
+		if a.actorRequest(addr, getReq, &resp) == nil {
+			err = a.m.authz.CheckCommand(*user, token, resp.Notebook.Username, model.RoleEditor)
+		}
+	case *apiv1.KillCommandRequest:
+		var resp *apiv1.GetCommandResponse
+		addr := fmt.Sprintf("/commands/%s", req.CommandId)
+		getReq := &apiv1.GetCommandRequest{CommandId: req.CommandId}
+		if a.actorRequest(addr, getReq, &resp) == nil {
+			err = a.m.authz.CheckCommand(*user, token, resp.Command.Username, model.RoleEditor)
+		}
+	case *apiv1.KillShellRequest:
+		var resp *apiv1.GetShellResponse
+		addr := fmt.Sprintf("/shells/%s", req.ShellId)
+		getReq := &apiv1.GetShellRequest{ShellId: req.ShellId}
+		if a.actorRequest(addr, getReq, &resp) == nil {
+			err = a.m.authz.CheckCommand(*user, token, resp.Shell.Username, model.RoleEditor)
+		}
+	case *apiv1.KillTensorboardRequest:
+		var resp *apiv1.GetTensorboardResponse
+		addr := tensorboardsAddr.Child(req.TensorboardId).String()
+		getReq := &apiv1.GetTensorboardRequest{TensorboardId: req.TensorboardId}
+		if a.actorRequest(addr, getReq, &resp) == nil {
+			err = a.m.authz.CheckCommand(*user, token, resp.Tensorboard.Username, model.RoleEditor)
+		}
+
+	case *apiv1.LaunchCommandRequest, *apiv1.LaunchNotebookRequest, *apiv1.LaunchShellRequest:
+		err = a.m.authz.CheckCluster(*user, token, model.RoleEditor)
+	case *apiv1.LaunchTensorboardRequest:
+		if err = a.m.authz.CheckCluster(*user, token, model.RoleEditor); err == nil {
+			err = a.m.authz.CheckTensorboard(
+				*user, token, toInts(req.ExperimentIds), toInts(req.TrialIds))
+		}
+
+	case *apiv1.GetCheckpointRequest:
+		err = a.m.authz.CheckCheckpoint(*user, token, req.CheckpointUuid, model.RoleViewer)
+	case *apiv1.PostCheckpointMetadataRequest:
+		if req.Checkpoint != nil {
+			err = a.m.authz.CheckCheckpoint(*user, token, req.Checkpoint.Uuid, model.RoleEditor)
+		}
+	case *apiv1.GetModelVersionRequest:
+		if err = a.m.authz.CheckCluster(*user, token, model.RoleViewer); err != nil {
+			break
+		}
+		if resp, gErr := a.GetModelVersion(ctx, req); gErr == nil &&
+			resp.ModelVersion.Checkpoint != nil {
+			err = a.m.authz.CheckExperiment(
+				*user, token, int(resp.ModelVersion.Checkpoint.ExperimentId), model.RoleViewer)
+		}
+	case *apiv1.PostModelVersionRequest:
+		if err = a.m.authz.CheckCluster(*user, token, model.RoleEditor); err == nil {
+			err = a.m.authz.CheckCheckpoint(*user, token, req.CheckpointUuid, model.RoleViewer)
+		}
+	case *apiv1.PostModelRequest, *apiv1.PatchModelRequest, *apiv1.PutTemplateRequest,
+		*apiv1.DeleteTemplateRequest:
+		err = a.m.authz.CheckCluster(*user, token, model.RoleEditor)
+	case *apiv1.GetModelsRequest, *apiv1.GetModelRequest, *apiv1.GetModelVersionsRequest,
+		*apiv1.GetTemplatesRequest, *apiv1.GetTemplateRequest, *apiv1.PreviewHPSearchRequest,
+		*apiv1.GetCommandsRequest, *apiv1.GetCommandRequest, *apiv1.GetNotebooksRequest,
+		*apiv1.GetNotebookRequest, *apiv1.NotebookLogsRequest, *apiv1.GetShellsRequest,
+		*apiv1.GetShellRequest, *apiv1.GetTensorboardsRequest, *apiv1.GetTensorboardRequest,
+		*apiv1.GetUsersRequest, *apiv1.GetUserRequest, *apiv1.GetMasterConfigRequest,
+		*apiv1.MasterLogsRequest, *apiv1.GetAgentsRequest, *apiv1.GetAgentRequest,
+		*apiv1.GetSlotsRequest, *apiv1.GetSlotRequest, *apiv1.GetResourcePoolsRequest:
+		// The model versions of a model are filtered by the visibility of their experiments.
+		err = a.m.authz.CheckCluster(*user, token, model.RoleViewer)
+
+	case *apiv1.PostUserRequest:
+		err = a.m.authz.CheckCluster(*user, token, model.RoleAdmin)
+	case *apiv1.SetUserPasswordRequest:
+		err = a.m.authz.CheckUser(*user, token, req.Username)
+
+	case *apiv1.EnableAgentRequest, *apiv1.DisableAgentRequest, *apiv1.DrainAgentRequest,
+		*apiv1.EnableSlotRequest, *apiv1.DisableSlotRequest:
+		err = a.m.authz.CheckCluster(*user, token, model.RoleAdmin)
+	case *apiv1.GetAuditLogRequest, *apiv1.GetUsageRequest:
+		// Reading the audit log and usage is allowed with read-only API tokens of admins.
+		if err = rbac.CheckTokenScope(token); err != nil {
+			break
+		}
+		if err = rbac.CheckAPIToken(token, model.RoleViewer); err == nil {
+			err = a.m.authz.CheckCluster(*user, nil, model.RoleAdmin)
+		}
+
+	case *apiv1.CreateExperimentRequest:
+		// The workspace of the new experiment is checked when it is saved.
+		err = rbac.CheckAPIToken(token, model.RoleEditor)
+	case *apiv1.GetExperimentsRequest, *apiv1.GetExperimentLabelsRequest:
+		// The experiments are filtered by visibility, so workspace-scoped API tokens may list them.
+		err = rbac.CheckAPIToken(token, model.RoleViewer)
+	case *apiv1.CurrentUserRequest, *apiv1.LogoutRequest:
+
+	default:
+		// Requests that are not authorized above are denied, so that new requests are not made
+		// without access control.
+		err = rbac.ErrPermissionDenied
+	}
+
+	switch err {
+	case nil:
+		return nil
+	case rbac.ErrNotVisible:
+		return status.Error(codes.NotFound, "not found")
+	case rbac.ErrPermissionDenied:
+		return grpc.ErrPermissionDenied
+	default:
+		return err
+	}
+}
+
+func toInts(ids []int32) []int {
+	ints := make([]int, 0, len(ids))
+	for _, id := range ids {
+		ints = append(ints, int(id))
+	}
+	return ints
+}
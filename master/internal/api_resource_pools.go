@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func (a *apiServer) GetResourcePools(
+	_ context.Context, _ *apiv1.GetResourcePoolsRequest,
+) (*apiv1.GetResourcePoolsResponse, error) {
+	resp := a.m.system.Ask(a.m.rm, resourcemanagers.GetResourcePoolSummaries{})
+	if err := resp.Error(); err != nil {
+		return nil, err
+	}
+	summaries, ok := resp.Get().([]resourcemanagers.ResourcePoolSummary)
+	if !ok {
+		return nil, status.Errorf(
+			codes.Internal, "unexpected resource pool summaries: %T", resp.Get())
+	}
+	pools := make([]*apiv1.ResourcePool, 0, len(summaries))
+	for _, s := range summaries {
+		pool := &apiv1.ResourcePool{
+			Name:        s.Name,
+			Description: s.Description,
+			NumAgents:   int32(s.NumAgents),
+			NumSlots:    int32(s.NumSlots),
+			UsedSlots:   int32(s.UsedSlots),
+		}
+		if p := s.Provisioner; p != nil {
+			pool.Provisioner = &apiv1.ProvisionerSummary{
+				MinInstances:     int32(p.MinInstances),
+				MaxInstances:     int32(p.MaxInstances),
+				CapacitySchedule: p.CapacitySchedule,
+				Instances:        int32(p.Instances),
+			}
+		}
+		pools = append(pools, pool)
+	}
+	return &apiv1.GetResourcePoolsResponse{ResourcePools: pools}, nil
+}
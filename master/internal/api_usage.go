@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+var usageGroupBys = map[apiv1.GetUsageRequest_GroupBy]model.UsageGroupBy{
+	apiv1.GetUsageRequest_GROUP_BY_UNSPECIFIED:   model.UsageGroupByUser,
+	apiv1.GetUsageRequest_GROUP_BY_USER:          model.UsageGroupByUser,
+	apiv1.GetUsageRequest_GROUP_BY_EXPERIMENT:    model.UsageGroupByExperiment,
+	apiv1.GetUsageRequest_GROUP_BY_LABEL:         model.UsageGroupByLabel,
+	apiv1.GetUsageRequest_GROUP_BY_RESOURCE_POOL: model.UsageGroupByResourcePool,
+	apiv1.GetUsageRequest_GROUP_BY_TASK:          model.UsageGroupByTask,
+}
+
+var usagePeriods = map[apiv1.GetUsageRequest_Period]model.UsagePeriod{
+	apiv1.GetUsageRequest_PERIOD_UNSPECIFIED: model.UsagePeriodNone,
+	apiv1.GetUsageRequest_PERIOD_DAY:         model.UsagePeriodDay,
+	apiv1.GetUsageRequest_PERIOD_WEEK:        model.UsagePeriodWeek,
+	apiv1.GetUsageRequest_PERIOD_MONTH:       model.UsagePeriodMonth,
+}
+
+func (a *apiServer) GetUsage(
+	_ context.Context, req *apiv1.GetUsageRequest,
+) (*apiv1.GetUsageResponse, error) {
+	groupBy, ok := usageGroupBys[req.GroupBy]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown group by: %s", req.GroupBy)
+	}
+	period, ok := usagePeriods[req.Period]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown period: %s", req.Period)
+	}
+	filter := model.UsageFilter{GroupBy: groupBy, Period: period}
+	if req.Since != nil {
+		since, err := ptypes.Timestamp(req.Since)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		filter.Since = &since
+	}
+	if req.Until != nil {
+		until, err := ptypes.Timestamp(req.Until)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		filter.Until = &until
+	}
+
+	entries, err := a.m.db.SlotUsageReport(filter)
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetUsageResponse{}
+	for _, entry := range entries {
+		periodStart, err := ptypes.TimestampProto(entry.PeriodStart)
+		if err != nil {
+			return nil, err
+		}
+		periodEnd, err := ptypes.TimestampProto(entry.PeriodEnd)
+		if err != nil {
+			return nil, err
+		}
+		resp.Entries = append(resp.Entries, &apiv1.UsageEntry{
+			PeriodStart: periodStart,
+			PeriodEnd:   periodEnd,
+			Key:         entry.Key,
+			SlotHours:   entry.SlotHours,
+			Cost:        entry.Cost,
+		})
+	}
+	return resp, nil
+}
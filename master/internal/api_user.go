@@ -79,15 +79,9 @@ func (a *apiServer) GetUser(
 }
 
 func (a *apiServer) PostUser(
-	ctx context.Context, req *apiv1.PostUserRequest) (*apiv1.PostUserResponse, error) {
-	curUser, _, err := grpc.GetUser(ctx, a.m.db)
-	if err != nil {
-		return nil, err
-	}
-	if !curUser.Admin {
-		return nil, grpc.ErrPermissionDenied
-	}
-	if err = grpc.ValidateRequest(
+	_ context.Context, req *apiv1.PostUserRequest) (*apiv1.PostUserResponse, error) {
+	// The caller is checked to be an admin by AuthorizeRequest.
+	if err := grpc.ValidateRequest(
 		func() (bool, string) { return req.User != nil, "no user specified" },
 		func() (bool, string) { return req.User.Username != "", "no username specified" },
 	); err != nil {
@@ -98,7 +92,7 @@ func (a *apiServer) PostUser(
 		Admin:    req.User.Admin,
 		Active:   req.User.Active,
 	}
-	if err = user.UpdatePasswordHash(req.Password); err != nil {
+	if err := user.UpdatePasswordHash(req.Password); err != nil {
 		return nil, err
 	}
 	var agentUserGroup *model.AgentUserGroup
@@ -109,7 +103,7 @@ func (a *apiServer) PostUser(
 		}
 	}
 
-	switch err = a.m.db.AddUser(user, agentUserGroup); {
+	switch err := a.m.db.AddUser(user, agentUserGroup); {
 	case err == db.ErrDuplicateRecord:
 		return nil, status.Error(codes.InvalidArgument, "user already exists")
 	case err != nil:
@@ -120,19 +114,13 @@ func (a *apiServer) PostUser(
 }
 
 func (a *apiServer) SetUserPassword(
-	ctx context.Context, req *apiv1.SetUserPasswordRequest) (*apiv1.SetUserPasswordResponse, error) {
-	curUser, _, err := grpc.GetUser(ctx, a.m.db)
-	if err != nil {
-		return nil, err
-	}
-	if !curUser.Admin && curUser.Username != req.Username {
-		return nil, grpc.ErrPermissionDenied
-	}
+	_ context.Context, req *apiv1.SetUserPasswordRequest) (*apiv1.SetUserPasswordResponse, error) {
+	// The caller is checked to be the user or an admin by AuthorizeRequest.
 	user := &model.User{Username: req.Username}
-	if err = user.UpdatePasswordHash(replicateClientSideSaltAndHash(req.Password)); err != nil {
+	if err := user.UpdatePasswordHash(replicateClientSideSaltAndHash(req.Password)); err != nil {
 		return nil, err
 	}
-	switch err = a.m.db.UpdateUser(user, []string{"password_hash"}, nil); {
+	switch err := a.m.db.UpdateUser(user, []string{"password_hash"}, nil); {
 	case err == db.ErrNotFound:
 		return nil, errUserNotFound
 	case err != nil:
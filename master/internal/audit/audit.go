@@ -0,0 +1,224 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// unauditedPathPrefixes lists the routes whose requests are not audited by the HTTP middleware:
+// the gRPC gateway is audited by the gRPC server, and the others carry traffic of tasks rather
+// than operations of users.
+var unauditedPathPrefixes = []string{
+	"/api/v1/",
+	"/proxy/",
+	"/trial_logs",
+	"/debug/",
+}
+
+// TrustedProxies are the networks of the reverse proxies in front of the master, whose
+// X-Forwarded-For headers are trusted to name the clients that they forward requests for.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a list of IP addresses and CIDR ranges.
+func ParseTrustedProxies(addrs []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(addrs))
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(addr)
+		if err != nil {
+			return nil, errors.Errorf("invalid trusted proxy (expecting IP or CIDR): %s", addr)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+func (t TrustedProxies) trusts(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range t {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client of a request that was received from the peer
+// address. The X-Forwarded-For headers, which each proxy appends the address of its own peer to,
+// are only followed back from the peer while the addresses are trusted proxies, so clients cannot
+// forge their address by sending the header themselves.
+func (t TrustedProxies) ClientIP(peer string, forwardedFor []string) string {
+	addr := peer
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		addr = host
+	}
+	var hops []string
+	for _, header := range forwardedFor {
+		for _, hop := range strings.Split(header, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	for i := len(hops) - 1; i >= 0 && hops[i] != "" && t.trusts(addr); i-- {
+		addr = hops[i]
+	}
+	return addr
+}
+
+// sensitiveRoutes lists the routes whose requests carry passwords. The digests of their payloads
+// are not recorded, since a digest of a password could be used to guess it.
+var sensitiveRoutes = map[string]bool{
+	"POST /login":            true,
+	"POST /users":            true,
+	"PATCH /users/:username": true,
+}
+
+// Record adds the entry to the audit log. Failing to record an entry does not fail the request, so
+// the error is only logged.
+func Record(d *db.PgDB, entry model.AuditEntry) {
+	if err := d.AddAuditEntry(&entry); err != nil {
+		log.WithError(err).Errorf("failed to record %s in the audit log", entry.Action)
+	}
+}
+
+// IsMutatingHTTPMethod returns true if requests with the HTTP method may change the state of the
+// cluster.
+func IsMutatingHTTPMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodConnect:
+		return false
+	default:
+		return true
+	}
+}
+
+// resourceFromParams returns the resource that a route targets from its first path parameter,
+// e.g., ("experiment", "12") for /experiments/:experiment_id/kill.
+func resourceFromParams(names, values []string) (string, string) {
+	if len(names) == 0 || len(values) == 0 {
+		return "", ""
+	}
+	name := names[0]
+	if name == "username" {
+		return "user", values[0]
+	}
+	for _, suffix := range []string{"_id", "_uuid", "_name"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return name, values[0]
+}
+
+// digestingReader computes the digest of the request body as the handler reads it.
+type digestingReader struct {
+	io.ReadCloser
+	hash hash.Hash
+	read int64
+}
+
+func (r *digestingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n]) //nolint:errcheck // Writing to a hash never fails.
+	r.read += int64(n)
+	return n, err
+}
+
+// newHash returns the hash that payload digests are computed with, which matches
+// model.AuditPayloadDigest.
+func newHash() hash.Hash {
+	return sha256.New()
+}
+
+// digest reads the rest of the body that the handler did not read and returns the digest of the
+// whole body.
+func (r *digestingReader) digest() string {
+	_, _ = io.Copy(ioutil.Discard, r)
+	if r.read == 0 {
+		return ""
+	}
+	return hex.EncodeToString(r.hash.Sum(nil))
+}
+
+// Middleware records every request that may change the state of the cluster in the audit log. It
+// must be registered after the middleware that replaces the echo context, so that it sees the user
+// that the request is authenticated as. The address of the client is taken from the
+// X-Forwarded-For header only for requests from the trusted proxies.
+func Middleware(d *db.PgDB, proxies TrustedProxies) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !IsMutatingHTTPMethod(c.Request().Method) {
+				return next(c)
+			}
+			for _, prefix := range unauditedPathPrefixes {
+				if strings.HasPrefix(c.Path(), prefix) {
+					return next(c)
+				}
+			}
+
+			action := c.Request().Method + " " + c.Path()
+			body := &digestingReader{ReadCloser: c.Request().Body, hash: newHash()}
+			if c.Request().Body != nil && !sensitiveRoutes[action] {
+				c.Request().Body = body
+			}
+
+			err := next(c)
+
+			sourceIP := proxies.ClientIP(
+				c.Request().RemoteAddr, c.Request().Header.Values(echo.HeaderXForwardedFor))
+			entry := model.AuditEntry{
+				Transport: model.AuditTransportHTTP,
+				Action:    action,
+				SourceIP:  sourceIP,
+				Outcome:   model.AuditOutcomeSuccess,
+			}
+			if c.Request().Body == body {
+				entry.PayloadDigest = body.digest()
+			}
+			entry.ResourceType, entry.ResourceID = resourceFromParams(c.ParamNames(), c.ParamValues())
+			if user, ok := c.Get("user").(model.User); ok {
+				entry.UserID = &user.ID
+				entry.Username = user.Username
+			}
+			if token, ok := c.Get("api-token").(model.APIToken); ok {
+				entry.APITokenID = &token.ID
+			}
+
+			code := c.Response().Status
+			if err != nil {
+				code = http.StatusInternalServerError
+				if he, ok := err.(*echo.HTTPError); ok {
+					code = he.Code
+				}
+			}
+			if code >= http.StatusBadRequest {
+				entry.Outcome = model.AuditOutcomeFailure
+			}
+			entry.Status = strconv.Itoa(code)
+
+			Record(d, entry)
+			return err
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestResourceFromParams(t *testing.T) {
+	typ, id := resourceFromParams([]string{"experiment_id"}, []string{"12"})
+	assert.Equal(t, typ, "experiment")
+	assert.Equal(t, id, "12")
+
+	typ, id = resourceFromParams([]string{"checkpoint_uuid"}, []string{"abc"})
+	assert.Equal(t, typ, "checkpoint")
+	assert.Equal(t, id, "abc")
+
+	typ, id = resourceFromParams([]string{"username"}, []string{"alice"})
+	assert.Equal(t, typ, "user")
+	assert.Equal(t, id, "alice")
+
+	typ, id = resourceFromParams([]string{"workspace", "experiment_id"}, []string{"ml", "3"})
+	assert.Equal(t, typ, "workspace")
+	assert.Equal(t, id, "ml")
+
+	typ, id = resourceFromParams(nil, nil)
+	assert.Equal(t, typ, "")
+	assert.Equal(t, id, "")
+}
+
+func TestDigestingReader(t *testing.T) {
+	payload := `{"username": "alice"}`
+	r := &digestingReader{
+		ReadCloser: ioutil.NopCloser(strings.NewReader(payload)),
+		hash:       newHash(),
+	}
+	// The handler may only read part of the body.
+	_, err := r.Read(make([]byte, 4))
+	assert.NilError(t, err)
+	assert.Equal(t, r.digest(), model.AuditPayloadDigest([]byte(payload)))
+
+	empty := &digestingReader{ReadCloser: ioutil.NopCloser(strings.NewReader("")), hash: newHash()}
+	assert.Equal(t, empty.digest(), "")
+}
+
+func TestIsMutatingHTTPMethod(t *testing.T) {
+	assert.Assert(t, IsMutatingHTTPMethod("POST"))
+	assert.Assert(t, IsMutatingHTTPMethod("DELETE"))
+	assert.Assert(t, !IsMutatingHTTPMethod("GET"))
+	assert.Assert(t, !IsMutatingHTTPMethod("HEAD"))
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	assert.NilError(t, err)
+
+	assert.Equal(t, proxies.ClientIP("198.51.100.4:1234", nil), "198.51.100.4")
+	// The header of a client that connects directly is ignored.
+	assert.Equal(t, proxies.ClientIP("198.51.100.4:1234", []string{"203.0.113.9"}), "198.51.100.4")
+	// The header is followed through trusted proxies only.
+	assert.Equal(t, proxies.ClientIP("10.0.0.1:1234", []string{"203.0.113.9, 192.0.2.1"}),
+		"203.0.113.9")
+	assert.Equal(t, proxies.ClientIP("10.0.0.1:1234", []string{"203.0.113.9, 198.51.100.4"}),
+		"198.51.100.4")
+	assert.Equal(t, proxies.ClientIP("10.0.0.1:1234", []string{"203.0.113.9", "10.0.0.2"}),
+		"203.0.113.9")
+
+	_, err = ParseTrustedProxies([]string{"proxy.example.com"})
+	assert.ErrorContains(t, err, "invalid trusted proxy")
+}
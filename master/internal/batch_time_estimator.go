@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/workload"
+)
+
+// batchTimeEstimator estimates how long training a number of batches takes from the training
+// workloads that the trials of an experiment have completed. It is shared by the trials of the
+// experiment, so that new trials can be estimated from the progress of earlier ones.
+type batchTimeEstimator struct {
+	mu      sync.Mutex
+	batches int
+	elapsed time.Duration
+}
+
+// record adds the time taken by a completed training workload to the estimate.
+func (e *batchTimeEstimator) record(msg workload.CompletedMessage) {
+	if e == nil || msg.Workload.Kind != workload.RunStep || msg.Workload.NumBatches <= 0 ||
+		msg.ExitedReason != nil || !msg.EndTime.After(msg.StartTime) {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.batches += msg.Workload.NumBatches
+	e.elapsed += msg.EndTime.Sub(msg.StartTime)
+}
+
+// estimate returns the expected time to train the number of batches, or zero if no training
+// workloads have been recorded.
+func (e *batchTimeEstimator) estimate(batches int) time.Duration {
+	if e == nil || batches <= 0 {
+		return 0
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.batches == 0 {
+		return 0
+	}
+	return time.Duration(float64(e.elapsed) / float64(e.batches) * float64(batches))
+}
@@ -21,12 +21,15 @@ func RegisterAPIHandler(
 	timeout int,
 	defaultAgentUserGroup model.AgentUserGroup,
 	taskSpec *tasks.TaskSpec,
+	idlePolicy model.IdlePolicyConfig,
+	reattachWait time.Duration,
 	middleware ...echo.MiddlewareFunc,
 ) {
 	system.ActorOf(actor.Addr("commands"), &commandManager{
 		defaultAgentUserGroup: defaultAgentUserGroup,
 		db:                    db,
 		taskSpec:              taskSpec,
+		reattachWait:          reattachWait,
 	})
 	echo.Any("/commands*", api.Route(system, nil), middleware...)
 
@@ -34,6 +37,8 @@ func RegisterAPIHandler(
 		defaultAgentUserGroup: defaultAgentUserGroup,
 		db:                    db,
 		taskSpec:              taskSpec,
+		idlePolicy:            idlePolicy,
+		reattachWait:          reattachWait,
 	})
 	echo.Any("/notebooks*", api.Route(system, nil), middleware...)
 
@@ -41,6 +46,8 @@ func RegisterAPIHandler(
 		defaultAgentUserGroup: defaultAgentUserGroup,
 		db:                    db,
 		taskSpec:              taskSpec,
+		idlePolicy:            idlePolicy,
+		reattachWait:          reattachWait,
 	})
 	echo.Any("/shells*", api.Route(system, nil), middleware...)
 
@@ -50,6 +57,7 @@ func RegisterAPIHandler(
 		taskSpec:              taskSpec,
 		proxyRef:              proxyRef,
 		timeout:               time.Duration(timeout) * time.Second,
+		reattachWait:          reattachWait,
 	})
 	echo.Any("/tensorboard*", api.Route(system, nil), middleware...)
 }
@@ -10,6 +10,8 @@ import (
 	"github.com/labstack/echo"
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/proxy"
 	"github.com/determined-ai/determined/master/internal/resourcemanagers"
 	"github.com/determined-ai/determined/master/internal/sproto"
@@ -40,6 +42,10 @@ type readinessCheck func(sproto.ContainerLog) bool
 // should stop and garbage collect its state.
 type terminateForGC struct{}
 
+// reattachTimeout is an internal message indicating that the agents did not report the container
+// of a command that was restored after the master restarted in time.
+type reattachTimeout struct{}
+
 // commandOwner describes the owner of a command.
 type commandOwner struct {
 	ID       model.UserID `json:"id"`
@@ -65,7 +71,9 @@ func DefaultConfig(taskContainerDefaults *model.TaskContainerDefaultsConfig) mod
 
 // command is executed in a containerized environment on a Determined cluster.
 type command struct {
-	config model.CommandConfig
+	db          *db.PgDB
+	commandType model.CommandType
+	config      model.CommandConfig
 
 	owner          commandOwner
 	agentUserGroup *model.AgentUserGroup
@@ -80,40 +88,65 @@ type command struct {
 	serviceAddress       *string
 
 	registeredTime time.Time
+	endTime        *time.Time
 	task           *resourcemanagers.AllocateRequest
 	container      *container.Container
 	allocation     resourcemanagers.Allocation
 	proxyNames     []string
 	exitStatus     *string
 	addresses      []container.Address
+	lastActivity   time.Time
+	idleWarned     bool
 
 	proxy       *actor.Ref
 	rps         *actor.Ref
 	eventStream *actor.Ref
+
+	// reattachWait is how long a command that was restored after the master restarted waits for
+	// the agents to report its container; zero disables reattaching to it.
+	reattachWait       time.Duration
+	restoredContainers []container.ID
+	resourcePool       string
+	reattachment       *resourcemanagers.Reattachment
 }
 
 // Receive implements the actor.Actor interface.
 func (c *command) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case actor.PreStart:
-		c.registeredTime = ctx.Self().RegisteredTime()
 		// Initialize an event stream manager.
 		c.eventStream, _ = ctx.ActorOf("events", newEventManager())
-		// Schedule the command with the cluster.
 		c.rps = ctx.Self().System().Get(actor.Addr("resourceManagers"))
 		c.proxy = ctx.Self().System().Get(actor.Addr("proxy"))
 
-		c.task = &resourcemanagers.AllocateRequest{
-			ID:             c.taskID,
-			Name:           c.config.Description,
-			SlotsNeeded:    c.config.Resources.Slots,
-			Label:          c.config.Resources.AgentLabel,
-			NonPreemptible: true,
-			FittingRequirements: resourcemanagers.FittingRequirements{
-				SingleAgent: true,
-			},
-			TaskActor: ctx.Self(),
+		if c.endTime != nil {
+			// The command was restored after the master restarted, and has exited.
+			actors.NotifyAfter(ctx, terminatedDuration-time.Since(*c.endTime), terminateForGC{})
+			return nil
+		}
+		if len(c.restoredContainers) > 0 {
+			// The command was restored after the master restarted, and its container may still be
+			// running on an agent.
+			c.task = c.allocateRequest(ctx)
+			c.reattachment = resourcemanagers.NewReattachment(*c.task, c.restoredContainers)
+			c.reattachment.Start(ctx)
+			actors.NotifyAfter(ctx, c.reattachWait, reattachTimeout{})
+			return nil
+		}
+
+		c.registeredTime = ctx.Self().RegisteredTime()
+		if err := c.db.AddCommand(&model.Command{
+			TaskID:         string(c.taskID),
+			Type:           c.commandType,
+			OwnerID:        c.owner.ID,
+			Config:         c.config,
+			RegisteredTime: c.registeredTime,
+		}); err != nil {
+			ctx.Log().WithError(err).Error("failed to record task; it is lost if the master restarts")
 		}
+
+		// Schedule the command with the cluster.
+		c.task = c.allocateRequest(ctx)
 		ctx.Tell(c.rps, *c.task)
 		ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), ScheduledEvent: &c.taskID})
 
@@ -123,6 +156,15 @@ func (c *command) Receive(ctx *actor.Context) error {
 	case resourcemanagers.ResourcesAllocated:
 		return c.receiveSchedulerMsg(ctx)
 
+	case sproto.TaskContainerReported:
+		c.receiveContainerReported(ctx, msg)
+
+	case reattachTimeout:
+		if c.reattachment != nil {
+			ctx.Log().Warnf("the container of the task was not reported within %s", c.reattachWait)
+			c.exit(ctx, masterRestartedExitStatus)
+		}
+
 	case getSummary:
 		if msg.userFilter == "" || c.owner.Username == msg.userFilter {
 			ctx.Respond(newSummary(c))
@@ -220,6 +262,7 @@ func (c *command) Receive(ctx *actor.Context) error {
 			ctx.Tell(c.eventStream, event{
 				Snapshot: newSummary(c), ContainerStartedEvent: msg.ContainerStarted,
 			})
+			c.startIdleChecks(ctx)
 
 		case msg.Container.State == container.Terminated:
 			for _, name := range c.proxyNames {
@@ -243,6 +286,16 @@ func (c *command) Receive(ctx *actor.Context) error {
 		log := msg.String()
 		ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), LogEvent: &log})
 
+	case checkIdle:
+		c.checkIdle(ctx)
+
+	case kernelsActivity:
+		if msg.err != nil {
+			ctx.Log().WithError(msg.err).Debug("failed to get the activity of kernels")
+		} else {
+			c.observeActivity(msg.last)
+		}
+
 	case terminateForGC:
 		ctx.Self().Stop()
 
@@ -255,12 +308,50 @@ func (c *command) Receive(ctx *actor.Context) error {
 	return nil
 }
 
+func (c *command) allocateRequest(ctx *actor.Context) *resourcemanagers.AllocateRequest {
+	return &resourcemanagers.AllocateRequest{
+		ID:             c.taskID,
+		Name:           c.config.Description,
+		SlotsNeeded:    c.config.Resources.Slots,
+		Label:          c.config.Resources.AgentLabel,
+		ResourcePool:   c.resourcePool,
+		NonPreemptible: true,
+		FittingRequirements: resourcemanagers.FittingRequirements{
+			SingleAgent: true,
+		},
+		TaskActor: ctx.Self(),
+		User:      c.owner.Username,
+	}
+}
+
+// receiveContainerReported reattaches a command that was restored after the master restarted to
+// its container once an agent reports it.
+func (c *command) receiveContainerReported(ctx *actor.Context, msg sproto.TaskContainerReported) {
+	if c.reattachment == nil || !c.reattachment.Reported(msg) {
+		return
+	}
+	allocated, err := c.reattachment.Reattach(ctx, c.rps)
+	c.reattachment = nil
+	if err != nil {
+		ctx.Log().WithError(err).Warn("failed to reattach to the container of the task")
+		c.exit(ctx, masterRestartedExitStatus)
+		return
+	}
+	ctx.Log().Info("reattached to the container of the task")
+	c.allocation = allocated.Allocations[0]
+	ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), AssignedEvent: allocated})
+}
+
 // handleAPIRequest handles API requests inbound to this actor.
 func (c *command) handleAPIRequest(ctx *actor.Context, apiCtx echo.Context) {
 	switch apiCtx.Request().Method {
 	case echo.GET:
 		ctx.Respond(apiCtx.JSON(http.StatusOK, newSummary(c)))
 	case echo.DELETE:
+		if !c.mayTerminate(apiCtx) {
+			ctx.Respond(echo.NewHTTPError(http.StatusForbidden, "insufficient permissions"))
+			return
+		}
 		c.terminate(ctx)
 		ctx.Respond(apiCtx.NoContent(http.StatusAccepted))
 	default:
@@ -268,6 +359,20 @@ func (c *command) handleAPIRequest(ctx *actor.Context, apiCtx echo.Context) {
 	}
 }
 
+// mayTerminate returns true if the user making the request owns the command or is an editor of
+// the cluster.
+func (c *command) mayTerminate(apiCtx echo.Context) bool {
+	detContext, ok := apiCtx.(*context.DetContext)
+	if !ok {
+		return true
+	}
+	if user, ok := detContext.Get("user").(model.User); ok && user.Username == c.owner.Username {
+		return true
+	}
+	role, ok := detContext.ClusterRole()
+	return !ok || role.Includes(model.RoleEditor)
+}
+
 func (c *command) receiveSchedulerMsg(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case resourcemanagers.ResourcesAllocated:
@@ -289,6 +394,14 @@ func (c *command) receiveSchedulerMsg(ctx *actor.Context) error {
 			AdditionalFiles: c.additionalFiles,
 		}
 		msg.Allocations[0].Start(ctx, taskSpec)
+		if c.reattachWait > 0 {
+			containerID := string(c.allocation.Summary().ID)
+			if err := c.db.AddTaskContainers(
+				string(c.taskID), msg.ResourcePool, []string{containerID},
+			); err != nil {
+				ctx.Log().WithError(err).Error("failed to record the container of the task")
+			}
+		}
 
 		ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), AssignedEvent: &msg})
 
@@ -325,9 +438,21 @@ func (c *command) terminate(ctx *actor.Context) {
 // 2. Forcible terminating a command by killing containers.
 // 3. The command container exits itself.
 func (c *command) exit(ctx *actor.Context, exitStatus string) {
+	c.reattachment = nil
 	c.exitStatus = &exitStatus
 	ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), ExitedEvent: c.exitStatus})
 
+	if c.endTime == nil {
+		endTime := time.Now()
+		c.endTime = &endTime
+		if err := c.db.CompleteCommand(string(c.taskID), endTime, exitStatus); err != nil {
+			ctx.Log().WithError(err).Error("failed to record that task exited")
+		}
+		if err := c.db.DeleteTaskContainers(string(c.taskID)); err != nil {
+			ctx.Log().WithError(err).Error("failed to delete the container of the task")
+		}
+	}
+
 	ctx.Tell(c.rps, resourcemanagers.ResourcesReleased{TaskActor: ctx.Self()})
 	actors.NotifyAfter(ctx, terminatedDuration, terminateForGC{})
 }
@@ -352,7 +477,7 @@ func (c *command) toNotebook(ctx *actor.Context) (*notebookv1.Notebook, error) {
 		Description:    c.config.Description,
 		Container:      c.container.Proto(),
 		ServiceAddress: serviceAddress,
-		StartTime:      protoutils.ToTimestamp(ctx.Self().RegisteredTime()),
+		StartTime:      protoutils.ToTimestamp(c.registeredTime),
 		Username:       c.owner.Username,
 	}, nil
 }
@@ -362,36 +487,42 @@ func (c *command) toCommand(ctx *actor.Context) *commandv1.Command {
 		Id:          ctx.Self().Address().Local(),
 		Description: c.config.Description,
 		Container:   c.container.Proto(),
-		StartTime:   protoutils.ToTimestamp(ctx.Self().RegisteredTime()),
+		StartTime:   protoutils.ToTimestamp(c.registeredTime),
 		Username:    c.owner.Username,
 	}
 }
 
 func (c *command) toShell(ctx *actor.Context) *shellv1.Shell {
+	// The keys of shells are not restored after the master restarts.
+	privateKey, _ := c.metadata["privateKey"].(string)
+	publicKey, _ := c.metadata["publicKey"].(string)
 	return &shellv1.Shell{
 		Id:          ctx.Self().Address().Local(),
 		Description: c.config.Description,
-		StartTime:   protoutils.ToTimestamp(ctx.Self().RegisteredTime()),
+		StartTime:   protoutils.ToTimestamp(c.registeredTime),
 		Container:   c.container.Proto(),
-		PrivateKey:  c.metadata["privateKey"].(string),
-		PublicKey:   c.metadata["publicKey"].(string),
+		PrivateKey:  privateKey,
+		PublicKey:   publicKey,
 		Username:    c.owner.Username,
 	}
 }
 
 func (c *command) toTensorboard(ctx *actor.Context) *tensorboardv1.Tensorboard {
+	// The experiments and trials of TensorBoards are not restored after the master restarts.
+	experimentIDs, _ := c.metadata["experiment_ids"].([]int)
+	trialIDs, _ := c.metadata["trial_ids"].([]int)
 	var eids []int32
-	for _, id := range c.metadata["experiment_ids"].([]int) {
+	for _, id := range experimentIDs {
 		eids = append(eids, int32(id))
 	}
 	var tids []int32
-	for _, id := range c.metadata["trial_ids"].([]int) {
+	for _, id := range trialIDs {
 		tids = append(tids, int32(id))
 	}
 	return &tensorboardv1.Tensorboard{
 		Id:             ctx.Self().Address().Local(),
 		Description:    c.config.Description,
-		StartTime:      protoutils.ToTimestamp(ctx.Self().RegisteredTime()),
+		StartTime:      protoutils.ToTimestamp(c.registeredTime),
 		Container:      c.container.Proto(),
 		ServiceAddress: fmt.Sprintf(tensorboardServiceAddress, c.taskID),
 		ExperimentIds:  eids,
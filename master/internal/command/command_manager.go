@@ -3,6 +3,7 @@ package command
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	petname "github.com/dustinkirkland/golang-petname"
 	"github.com/labstack/echo"
@@ -30,6 +31,7 @@ type commandManager struct {
 
 	defaultAgentUserGroup model.AgentUserGroup
 	taskSpec              *tasks.TaskSpec
+	reattachWait          time.Duration
 }
 
 // CommandLaunchRequest describes a request to launch a new command.
@@ -40,6 +42,9 @@ type CommandLaunchRequest struct {
 
 func (c *commandManager) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+		restoreCommands(ctx, c.db, model.CommandTypeCommand, c.reattachWait)
+
 	case *apiv1.GetCommandsRequest:
 		resp := &apiv1.GetCommandsResponse{}
 		for _, command := range ctx.AskAll(&commandv1.Command{}, ctx.Children()...).GetAll() {
@@ -69,7 +74,7 @@ func (c *commandManager) processLaunchRequest(
 	req CommandLaunchRequest,
 ) (*summary, int, error) {
 	commandReq, err := parseCommandRequest(*req.User, c.db, req.CommandParams,
-		&c.taskSpec.TaskContainerDefaults,
+		&c.taskSpec.TaskContainerDefaults, model.IdlePolicyConfig{},
 	)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
@@ -143,12 +148,15 @@ func (c *commandManager) newCommand(req *commandRequest) *command {
 	setPodSpec(&config, c.taskSpec.TaskContainerDefaults)
 
 	return &command{
-		taskID:    resourcemanagers.NewTaskID(),
-		config:    config,
-		userFiles: req.UserFiles,
+		db:          c.db,
+		commandType: model.CommandTypeCommand,
+		taskID:      resourcemanagers.NewTaskID(),
+		config:      config,
+		userFiles:   req.UserFiles,
 
 		owner:          req.Owner,
 		agentUserGroup: req.AgentUserGroup,
 		taskSpec:       c.taskSpec,
+		reattachWait:   c.reattachWait,
 	}
 }
@@ -54,6 +54,9 @@ type event struct {
 	TerminateRequestEvent *resourcemanagers.ReleaseResources `json:"terminate_request_event"`
 	// ExitedEvent is triggered when the command has terminated.
 	ExitedEvent *string `json:"exited_event"`
+	// IdleWarningEvent is triggered when the command is about to be, or is, terminated for being
+	// idle.
+	IdleWarningEvent *string `json:"idle_warning_event"`
 	// LogEvent is triggered when a new log message is available.
 	LogEvent *string `json:"log_event"`
 }
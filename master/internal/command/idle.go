@@ -0,0 +1,141 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/proxy"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// idleCheckInterval is how often notebooks and shells with an idle policy check their activity.
+const idleCheckInterval = 30 * time.Second
+
+// kernelsTimeout bounds how long notebooks wait on Jupyter for the activity of their kernels.
+const kernelsTimeout = 10 * time.Second
+
+// checkIdle is an internal message asking a notebook or shell to check whether it is idle.
+type checkIdle struct{}
+
+// kernelsActivity is an internal message with the latest activity of the kernels of a notebook.
+type kernelsActivity struct {
+	last time.Time
+	err  error
+}
+
+// startIdleChecks starts checking whether the command is idle, if it is a notebook or shell whose
+// idle policy terminates it.
+func (c *command) startIdleChecks(ctx *actor.Context) {
+	switch {
+	case c.config.IdlePolicy.Timeout == 0:
+		return
+	case c.commandType != model.CommandTypeNotebook && c.commandType != model.CommandTypeShell:
+		return
+	}
+	c.lastActivity = time.Now()
+	actors.NotifyAfter(ctx, idleCheckInterval, checkIdle{})
+}
+
+// checkIdle updates the latest activity of the command from the signals of its idle policy,
+// warns when the command is about to be terminated for being idle, and terminates it once it has
+// been idle for the timeout of the policy.
+func (c *command) checkIdle(ctx *actor.Context) {
+	if c.exitStatus != nil || len(c.addresses) == 0 {
+		return
+	}
+	policy := c.config.IdlePolicy
+	uses := func(activity string) bool {
+		for _, a := range policy.Activity {
+			if a == activity {
+				return true
+			}
+		}
+		return false
+	}
+
+	services, _ := ctx.Ask(c.proxy, proxy.GetSummary{}).Get().(map[string]proxy.Service)
+	if service, ok := services[string(c.taskID)]; ok {
+		if uses(model.IdleActivityProxy) {
+			c.observeActivity(service.LastRequested)
+		}
+		if uses(model.IdleActivityConnections) && service.Connections > 0 {
+			c.observeActivity(time.Now())
+		}
+	}
+	if uses(model.IdleActivityKernels) && c.commandType == model.CommandTypeNotebook {
+		// The result arrives as a message, so that the command does not wait on Jupyter.
+		url := fmt.Sprintf("http://%s:%d/proxy/%s/api/kernels",
+			c.addresses[0].HostIP, c.addresses[0].HostPort, c.taskID)
+		self := ctx.Self()
+		go func() {
+			last, err := getKernelsActivity(url)
+			self.System().Tell(self, kernelsActivity{last: last, err: err})
+		}()
+	}
+
+	timeout := time.Duration(policy.Timeout) * time.Second
+	warning := time.Duration(policy.WarningPeriod) * time.Second
+	idle := time.Since(c.lastActivity).Round(time.Second)
+	switch {
+	case idle >= timeout:
+		msg := fmt.Sprintf("terminating %s after being idle for %s", c.config.Description, idle)
+		ctx.Log().Info(msg)
+		ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), IdleWarningEvent: &msg, LogEvent: &msg})
+		c.terminate(ctx)
+		return
+	case idle >= timeout-warning && !c.idleWarned:
+		c.idleWarned = true
+		msg := fmt.Sprintf("%s has been idle for %s and will be terminated in %s unless it is used",
+			c.config.Description, idle, timeout-idle)
+		ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), IdleWarningEvent: &msg, LogEvent: &msg})
+	case idle < timeout-warning:
+		c.idleWarned = false
+	}
+	actors.NotifyAfter(ctx, idleCheckInterval, checkIdle{})
+}
+
+// observeActivity records activity of the command at the given time.
+func (c *command) observeActivity(t time.Time) {
+	if t.After(c.lastActivity) {
+		c.lastActivity = t
+	}
+}
+
+// getKernelsActivity returns the latest activity of the kernels of a Jupyter server, which is now
+// if any kernel is busy.
+func getKernelsActivity(url string) (time.Time, error) {
+	client := http.Client{Timeout: kernelsTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, errors.Errorf("unexpected status from Jupyter: %s", resp.Status)
+	}
+	var kernels []struct {
+		LastActivity   time.Time `json:"last_activity"`
+		ExecutionState string    `json:"execution_state"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&kernels); err != nil {
+		return time.Time{}, errors.Wrap(err, "error parsing kernels of Jupyter")
+	}
+	var last time.Time
+	for _, kernel := range kernels {
+		if kernel.ExecutionState == "busy" {
+			return time.Now(), nil
+		}
+		if kernel.LastActivity.After(last) {
+			last = kernel.LastActivity
+		}
+	}
+	return last, nil
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestGetKernelsActivity(t *testing.T) {
+	var kernels string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, kernels)
+	}))
+	defer server.Close()
+
+	kernels = `[
+  {"id": "a", "last_activity": "2020-10-20T10:00:00.000000Z", "execution_state": "idle"},
+  {"id": "b", "last_activity": "2020-10-20T11:30:00.000000Z", "execution_state": "idle"}
+]`
+	last, err := getKernelsActivity(server.URL)
+	assert.NilError(t, err)
+	assert.Equal(t, last, time.Date(2020, 10, 20, 11, 30, 0, 0, time.UTC))
+
+	kernels = `[
+  {"id": "a", "last_activity": "2020-10-20T10:00:00.000000Z", "execution_state": "busy"}
+]`
+	last, err = getKernelsActivity(server.URL)
+	assert.NilError(t, err)
+	assert.Assert(t, time.Since(last) < time.Minute)
+
+	kernels = `[]`
+	last, err = getKernelsActivity(server.URL)
+	assert.NilError(t, err)
+	assert.Assert(t, last.IsZero())
+}
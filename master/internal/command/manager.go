@@ -42,13 +42,17 @@ func respondBadRequest(ctx *actor.Context, err error) {
 // - template: The configuration template name.
 // - user_files: The files to run with the command.
 // - data: Additional data for a command.
+//
+// The idle policy of the command defaults to the given one.
 func parseCommandRequest(
 	user model.User,
 	db *db.PgDB,
 	params *CommandParams,
 	taskContainerDefaults *model.TaskContainerDefaultsConfig,
+	idlePolicy model.IdlePolicyConfig,
 ) (*commandRequest, error) {
 	config := DefaultConfig(taskContainerDefaults)
+	config.IdlePolicy = idlePolicy
 	if params.Template != nil {
 		template, err := db.TemplateByName(*params.Template)
 		if err != nil {
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	petname "github.com/dustinkirkland/golang-petname"
 	"github.com/labstack/echo"
@@ -108,6 +109,8 @@ type notebookManager struct {
 
 	defaultAgentUserGroup model.AgentUserGroup
 	taskSpec              *tasks.TaskSpec
+	idlePolicy            model.IdlePolicyConfig
+	reattachWait          time.Duration
 }
 
 // NotebookLaunchRequest describes a request to launch a new notebook.
@@ -121,7 +124,7 @@ func (n *notebookManager) processLaunchRequest(
 	req NotebookLaunchRequest,
 ) (*summary, int, error) {
 	commandReq, err := parseCommandRequest(
-		*req.User, n.db, req.CommandParams, &n.taskSpec.TaskContainerDefaults,
+		*req.User, n.db, req.CommandParams, &n.taskSpec.TaskContainerDefaults, n.idlePolicy,
 	)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
@@ -154,6 +157,9 @@ func (n *notebookManager) processLaunchRequest(
 
 func (n *notebookManager) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+		restoreCommands(ctx, n.db, model.CommandTypeNotebook, n.reattachWait)
+
 	case *apiv1.GetNotebooksRequest:
 		resp := &apiv1.GetNotebooksResponse{}
 		for _, notebook := range ctx.AskAll(&notebookv1.Notebook{}, ctx.Children()...).GetAll() {
@@ -248,9 +254,11 @@ func (n *notebookManager) newNotebook(req *commandRequest) (*command, error) {
 	}
 
 	return &command{
-		taskID:    taskID,
-		config:    config,
-		userFiles: req.UserFiles,
+		db:          n.db,
+		commandType: model.CommandTypeNotebook,
+		taskID:      taskID,
+		config:      config,
+		userFiles:   req.UserFiles,
 		additionalFiles: archive.Archive{
 			req.AgentUserGroup.OwnedArchiveItem(jupyterDir, nil, 0700, tar.TypeDir),
 			req.AgentUserGroup.OwnedArchiveItem(jupyterConfigDir, nil, 0700, tar.TypeDir),
@@ -283,5 +291,6 @@ func (n *notebookManager) newNotebook(req *commandRequest) (*command, error) {
 		owner:          req.Owner,
 		agentUserGroup: req.AgentUserGroup,
 		taskSpec:       n.taskSpec,
+		reattachWait:   n.reattachWait,
 	}, nil
 }
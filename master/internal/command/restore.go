@@ -0,0 +1,71 @@
+package command
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// masterRestartedExitStatus is the exit status of the tasks that had not exited when the master
+// stopped and whose containers were not reported by an agent in time. Their containers are killed
+// when their agents reconnect to the master.
+const masterRestartedExitStatus = "task was terminated because the master restarted"
+
+// restoreCommands restores the tasks of the given type that the master knew of before it
+// restarted, so that they are listed until they are garbage collected. Tasks that had not exited
+// reattach to their containers if an agent reports them within reattachWait, and are terminated
+// otherwise.
+func restoreCommands(
+	ctx *actor.Context, pgDB *db.PgDB, commandType model.CommandType, reattachWait time.Duration,
+) {
+	records, err := pgDB.CommandsToRestore(commandType, time.Now().Add(-terminatedDuration))
+	if err != nil {
+		ctx.Log().WithError(err).Error("failed to restore tasks")
+		return
+	}
+	for _, record := range records {
+		var containers []model.TaskContainer
+		if record.EndTime == nil && reattachWait > 0 {
+			if containers, err = pgDB.TaskContainers(record.TaskID); err != nil {
+				ctx.Log().WithError(err).Errorf("failed to get the container of task %s",
+					record.TaskID)
+			}
+		}
+
+		var restoredContainers []container.ID
+		var resourcePool string
+		for _, c := range containers {
+			restoredContainers = append(restoredContainers, container.ID(c.ID))
+			resourcePool = c.ResourcePool
+		}
+
+		if record.EndTime == nil && len(restoredContainers) == 0 {
+			endTime, exitStatus := time.Now(), masterRestartedExitStatus
+			if err := pgDB.CompleteCommand(record.TaskID, endTime, exitStatus); err != nil {
+				ctx.Log().WithError(err).Errorf("failed to terminate task %s", record.TaskID)
+				continue
+			}
+			ctx.Log().Infof("terminated task %s, which was running when the master stopped",
+				record.TaskID)
+			record.EndTime, record.ExitStatus = &endTime, &exitStatus
+		}
+		ctx.ActorOf(record.TaskID, &command{
+			db:             pgDB,
+			commandType:    record.Type,
+			config:         record.Config,
+			owner:          commandOwner{ID: record.OwnerID, Username: record.Username},
+			taskID:         resourcemanagers.TaskID(record.TaskID),
+			registeredTime: record.RegisteredTime,
+			endTime:        record.EndTime,
+			exitStatus:     record.ExitStatus,
+
+			reattachWait:       reattachWait,
+			restoredContainers: restoredContainers,
+			resourcePool:       resourcePool,
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/proxy"
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/container"
+	image "github.com/determined-ai/determined/master/pkg/tasks"
+)
+
+type restoredAllocation struct{ id container.ID }
+
+func (a restoredAllocation) Summary() resourcemanagers.ContainerSummary {
+	return resourcemanagers.ContainerSummary{ID: a.id}
+}
+func (a restoredAllocation) Start(*actor.Context, image.TaskSpec) {}
+func (a restoredAllocation) Kill(*actor.Context)                  {}
+
+// restartedCluster plays the agents, the resource managers and the proxy of a master that
+// restarted while an agent kept the given container running.
+type restartedCluster struct {
+	running container.Container
+}
+
+func (r *restartedCluster) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart, actor.PostStop:
+	case sproto.ReportTaskContainers:
+		ctx.Tell(msg.TaskActor, sproto.TaskContainerReported{Agent: ctx.Self(), Container: r.running})
+	case sproto.ClaimTaskContainer:
+		ctx.Respond(msg.ContainerID == r.running.ID)
+		ctx.Tell(msg.TaskActor, sproto.TaskContainerStateChanged{
+			Container: r.running,
+			ContainerStarted: &sproto.TaskContainerStarted{
+				Addresses: []container.Address{{HostIP: "10.0.0.1", HostPort: 8888}},
+			},
+		})
+	case resourcemanagers.RestoreAllocation:
+		ctx.Respond(resourcemanagers.ResourcesAllocated{
+			ID:           msg.ID,
+			ResourcePool: msg.ResourcePool,
+			Allocations:  []resourcemanagers.Allocation{restoredAllocation{id: r.running.ID}},
+		})
+	case proxy.Register:
+		ctx.Respond(msg.URL)
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func TestReattachRestoredCommand(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	running := container.Container{
+		Parent: actor.Addr("notebook"), ID: "running", State: container.Running,
+	}
+	cluster := &restartedCluster{running: running}
+	system.MustActorOf(sproto.AgentsAddr, cluster)
+	system.MustActorOf(actor.Addr("resourceManagers"), cluster)
+	system.MustActorOf(actor.Addr("proxy"), cluster)
+
+	ref := system.MustActorOf(running.Parent, &command{
+		taskID:             "task",
+		registeredTime:     time.Now(),
+		reattachWait:       time.Hour,
+		restoredContainers: []container.ID{running.ID},
+		resourcePool:       "default",
+	})
+
+	var s summary
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); {
+		s = system.Ask(ref, getSummary{}).Get().(summary)
+		if s.State == container.Running.String() {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, s.State, container.Running.String())
+	assert.DeepEqual(t, s.Addresses, []container.Address{{HostIP: "10.0.0.1", HostPort: 8888}})
+	assert.Assert(t, s.ExitStatus == nil)
+}
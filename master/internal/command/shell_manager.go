@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	petname "github.com/dustinkirkland/golang-petname"
 	"github.com/labstack/echo"
@@ -43,6 +44,8 @@ type shellManager struct {
 
 	defaultAgentUserGroup model.AgentUserGroup
 	taskSpec              *tasks.TaskSpec
+	idlePolicy            model.IdlePolicyConfig
+	reattachWait          time.Duration
 }
 
 // ShellLaunchRequest describes a request to launch a new shell.
@@ -53,6 +56,9 @@ type ShellLaunchRequest struct {
 
 func (s *shellManager) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+		restoreCommands(ctx, s.db, model.CommandTypeShell, s.reattachWait)
+
 	case *apiv1.GetShellsRequest:
 		resp := &apiv1.GetShellsResponse{}
 		for _, shell := range ctx.AskAll(&shellv1.Shell{}, ctx.Children()...).GetAll() {
@@ -80,7 +86,7 @@ func (s *shellManager) processLaunchRequest(
 ) (*summary, int, error) {
 	commandReq, err := parseCommandRequest(
 		*req.User, s.db, req.CommandParams,
-		&s.taskSpec.TaskContainerDefaults,
+		&s.taskSpec.TaskContainerDefaults, s.idlePolicy,
 	)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
@@ -204,6 +210,8 @@ func (s *shellManager) newShell(
 	}
 
 	return &command{
+		db:              s.db,
+		commandType:     model.CommandTypeShell,
 		taskID:          taskID,
 		config:          config,
 		userFiles:       req.UserFiles,
@@ -222,5 +230,6 @@ func (s *shellManager) newShell(
 		owner:          req.Owner,
 		agentUserGroup: req.AgentUserGroup,
 		taskSpec:       s.taskSpec,
+		reattachWait:   s.reattachWait,
 	}
 }
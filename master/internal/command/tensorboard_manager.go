@@ -67,6 +67,7 @@ type tensorboardManager struct {
 	timeout               time.Duration
 	proxyRef              *actor.Ref
 	taskSpec              *tasks.TaskSpec
+	reattachWait          time.Duration
 }
 
 type tensorboardTick struct{}
@@ -74,6 +75,7 @@ type tensorboardTick struct{}
 func (t *tensorboardManager) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case actor.PreStart:
+		restoreCommands(ctx, t.db, model.CommandTypeTensorBoard, t.reattachWait)
 		actors.NotifyAfter(ctx, tickInterval, tensorboardTick{})
 	case *apiv1.GetTensorboardsRequest:
 		resp := &apiv1.GetTensorboardsResponse{}
@@ -124,7 +126,8 @@ func (t *tensorboardManager) processLaunchRequest(
 	req *TensorboardRequest,
 ) (*summary, int, error) {
 	commandReq, err := parseCommandRequest(
-		*user, t.db, req.CommandParams, &t.taskSpec.TaskContainerDefaults)
+		*user, t.db, req.CommandParams, &t.taskSpec.TaskContainerDefaults,
+		model.IdlePolicyConfig{})
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
@@ -360,6 +363,8 @@ func (t *tensorboardManager) newTensorBoard(
 	setPodSpec(&config, t.taskSpec.TaskContainerDefaults)
 
 	return &command{
+		db:              t.db,
+		commandType:     model.CommandTypeTensorBoard,
 		taskID:          taskID,
 		config:          config,
 		userFiles:       commandReq.UserFiles,
@@ -377,6 +382,7 @@ func (t *tensorboardManager) newTensorBoard(
 		owner:          commandReq.Owner,
 		agentUserGroup: commandReq.AgentUserGroup,
 		taskSpec:       t.taskSpec,
+		reattachWait:   t.reattachWait,
 	}, nil
 }
 
@@ -9,7 +9,9 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/audit"
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/oidc"
 	"github.com/determined-ai/determined/master/internal/provisioner"
 	"github.com/determined-ai/determined/master/internal/resourcemanagers"
 	"github.com/determined-ai/determined/master/pkg/check"
@@ -60,6 +62,10 @@ func DefaultConfig() *Config {
 		},
 		EnableCors:  false,
 		ClusterName: "",
+		IdlePolicy:  model.DefaultIdlePolicyConfig(),
+		HighAvailability: HighAvailabilityConfig{
+			ElectionInterval: 5,
+		},
 	}
 }
 
@@ -72,6 +78,7 @@ type Config struct {
 	Log                   logger.Config                     `json:"log"`
 	DB                    db.Config                         `json:"db"`
 	TensorBoardTimeout    int                               `json:"tensorboard_timeout"`
+	IdlePolicy            model.IdlePolicyConfig            `json:"idle_policy"`
 	Security              SecurityConfig                    `json:"security"`
 	CheckpointStorage     CheckpointStorageConfig           `json:"checkpoint_storage"`
 	TaskContainerDefaults model.TaskContainerDefaultsConfig `json:"task_container_defaults"`
@@ -81,6 +88,7 @@ type Config struct {
 	Telemetry             TelemetryConfig                   `json:"telemetry"`
 	EnableCors            bool                              `json:"enable_cors"`
 	ClusterName           string                            `json:"cluster_name"`
+	HighAvailability      HighAvailabilityConfig            `json:"high_availability"`
 
 	Scheduler   *resourcemanagers.Config `json:"scheduler"`
 	Provisioner *provisioner.Config      `json:"provisioner"`
@@ -94,6 +102,26 @@ func (c Config) Printable() ([]byte, error) {
 	c.DB.Password = hiddenValue
 	c.Telemetry.SegmentMasterKey = hiddenValue
 	c.Telemetry.SegmentWebUIKey = hiddenValue
+	if c.Security.OIDC != nil {
+		oidcConfig := *c.Security.OIDC
+		oidcConfig.ClientSecret = hiddenValue
+		c.Security.OIDC = &oidcConfig
+	}
+	if c.Provisioner != nil {
+		provisionerConfig := c.Provisioner.Printable(hiddenValue)
+		c.Provisioner = &provisionerConfig
+	}
+	if c.ResourcePoolsConfig != nil {
+		pools := make([]resourcemanagers.ResourcePoolConfig, 0, len(c.ResourcePools))
+		for _, pool := range c.ResourcePools {
+			if pool.Provider != nil {
+				provisionerConfig := pool.Provider.Printable(hiddenValue)
+				pool.Provider = &provisionerConfig
+			}
+			pools = append(pools, pool)
+		}
+		c.ResourcePoolsConfig = &resourcemanagers.ResourcePoolsConfig{ResourcePools: pools}
+	}
 
 	cs, err := c.CheckpointStorage.printable()
 	if err != nil {
@@ -242,6 +270,16 @@ func (c *CheckpointStorageConfig) UnmarshalJSON(data []byte) error {
 type SecurityConfig struct {
 	DefaultTask model.AgentUserGroup `json:"default_task"`
 	TLS         TLSConfig            `json:"tls"`
+	OIDC        *oidc.Config         `json:"oidc,omitempty"`
+	// TrustedProxies lists the IP addresses and CIDR ranges of the reverse proxies in front of the
+	// master, whose X-Forwarded-For headers name the clients in the audit log.
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+// Validate implements the check.Validatable interface.
+func (s SecurityConfig) Validate() []error {
+	_, err := audit.ParseTrustedProxies(s.TrustedProxies)
+	return []error{err}
 }
 
 // TLSConfig is the configuration for setting up serving over TLS.
@@ -282,3 +320,19 @@ type TelemetryConfig struct {
 	SegmentMasterKey string `json:"segment_master_key"`
 	SegmentWebUIKey  string `json:"segment_webui_key"`
 }
+
+// HighAvailabilityConfig is the configuration for running several masters of a cluster, one of
+// which leads the cluster while the others stand by to take over.
+type HighAvailabilityConfig struct {
+	Enabled bool `json:"enabled"`
+	// ElectionInterval is how often, in seconds, masters on standby try to become the leader and the
+	// leader checks that it still is.
+	ElectionInterval int `json:"election_interval"`
+}
+
+// Validate implements the check.Validatable interface.
+func (h HighAvailabilityConfig) Validate() []error {
+	return []error{
+		check.GreaterThan(h.ElectionInterval, 0, "election_interval must be > 0"),
+	}
+}
@@ -156,3 +156,23 @@ checkpoint_storage:
 	assert.NilError(t, err)
 	assert.DeepEqual(t, unmarshaled, expected)
 }
+
+func TestPrintableHidesWebhookHeaders(t *testing.T) {
+	external := provisioner.DefaultExternalClusterConfig()
+	external.WebhookURL = "https://provisioner.example.com"
+	external.WebhookHeaders = map[string]string{"Authorization": "Bearer secret"}
+	config := DefaultConfig()
+	config.ResourcePoolsConfig = &resourcemanagers.ResourcePoolsConfig{
+		ResourcePools: []resourcemanagers.ResourcePoolConfig{{
+			PoolName: "default",
+			Provider: &provisioner.Config{External: external},
+		}},
+	}
+
+	printable, err := config.Printable()
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(string(printable), "Bearer secret"))
+	assert.Assert(t, strings.Contains(string(printable), `"Authorization":"********"`))
+	// The configuration itself is unchanged.
+	assert.Equal(t, external.WebhookHeaders["Authorization"], "Bearer secret")
+}
@@ -42,3 +42,30 @@ func (c *DetContext) MustGetUserSession() model.UserSession {
 	}
 	return session.(model.UserSession)
 }
+
+// SetClusterRole sets the role of the authenticated user in the whole cluster for an echo request
+// context.
+func (c *DetContext) SetClusterRole(role model.Role) {
+	c.Set("cluster-role", role)
+}
+
+// ClusterRole returns the role of the authenticated user in the whole cluster for the relevant
+// echo request context, or false if it has not been set.
+func (c *DetContext) ClusterRole() (model.Role, bool) {
+	role, ok := c.Get("cluster-role").(model.Role)
+	return role, ok
+}
+
+// SetAPIToken records that the request is authenticated with the API token.
+func (c *DetContext) SetAPIToken(token model.APIToken) {
+	c.Set("api-token", token)
+}
+
+// APIToken returns the API token that the request is authenticated with, or nil if the request
+// is authenticated with a session.
+func (c *DetContext) APIToken() *model.APIToken {
+	if token, ok := c.Get("api-token").(model.APIToken); ok {
+		return &token
+	}
+	return nil
+}
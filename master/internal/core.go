@@ -24,14 +24,19 @@ import (
 	"github.com/soheilhy/cmux"
 
 	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/audit"
 	"github.com/determined-ai/determined/master/internal/command"
 	"github.com/determined-ai/determined/master/internal/context"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/grpc"
+	"github.com/determined-ai/determined/master/internal/oidc"
+	"github.com/determined-ai/determined/master/internal/prom"
 	"github.com/determined-ai/determined/master/internal/proxy"
+	"github.com/determined-ai/determined/master/internal/rbac"
 	"github.com/determined-ai/determined/master/internal/resourcemanagers"
 	"github.com/determined-ai/determined/master/internal/telemetry"
 	"github.com/determined-ai/determined/master/internal/template"
+	"github.com/determined-ai/determined/master/internal/usage"
 	"github.com/determined-ai/determined/master/internal/user"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
@@ -64,6 +69,10 @@ type Master struct {
 	db            *db.PgDB
 	proxy         *actor.Ref
 	trialLogger   *actor.Ref
+	authz         *rbac.Authorizer
+	leaderLock    leaderLock
+
+	trustedProxies audit.TrustedProxies
 }
 
 // New creates an instance of the Determined master.
@@ -133,11 +142,11 @@ func (m *Master) getMasterLogs(c echo.Context) (interface{}, error) {
 	return entries, nil
 }
 
-func (m *Master) startServers(cert *tls.Certificate) error {
-	// Create the base TCP socket listener and, if configured, set up TLS wrapping.
+// listen creates the base TCP socket listener and, if configured, sets up TLS wrapping.
+func (m *Master) listen(cert *tls.Certificate) (net.Listener, error) {
 	baseListener, err := net.Listen("tcp", fmt.Sprintf(":%d", m.config.Port))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if cert != nil {
@@ -147,6 +156,14 @@ func (m *Master) startServers(cert *tls.Certificate) error {
 			PreferServerCipherSuites: true,
 		})
 	}
+	return baseListener, nil
+}
+
+func (m *Master) startServers(cert *tls.Certificate) error {
+	baseListener, err := m.listen(cert)
+	if err != nil {
+		return err
+	}
 
 	// Initialize listeners and multiplexing.
 	if err := grpc.RegisterHTTPProxy(m.echo, m.config.Port, cert); err != nil {
@@ -169,7 +186,7 @@ func (m *Master) startServers(cert *tls.Certificate) error {
 		}()
 	}
 	start("gRPC server", func() error {
-		return grpc.NewGRPCServer(m.db, &apiServer{m: m}).Serve(grpcListener)
+		return grpc.NewGRPCServer(m.db, &apiServer{m: m}, m.trustedProxies).Serve(grpcListener)
 	})
 	start("HTTP server", func() error {
 		m.echo.Listener = httpListener
@@ -177,6 +194,9 @@ func (m *Master) startServers(cert *tls.Certificate) error {
 		return m.echo.StartServer(m.echo.Server)
 	})
 	start("cmux listener", mux.Serve)
+	if m.leaderLock != nil {
+		start("leader election", m.holdLeadership)
+	}
 
 	log.Infof("accepting incoming connections on port %d", m.config.Port)
 	return <-errs
@@ -201,6 +221,14 @@ func (m *Master) restoreExperiment(e *model.Experiment) {
 	telemetry.ReportExperimentStateChanged(m.system, m.db, *e)
 }
 
+// detContextMiddleware extends the default context of requests.
+func detContextMiddleware(h echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		cc := &context.DetContext{Context: c}
+		return h(cc)
+	}
+}
+
 // convertDBErrorsToNotFound helps reduce boilerplate in our handlers, by
 // classifying database "not found" errors as HTTP "not found" errors.
 func convertDBErrorsToNotFound(next echo.HandlerFunc) echo.HandlerFunc {
@@ -302,8 +330,6 @@ func (m *Master) Run() error {
 		MasterCert:            cert,
 	}
 
-	go m.cleanUpSearcherEvents()
-
 	// Actor structure:
 	// master system
 	// +- Agent Group (actors.Group: agents)
@@ -331,7 +357,23 @@ func (m *Master) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "cannot initialize user manager")
 	}
-	authFuncs := []echo.MiddlewareFunc{userService.ProcessAuthentication}
+	m.authz = rbac.New(m.db)
+	authFuncs := []echo.MiddlewareFunc{
+		userService.ProcessAuthentication, m.authz.ProcessAuthorization,
+	}
+
+	m.trustedProxies, err = audit.ParseTrustedProxies(m.config.Security.TrustedProxies)
+	if err != nil {
+		return err
+	}
+
+	if m.config.HighAvailability.Enabled {
+		if m.leaderLock, err = m.awaitLeadership(cert, userService, authFuncs); err != nil {
+			return err
+		}
+	}
+
+	go m.cleanUpSearcherEvents()
 
 	m.proxy, _ = m.system.ActorOf(actor.Addr("proxy"), &proxy.Proxy{})
 
@@ -370,19 +412,21 @@ func (m *Master) Run() error {
 	m.echo.Use(middleware.SecureWithConfig(secureConfig))
 
 	// Register middleware that extends default context.
-	m.echo.Use(func(h echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			cc := &context.DetContext{Context: c}
-			return h(cc)
-		}
-	})
+	m.echo.Use(detContextMiddleware)
 
+	m.echo.Use(prom.Middleware)
+	m.echo.Use(audit.Middleware(m.db, m.trustedProxies))
 	m.echo.Use(convertDBErrorsToNotFound)
 
 	m.echo.Logger = logger.New()
 	m.echo.HideBanner = true
 	m.echo.HTTPErrorHandler = api.JSONErrorHandler
 
+	// Slot usage, which the resource managers report.
+	if _, err = usage.Setup(m.system, m.db); err != nil {
+		return err
+	}
+
 	// Resource Manager.
 	m.rm = resourcemanagers.Setup(
 		m.system, m.echo, m.config.ResourceManager, m.config.ResourcePoolsConfig, cert,
@@ -457,6 +501,7 @@ func (m *Master) Run() error {
 
 	m.echo.GET("/config", api.Route(m.getConfig))
 	m.echo.GET("/info", api.Route(m.getInfo))
+	m.echo.GET("/leader", api.Route(m.getLeader))
 	m.echo.GET("/logs", api.Route(m.getMasterLogs), authFuncs...)
 
 	m.echo.GET("/experiment-list", api.Route(m.getExperimentList), authFuncs...)
@@ -501,6 +546,8 @@ func (m *Master) Run() error {
 	m.echo.GET("/ws/data-layer/*",
 		api.WebSocketRoute(m.rwCoordinatorWebSocket))
 
+	prom.RegisterAPIHandler(m.echo, m.db, m.system)
+
 	m.echo.Any("/debug/pprof/*", echo.WrapHandler(http.HandlerFunc(pprof.Index)))
 	m.echo.Any("/debug/pprof/cmdline", echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)))
 	m.echo.Any("/debug/pprof/profile", echo.WrapHandler(http.HandlerFunc(pprof.Profile)))
@@ -514,6 +561,14 @@ func (m *Master) Run() error {
 	m.echo.CONNECT("*", handler.Get().(echo.HandlerFunc))
 
 	user.RegisterAPIHandler(m.echo, userService, authFuncs...)
+	rbac.RegisterAPIHandler(m.echo, m.authz, authFuncs...)
+	if m.config.Security.OIDC != nil {
+		oidcService, oErr := oidc.New(m.db, *m.config.Security.OIDC)
+		if oErr != nil {
+			return errors.Wrap(oErr, "cannot initialize single sign-on")
+		}
+		oidc.RegisterAPIHandler(m.echo, oidcService)
+	}
 	command.RegisterAPIHandler(
 		m.system,
 		m.echo,
@@ -522,6 +577,8 @@ func (m *Master) Run() error {
 		m.config.TensorBoardTimeout,
 		m.config.Security.DefaultTask,
 		m.taskSpec,
+		m.config.IdlePolicy,
+		m.config.ResourceManager.AgentReconnectWait(),
 		authFuncs...,
 	)
 	template.RegisterAPIHandler(m.echo, m.db, authFuncs...)
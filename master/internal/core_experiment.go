@@ -17,6 +17,8 @@ import (
 
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/rbac"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/archive"
@@ -91,8 +93,48 @@ func (m *Master) getExperimentSummaries(c echo.Context) (interface{}, error) {
 		states = strings.Join(allStates, ",")
 	}
 	var results []ExperimentSummary
-	err := m.db.Query("get_experiment_summaries", &results, states)
-	return results, err
+	if err := m.db.Query("get_experiment_summaries", &results, states); err != nil {
+		return nil, err
+	}
+	detContext := c.(*context.DetContext)
+	visible, err := m.authz.VisibleExperiments(detContext.MustGetUser(), detContext.APIToken())
+	if err != nil {
+		return nil, err
+	}
+	filtered := results[:0]
+	for _, result := range results {
+		if visible(result.ID) {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered, nil
+}
+
+// filterVisibleExperiments removes the experiments that are hidden from the authenticated user
+// from a JSON list of experiments.
+func (m *Master) filterVisibleExperiments(c echo.Context, raw []byte) ([]byte, error) {
+	detContext := c.(*context.DetContext)
+	visible, err := m.authz.VisibleExperiments(detContext.MustGetUser(), detContext.APIToken())
+	if err != nil {
+		return nil, err
+	}
+	var experiments []json.RawMessage
+	if err = json.Unmarshal(raw, &experiments); err != nil {
+		return nil, errors.Wrap(err, "error parsing experiments")
+	}
+	filtered := make([]json.RawMessage, 0, len(experiments))
+	for _, experiment := range experiments {
+		var id struct {
+			ID int `json:"id"`
+		}
+		if err = json.Unmarshal(experiment, &id); err != nil {
+			return nil, errors.Wrap(err, "error parsing experiment")
+		}
+		if visible(id.ID) {
+			filtered = append(filtered, experiment)
+		}
+	}
+	return json.Marshal(filtered)
 }
 
 func (m *Master) getExperimentList(c echo.Context) (interface{}, error) {
@@ -101,10 +143,16 @@ func (m *Master) getExperimentList(c echo.Context) (interface{}, error) {
 	if err != nil {
 		skipInactive = false
 	}
+	var experiments []byte
 	if userFilter != "" {
-		return m.db.ExperimentDescriptorsRawForUser(true, skipInactive, userFilter)
+		experiments, err = m.db.ExperimentDescriptorsRawForUser(true, skipInactive, userFilter)
+	} else {
+		experiments, err = m.db.ExperimentDescriptorsRaw(true, skipInactive)
+	}
+	if err != nil {
+		return nil, err
 	}
-	return m.db.ExperimentDescriptorsRaw(true, skipInactive)
+	return m.filterVisibleExperiments(c, experiments)
 }
 
 func (m *Master) getExperiments(c echo.Context) (interface{}, error) {
@@ -115,7 +163,11 @@ func (m *Master) getExperiments(c echo.Context) (interface{}, error) {
 
 	skipArchived := query.Filter != "all"
 
-	return m.db.ExperimentListRaw(skipArchived, query.User, query.Limit, query.Offset)
+	experiments, err := m.db.ExperimentListRaw(skipArchived, query.User, query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+	return m.filterVisibleExperiments(c, experiments)
 }
 
 func (m *Master) getExperiment(c echo.Context) (interface{}, error) {
@@ -415,6 +467,31 @@ func (m *Master) parseCreateExperiment(params *CreateExperimentParams) (
 	return dbExp, params.ValidateOnly, err
 }
 
+// assignWorkspace places a new experiment in the workspace named in its configuration. Creating
+// an experiment requires the editor role in its workspace, or in the whole cluster if it is not in
+// a workspace.
+func (m *Master) assignWorkspace(
+	user model.User, token *model.APIToken, dbExp *model.Experiment,
+) error {
+	if dbExp.Config.Workspace == "" {
+		err := m.authz.CheckWorkspace(user, token, nil, model.RoleEditor)
+		if err == rbac.ErrNotVisible {
+			// The API token is scoped to a workspace.
+			return rbac.ErrPermissionDenied
+		}
+		return err
+	}
+	workspace, err := m.db.WorkspaceByName(dbExp.Config.Workspace)
+	if err != nil {
+		return err
+	}
+	if err = m.authz.CheckWorkspace(user, token, &workspace.ID, model.RoleEditor); err != nil {
+		return err
+	}
+	dbExp.WorkspaceID = &workspace.ID
+	return nil
+}
+
 func (m *Master) postExperiment(c echo.Context) (interface{}, error) {
 	body, err := ioutil.ReadAll(c.Request().Body)
 	if err != nil {
@@ -440,6 +517,16 @@ func (m *Master) postExperiment(c echo.Context) (interface{}, error) {
 		return nil, c.NoContent(http.StatusNoContent)
 	}
 
+	switch err = m.assignWorkspace(user, c.(*context.DetContext).APIToken(), dbExp); {
+	case errors.Cause(err) == db.ErrNotFound, err == rbac.ErrNotVisible:
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("workspace not found: %s", dbExp.Config.Workspace))
+	case err == rbac.ErrPermissionDenied:
+		return nil, echo.NewHTTPError(http.StatusForbidden, err.Error())
+	case err != nil:
+		return nil, err
+	}
+
 	dbExp.OwnerID = &user.ID
 	e, err := newExperiment(m, dbExp)
 	if err != nil {
@@ -523,3 +610,89 @@ func (m *Master) postExperimentKill(c echo.Context) (interface{}, error) {
 	}
 	return nil, nil
 }
+
+// parseForkExperiment creates a new experiment that reuses the model definition of an existing
+// experiment and its config, with the given changes to the config applied.
+func (m *Master) parseForkExperiment(parentID int, configPatch string) (*model.Experiment, error) {
+	parent, err := m.db.ExperimentByID(parentID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find parent experiment %v", parentID)
+	}
+	config, err := patchExperimentConfig(parent.Config, configPatch)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewExperiment(
+		config, parent.ModelDefinitionBytes, &parentID, false,
+		parent.GitRemote, parent.GitCommit, parent.GitCommitter, parent.GitCommitDate)
+}
+
+// patchExperimentConfig returns a copy of the experiment config with the changes in the given
+// YAML applied. Like templates, the changes are merged into the config field by field.
+func patchExperimentConfig(
+	config model.ExperimentConfig, configPatch string,
+) (model.ExperimentConfig, error) {
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return model.ExperimentConfig{}, errors.Wrap(err, "failed to marshal experiment config")
+	}
+	var patched model.ExperimentConfig
+	if err = json.Unmarshal(configBytes, &patched); err != nil {
+		return model.ExperimentConfig{}, errors.Wrap(err, "failed to copy experiment config")
+	}
+	if err = yaml.Unmarshal(
+		[]byte(configPatch), &patched, yaml.DisallowUnknownFields,
+	); err != nil {
+		return model.ExperimentConfig{}, errors.Wrap(err, "invalid experiment configuration")
+	}
+	if err = check.Validate(patched); err != nil {
+		return model.ExperimentConfig{}, errors.Wrap(err, "invalid experiment configuration")
+	}
+	return patched, nil
+}
+
+// continueTrialConfig returns the config of an experiment that continues training a trial from
+// its latest checkpoint, after completedBatches, until it is trained for maxLength in total, using
+// the hyperparameters of the trial.
+func continueTrialConfig(
+	config model.ExperimentConfig, trial *model.Trial, maxLength model.Length, completedBatches int,
+) (model.ExperimentConfig, error) {
+	globalBatchSize, ok := trial.HParams[model.GlobalBatchSize].(float64)
+	if !ok {
+		if size, isInt := trial.HParams[model.GlobalBatchSize].(int); isInt {
+			globalBatchSize = float64(size)
+		}
+	}
+	if maxLength.Unit == model.Epochs && config.RecordsPerEpoch <= 0 {
+		return model.ExperimentConfig{}, errors.New(
+			"records_per_epoch must be specified to continue training in epochs")
+	}
+	completed := int(model.UnitsFromBatches(completedBatches, model.NewUnitContext(
+		maxLength.Unit, int(globalBatchSize), config.RecordsPerEpoch)))
+	if completed >= maxLength.Units {
+		return model.ExperimentConfig{}, errors.Errorf(
+			"trial %d was already trained for %d %s, which is not less than max_length",
+			trial.ID, completed, maxLength.Unit)
+	}
+	// The continued trial starts counting from zero, so it trains for the rest of the length.
+	remaining := maxLength.Sub(model.NewLength(maxLength.Unit, completed))
+
+	config.Hyperparameters = make(model.Hyperparameters)
+	for name, val := range trial.HParams {
+		config.Hyperparameters[name] = model.Hyperparameter{
+			ConstHyperparameter: &model.ConstHyperparameter{Val: val},
+		}
+	}
+	trialID := trial.ID
+	config.Searcher = model.SearcherConfig{
+		Metric:          config.Searcher.Metric,
+		SmallerIsBetter: config.Searcher.SmallerIsBetter,
+		SourceTrialID:   &trialID,
+		SingleConfig:    &model.SingleConfig{MaxLength: remaining},
+	}
+	if err := check.Validate(config); err != nil {
+		return model.ExperimentConfig{}, errors.Wrapf(
+			err, "invalid experiment configuration for trial %d", trial.ID)
+	}
+	return config, nil
+}
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const forkTestConfig = `
+description: parent
+entrypoint: model_def:Trial
+checkpoint_storage:
+  type: shared_fs
+  host_path: /tmp
+hyperparameters:
+  global_batch_size: 32
+  lr:
+    type: double
+    minval: 0.001
+    maxval: 0.1
+searcher:
+  name: random
+  metric: loss
+  max_trials: 4
+  max_length:
+    batches: 1000
+`
+
+func forkTestExperimentConfig(t *testing.T) model.ExperimentConfig {
+	config := model.DefaultExperimentConfig(nil)
+	assert.NilError(t, yaml.Unmarshal([]byte(forkTestConfig), &config, yaml.DisallowUnknownFields))
+	return config
+}
+
+func TestPatchExperimentConfig(t *testing.T) {
+	parent := forkTestExperimentConfig(t)
+
+	patched, err := patchExperimentConfig(parent, `
+description: fork
+hyperparameters:
+  dropout: 0.5
+searcher:
+  name: random
+  max_trials: 8
+`)
+	assert.NilError(t, err)
+	assert.Equal(t, patched.Description, "fork")
+	assert.Equal(t, patched.Searcher.RandomConfig.MaxTrials, 8)
+	assert.Equal(t, patched.Searcher.RandomConfig.MaxLength, model.NewLengthInBatches(1000))
+	assert.Equal(t, patched.Searcher.Metric, "loss")
+	assert.Equal(t, len(patched.Hyperparameters), 3)
+
+	// The parent config must not be modified.
+	assert.Equal(t, parent.Description, "parent")
+	assert.Equal(t, parent.Searcher.RandomConfig.MaxTrials, 4)
+	assert.Equal(t, len(parent.Hyperparameters), 2)
+
+	_, err = patchExperimentConfig(parent, "unknown_field: 1")
+	assert.ErrorContains(t, err, "unknown_field")
+	_, err = patchExperimentConfig(parent, "searcher: {name: random, max_trials: 0}")
+	assert.ErrorContains(t, err, "invalid experiment configuration")
+}
+
+func TestContinueTrialConfig(t *testing.T) {
+	parent := forkTestExperimentConfig(t)
+	trial := &model.Trial{
+		ID:      7,
+		HParams: model.JSONObj{"global_batch_size": float64(32), "lr": 0.01},
+	}
+
+	// The trial was checkpointed after 1000 batches, so it trains for the rest of the length.
+	config, err := continueTrialConfig(parent, trial, model.NewLengthInBatches(3000), 1000)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, config.Searcher, model.SearcherConfig{
+		Metric:          "loss",
+		SmallerIsBetter: true,
+		SourceTrialID:   &trial.ID,
+		SingleConfig:    &model.SingleConfig{MaxLength: model.NewLengthInBatches(2000)},
+	})
+	assert.DeepEqual(t, config.Hyperparameters, model.Hyperparameters{
+		"global_batch_size": {ConstHyperparameter: &model.ConstHyperparameter{Val: float64(32)}},
+		"lr":                {ConstHyperparameter: &model.ConstHyperparameter{Val: 0.01}},
+	})
+	assert.Assert(t, parent.Searcher.RandomConfig != nil)
+
+	// Lengths in records and epochs count the records of the completed batches.
+	config, err = continueTrialConfig(parent, trial, model.NewLengthInRecords(64000), 1000)
+	assert.NilError(t, err)
+	assert.Equal(t, config.Searcher.SingleConfig.MaxLength, model.NewLengthInRecords(32000))
+	parent.RecordsPerEpoch = 3200
+	config, err = continueTrialConfig(parent, trial, model.NewLengthInEpochs(30), 1050)
+	assert.NilError(t, err)
+	assert.Equal(t, config.Searcher.SingleConfig.MaxLength, model.NewLengthInEpochs(20))
+
+	_, err = continueTrialConfig(parent, trial, model.NewLengthInBatches(1000), 1000)
+	assert.ErrorContains(t, err, "already trained for 1000 batches")
+	parent.RecordsPerEpoch = 0
+	_, err = continueTrialConfig(parent, trial, model.NewLengthInEpochs(30), 1000)
+	assert.ErrorContains(t, err, "records_per_epoch must be specified")
+}
@@ -2,9 +2,11 @@ package internal
 
 import (
 	"io/ioutil"
+	"net/http"
 
 	"github.com/ghodss/yaml"
 	"github.com/labstack/echo"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/determined-ai/determined/master/pkg/check"
@@ -12,6 +14,11 @@ import (
 	"github.com/determined-ai/determined/master/pkg/searcher"
 )
 
+// errCustomSearcherPreview is returned for previews of custom searches, which would have to call
+// the external search service and start a search on it.
+var errCustomSearcherPreview = errors.New(
+	"the custom searcher cannot be previewed, since its search is run by an external service")
+
 func (m *Master) getSearcherPreview(c echo.Context) (interface{}, error) {
 	body, err := ioutil.ReadAll(c.Request().Body)
 	if err != nil {
@@ -25,6 +32,10 @@ func (m *Master) getSearcherPreview(c echo.Context) (interface{}, error) {
 		return nil, verr
 	}
 
+	if config.Searcher.CustomConfig != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, errCustomSearcherPreview.Error())
+	}
+
 	sm := searcher.NewSearchMethod(config.Searcher)
 	s := searcher.NewSearcher(0, sm, config.Hyperparameters)
 	return searcher.Simulate(s, nil, searcher.RandomValidation, true, config.Searcher.Metric)
@@ -14,7 +14,7 @@ import (
 	_ "github.com/golang-migrate/migrate/source/file" // Load migrations from files.
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // Use pq Postgres driver.
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
@@ -32,7 +32,7 @@ type PgDB struct {
 func ConnectPostgres(url string) (*PgDB, error) {
 	numTries := 0
 	for {
-		sql, err := sqlx.Connect("postgres", url)
+		sql, err := connect(url)
 		if err == nil {
 			return &PgDB{sql: sql, queries: &staticQueryMap{queries: make(map[string]string)}}, err
 		}
@@ -44,6 +44,20 @@ func ConnectPostgres(url string) (*PgDB, error) {
 	}
 }
 
+// connect opens a database whose connections record the latency of their queries.
+func connect(url string) (*sqlx.DB, error) {
+	connector, err := pq.NewConnector(url)
+	if err != nil {
+		return nil, err
+	}
+	db := sqlx.NewDb(sql.OpenDB(instrumentedConnector{connector}), "postgres")
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
 const (
 	// uniqueViolation is the error code that Postgres uses to indicate that an attempted insert/update
 	// violates a uniqueness constraint.  Obtained from:
@@ -655,9 +669,9 @@ func (db *PgDB) AddExperiment(experiment *model.Experiment) error {
 	err := db.namedGet(&experiment.ID, `
 INSERT INTO experiments
 (state, config, model_definition, start_time, end_time, archived,
- git_remote, git_commit, git_committer, git_commit_date, owner_id)
+ git_remote, git_commit, git_committer, git_commit_date, owner_id, workspace_id)
 VALUES (:state, :config, :model_definition, :start_time, :end_time, :archived,
-        :git_remote, :git_commit, :git_committer, :git_commit_date, :owner_id)
+        :git_remote, :git_commit, :git_committer, :git_commit_date, :owner_id, :workspace_id)
 RETURNING id`, experiment)
 	if err != nil {
 		return errors.Wrapf(err, "error inserting experiment %v", *experiment)
@@ -671,7 +685,7 @@ func (db *PgDB) ExperimentByID(id int) (*model.Experiment, error) {
 
 	if err := db.query(`
 SELECT id, state, config, model_definition, start_time, end_time, archived,
-       git_remote, git_commit, git_committer, git_commit_date, owner_id
+       git_remote, git_commit, git_committer, git_commit_date, owner_id, workspace_id
 FROM experiments
 WHERE id = $1`, &experiment, id); err != nil {
 		return nil, err
@@ -692,7 +706,7 @@ func (db *PgDB) ExperimentWithoutBackwardsIncompatibleFieldsByID(
 SELECT id, state,
   config #- '{searcher}' #- '{min_validation_period}' #- '{min_checkpoint_period}' AS config,
   model_definition, start_time, end_time, archived,
-  git_remote, git_commit, git_committer, git_commit_date, owner_id
+  git_remote, git_commit, git_committer, git_commit_date, owner_id, workspace_id
 FROM experiments
 WHERE id = $1`, &experiment, id); err != nil {
 		return nil, err
@@ -709,7 +723,7 @@ func (db *PgDB) ExperimentWithoutConfigByID(id int) (*model.Experiment, error) {
 
 	if err := db.query(`
 SELECT id, state, model_definition, start_time, end_time, archived,
-       git_remote, git_commit, git_committer, git_commit_date, owner_id
+       git_remote, git_commit, git_committer, git_commit_date, owner_id, workspace_id
 FROM experiments
 WHERE id = $1`, &experiment, id); err != nil {
 		return nil, err
@@ -744,7 +758,7 @@ FROM experiments e, trials t  WHERE t.id = $1 AND e.id = t.experiment_id`,
 func (db *PgDB) NonTerminalExperiments() ([]*model.Experiment, error) {
 	rows, err := db.sql.Queryx(`
 SELECT id, state, config, model_definition, start_time, end_time, archived,
-       git_remote, git_commit, git_committer, git_commit_date, owner_id
+       git_remote, git_commit, git_committer, git_commit_date, owner_id, workspace_id
 FROM experiments
 WHERE state IN ('ACTIVE', 'PAUSED', 'STOPPING_CANCELED', 'STOPPING_COMPLETED', 'STOPPING_ERROR')`)
 	if err == sql.ErrNoRows {
@@ -907,6 +921,13 @@ WHERE experiment_id = $1;
 	if err != nil {
 		return errors.Wrapf(err, "error deleting events for experiment %v", id)
 	}
+	_, err = tx.Exec(`
+DELETE FROM searcher_snapshots
+WHERE experiment_id = $1;
+`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting searcher snapshot for experiment %v", id)
+	}
 	result, err := tx.Exec(`
 DELETE FROM experiments
 WHERE id = $1
@@ -974,6 +995,67 @@ ORDER BY id ASC`, id)
 	return nil
 }
 
+// SearcherSnapshot returns the latest searcher snapshot of an experiment, or nil if the experiment
+// does not have one.
+func (db *PgDB) SearcherSnapshot(experimentID int) (*model.SearcherSnapshot, error) {
+	var snapshot model.SearcherSnapshot
+	switch err := db.query(`
+SELECT experiment_id, event_id, content
+FROM searcher_snapshots
+WHERE experiment_id = $1`, &snapshot, experimentID); {
+	case errors.Cause(err) == ErrNotFound:
+		return nil, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "error querying searcher snapshot of experiment %v", experimentID)
+	}
+	return &snapshot, nil
+}
+
+// SaveSearcherSnapshot replaces the searcher snapshot of an experiment. The snapshot must reflect
+// every searcher event of the experiment that has been saved; the ID of the latest of those
+// events is returned.
+func (db *PgDB) SaveSearcherSnapshot(experimentID int, content []byte) (int, error) {
+	var eventID int
+	if err := db.sql.QueryRowx(`
+INSERT INTO searcher_snapshots (experiment_id, event_id, content)
+SELECT $1, GREATEST(
+    (SELECT COALESCE(max(id), 0) FROM searcher_events WHERE experiment_id = $1),
+    (SELECT COALESCE(max(event_id), 0) FROM searcher_snapshots WHERE experiment_id = $1)
+), $2
+ON CONFLICT (experiment_id) DO UPDATE
+SET event_id = EXCLUDED.event_id, content = EXCLUDED.content
+RETURNING event_id`, experimentID, content).Scan(&eventID); err != nil {
+		return 0, errors.Wrapf(err, "error saving searcher snapshot of experiment %v", experimentID)
+	}
+	return eventID, nil
+}
+
+// CompactSearcherEvents deletes the searcher events of an experiment that are reflected in its
+// searcher snapshot, i.e., those up to and including eventID. The completed workloads of the
+// given trials are kept, since the trials replay them to restore their own state.
+func (db *PgDB) CompactSearcherEvents(experimentID int, eventID int, openTrialIDs []int) error {
+	res, err := db.sql.Exec(`
+DELETE FROM searcher_events
+WHERE experiment_id = $1
+    AND id <= $2
+    AND NOT (event_type = 'WorkloadCompleted'
+        AND (content->'msg'->'workload'->>'trial_id')::int = ANY($3::int[]))`,
+		experimentID, eventID, pq.Array(openTrialIDs))
+	if err != nil {
+		return errors.Wrapf(err, "error compacting searcher events of experiment %v", experimentID)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		log.Errorf(
+			"RowsAffected failed in compacting searcher events for experiment %v, error: %v",
+			experimentID, err)
+		return nil
+	}
+	log.Debugf("compacted %v searcher events for experiment %v", num, experimentID)
+	return nil
+}
+
 // ExperimentConfig returns the full config object for an experiment.
 func (db *PgDB) ExperimentConfig(id int) (*model.ExperimentConfig, error) {
 	expConfigBytes, err := db.rawQuery(`
@@ -1177,7 +1259,8 @@ RETURNING id`, trial)
 func (db *PgDB) TrialByID(id int) (*model.Trial, error) {
 	trial := model.Trial{}
 	if err := db.query(`
-SELECT id, experiment_id, state, start_time, end_time, hparams, warm_start_checkpoint_id, seed
+SELECT id, experiment_id, state, start_time, end_time, hparams, warm_start_checkpoint_id, seed,
+       task_id
 FROM trials
 WHERE id = $1`, &trial, id); err != nil {
 		return nil, errors.Wrapf(err, "error querying for trial %v", id)
@@ -1219,7 +1302,8 @@ WHERE id = :id`, setClause(toUpdate)), trial)
 
 // RollbackSearcherEvents rolls back the events for an experiment to the last step with a
 // checkpoint. This is (and should only be) called by master restart to roll searcher events back
-// to the last checkpoint for each trial in the given experiment.
+// to the last checkpoint for each trial in the given experiment. Trials with recorded containers
+// are not rolled back, since they continue where they were if they reattach to their containers.
 func (db *PgDB) RollbackSearcherEvents(experimentID int) error {
 	_, err := db.sql.Exec(`
 DELETE FROM searcher_events se
@@ -1234,7 +1318,13 @@ USING (
 WHERE experiment_id = $1
     AND (se.content->'msg'->'workload'->>'trial_id')::int = latest_checkpoint.trial_id
     AND (se.content->'msg'->'workload'->>'step_id')::int > latest_checkpoint.step_id
-    AND (se.content->'msg'->'workload'->>'step_id')::int != 0;
+    AND (se.content->'msg'->'workload'->>'step_id')::int != 0
+    AND NOT EXISTS (
+        SELECT 1
+        FROM trials t
+        JOIN task_containers tc ON tc.task_id = t.task_id
+        WHERE t.id = latest_checkpoint.trial_id
+    );
 	`, experimentID)
 	if err != nil {
 		return errors.Wrapf(err, "error rolling back events for experiment %d", experimentID)
@@ -1896,8 +1986,13 @@ func (db *PgDB) AddSearcherEvents(events []*model.SearcherEvent) error {
 	return nil
 }
 
-// DeleteSearcherEvents deletes all searcher events for a specific experiment from the database.
+// DeleteSearcherEvents deletes all searcher events and the searcher snapshot for a specific
+// experiment from the database.
 func (db *PgDB) DeleteSearcherEvents(expID int) error {
+	if _, err := db.sql.Exec(
+		"DELETE FROM searcher_snapshots WHERE experiment_id = $1", expID); err != nil {
+		return errors.Wrapf(err, "error in deleting searcher snapshot for experiment %v", expID)
+	}
 	res, err := db.sql.Exec("DELETE FROM searcher_events WHERE experiment_id = $1", expID)
 	if err != nil {
 		return errors.Wrapf(err, "error in deleting searcher events for experiment %v", expID)
@@ -1913,10 +2008,19 @@ func (db *PgDB) DeleteSearcherEvents(expID int) error {
 	return nil
 }
 
-// DeleteSearcherEventsForTerminalStateExperiments deletes all searcher events for
+// DeleteSearcherEventsForTerminalStateExperiments deletes all searcher events and snapshots for
 // terminal state experiments from the database. This is used to clean up searcher
 // events if master crashes before deleting searcher events.
 func (db *PgDB) DeleteSearcherEventsForTerminalStateExperiments() error {
+	if _, err := db.sql.Exec(`
+DELETE FROM searcher_snapshots
+WHERE experiment_id IN (
+	SELECT id
+	FROM experiments
+	WHERE state IN ('COMPLETED', 'CANCELED', 'ERROR'))`); err != nil {
+		return err
+	}
+
 	res, err := db.sql.Exec(`
 DELETE FROM searcher_events
 WHERE experiment_id IN (
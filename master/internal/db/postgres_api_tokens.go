@@ -0,0 +1,88 @@
+package db
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// apiTokenLastUsedResolution is how stale the last-used time of an API token may become, so that
+// not every request that uses a token writes to the database.
+const apiTokenLastUsedResolution = time.Minute
+
+// AddAPIToken adds an API token and sets its ID and creation time.
+func (db *PgDB) AddAPIToken(token *model.APIToken) error {
+	if err := db.sql.QueryRowx(`
+INSERT INTO api_tokens (user_id, name, token_hash, expiry, read_only, workspace_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, created_at`,
+		token.UserID, token.Name, token.TokenHash, token.Expiry, token.ReadOnly, token.WorkspaceID,
+	).Scan(&token.ID, &token.CreatedAt); err != nil {
+		return errors.Wrapf(err, "error adding API token %s", token.Name)
+	}
+	return nil
+}
+
+// APITokens returns the API tokens of the user.
+func (db *PgDB) APITokens(userID model.UserID) ([]model.APIToken, error) {
+	var tokens []model.APIToken
+	if err := db.queryRows(`
+SELECT t.id, t.user_id, t.name, t.token_hash, t.created_at, t.expiry, t.last_used, t.read_only,
+       t.workspace_id, w.name AS workspace
+FROM api_tokens t
+LEFT JOIN workspaces w ON w.id = t.workspace_id
+WHERE t.user_id = $1
+ORDER BY t.id`, &tokens, userID); err != nil {
+		return nil, errors.Wrapf(err, "error listing API tokens of user %d", userID)
+	}
+	return tokens, nil
+}
+
+// DeleteAPIToken revokes an API token of the user, returning ErrNotFound if the user has no such
+// token.
+func (db *PgDB) DeleteAPIToken(userID model.UserID, id int) error {
+	res, err := db.sql.Exec(`DELETE FROM api_tokens WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting API token %d", id)
+	}
+	if num, err := res.RowsAffected(); err != nil {
+		return errors.WithStack(err)
+	} else if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UserByAPIToken returns the user that an unexpired API token belongs to, along with the token,
+// and records that the token was used.
+func (db *PgDB) UserByAPIToken(token string) (*model.User, *model.APIToken, error) {
+	var apiToken model.APIToken
+	err := db.query(`
+SELECT id, user_id, name, token_hash, created_at, expiry, last_used, read_only, workspace_id
+FROM api_tokens WHERE token_hash = $1`, &apiToken, model.HashAPIToken(token))
+	if errors.Cause(err) == ErrNotFound {
+		return nil, nil, ErrNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	if apiToken.Expired(now) {
+		return nil, nil, ErrNotFound
+	}
+
+	var user model.User
+	if err = db.query(`SELECT * FROM users WHERE id = $1`, &user, apiToken.UserID); err != nil {
+		return nil, nil, err
+	}
+
+	if apiToken.LastUsed == nil || now.Sub(*apiToken.LastUsed) > apiTokenLastUsedResolution {
+		if _, err = db.sql.Exec(
+			`UPDATE api_tokens SET last_used = $2 WHERE id = $1`, apiToken.ID, now); err != nil {
+			return nil, nil, errors.Wrapf(err, "error recording use of API token %d", apiToken.ID)
+		}
+		apiToken.LastUsed = &now
+	}
+	return &user, &apiToken, nil
+}
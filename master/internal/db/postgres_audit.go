@@ -0,0 +1,102 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// AddAuditEntry records an entry in the audit log and sets its ID and time.
+func (db *PgDB) AddAuditEntry(entry *model.AuditEntry) error {
+	if err := db.sql.QueryRowx(`
+INSERT INTO audit_log (user_id, username, api_token_id, transport, action, resource_type,
+                       resource_id, payload_digest, source_ip, outcome, status)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id, time`,
+		entry.UserID, entry.Username, entry.APITokenID, entry.Transport, entry.Action,
+		entry.ResourceType, entry.ResourceID, entry.PayloadDigest, entry.SourceIP, entry.Outcome,
+		entry.Status,
+	).Scan(&entry.ID, &entry.Time); err != nil {
+		return errors.Wrapf(err, "error adding audit entry for %s", entry.Action)
+	}
+	return nil
+}
+
+// auditFilterClause returns the WHERE clause and its arguments that select the audit entries that
+// match the filter. The action matches case-insensitively on any part of it.
+func auditFilterClause(filter model.AuditFilter) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Username != "" {
+		add("username = $%d", filter.Username)
+	}
+	if filter.Action != "" {
+		add("action ILIKE '%%' || $%d || '%%'", filter.Action)
+	}
+	if filter.ResourceType != "" {
+		add("resource_type = $%d", filter.ResourceType)
+	}
+	if filter.ResourceID != "" {
+		add("resource_id = $%d", filter.ResourceID)
+	}
+	if filter.Outcome != "" {
+		add("outcome = $%d", filter.Outcome)
+	}
+	if filter.Since != nil {
+		add("time >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		add("time < $%d", *filter.Until)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// CountAuditEntries returns the number of audit entries that match the filter.
+func (db *PgDB) CountAuditEntries(filter model.AuditFilter) (int, error) {
+	where, args := auditFilterClause(filter)
+	var count int
+	if err := db.sql.QueryRowx(
+		fmt.Sprintf(`SELECT count(*) FROM audit_log %s`, where), args...,
+	).Scan(&count); err != nil {
+		return 0, errors.Wrap(err, "error counting audit entries")
+	}
+	return count, nil
+}
+
+// AuditEntries returns the audit entries that match the filter in the order that they were
+// recorded, or in reverse if desc is set, skipping offset entries and returning at most limit
+// entries unless limit is zero.
+func (db *PgDB) AuditEntries(
+	filter model.AuditFilter, desc bool, offset, limit int,
+) ([]model.AuditEntry, error) {
+	where, args := auditFilterClause(filter)
+	order := "ASC"
+	if desc {
+		order = "DESC"
+	}
+	query := fmt.Sprintf(`
+SELECT id, time, user_id, username, api_token_id, transport, action, resource_type, resource_id,
+       payload_digest, source_ip, outcome, status
+FROM audit_log %s
+ORDER BY id %s
+OFFSET %d`, where, order, offset)
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	var entries []model.AuditEntry
+	if err := db.queryRows(query, &entries, args...); err != nil {
+		return nil, errors.Wrap(err, "error listing audit entries")
+	}
+	return entries, nil
+}
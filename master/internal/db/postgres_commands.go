@@ -0,0 +1,50 @@
+package db
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// AddCommand records a command, notebook, shell or TensorBoard that was launched.
+func (db *PgDB) AddCommand(c *model.Command) error {
+	if _, err := db.sql.Exec(`
+INSERT INTO commands (task_id, type, owner_id, config, registered_time)
+VALUES ($1, $2, $3, $4, $5)`,
+		c.TaskID, c.Type, c.OwnerID, c.Config, c.RegisteredTime,
+	); err != nil {
+		return errors.Wrapf(err, "error adding command %s", c.TaskID)
+	}
+	return nil
+}
+
+// CompleteCommand records that a command exited with the given status, unless it is already
+// recorded as exited.
+func (db *PgDB) CompleteCommand(taskID string, endTime time.Time, exitStatus string) error {
+	if _, err := db.sql.Exec(`
+UPDATE commands SET end_time = $2, exit_status = $3
+WHERE task_id = $1 AND end_time IS NULL`, taskID, endTime, exitStatus); err != nil {
+		return errors.Wrapf(err, "error completing command %s", taskID)
+	}
+	return nil
+}
+
+// CommandsToRestore returns the commands of the given type that have not exited or that exited
+// after the given time.
+func (db *PgDB) CommandsToRestore(
+	commandType model.CommandType, exitedAfter time.Time,
+) ([]model.Command, error) {
+	var commands []model.Command
+	if err := db.queryRows(`
+SELECT c.task_id, c.type, c.owner_id, c.config, c.registered_time, c.end_time, c.exit_status,
+       u.username
+FROM commands c
+JOIN users u ON u.id = c.owner_id
+WHERE c.type = $1 AND (c.end_time IS NULL OR c.end_time > $2)
+ORDER BY c.registered_time`, &commands, commandType, exitedAfter); err != nil {
+		return nil, errors.Wrapf(err, "error listing commands of type %s", commandType)
+	}
+	return commands, nil
+}
@@ -20,11 +20,14 @@ const (
 )
 
 // ExperimentLabelUsage returns a flattened and deduplicated list of all the
-// labels in use across all experiments.
-func (db *PgDB) ExperimentLabelUsage() (labelUsage map[string]int, err error) {
+// labels in use across the experiments that are visible.
+func (db *PgDB) ExperimentLabelUsage(
+	visible func(experimentID int) bool,
+) (labelUsage map[string]int, err error) {
 	// First, assemble all the JSON lists that the database returns into a
 	// single tally of all the labels
 	type dbLabelList struct {
+		ID     int
 		Labels []byte
 	}
 	var rawLists []dbLabelList
@@ -33,10 +36,12 @@ func (db *PgDB) ExperimentLabelUsage() (labelUsage map[string]int, err error) {
 		return nil, fmt.Errorf("error in get_experiment_labels query: %w", err)
 	}
 	labelUsage = make(map[string]int)
+	seen := make(map[string]bool)
 	for _, rawList := range rawLists {
-		if len(rawList.Labels) == 0 {
+		if len(rawList.Labels) == 0 || !visible(rawList.ID) || seen[string(rawList.Labels)] {
 			continue
 		}
+		seen[string(rawList.Labels)] = true
 		var parsedList []string
 		err = json.Unmarshal(rawList.Labels, &parsedList)
 		if err != nil {
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// leaderLockID is the key of the Postgres advisory lock that is held by the master that leads the
+// cluster. Every master of a cluster must use the same key.
+const leaderLockID = 0x64657421
+
+// LeaderLock is the advisory lock held by the master that leads the cluster. Advisory locks belong
+// to database sessions, so the lock is held on a dedicated connection and is released by Postgres
+// when that connection is lost.
+type LeaderLock struct {
+	conn *sql.Conn
+}
+
+// TryLeaderLock tries to take the leader lock without waiting for it. It returns nil if another
+// master holds the lock.
+func (db *PgDB) TryLeaderLock(ctx context.Context) (*LeaderLock, error) {
+	conn, err := db.sql.Conn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening connection for leader lock")
+	}
+	var acquired bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockID).Scan(&acquired)
+	if err != nil || !acquired {
+		if cErr := conn.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+		return nil, errors.Wrap(err, "error trying to take leader lock")
+	}
+	return &LeaderLock{conn: conn}, nil
+}
+
+// Check returns an error unless the lock is still held by its session.
+func (l *LeaderLock) Check(ctx context.Context) error {
+	var held bool
+	if err := l.conn.QueryRowContext(ctx, `
+SELECT EXISTS(
+  SELECT 1 FROM pg_locks
+  WHERE locktype = 'advisory' AND objid = $1 AND pid = pg_backend_pid() AND granted
+)`, leaderLockID).Scan(&held); err != nil {
+		return errors.Wrap(err, "error checking leader lock")
+	}
+	if !held {
+		return errors.New("leader lock is no longer held")
+	}
+	return nil
+}
+
+// Release releases the lock by closing its session.
+func (l *LeaderLock) Release() error {
+	return l.conn.Close()
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/prom"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// instrumentedConnector opens connections that record the latency of their queries and statements.
+type instrumentedConnector struct {
+	driver.Connector
+}
+
+func (c instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: conn}, nil
+}
+
+// instrumentedConn records the latency of the queries and statements that are sent directly on the
+// connection, which is how database/sql sends queries without a prepared statement. It implements
+// the optional interfaces of database/sql/driver that connections of lib/pq implement.
+type instrumentedConn struct {
+	driver.Conn
+}
+
+func (c *instrumentedConn) QueryContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer prom.ObserveDBQuery("query", time.Now())
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c *instrumentedConn) ExecContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer prom.ObserveDBQuery("exec", time.Now())
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck // Fallback for drivers without BeginTx.
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// countByState returns the number of rows of the table by their state.
+func (db *PgDB) countByState(table string) (map[model.State]int, error) {
+	rows, err := db.sql.Queryx(`SELECT state, count(*) FROM ` + table + ` GROUP BY state`)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error counting %s by state", table)
+	}
+	defer rows.Close()
+	counts := make(map[model.State]int)
+	for rows.Next() {
+		var state model.State
+		var count int
+		if err := rows.Scan(&state, &count); err != nil {
+			return nil, errors.Wrapf(err, "error counting %s by state", table)
+		}
+		counts[state] = count
+	}
+	return counts, rows.Err()
+}
+
+// CountExperimentsByState returns the number of experiments in each state.
+func (db *PgDB) CountExperimentsByState() (map[model.State]int, error) {
+	return db.countByState("experiments")
+}
+
+// CountTrialsByState returns the number of trials in each state.
+func (db *PgDB) CountTrialsByState() (map[model.State]int, error) {
+	return db.countByState("trials")
+}
@@ -0,0 +1,214 @@
+package db
+
+import (
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// wrapUniqueViolation returns ErrDuplicateRecord if the error is caused by a uniqueness constraint.
+func wrapUniqueViolation(err error, message string) error {
+	if pgerr, ok := errors.Cause(err).(*pq.Error); ok && pgerr.Code == uniqueViolation {
+		return ErrDuplicateRecord
+	}
+	return errors.Wrap(err, message)
+}
+
+// AddWorkspace adds a workspace with the given name.
+func (db *PgDB) AddWorkspace(workspace *model.Workspace) error {
+	if err := db.namedGet(&workspace.ID, `
+INSERT INTO workspaces (name) VALUES (:name) RETURNING id`, workspace); err != nil {
+		return wrapUniqueViolation(err, "error adding workspace")
+	}
+	return nil
+}
+
+// WorkspaceByName looks up a workspace by name, returning ErrNotFound if none exists.
+func (db *PgDB) WorkspaceByName(name string) (*model.Workspace, error) {
+	var workspace model.Workspace
+	if err := db.query(`
+SELECT id, name FROM workspaces WHERE name = $1`, &workspace, name); err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// Workspaces returns all of the workspaces.
+func (db *PgDB) Workspaces() ([]model.Workspace, error) {
+	var workspaces []model.Workspace
+	if err := db.queryRows(`
+SELECT id, name FROM workspaces ORDER BY name`, &workspaces); err != nil {
+		return nil, errors.Wrap(err, "error listing workspaces")
+	}
+	return workspaces, nil
+}
+
+// ExperimentAccessByID returns the owner and workspace of the experiment.
+func (db *PgDB) ExperimentAccessByID(experimentID int) (*model.ExperimentAccess, error) {
+	var access model.ExperimentAccess
+	if err := db.query(`
+SELECT id, owner_id, workspace_id FROM experiments WHERE id = $1`,
+		&access, experimentID); err != nil {
+		return nil, err
+	}
+	return &access, nil
+}
+
+// ExperimentIDByCheckpointUUID looks up the ID of the experiment that the checkpoint belongs to.
+func (db *PgDB) ExperimentIDByCheckpointUUID(checkpointUUID string) (int, error) {
+	var experimentID int
+	if err := db.sql.Get(&experimentID, `
+SELECT t.experiment_id FROM checkpoints c JOIN trials t ON c.trial_id = t.id WHERE c.uuid = $1`,
+		checkpointUUID); err != nil {
+		return 0, errors.Wrapf(err, "querying for experiment id for checkpoint %v", checkpointUUID)
+	}
+	return experimentID, nil
+}
+
+// WorkspaceExperimentAccess returns the owners and workspaces of all experiments that are in a
+// workspace.
+func (db *PgDB) WorkspaceExperimentAccess() ([]model.ExperimentAccess, error) {
+	var access []model.ExperimentAccess
+	if err := db.queryRows(`
+SELECT id, owner_id, workspace_id FROM experiments WHERE workspace_id IS NOT NULL`,
+		&access); err != nil {
+		return nil, errors.Wrap(err, "error querying for workspaces of experiments")
+	}
+	return access, nil
+}
+
+// SetExperimentWorkspace moves the experiment to the workspace, or out of any workspace if
+// workspaceID is nil.
+func (db *PgDB) SetExperimentWorkspace(experimentID int, workspaceID *int) error {
+	res, err := db.sql.Exec(`
+UPDATE experiments SET workspace_id = $2 WHERE id = $1`, experimentID, workspaceID)
+	if err != nil {
+		return errors.Wrapf(err, "error setting workspace of experiment %d", experimentID)
+	}
+	if num, err := res.RowsAffected(); err != nil {
+		return errors.WithStack(err)
+	} else if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddGroup adds a group of users with the given name.
+func (db *PgDB) AddGroup(group *model.Group) error {
+	if err := db.namedGet(&group.ID, `
+INSERT INTO groups (group_name) VALUES (:group_name) RETURNING id`, group); err != nil {
+		return wrapUniqueViolation(err, "error adding group")
+	}
+	return nil
+}
+
+// GroupByName looks up a group by name, returning ErrNotFound if none exists.
+func (db *PgDB) GroupByName(name string) (*model.Group, error) {
+	var group model.Group
+	if err := db.query(`
+SELECT id, group_name FROM groups WHERE group_name = $1`, &group, name); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// Groups returns all of the groups along with their members.
+func (db *PgDB) Groups() ([]model.Group, error) {
+	var rows []struct {
+		ID        int            `db:"id"`
+		Name      string         `db:"group_name"`
+		Usernames pq.StringArray `db:"usernames"`
+	}
+	if err := db.queryRows(`
+SELECT g.id, g.group_name,
+       array_remove(array_agg(u.username ORDER BY u.username), NULL) AS usernames
+FROM groups g
+LEFT JOIN user_group_membership m ON m.group_id = g.id
+LEFT JOIN users u ON u.id = m.user_id
+GROUP BY g.id
+ORDER BY g.group_name`, &rows); err != nil {
+		return nil, errors.Wrap(err, "error listing groups")
+	}
+	groups := make([]model.Group, 0, len(rows))
+	for _, row := range rows {
+		groups = append(groups, model.Group{ID: row.ID, Name: row.Name, Usernames: row.Usernames})
+	}
+	return groups, nil
+}
+
+// AddGroupMember adds the user to the group; adding an existing member does nothing.
+func (db *PgDB) AddGroupMember(groupID int, userID model.UserID) error {
+	if _, err := db.sql.Exec(`
+INSERT INTO user_group_membership (user_id, group_id) VALUES ($1, $2)
+ON CONFLICT DO NOTHING`, userID, groupID); err != nil {
+		return errors.Wrapf(err, "error adding user %d to group %d", userID, groupID)
+	}
+	return nil
+}
+
+// DeleteGroupMember removes the user from the group.
+func (db *PgDB) DeleteGroupMember(groupID int, userID model.UserID) error {
+	if _, err := db.sql.Exec(`
+DELETE FROM user_group_membership WHERE user_id = $1 AND group_id = $2`,
+		userID, groupID); err != nil {
+		return errors.Wrapf(err, "error removing user %d from group %d", userID, groupID)
+	}
+	return nil
+}
+
+// AddRoleAssignment binds a role to a user or a group.
+func (db *PgDB) AddRoleAssignment(assignment *model.RoleAssignment) error {
+	if err := db.namedGet(&assignment.ID, `
+INSERT INTO role_assignments (role, workspace_id, user_id, group_id)
+VALUES (:role, :workspace_id, :user_id, :group_id)
+RETURNING id`, assignment); err != nil {
+		return errors.Wrap(err, "error adding role assignment")
+	}
+	return nil
+}
+
+// DeleteRoleAssignment deletes a role assignment by ID.
+func (db *PgDB) DeleteRoleAssignment(id int) error {
+	res, err := db.sql.Exec(`DELETE FROM role_assignments WHERE id = $1`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting role assignment %d", id)
+	}
+	if num, err := res.RowsAffected(); err != nil {
+		return errors.WithStack(err)
+	} else if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RoleAssignments returns all of the role assignments.
+func (db *PgDB) RoleAssignments() ([]model.RoleAssignment, error) {
+	var assignments []model.RoleAssignment
+	if err := db.queryRows(`
+SELECT r.id, r.role, r.workspace_id, r.user_id, r.group_id,
+       w.name AS workspace, u.username, g.group_name
+FROM role_assignments r
+LEFT JOIN workspaces w ON w.id = r.workspace_id
+LEFT JOIN users u ON u.id = r.user_id
+LEFT JOIN groups g ON g.id = r.group_id
+ORDER BY r.id`, &assignments); err != nil {
+		return nil, errors.Wrap(err, "error listing role assignments")
+	}
+	return assignments, nil
+}
+
+// UserRoles returns the roles that are bound to the user, directly or through their groups, in
+// the whole cluster and, if workspaceID is not nil, in the workspace.
+func (db *PgDB) UserRoles(userID model.UserID, workspaceID *int) ([]model.Role, error) {
+	var roles []model.Role
+	if err := db.sql.Select(&roles, `
+SELECT r.role FROM role_assignments r
+WHERE (r.workspace_id IS NULL OR r.workspace_id = $2)
+  AND (r.user_id = $1 OR r.group_id IN (
+       SELECT group_id FROM user_group_membership WHERE user_id = $1))`,
+		userID, workspaceID); err != nil {
+		return nil, errors.Wrapf(err, "error querying for roles of user %d", userID)
+	}
+	return roles, nil
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// AddTaskContainers records the containers that were started for a task, in the order of their
+// ranks.
+func (db *PgDB) AddTaskContainers(taskID, resourcePool string, containerIDs []string) error {
+	if _, err := db.sql.Exec(`
+INSERT INTO task_containers (id, task_id, resource_pool, rank)
+SELECT c.id, $2, $3, c.rank - 1
+FROM unnest($1::text[]) WITH ORDINALITY AS c(id, rank)`,
+		pq.Array(containerIDs), taskID, resourcePool,
+	); err != nil {
+		return errors.Wrapf(err, "error adding containers of task %s", taskID)
+	}
+	return nil
+}
+
+// TaskContainers returns the recorded containers of a task, in the order of their ranks.
+func (db *PgDB) TaskContainers(taskID string) ([]model.TaskContainer, error) {
+	var containers []model.TaskContainer
+	if err := db.queryRows(`
+SELECT id, task_id, resource_pool, rank
+FROM task_containers
+WHERE task_id = $1
+ORDER BY rank`, &containers, taskID); err != nil {
+		return nil, errors.Wrapf(err, "error listing containers of task %s", taskID)
+	}
+	return containers, nil
+}
+
+// DeleteTaskContainers deletes the recorded containers of a task once it released its resources.
+func (db *PgDB) DeleteTaskContainers(taskID string) error {
+	if _, err := db.sql.Exec(`
+DELETE FROM task_containers WHERE task_id = $1`, taskID); err != nil {
+		return errors.Wrapf(err, "error deleting containers of task %s", taskID)
+	}
+	return nil
+}
+
+// SetTrialTaskID records the task ID of the current run of a trial, which its containers are
+// recorded under.
+func (db *PgDB) SetTrialTaskID(trialID int, taskID string) error {
+	if _, err := db.sql.Exec(`
+UPDATE trials SET task_id = $2 WHERE id = $1`, trialID, taskID); err != nil {
+		return errors.Wrapf(err, "error setting task ID of trial %d", trialID)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// usageGroupKeys maps the attributes that usage reports can be grouped by to the SQL expressions
+// of their keys.
+var usageGroupKeys = map[model.UsageGroupBy]string{
+	model.UsageGroupByUser:         "u.username",
+	model.UsageGroupByExperiment:   "coalesce(u.experiment_id::text, '')",
+	model.UsageGroupByLabel:        "coalesce(l.label, '')",
+	model.UsageGroupByResourcePool: "u.resource_pool",
+	model.UsageGroupByTask:         "u.task_id",
+}
+
+// AddSlotUsage records that slots were allocated to a task and sets the ID of the record.
+func (db *PgDB) AddSlotUsage(u *model.SlotUsage) error {
+	labels := u.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	if err := db.sql.QueryRowx(`
+INSERT INTO slot_usage (task_id, task_name, username, experiment_id, labels, resource_pool, slots,
+                        slot_hourly_rate, start_time, end_time)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id`,
+		u.TaskID, u.TaskName, u.Username, u.ExperimentID, pq.Array(labels), u.ResourcePool,
+		u.Slots, u.SlotHourlyRate, u.StartTime, u.EndTime,
+	).Scan(&u.ID); err != nil {
+		return errors.Wrapf(err, "error adding slot usage of task %s", u.TaskID)
+	}
+	return nil
+}
+
+// EndSlotUsage records that the slots of a task were released, unless they are already recorded
+// as released.
+func (db *PgDB) EndSlotUsage(taskID string, endTime time.Time) error {
+	if _, err := db.sql.Exec(`
+UPDATE slot_usage SET end_time = $2
+WHERE task_id = $1 AND end_time IS NULL`, taskID, endTime); err != nil {
+		return errors.Wrapf(err, "error ending slot usage of task %s", taskID)
+	}
+	return nil
+}
+
+// EndAllSlotUsage records that the slots of all tasks that have not released them were released
+// at the given time, and returns the number of allocations that were ended.
+func (db *PgDB) EndAllSlotUsage(endTime time.Time) (int64, error) {
+	res, err := db.sql.Exec(`
+UPDATE slot_usage SET end_time = greatest(start_time, $1)
+WHERE end_time IS NULL`, endTime)
+	if err != nil {
+		return 0, errors.Wrap(err, "error ending slot usage")
+	}
+	return res.RowsAffected()
+}
+
+// ExperimentSlotHours returns the slot hours that the tasks of an experiment have used so far,
+// including those of allocations that have not been released.
+func (db *PgDB) ExperimentSlotHours(experimentID int) (float64, error) {
+	var slotHours float64
+	if err := db.sql.QueryRowx(`
+SELECT coalesce(sum(slots * extract(epoch FROM coalesce(end_time, now()) - start_time)), 0)::float8
+       / 3600
+FROM slot_usage
+WHERE experiment_id = $1`, experimentID).Scan(&slotHours); err != nil {
+		return 0, errors.Wrapf(err, "error computing slot hours of experiment %d", experimentID)
+	}
+	return slotHours, nil
+}
+
+// SlotUsageReport returns the slot hours and the cost of the slot usage that the filter selects,
+// aggregated by the attribute and split into the periods of the filter. The entries are ordered by
+// period and then by descending slot hours.
+func (db *PgDB) SlotUsageReport(filter model.UsageFilter) ([]model.UsageEntry, error) {
+	key, ok := usageGroupKeys[filter.GroupBy]
+	if !ok {
+		return nil, errors.Errorf("cannot group slot usage by %q", filter.GroupBy)
+	}
+	var join string
+	if filter.GroupBy == model.UsageGroupByLabel {
+		join = "LEFT JOIN LATERAL unnest(u.labels) AS l(label) ON true"
+	}
+
+	var periods string
+	switch filter.Period {
+	case model.UsagePeriodNone:
+		periods = `SELECT since AS period_start, until AS period_end FROM bounds`
+	case model.UsagePeriodDay, model.UsagePeriodWeek, model.UsagePeriodMonth:
+		periods = fmt.Sprintf(`
+SELECT greatest(s, b.since) AS period_start, least(s + interval '1 %[1]s', b.until) AS period_end
+FROM bounds b, generate_series(date_trunc('%[1]s', b.since), b.until, interval '1 %[1]s') AS s
+WHERE s < b.until`, filter.Period)
+	default:
+		return nil, errors.Errorf("cannot split slot usage into periods of %q", filter.Period)
+	}
+
+	// Only the part of each allocation that overlaps a period counts towards it.
+	seconds := `u.slots * extract(epoch FROM least(coalesce(u.end_time, now()), p.period_end)
+                             - greatest(u.start_time, p.period_start))`
+	query := fmt.Sprintf(`
+WITH bounds AS (
+    SELECT coalesce($1::timestamptz, (SELECT min(start_time) FROM slot_usage), now()) AS since,
+           coalesce($2::timestamptz, now()) AS until
+), periods AS (%s)
+SELECT p.period_start, p.period_end, %s AS key,
+       sum(%s)::float8 / 3600 AS slot_hours,
+       sum(%s * coalesce(u.slot_hourly_rate, 0))::float8 / 3600 AS cost
+FROM periods p
+JOIN slot_usage u
+  ON u.start_time < p.period_end AND coalesce(u.end_time, now()) > p.period_start
+%s
+GROUP BY p.period_start, p.period_end, key
+ORDER BY p.period_start, slot_hours DESC, key`, periods, key, seconds, seconds, join)
+
+	var entries []model.UsageEntry
+	if err := db.queryRows(query, &entries, filter.Since, filter.Until); err != nil {
+		return nil, errors.Wrap(err, "error reporting slot usage")
+	}
+	return entries, nil
+}
@@ -179,6 +179,67 @@ func (db *PgDB) AddUser(user *model.User, ug *model.AgentUserGroup) error {
 	return nil
 }
 
+// UserByOIDCIdentity looks up the user that is linked to the subject of an OpenID Connect identity
+// provider, returning ErrNotFound if there is none.
+func (db *PgDB) UserByOIDCIdentity(issuer, subject string) (*model.User, error) {
+	var user model.User
+	if err := db.query(`
+SELECT u.* FROM users u JOIN oidc_identities i ON i.user_id = u.id
+WHERE i.issuer = $1 AND i.subject = $2`, &user, issuer, subject); errors.Cause(err) == ErrNotFound {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func addOIDCIdentity(tx sqlx.Execer, userID model.UserID, issuer, subject string) error {
+	if _, err := tx.Exec(`
+INSERT INTO oidc_identities (issuer, subject, user_id) VALUES ($1, $2, $3)`,
+		issuer, subject, userID); err != nil {
+		return wrapUniqueViolation(err, "error linking user to OpenID Connect identity")
+	}
+	return nil
+}
+
+// AddOIDCUser creates a new user without a password that is linked to the subject of an OpenID
+// Connect identity provider.
+func (db *PgDB) AddOIDCUser(user *model.User, issuer, subject string) error {
+	tx, err := db.sql.Beginx()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if tx == nil {
+			return
+		}
+
+		if rErr := tx.Rollback(); rErr != nil {
+			log.Errorf("error during rollback: %v", rErr)
+		}
+	}()
+
+	userID, err := addUser(tx, user)
+	if err != nil {
+		return err
+	}
+	if err = addOIDCIdentity(tx, userID, issuer, subject); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.WithStack(err)
+	}
+	tx = nil
+	return nil
+}
+
+// LinkOIDCIdentity links an existing user to the subject of an OpenID Connect identity provider,
+// returning ErrDuplicateRecord if the user is already linked to another subject of the provider.
+func (db *PgDB) LinkOIDCIdentity(userID model.UserID, issuer, subject string) error {
+	return addOIDCIdentity(db.sql, userID, issuer, subject)
+}
+
 // UpdateUser updates an existing user.  `toUpdate` names the fields to update.
 func (db *PgDB) UpdateUser(updated *model.User, toUpdate []string, ug *model.AgentUserGroup) error {
 	tx, err := db.sql.Beginx()
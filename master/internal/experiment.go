@@ -3,6 +3,7 @@ package internal
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -13,6 +14,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/internal/telemetry"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/archive"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/searcher"
@@ -48,6 +50,7 @@ type (
 	restoreTrials  struct{}
 	trialsRestored struct{}
 	killExperiment struct{}
+	checkSlotHours struct{}
 
 	// doneProcessingSearcherOperations message is only used during master restart, to ensure that
 	// all the searcher operations created by a given event (experiment created / trial created /
@@ -76,6 +79,10 @@ const (
 	// due to the contents of the SearcherEvents than the number of them; see the comment in
 	// convertSearcherEvent()
 	searcherEventBuffer = 1000
+
+	// slotHoursCheckPeriod is how often the slot hours that an experiment has used are compared to
+	// its budget.
+	slotHoursCheckPeriod = time.Minute
 )
 
 type experiment struct {
@@ -85,12 +92,25 @@ type experiment struct {
 	trialLogger         *actor.Ref
 	db                  *db.PgDB
 	searcher            *searcher.Searcher
+	searcherCaller      *actor.Ref
 	warmStartCheckpoint *model.Checkpoint
 	bestValidation      *float64
 	replaying           bool
 
 	pendingEvents []*model.SearcherEvent
 
+	// trials tracks the open trials of the experiment for searcher snapshots.
+	trials              map[searcher.RequestID]*trialSearcherState
+	restoredSnapshot    *experimentSnapshot
+	eventsSinceSnapshot int
+
+	// batchTimes estimates the remaining run time of the trials for the scheduler.
+	batchTimes *batchTimeEstimator
+	// reattachWait is how long trials that are restored after a master restart wait for the agents
+	// to report the containers that they were running; zero disables reattaching to them.
+	reattachWait time.Duration
+
+	owner          string
 	agentUserGroup *model.AgentUserGroup
 	taskSpec       *tasks.TaskSpec
 }
@@ -122,6 +142,11 @@ func newExperiment(master *Master, expModel *model.Experiment) (*experiment, err
 		}
 	}
 
+	owner, err := master.db.UserByID(*expModel.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
 	agentUserGroup, err := master.db.AgentUserGroup(*expModel.OwnerID)
 	if err != nil {
 		return nil, err
@@ -140,7 +165,11 @@ func newExperiment(master *Master, expModel *model.Experiment) (*experiment, err
 		searcher:            search,
 		warmStartCheckpoint: checkpoint,
 		pendingEvents:       make([]*model.SearcherEvent, 0, searcherEventBuffer),
+		trials:              make(map[searcher.RequestID]*trialSearcherState),
+		batchTimes:          &batchTimeEstimator{},
+		reattachWait:        master.config.ResourceManager.AgentReconnectWait(),
 
+		owner:          owner.Username,
 		agentUserGroup: agentUserGroup,
 		taskSpec:       master.taskSpec,
 	}, nil
@@ -162,12 +191,20 @@ func marshalInto(unmarshaled interface{}, obj interface{}) error {
 // experiments during Master restart. The SearcherEvent log can become tens of GB for a large
 // experiment when loaded into memory, and this lets us avoid asking the database to pass us all
 // the rows at once.
-func newSearcherEventCallback(master *Master, ref *actor.Ref) func(model.SearcherEvent) error {
-	requestIDs := make(map[int]searcher.RequestID)
-
+//
+// If the experiment was restored from a searcher snapshot, requestIDs holds the trials that were
+// open when the snapshot was taken; of the events up to and including snapshotEventID, only the
+// completed workloads of those trials are replayed, so that the trials can restore their state.
+func newSearcherEventCallback(
+	master *Master, ref *actor.Ref, snapshotEventID int, requestIDs map[int]searcher.RequestID,
+) func(model.SearcherEvent) error {
 	return func(event model.SearcherEvent) error {
 		switch event.EventType {
 		case TrialCreatedEventType:
+			if event.ID <= snapshotEventID {
+				// The trials that were open at the time of the snapshot were created from it.
+				return nil
+			}
 			log.Debugf("\x1b[32mrestore: trial created\x1b[m %v %v",
 				event.Content["request_id"], event.Content["trial_id"])
 
@@ -202,10 +239,15 @@ func newSearcherEventCallback(master *Master, ref *actor.Ref) func(model.Searche
 			if err := marshalInto(obj, &msg); err != nil {
 				return errors.Wrap(err, "failed to process completed message")
 			}
+			requestID, ok := requestIDs[msg.Workload.TrialID]
+			if !ok && event.ID <= snapshotEventID {
+				// The trial was closed before the snapshot was taken.
+				return nil
+			}
 
 			// Pass the workload completed message to the Trial. It will pass the event along to
 			// the experiment before this Ask gets a response.
-			master.system.AskAt(ref.Address().Child(requestIDs[msg.Workload.TrialID]), msg).Get()
+			master.system.AskAt(ref.Address().Child(requestID), msg).Get()
 
 			// Wait for the experiment to handle any searcher operations due to the completed
 			// workload.
@@ -243,6 +285,20 @@ func restoreExperiment(master *Master, expModel *model.Experiment) error {
 	log.Info("restoring experiment")
 	e.replaying = true
 
+	snapshotEventID, err := e.loadSnapshot()
+	if err != nil {
+		return errors.Wrapf(err, "failed to restore searcher snapshot")
+	}
+	requestIDs := make(map[int]searcher.RequestID)
+	if e.restoredSnapshot != nil {
+		log.Infof("restoring searcher from snapshot at event %d", snapshotEventID)
+		for _, trial := range e.restoredSnapshot.Trials {
+			if trial.TrialID != 0 {
+				requestIDs[trial.TrialID] = trial.Create.RequestID
+			}
+		}
+	}
+
 	ref, _ := master.system.ActorOf(actor.Addr("experiments", e.ID), e)
 
 	// Wait for the experiment to handle any initial searcher operations.
@@ -252,7 +308,9 @@ func restoreExperiment(master *Master, expModel *model.Experiment) error {
 		return errors.Wrapf(err, "failed to rollback searcher events")
 	}
 
-	if err = e.db.ForEachSearcherEvent(e.ID, newSearcherEventCallback(master, ref)); err != nil {
+	if err = e.db.ForEachSearcherEvent(
+		e.ID, newSearcherEventCallback(master, ref, snapshotEventID, requestIDs),
+	); err != nil {
 		return errors.Wrapf(err, "failed to get searcher events")
 	}
 
@@ -291,16 +349,42 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 			Priority: e.Config.Resources.Priority,
 			Handler:  ctx.Self(),
 		})
+		if e.Config.Resources.MaxSlotHours != nil {
+			actors.NotifyAfter(ctx, slotHoursCheckPeriod, checkSlotHours{})
+		}
+		if !e.replaying {
+			e.startSearcherCaller(ctx)
+		}
+		if e.restoredSnapshot != nil {
+			e.restoreSnapshotTrials(ctx)
+			break
+		}
 		ops, err := e.searcher.InitialOperations()
 		e.processOperations(ctx, ops, err)
 	case trialCreated:
+		if trial, ok := e.trials[msg.create.RequestID]; ok {
+			trial.TrialID = msg.trialID
+		}
+		// Trials that were restored from a searcher snapshot are already known to the searcher.
+		if _, ok := e.searcher.TrialID(msg.create.RequestID); ok {
+			break
+		}
 		ops, err := e.searcher.TrialCreated(msg.create, msg.trialID)
 		e.processOperations(ctx, ops, err)
 	case trialCompletedOperation:
+		if trial := e.trialState(msg.trialID); trial != nil && !trial.operationCompleted() {
+			break
+		}
 		ops, err := e.searcher.OperationCompleted(msg.trialID, msg.op, msg.metrics)
 		e.processOperations(ctx, ops, err)
 	case trialCompletedWorkload:
-		e.searcher.WorkloadCompleted(msg.completedMessage, msg.unitsCompleted)
+		// Workloads that are replayed after restoring from a searcher snapshot are still saved as
+		// searcher events, but do not count towards the progress again.
+		unitsCompleted := msg.unitsCompleted
+		if trial := e.trialState(msg.trialID); trial != nil && !trial.workloadCompleted() {
+			unitsCompleted = 0
+		}
+		e.searcher.WorkloadCompleted(msg.completedMessage, unitsCompleted)
 		e.processOperations(ctx, nil, nil) // We call processOperations to flush searcher events.
 		if msg.completedMessage.Workload.Kind == workload.ComputeValidationMetrics &&
 			// Messages indicating trial failures won't have metrics (or need their status).
@@ -312,8 +396,14 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 			ctx.Log().WithError(err).Error("failed to save experiment progress")
 		}
 	case trialExitedEarly:
+		if trial := e.trialState(msg.trialID); trial != nil && !trial.exitedEarly() {
+			break
+		}
 		ops, err := e.searcher.TrialExitedEarly(msg.trialID, msg.exitedReason)
 		e.processOperations(ctx, ops, err)
+	case searcherCallCompleted:
+		ops, err := e.searcher.CallCompleted(msg.result)
+		e.processOperations(ctx, ops, err)
 	case sendNextWorkload:
 		// Pass this back to the trial; this message is just used to allow the trial to synchronize
 		// with the searcher.
@@ -321,6 +411,7 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 	case actor.ChildFailed:
 		ctx.Log().WithError(msg.Error).Error("trial failed unexpectedly")
 		requestID := searcher.MustParse(msg.Child.Address().Local())
+		delete(e.trials, requestID)
 		ops, err := e.searcher.TrialClosed(requestID)
 		e.processOperations(ctx, ops, err)
 		if e.canTerminate(ctx) {
@@ -328,6 +419,7 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 		}
 	case actor.ChildStopped:
 		requestID := searcher.MustParse(msg.Child.Address().Local())
+		delete(e.trials, requestID)
 		ops, err := e.searcher.TrialClosed(requestID)
 		e.processOperations(ctx, ops, err)
 		if e.canTerminate(ctx) {
@@ -352,6 +444,7 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 		ctx.Respond(ctx.AskAll(restoreTrial{}, ctx.Children()...))
 	case trialsRestored:
 		e.replaying = false
+		e.startSearcherCaller(ctx)
 
 	// Patch experiment messages.
 	case model.State:
@@ -365,6 +458,10 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 		msg.Handler = ctx.Self()
 		ctx.Tell(e.rm, msg)
 
+	case checkSlotHours:
+		e.checkSlotHours(ctx)
+		actors.NotifyAfter(ctx, slotHoursCheckPeriod, checkSlotHours{})
+
 	case killExperiment:
 		if _, running := model.RunningStates[e.State]; running {
 			e.updateState(ctx, model.StoppingCanceledState)
@@ -386,6 +483,13 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 			e.updateState(ctx, model.StoppingErrorState)
 		}
 
+		if e.searcherCaller != nil {
+			e.searcherCaller.Stop()
+		}
+		if err := e.searcher.Close(); err != nil {
+			ctx.Log().WithError(err).Error("failed to close searcher")
+		}
+
 		state := model.StoppingToTerminalStates[e.State]
 		if wasPatched, err := e.Transition(state); err != nil {
 			return err
@@ -427,7 +531,11 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 		}
 
 	case *apiv1.PauseExperimentRequest:
-		switch ok := e.updateState(ctx, model.PausedState); ok {
+		ok := e.updateState(ctx, model.PausedState)
+		if !ctx.ExpectingResponse() {
+			break
+		}
+		switch ok {
 		case true:
 			ctx.Respond(&apiv1.PauseExperimentResponse{})
 		default:
@@ -473,6 +581,27 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 	return nil
 }
 
+// checkSlotHours pauses the experiment through the same path as a user's request once its tasks
+// have used the slot hours of its budget. The experiment is paused again if it is activated
+// without raising the budget.
+func (e *experiment) checkSlotHours(ctx *actor.Context) {
+	maxSlotHours := e.Config.Resources.MaxSlotHours
+	if maxSlotHours == nil || e.replaying || e.State != model.ActiveState {
+		return
+	}
+	slotHours, err := e.db.ExperimentSlotHours(e.ID)
+	if err != nil {
+		ctx.Log().WithError(err).Error("failed to check the slot hours of the experiment")
+		return
+	}
+	if slotHours < *maxSlotHours {
+		return
+	}
+	ctx.Log().Infof("pausing experiment after it used %.2f of its %.2f slot hours",
+		slotHours, *maxSlotHours)
+	ctx.Tell(ctx.Self(), &apiv1.PauseExperimentRequest{Id: int32(e.ID)})
+}
+
 func (e *experiment) processOperations(
 	ctx *actor.Context, ops []searcher.Operation, err error) {
 	if _, ok := model.StoppingStates[e.State]; ok {
@@ -484,32 +613,28 @@ func (e *experiment) processOperations(
 		return
 	}
 
+	for _, call := range e.searcher.Calls() {
+		ctx.Tell(e.searcherCaller, call)
+	}
+
 	trialOperations := make(map[searcher.RequestID][]searcher.Operation)
 	for _, operation := range ops {
 		ctx.Log().Debugf("handling searcher op: %v", operation)
 		switch op := operation.(type) {
 		case searcher.Create:
-			checkpoint := e.warmStartCheckpoint
-			// If the Create specifies a checkpoint, ignore the experiment-wide one.
-			if op.Checkpoint != nil {
-				trialID, ok := e.searcher.TrialID(op.Checkpoint.RequestID)
-				if !ok {
-					ctx.Log().Error(errors.Errorf(
-						"invalid request ID in Create operation: %d", op.Checkpoint.RequestID))
-					e.updateState(ctx, model.StoppingErrorState)
-					return
-				}
-				checkpointModel, err := checkpointFromTrialIDOrUUID(e.db, &trialID, nil)
-				if err != nil {
-					ctx.Log().Error(errors.Wrap(err, "checkpoint not found"))
-					e.updateState(ctx, model.StoppingErrorState)
-					return
-				}
-				checkpoint = checkpointModel
+			checkpoint, err := e.trialCheckpoint(op)
+			if err != nil {
+				ctx.Log().Error(err)
+				e.updateState(ctx, model.StoppingErrorState)
+				return
 			}
+			e.trials[op.RequestID] = &trialSearcherState{Create: op}
 			ctx.ActorOf(op.RequestID, newTrial(e, op, checkpoint))
 		case searcher.Requested:
 			trialOperations[op.GetRequestID()] = append(trialOperations[op.GetRequestID()], op)
+			if trial, ok := e.trials[op.GetRequestID()]; ok {
+				trial.Operations = append(trial.Operations, op)
+			}
 		case searcher.Shutdown:
 			if op.Failure {
 				e.updateState(ctx, model.StoppingErrorState)
@@ -552,11 +677,44 @@ func (e *experiment) processOperations(
 				e.updateState(ctx, model.StoppingErrorState)
 				return
 			}
+			e.eventsSinceSnapshot += len(e.pendingEvents)
 			e.pendingEvents = e.pendingEvents[:0]
+			if e.eventsSinceSnapshot >= searcherSnapshotInterval && !e.searcher.CallsPending() {
+				e.snapshotSearcher(ctx)
+			}
 		}
 	}
 }
 
+// startSearcherCaller moves the calls of the search method to external services off the experiment
+// actor, if it makes any. While the experiment is restored, the calls are made by the experiment
+// itself, since the restore waits for the operations of each replayed event.
+func (e *experiment) startSearcherCaller(ctx *actor.Context) {
+	if !e.searcher.SetAsync(true) {
+		return
+	}
+	addr := actor.Addr(fmt.Sprintf("experiment-%d-searcher", e.ID))
+	e.searcherCaller, _ = ctx.Self().System().ActorOf(addr, &searcherCaller{experiment: ctx.Self()})
+}
+
+// trialCheckpoint returns the checkpoint that a trial created by the given operation starts from.
+func (e *experiment) trialCheckpoint(create searcher.Create) (*model.Checkpoint, error) {
+	// If the Create specifies a checkpoint, ignore the experiment-wide one.
+	if create.Checkpoint == nil {
+		return e.warmStartCheckpoint, nil
+	}
+	trialID, ok := e.searcher.TrialID(create.Checkpoint.RequestID)
+	if !ok {
+		return nil, errors.Errorf(
+			"invalid request ID in Create operation: %d", create.Checkpoint.RequestID)
+	}
+	checkpoint, err := checkpointFromTrialIDOrUUID(e.db, &trialID, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "checkpoint not found")
+	}
+	return checkpoint, nil
+}
+
 func (e *experiment) isBestValidation(metrics workload.ValidationMetrics) bool {
 	metricName := e.Config.Searcher.Metric
 	validation, err := metrics.Metric(metricName)
@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/searcher"
+)
+
+// searcherSnapshotInterval is the number of searcher events that are saved between snapshots of
+// the searcher state. After each snapshot, the events that it reflects are compacted away, so
+// that restoring the experiment only has to replay the events that come after the snapshot.
+const searcherSnapshotInterval = 5000
+
+// experimentSnapshot is the state of an experiment that is saved along with its searcher state.
+type experimentSnapshot struct {
+	Searcher       json.RawMessage       `json:"searcher"`
+	BestValidation *float64              `json:"best_validation"`
+	Trials         []*trialSearcherState `json:"trials"`
+}
+
+// trialSearcherState tracks what the searcher knows about an open trial. It is saved in searcher
+// snapshots so that the trial can be recreated and, since the trial restores its own state by
+// replaying its completed workloads, so that the searcher is not told about the same workloads and
+// operations again.
+type trialSearcherState struct {
+	Create  searcher.Create `json:"create"`
+	TrialID int             `json:"trial_id"`
+	// Operations are the operations that have been sent to the trial.
+	Operations          searcher.OperationList `json:"operations"`
+	OperationsCompleted int                    `json:"operations_completed"`
+	WorkloadsCompleted  int                    `json:"workloads_completed"`
+	ExitedEarly         bool                   `json:"exited_early"`
+
+	operationsSeen int
+	workloadsSeen  int
+}
+
+// operationCompleted records that the trial completed an operation and returns whether the
+// searcher should be told about it.
+func (s *trialSearcherState) operationCompleted() bool {
+	s.operationsSeen++
+	if s.operationsSeen <= s.OperationsCompleted {
+		return false
+	}
+	s.OperationsCompleted = s.operationsSeen
+	return true
+}
+
+// workloadCompleted records that the trial completed a workload and returns whether the searcher
+// should count it towards its progress.
+func (s *trialSearcherState) workloadCompleted() bool {
+	s.workloadsSeen++
+	if s.workloadsSeen <= s.WorkloadsCompleted {
+		return false
+	}
+	s.WorkloadsCompleted = s.workloadsSeen
+	return true
+}
+
+// exitedEarly records that the trial exited early and returns whether the searcher should be told
+// about it.
+func (s *trialSearcherState) exitedEarly() bool {
+	if s.ExitedEarly {
+		return false
+	}
+	s.ExitedEarly = true
+	return true
+}
+
+// trialState returns the searcher state of the open trial with the given ID, or nil if there is
+// no such trial.
+func (e *experiment) trialState(trialID int) *trialSearcherState {
+	requestID, ok := e.searcher.RequestID(trialID)
+	if !ok {
+		return nil
+	}
+	return e.trials[requestID]
+}
+
+// snapshotSearcher saves a snapshot of the searcher state and compacts the searcher events that
+// it reflects. All searcher events must have been saved beforehand.
+func (e *experiment) snapshotSearcher(ctx *actor.Context) {
+	e.eventsSinceSnapshot = 0
+
+	searcherState, err := e.searcher.Snapshot()
+	if err == searcher.ErrSnapshotUnsupported {
+		return
+	} else if err != nil {
+		ctx.Log().WithError(err).Error("failed to snapshot searcher")
+		return
+	}
+
+	snapshot := experimentSnapshot{Searcher: searcherState, BestValidation: e.bestValidation}
+	var openTrialIDs []int
+	for _, trial := range e.trials {
+		snapshot.Trials = append(snapshot.Trials, trial)
+		if trial.TrialID != 0 {
+			openTrialIDs = append(openTrialIDs, trial.TrialID)
+		}
+	}
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		ctx.Log().WithError(err).Error("failed to serialize searcher snapshot")
+		return
+	}
+
+	eventID, err := e.db.SaveSearcherSnapshot(e.ID, content)
+	if err != nil {
+		ctx.Log().WithError(err).Error("failed to save searcher snapshot")
+		return
+	}
+	if err := e.db.CompactSearcherEvents(e.ID, eventID, openTrialIDs); err != nil {
+		ctx.Log().WithError(err).Error("failed to compact searcher events")
+	}
+}
+
+// loadSnapshot restores the searcher of a newly created experiment from its latest snapshot, if it
+// has one. It returns the ID of the last searcher event reflected in the snapshot.
+func (e *experiment) loadSnapshot() (int, error) {
+	modelSnapshot, err := e.db.SearcherSnapshot(e.ID)
+	if err != nil || modelSnapshot == nil {
+		return 0, err
+	}
+
+	snapshot, err := parseSnapshot(modelSnapshot.Content, e.Config.Hyperparameters)
+	if err != nil {
+		return 0, err
+	}
+	if err = e.searcher.Restore(snapshot.Searcher); err != nil {
+		return 0, err
+	}
+	e.bestValidation = snapshot.BestValidation
+	e.restoredSnapshot = snapshot
+	return modelSnapshot.EventID, nil
+}
+
+// parseSnapshot parses a saved experiment snapshot. The hyperparameters of its trials are
+// converted back into the types that they were sampled as, so that the recreated trials see the
+// same hyperparameters as they did before the restore.
+func parseSnapshot(content []byte, hparams model.Hyperparameters) (*experimentSnapshot, error) {
+	var snapshot experimentSnapshot
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return nil, errors.Wrap(err, "failed to parse searcher snapshot")
+	}
+	for _, trial := range snapshot.Trials {
+		trial.Create = searcher.RestoreCreate(hparams, trial.Create)
+	}
+	return &snapshot, nil
+}
+
+// restoreSnapshotTrials recreates the trials that were open when the searcher snapshot that the
+// experiment is restored from was taken.
+func (e *experiment) restoreSnapshotTrials(ctx *actor.Context) {
+	for _, trial := range e.restoredSnapshot.Trials {
+		checkpoint, err := e.trialCheckpoint(trial.Create)
+		if err != nil {
+			ctx.Log().Error(err)
+			e.updateState(ctx, model.StoppingErrorState)
+			return
+		}
+		e.trials[trial.Create.RequestID] = trial
+		ref, _ := ctx.ActorOf(trial.Create.RequestID, newTrial(e, trial.Create, checkpoint))
+		ctx.Tell(ref, []searcher.Operation(trial.Operations))
+		if trial.TrialID != 0 {
+			ctx.Tell(ref, trialCreated{create: trial.Create, trialID: trial.TrialID})
+		}
+	}
+	e.restoredSnapshot = nil
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/nprand"
+	"github.com/determined-ai/determined/master/pkg/searcher"
+)
+
+func TestParseSnapshotRestoresHparams(t *testing.T) {
+	hparams := model.Hyperparameters{
+		"int":   {IntHyperparameter: &model.IntHyperparameter{Minval: 0, Maxval: 100}},
+		"float": {DoubleHyperparameter: &model.DoubleHyperparameter{Minval: 0, Maxval: 1}},
+		"optimizer": {NestedHyperparameter: &map[string]model.Hyperparameter{
+			"layers": {IntHyperparameter: &model.IntHyperparameter{Minval: 1, Maxval: 8}},
+		}},
+	}
+	sample := map[string]interface{}{"int": 3, "float": 2.0, "optimizer.layers": 4}
+	create := searcher.NewCreate(nprand.New(0), sample, model.TrialWorkloadSequencerType)
+	saved := experimentSnapshot{
+		Searcher: json.RawMessage(`{}`),
+		Trials:   []*trialSearcherState{{Create: create, TrialID: 1}},
+	}
+
+	content, err := json.Marshal(saved)
+	assert.NilError(t, err)
+	snapshot, err := parseSnapshot(content, hparams)
+	assert.NilError(t, err)
+
+	assert.Equal(t, len(snapshot.Trials), 1)
+	restored := snapshot.Trials[0].Create
+	assert.DeepEqual(t, map[string]interface{}(restored.Hparams), sample)
+	assert.Equal(t, restored.RequestID, create.RequestID)
+	assert.Equal(t, restored.TrialSeed, create.TrialSeed)
+}
@@ -50,3 +50,26 @@ func TestBestValidation(t *testing.T) {
 		[]metricCase{{5, true}, {9, true}, {4, false}, {10, true}, {7, false}, {3, false}},
 	)
 }
+
+func TestTrialSearcherStateReplay(t *testing.T) {
+	// A trial that was snapshotted after completing two operations and three workloads replays
+	// its workloads after a restore; the searcher should only be told about new ones.
+	trial := &trialSearcherState{OperationsCompleted: 2, WorkloadsCompleted: 3, ExitedEarly: true}
+
+	var operations, workloads []bool
+	for i := 0; i < 4; i++ {
+		operations = append(operations, trial.operationCompleted())
+		workloads = append(workloads, trial.workloadCompleted())
+	}
+	assert.DeepEqual(t, operations, []bool{false, false, true, true})
+	assert.DeepEqual(t, workloads, []bool{false, false, false, true})
+	assert.Equal(t, trial.OperationsCompleted, 4)
+	assert.Equal(t, trial.WorkloadsCompleted, 4)
+	assert.Equal(t, trial.exitedEarly(), false)
+
+	fresh := &trialSearcherState{}
+	assert.Equal(t, fresh.operationCompleted(), true)
+	assert.Equal(t, fresh.workloadCompleted(), true)
+	assert.Equal(t, fresh.exitedEarly(), true)
+	assert.Equal(t, fresh.exitedEarly(), false)
+}
@@ -18,35 +18,66 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 
+	"github.com/determined-ai/determined/master/internal/audit"
 	"github.com/determined-ai/determined/master/internal/db"
 	proto "github.com/determined-ai/determined/proto/pkg/apiv1"
 )
 
 const jsonPretty = "application/json+pretty"
 
-// NewGRPCServer creates a Determined gRPC service.
-func NewGRPCServer(db *db.PgDB, srv proto.DeterminedServer) *grpc.Server {
+// NewGRPCServer creates a Determined gRPC service. The audit log takes the addresses of clients
+// from the X-Forwarded-For header of requests from the trusted proxies.
+func NewGRPCServer(
+	db *db.PgDB, srv proto.DeterminedServer, proxies audit.TrustedProxies,
+) *grpc.Server {
+	return newGRPCServer(db, srv, proxies, false)
+}
+
+// NewStandbyGRPCServer creates the gRPC service of a master on standby, which serves the methods
+// that only read from the database and replies to the others that the master is on standby.
+func NewStandbyGRPCServer(
+	db *db.PgDB, srv proto.DeterminedServer, proxies audit.TrustedProxies,
+) *grpc.Server {
+	return newGRPCServer(db, srv, proxies, true)
+}
+
+func newGRPCServer(
+	db *db.PgDB, srv proto.DeterminedServer, proxies audit.TrustedProxies, standby bool,
+) *grpc.Server {
 	logger := logrus.NewEntry(logrus.StandardLogger())
 	opts := []grpclogrus.Option{
 		grpclogrus.WithLevels(grpcCodeToLogrusLevel),
 	}
 	grpclogrus.ReplaceGrpcLogger(logger)
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		streamMetricsInterceptor,
+		grpclogrus.StreamServerInterceptor(logger, opts...),
+		grpcrecovery.StreamServerInterceptor(),
+	}
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		unaryMetricsInterceptor,
+		grpclogrus.UnaryServerInterceptor(logger, opts...),
+		grpcrecovery.UnaryServerInterceptor(grpcrecovery.WithRecoveryHandler(
+			func(p interface{}) (err error) {
+				logger.Error(string(debug.Stack()))
+				return status.Errorf(codes.Internal, "%s", p)
+			},
+		)),
+	}
+	if standby {
+		// Requests that are not served are rejected before they are audited.
+		streamInterceptors = append(streamInterceptors, standbyStreamInterceptor)
+		unaryInterceptors = append(unaryInterceptors, standbyUnaryInterceptor)
+	}
 	grpcS := grpc.NewServer(
-		grpc.StreamInterceptor(grpcmiddleware.ChainStreamServer(
-			grpclogrus.StreamServerInterceptor(logger, opts...),
-			grpcrecovery.StreamServerInterceptor(),
+		grpc.StreamInterceptor(grpcmiddleware.ChainStreamServer(append(streamInterceptors,
+			streamAuditInterceptor(db, proxies),
 			streamAuthInterceptor(db),
-		)),
-		grpc.UnaryInterceptor(grpcmiddleware.ChainUnaryServer(
-			grpclogrus.UnaryServerInterceptor(logger, opts...),
-			grpcrecovery.UnaryServerInterceptor(grpcrecovery.WithRecoveryHandler(
-				func(p interface{}) (err error) {
-					logger.Error(string(debug.Stack()))
-					return status.Errorf(codes.Internal, "%s", p)
-				},
-			)),
+		)...)),
+		grpc.UnaryInterceptor(grpcmiddleware.ChainUnaryServer(append(unaryInterceptors,
+			unaryAuditInterceptor(db, proxies),
 			unaryAuthInterceptor(db),
-		)),
+		)...)),
 	)
 	proto.RegisterDeterminedServer(grpcS, srv)
 	return grpcS
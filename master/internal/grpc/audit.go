@@ -0,0 +1,238 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/determined-ai/determined/master/internal/audit"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// readOnlyMethods lists the methods that only read although their names do not start with Get.
+var readOnlyMethods = map[string]bool{
+	"CurrentUser":     true,
+	"MasterLogs":      true,
+	"TrialLogs":       true,
+	"TrialLogsFields": true,
+	"NotebookLogs":    true,
+	"MetricNames":     true,
+	"MetricBatches":   true,
+	"TrialsSnapshot":  true,
+	"TrialsSample":    true,
+	"PreviewHPSearch": true,
+}
+
+// sensitiveMethods lists the methods whose requests carry passwords. The digests of their payloads
+// are not recorded, since a digest of a password could be used to guess it.
+var sensitiveMethods = map[string]bool{
+	"Login":           true,
+	"PostUser":        true,
+	"SetUserPassword": true,
+}
+
+// auditResources maps the names of methods, by their suffix, to the type of resource that they
+// act on and the request fields that may hold its ID. The first matching suffix wins, so longer
+// suffixes come first.
+var auditResources = []struct {
+	suffix       string
+	resourceType string
+	fields       []string
+}{
+	{"ModelVersion", "model", []string{"model_name"}},
+	{"CheckpointMetadata", "checkpoint", []string{"checkpoint.uuid"}},
+	{"UserPassword", "user", []string{"username"}},
+	{"Experiment", "experiment", []string{"id", "experiment.id"}},
+	{"Trial", "trial", []string{"id"}},
+	{"Template", "template", []string{"template_name", "template.name"}},
+	{"Notebook", "notebook", []string{"notebook_id"}},
+	{"Shell", "shell", []string{"shell_id"}},
+	{"Command", "command", []string{"command_id"}},
+	{"Tensorboard", "tensorboard", []string{"tensorboard_id"}},
+	{"Agent", "agent", []string{"agent_id"}},
+	{"Slot", "agent", []string{"agent_id"}},
+	{"Model", "model", []string{"model.name"}},
+	{"User", "user", []string{"user.username"}},
+	{"Login", "user", []string{"username"}},
+}
+
+// methodName returns the name of a method without its service, e.g., KillExperiment for
+// /determined.api.v1.Determined/KillExperiment.
+func methodName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
+
+// isMutatingMethod returns true if the method may change the state of the cluster.
+func isMutatingMethod(fullMethod string) bool {
+	name := methodName(fullMethod)
+	return !strings.HasPrefix(name, "Get") && !readOnlyMethods[name]
+}
+
+// fieldString returns the value of the field at the dotted path in the message, or the empty
+// string if it is not set.
+func fieldString(m protoreflect.Message, path string) string {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil || !m.Has(fd) {
+			return ""
+		}
+		if i == len(names)-1 {
+			return fmt.Sprint(m.Get(fd).Interface())
+		}
+		if fd.Kind() != protoreflect.MessageKind {
+			return ""
+		}
+		m = m.Get(fd).Message()
+	}
+	return ""
+}
+
+// auditResource returns the type and the ID of the resource that the request acts on.
+func auditResource(fullMethod string, req interface{}) (string, string) {
+	name := methodName(fullMethod)
+	for _, r := range auditResources {
+		if !strings.HasSuffix(name, r.suffix) {
+			continue
+		}
+		if msg, ok := req.(proto.Message); ok {
+			for _, field := range r.fields {
+				if id := fieldString(msg.ProtoReflect(), field); id != "" {
+					return r.resourceType, id
+				}
+			}
+		}
+		return r.resourceType, ""
+	}
+	return "", ""
+}
+
+// payloadDigest returns the digest of the request, or the empty string for methods whose requests
+// carry passwords.
+func payloadDigest(fullMethod string, req interface{}) string {
+	msg, ok := req.(proto.Message)
+	if !ok || sensitiveMethods[methodName(fullMethod)] {
+		return ""
+	}
+	payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return ""
+	}
+	return model.AuditPayloadDigest(payload)
+}
+
+// gatewayProxies are the addresses that grpc-gateway, which runs in the master and forwards the
+// address of its HTTP client in the X-Forwarded-For header, connects to the gRPC server from.
+var gatewayProxies, _ = audit.ParseTrustedProxies([]string{"127.0.0.0/8", "::1"})
+
+// sourceIP returns the address of the client, following the X-Forwarded-For header through the
+// gateway and the trusted proxies.
+func sourceIP(ctx context.Context, proxies audit.TrustedProxies) string {
+	var peerAddr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		peerAddr = p.Addr.String()
+	}
+	var forwardedFor []string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		forwardedFor = md["x-forwarded-for"]
+	}
+	return proxies.ClientIP(peerAddr, forwardedFor)
+}
+
+// auditEntry returns the audit log entry of a call of the method with the request, which was made
+// by the user with the API token, if any, and failed with the error, if any.
+func auditEntry(
+	ctx context.Context, fullMethod string, req interface{}, user *model.User,
+	token *model.APIToken, err error, proxies audit.TrustedProxies,
+) model.AuditEntry {
+	entry := model.AuditEntry{
+		Transport: model.AuditTransportGRPC,
+		Action:    fullMethod,
+		SourceIP:  sourceIP(ctx, proxies),
+		Outcome:   model.AuditOutcomeSuccess,
+		Status:    status.Code(err).String(),
+	}
+	if err != nil {
+		entry.Outcome = model.AuditOutcomeFailure
+	}
+	entry.ResourceType, entry.ResourceID = auditResource(fullMethod, req)
+	entry.PayloadDigest = payloadDigest(fullMethod, req)
+	if user != nil {
+		entry.UserID = &user.ID
+		entry.Username = user.Username
+		if token != nil {
+			entry.APITokenID = &token.ID
+		}
+	} else if unauthenticatedMethods[fullMethod] && entry.ResourceType == "user" {
+		// The user of a login is the one that it claims to be.
+		entry.Username = entry.ResourceID
+	}
+	return entry
+}
+
+// unaryAuditInterceptor records every unary call of a mutating method in the audit log. It runs
+// before the authentication interceptor so that calls that are refused are recorded as well.
+func unaryAuditInterceptor(
+	db *db.PgDB, proxies audit.TrustedProxies,
+) grpc.UnaryServerInterceptor {
+	proxies = append(append(audit.TrustedProxies{}, gatewayProxies...), proxies...)
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if !isMutatingMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		// The user is looked up before the call, since logging out ends the session.
+		user, token, _ := GetUserAndAPIToken(ctx, db)
+		resp, err := handler(ctx, req)
+		audit.Record(db, auditEntry(ctx, info.FullMethod, req, user, token, err, proxies))
+		return resp, err
+	}
+}
+
+// auditingServerStream keeps the first message that is received on a stream, which is the request
+// of a server-streaming call.
+type auditingServerStream struct {
+	grpc.ServerStream
+	req interface{}
+}
+
+func (s *auditingServerStream) RecvMsg(m interface{}) error {
+	if s.req == nil {
+		// The message is kept even if it is refused by the authentication interceptor.
+		s.req = m
+	}
+	return s.ServerStream.RecvMsg(m)
+}
+
+// streamAuditInterceptor records every streaming call of a mutating method in the audit log once
+// the stream ends, like unaryAuditInterceptor does for unary calls. The streaming methods of the
+// API currently all only read, so none of them are recorded.
+func streamAuditInterceptor(
+	db *db.PgDB, proxies audit.TrustedProxies,
+) grpc.StreamServerInterceptor {
+	proxies = append(append(audit.TrustedProxies{}, gatewayProxies...), proxies...)
+	return func(
+		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		if !isMutatingMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx := ss.Context()
+		user, token, _ := GetUserAndAPIToken(ctx, db)
+		stream := &auditingServerStream{ServerStream: ss}
+		err := handler(srv, stream)
+		audit.Record(db, auditEntry(ctx, info.FullMethod, stream.req, user, token, err, proxies))
+		return err
+	}
+}
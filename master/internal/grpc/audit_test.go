@@ -0,0 +1,106 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/audit"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/experimentv1"
+	"github.com/determined-ai/determined/proto/pkg/userv1"
+)
+
+const service = "/determined.api.v1.Determined/"
+
+func TestIsMutatingMethod(t *testing.T) {
+	assert.Assert(t, isMutatingMethod(service+"KillExperiment"))
+	assert.Assert(t, isMutatingMethod(service+"Login"))
+	assert.Assert(t, !isMutatingMethod(service+"GetExperiments"))
+	assert.Assert(t, !isMutatingMethod(service+"TrialLogs"))
+}
+
+func TestAuditResource(t *testing.T) {
+	cases := []struct {
+		method string
+		req    interface{}
+		typ    string
+		id     string
+	}{
+		{"KillExperiment", &apiv1.KillExperimentRequest{Id: 3}, "experiment", "3"},
+		{"PatchExperiment", &apiv1.PatchExperimentRequest{
+			Experiment: &experimentv1.Experiment{Id: 4},
+		}, "experiment", "4"},
+		{"KillTrial", &apiv1.KillTrialRequest{Id: 5}, "trial", "5"},
+		{"DisableSlot", &apiv1.DisableSlotRequest{AgentId: "a", SlotId: "1"}, "agent", "a"},
+		{"PostModelVersion", &apiv1.PostModelVersionRequest{ModelName: "m"}, "model", "m"},
+		{"PostUser", &apiv1.PostUserRequest{User: &userv1.User{Username: "bob"}}, "user", "bob"},
+		{"SetUserPassword", &apiv1.SetUserPasswordRequest{Username: "bob"}, "user", "bob"},
+		{"LaunchNotebook", &apiv1.LaunchNotebookRequest{}, "notebook", ""},
+		{"Logout", &apiv1.LogoutRequest{}, "", ""},
+	}
+	for _, c := range cases {
+		typ, id := auditResource(service+c.method, c.req)
+		assert.Equal(t, typ, c.typ, c.method)
+		assert.Equal(t, id, c.id, c.method)
+	}
+}
+
+func TestPayloadDigest(t *testing.T) {
+	kill := &apiv1.KillExperimentRequest{Id: 3}
+	assert.Assert(t, payloadDigest(service+"KillExperiment", kill) != "")
+	assert.Equal(t, payloadDigest(service+"Login",
+		&apiv1.LoginRequest{Username: "alice", Password: "secret"}), "")
+	assert.Equal(t, payloadDigest(service+"SetUserPassword",
+		&apiv1.SetUserPasswordRequest{Username: "alice", Password: "secret"}), "")
+}
+
+func TestSourceIP(t *testing.T) {
+	proxies := append(audit.TrustedProxies{}, gatewayProxies...)
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4321},
+	})
+	assert.Equal(t, sourceIP(ctx, proxies), "10.0.0.1")
+
+	// Clients that connect directly cannot forge their address.
+	forged := metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", "192.0.2.7"))
+	assert.Equal(t, sourceIP(forged, proxies), "10.0.0.1")
+
+	// The gateway forwards the address of its client.
+	ctx = peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 4321},
+	})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", "192.0.2.7, 10.0.0.2"))
+	assert.Equal(t, sourceIP(ctx, proxies), "10.0.0.2")
+}
+
+// fakeServerStream receives a single message.
+type fakeServerStream struct {
+	grpc.ServerStream
+	msg proto.Message
+}
+
+func (s *fakeServerStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), s.msg)
+	return nil
+}
+
+func TestAuditingServerStream(t *testing.T) {
+	stream := &auditingServerStream{
+		ServerStream: &fakeServerStream{msg: &apiv1.TrialLogsRequest{TrialId: 7}},
+	}
+	var req apiv1.TrialLogsRequest
+	assert.NilError(t, stream.RecvMsg(&req))
+	assert.Equal(t, req.TrialId, int32(7))
+	assert.Equal(t, stream.req, &req)
+
+	var next apiv1.TrialLogsRequest
+	assert.NilError(t, stream.RecvMsg(&next))
+	assert.Equal(t, stream.req, &req)
+}
@@ -40,46 +40,102 @@ var (
 	ErrPermissionDenied = status.Error(codes.PermissionDenied, "user does not have permission")
 )
 
-// GetUser returns the currently logged in user.
-func GetUser(ctx context.Context, d *db.PgDB) (*model.User, *model.UserSession, error) {
+// authenticate returns the user that the request is authenticated as, along with the session or
+// the API token that the request uses.
+func authenticate(ctx context.Context, d *db.PgDB) (
+	*model.User, *model.UserSession, *model.APIToken, error,
+) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return nil, nil, ErrTokenMissing
+		return nil, nil, nil, ErrTokenMissing
 	}
 	tokens := md[userTokenHeader]
 	if len(tokens) == 0 {
 		tokens = md[gatewayTokenHeader]
 		if len(tokens) == 0 {
-			return nil, nil, ErrTokenMissing
+			return nil, nil, nil, ErrTokenMissing
 		}
 	}
 
 	token := tokens[0]
 	if !strings.HasPrefix(token, "Bearer ") {
-		return nil, nil, ErrInvalidCredentials
+		return nil, nil, nil, ErrInvalidCredentials
 	}
 	token = strings.TrimPrefix(token, "Bearer ")
 
-	switch user, session, err := d.UserByToken(token); err {
+	var user *model.User
+	var session *model.UserSession
+	var apiToken *model.APIToken
+	var err error
+	if model.IsAPIToken(token) {
+		user, apiToken, err = d.UserByAPIToken(token)
+	} else {
+		user, session, err = d.UserByToken(token)
+	}
+	switch err {
 	case nil:
 		if !user.Active {
-			return nil, nil, ErrPermissionDenied
+			return nil, nil, nil, ErrPermissionDenied
 		}
-		return user, session, nil
+		return user, session, apiToken, nil
 	case db.ErrNotFound:
-		return nil, nil, ErrInvalidCredentials
+		return nil, nil, nil, ErrInvalidCredentials
 	default:
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 }
 
+// GetUser returns the currently logged in user. The session is nil if the request is
+// authenticated with an API token.
+func GetUser(ctx context.Context, d *db.PgDB) (*model.User, *model.UserSession, error) {
+	user, session, _, err := authenticate(ctx, d)
+	return user, session, err
+}
+
+// GetUserAndAPIToken returns the currently logged in user along with the API token that the
+// request is authenticated with, which is nil if the request uses a session.
+func GetUserAndAPIToken(ctx context.Context, d *db.PgDB) (*model.User, *model.APIToken, error) {
+	user, _, apiToken, err := authenticate(ctx, d)
+	return user, apiToken, err
+}
+
+// RequestAuthorizer is implemented by servers that decide whether an authenticated user may make
+// a request, e.g., based on their roles for the resources that the request refers to. The API
+// token is nil unless the request is authenticated with one.
+type RequestAuthorizer interface {
+	AuthorizeRequest(
+		ctx context.Context, user *model.User, token *model.APIToken, req interface{},
+	) error
+}
+
+// authorizingServerStream authorizes each request that is received on a stream.
+type authorizingServerStream struct {
+	grpc.ServerStream
+	user       *model.User
+	token      *model.APIToken
+	authorizer RequestAuthorizer
+}
+
+func (s *authorizingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.authorizer.AuthorizeRequest(s.Context(), s.user, s.token, m)
+}
+
 func streamAuthInterceptor(db *db.PgDB) grpc.StreamServerInterceptor {
 	return func(
 		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
 	) error {
-		if _, _, err := GetUser(ss.Context(), db); err != nil {
+		user, token, err := GetUserAndAPIToken(ss.Context(), db)
+		if err != nil {
 			return err
 		}
+		if authorizer, ok := srv.(RequestAuthorizer); ok {
+			ss = &authorizingServerStream{
+				ServerStream: ss, user: user, token: token, authorizer: authorizer,
+			}
+		}
 		return handler(srv, ss)
 	}
 }
@@ -89,9 +145,15 @@ func unaryAuthInterceptor(db *db.PgDB) grpc.UnaryServerInterceptor {
 		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
 	) (resp interface{}, err error) {
 		if !unauthenticatedMethods[info.FullMethod] {
-			if _, _, err := GetUser(ctx, db); err != nil {
+			user, token, err := GetUserAndAPIToken(ctx, db)
+			if err != nil {
 				return nil, err
 			}
+			if authorizer, ok := info.Server.(RequestAuthorizer); ok {
+				if err := authorizer.AuthorizeRequest(ctx, user, token, req); err != nil {
+					return nil, err
+				}
+			}
 		}
 		return handler(ctx, req)
 	}
@@ -0,0 +1,33 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/prom"
+)
+
+func observeCall(fullMethod string, start time.Time, err error) {
+	prom.GRPCRequestSeconds.WithLabelValues(
+		methodName(fullMethod), status.Code(err).String(),
+	).Observe(time.Since(start).Seconds())
+}
+
+// unaryMetricsInterceptor records the latency of every unary call.
+func unaryMetricsInterceptor(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (resp interface{}, err error) {
+	defer func(start time.Time) { observeCall(info.FullMethod, start, err) }(time.Now())
+	return handler(ctx, req)
+}
+
+// streamMetricsInterceptor records the duration of every streaming call.
+func streamMetricsInterceptor(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) (err error) {
+	defer func(start time.Time) { observeCall(info.FullMethod, start, err) }(time.Now())
+	return handler(srv, ss)
+}
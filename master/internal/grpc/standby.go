@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StandbyMessage is the reply of a master on standby to the requests that only the leader serves.
+const StandbyMessage = "this master is on standby; send the request to the leader of the cluster"
+
+// standbyMethods lists the methods that a master on standby serves. They only read from the
+// database, which the masters share, rather than from the actors of the leader. Logging in and out
+// is allowed as well, since sessions are stored in the database.
+var standbyMethods = map[string]bool{
+	"Login":                          true,
+	"Logout":                         true,
+	"CurrentUser":                    true,
+	"GetMaster":                      true,
+	"GetMasterConfig":                true,
+	"GetUsers":                       true,
+	"GetUser":                        true,
+	"GetAuditLog":                    true,
+	"GetUsage":                       true,
+	"GetExperiments":                 true,
+	"GetExperiment":                  true,
+	"GetExperimentLabels":            true,
+	"GetExperimentValidationHistory": true,
+	"GetExperimentCheckpoints":       true,
+	"GetExperimentTrials":            true,
+	"PreviewHPSearch":                true,
+	"MetricNames":                    true,
+	"MetricBatches":                  true,
+	"TrialsSnapshot":                 true,
+	"TrialsSample":                   true,
+	"GetTrial":                       true,
+	"GetTrialCheckpoints":            true,
+	"TrialLogs":                      true,
+	"TrialLogsFields":                true,
+	"GetCheckpoint":                  true,
+	"GetModels":                      true,
+	"GetModel":                       true,
+	"GetModelVersions":               true,
+	"GetModelVersion":                true,
+	"GetTemplates":                   true,
+	"GetTemplate":                    true,
+}
+
+// errStandby is returned for the methods that a master on standby does not serve, so that clients
+// retry against the leader.
+var errStandby = status.Error(codes.Unavailable, StandbyMessage)
+
+func standbyStreamInterceptor(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	if !standbyMethods[methodName(info.FullMethod)] {
+		return errStandby
+	}
+	return handler(srv, ss)
+}
+
+func standbyUnaryInterceptor(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if !standbyMethods[methodName(info.FullMethod)] {
+		return nil, errStandby
+	}
+	return handler(ctx, req)
+}
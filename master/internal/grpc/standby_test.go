@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func TestStandbyMethodsExist(t *testing.T) {
+	methods := apiv1.File_determined_api_v1_api_proto.Services().ByName("Determined").Methods()
+	for name := range standbyMethods {
+		assert.Assert(t, methods.ByName(protoreflect.Name(name)) != nil, name)
+	}
+}
+
+func TestStandbyInterceptors(t *testing.T) {
+	unary := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream) error {
+		return nil
+	}
+	for _, tc := range []struct {
+		method string
+		served bool
+	}{
+		{"GetExperiments", true},
+		{"TrialLogs", true},
+		{"Login", true},
+		{"KillExperiment", false},
+		{"CreateExperiment", false},
+		// Agents and commands are only known to the actors of the leader.
+		{"GetAgents", false},
+		{"GetNotebooks", false},
+	} {
+		_, err := standbyUnaryInterceptor(
+			context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: service + tc.method}, unary)
+		streamErr := standbyStreamInterceptor(
+			nil, nil, &grpc.StreamServerInfo{FullMethod: service + tc.method}, stream)
+		if tc.served {
+			assert.NilError(t, err, tc.method)
+			assert.NilError(t, streamErr, tc.method)
+		} else {
+			assert.Equal(t, status.Code(err), codes.Unavailable, tc.method)
+			assert.Equal(t, status.Code(streamErr), codes.Unavailable, tc.method)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/soheilhy/cmux"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/audit"
+	"github.com/determined-ai/determined/master/internal/grpc"
+	"github.com/determined-ai/determined/master/internal/user"
+	"github.com/determined-ai/determined/master/pkg/logger"
+)
+
+// leaderStatus reports whether a master leads the cluster. Load balancers in front of several
+// masters use it to route requests to the leader.
+type leaderStatus struct {
+	Leader   bool   `json:"leader"`
+	MasterID string `json:"master_id"`
+}
+
+func (m *Master) getLeader(c echo.Context) (interface{}, error) {
+	return leaderStatus{Leader: true, MasterID: m.MasterID}, nil
+}
+
+// leaderLock is the lock held by the master that leads the cluster.
+type leaderLock interface {
+	// Check returns an error unless the lock is still held.
+	Check(ctx context.Context) error
+	// Release releases the lock.
+	Release() error
+}
+
+// election elects the leader of the cluster among the masters that share a database. The leader
+// holds the lock as a lease: it checks the lock every half interval and gives up leadership if a
+// check fails or does not finish within half an interval, so it stops at most an interval after
+// it loses the lock. A new leader waits out that interval before it takes over, which fences off
+// the writes of the previous leader.
+type election struct {
+	tryLock  func(ctx context.Context) (leaderLock, error)
+	interval time.Duration
+}
+
+func (m *Master) election() election {
+	return election{
+		tryLock: func(ctx context.Context) (leaderLock, error) {
+			lock, err := m.db.TryLeaderLock(ctx)
+			if lock == nil {
+				// Avoid a non-nil interface holding a nil lock.
+				return nil, err
+			}
+			return lock, err
+		},
+		interval: time.Duration(m.config.HighAvailability.ElectionInterval) * time.Second,
+	}
+}
+
+// await tries to take the leader lock every interval until it does, and returns the lock once
+// the previous leader must have stopped. The first time it fails, it calls standBy, which returns
+// a function to stop standing by and a channel that receives the error that standing by fails
+// with.
+func (e election) await(
+	standBy func() (stop func(context.Context) error, errs <-chan error, err error),
+) (leaderLock, error) {
+	var stop func(context.Context) error
+	var errs <-chan error
+	for {
+		switch lock, err := e.tryLock(context.Background()); {
+		case err != nil:
+			log.WithError(err).Warn("failed to try to become the leader of the cluster")
+		case lock != nil:
+			log.Infof("took the leader lock; taking over the cluster in %s", e.interval)
+			time.Sleep(e.interval)
+			if err := lock.Check(context.Background()); err != nil {
+				_ = lock.Release()
+				return nil, errors.Wrap(err, "lost leadership of the cluster while taking over")
+			}
+			log.Info("this master is now the leader of the cluster")
+			if stop != nil {
+				ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+				defer cancel()
+				if err := stop(ctx); err != nil {
+					_ = lock.Release()
+					return nil, errors.Wrap(err, "failed to stop standby server")
+				}
+			}
+			return lock, nil
+		}
+
+		if stop == nil {
+			log.Info("another master leads the cluster; standing by")
+			var err error
+			if stop, errs, err = standBy(); err != nil {
+				return nil, err
+			}
+		}
+
+		select {
+		case err := <-errs:
+			if err != http.ErrServerClosed {
+				return nil, errors.Wrap(err, "standby server failed")
+			}
+		case <-time.After(e.interval):
+		}
+	}
+}
+
+// hold checks that this master still holds the leader lock, and returns an error once it may
+// not, so that the master exits and another master takes over.
+func (e election) hold(lock leaderLock) error {
+	ticker := time.NewTicker(e.interval / 2)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), e.interval/2)
+		err := lock.Check(ctx)
+		cancel()
+		if err != nil {
+			return errors.Wrap(err, "lost leadership of the cluster")
+		}
+	}
+	return nil
+}
+
+// awaitLeadership waits for this master to become the leader of the cluster, and returns the
+// leader lock. Until then, the master stands by and serves read-only API requests from the
+// database; requests that change the state of the cluster, and connections from agents, must go
+// to the leader.
+func (m *Master) awaitLeadership(
+	cert *tls.Certificate, userService *user.Service, authFuncs []echo.MiddlewareFunc,
+) (leaderLock, error) {
+	return m.election().await(func() (func(context.Context) error, <-chan error, error) {
+		listener, err := m.listen(cert)
+		if err != nil {
+			return nil, nil, err
+		}
+		standby, err := m.newStandbyServer(cert, userService, authFuncs)
+		if err != nil {
+			_ = listener.Close()
+			return nil, nil, err
+		}
+		grpcServer := grpc.NewStandbyGRPCServer(m.db, &apiServer{m: m}, m.trustedProxies)
+
+		mux := cmux.New(listener)
+		grpcListener := mux.MatchWithWriters(
+			cmux.HTTP2MatchHeaderFieldSendSettings("content-type", "application/grpc"),
+		)
+		standby.Listener = mux.Match(cmux.HTTP1(), cmux.HTTP2())
+
+		errs := make(chan error, 3)
+		go func() {
+			errs <- grpcServer.Serve(grpcListener)
+		}()
+		go func() {
+			errs <- standby.StartServer(standby.Server)
+		}()
+		go func() {
+			errs <- mux.Serve()
+		}()
+		stop := func(ctx context.Context) error {
+			grpcServer.Stop()
+			err := standby.Shutdown(ctx)
+			// Closing the listener frees the port for the servers of the leader.
+			if cErr := listener.Close(); err == nil {
+				err = cErr
+			}
+			return err
+		}
+		return stop, errs, nil
+	})
+}
+
+// newStandbyServer returns the server of a master on standby. It serves the routes that read from
+// the database, including the /api/v1 routes of the gRPC methods that the standby gRPC server
+// serves, and rejects requests that would change the state of the cluster.
+func (m *Master) newStandbyServer(
+	cert *tls.Certificate, userService *user.Service, authFuncs []echo.MiddlewareFunc,
+) (*echo.Echo, error) {
+	e := echo.New()
+	e.Use(middleware.Recover())
+	e.Use(detContextMiddleware)
+	e.Use(rejectOnStandby)
+	e.Use(audit.Middleware(m.db, m.trustedProxies))
+	e.Use(convertDBErrorsToNotFound)
+	e.Logger = logger.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.HTTPErrorHandler = api.JSONErrorHandler
+
+	if err := grpc.RegisterHTTPProxy(e, m.config.Port, cert); err != nil {
+		return nil, errors.Wrap(err, "failed to register gRPC gateway")
+	}
+
+	e.GET("/info", api.Route(m.getInfo))
+	e.GET("/leader", func(c echo.Context) error {
+		return c.JSON(http.StatusServiceUnavailable, leaderStatus{MasterID: m.MasterID})
+	})
+
+	experimentsGroup := e.Group("/experiments", authFuncs...)
+	experimentsGroup.GET("", api.Route(m.getExperiments))
+	experimentsGroup.GET("/:experiment_id", api.Route(m.getExperiment))
+	experimentsGroup.GET("/:experiment_id/checkpoints", api.Route(m.getExperimentCheckpoints))
+	experimentsGroup.GET("/:experiment_id/config", api.Route(m.getExperimentConfig))
+	experimentsGroup.GET("/:experiment_id/model_def", m.getExperimentModelDefinition)
+	experimentsGroup.GET("/:experiment_id/summary", api.Route(m.getExperimentSummary))
+	experimentsGroup.GET("/:experiment_id/metrics/summary", api.Route(m.getExperimentSummaryMetrics))
+	e.GET("/experiment-list", api.Route(m.getExperimentList), authFuncs...)
+	e.GET("/experiment-summaries", api.Route(m.getExperimentSummaries), authFuncs...)
+
+	trialsGroup := e.Group("/trials", authFuncs...)
+	trialsGroup.GET("/:trial_id", api.Route(m.getTrial))
+	trialsGroup.GET("/:trial_id/details", api.Route(m.getTrialDetails))
+	trialsGroup.GET("/:trial_id/logs", m.getTrialLogs)
+	trialsGroup.GET("/:trial_id/metrics", api.Route(m.getTrialMetrics))
+	trialsGroup.GET("/:trial_id/logsv2", api.Route(m.getTrialLogsV2))
+
+	checkpointsGroup := e.Group("/checkpoints", authFuncs...)
+	checkpointsGroup.GET("", api.Route(m.getCheckpoints))
+	checkpointsGroup.GET("/:checkpoint_uuid", api.Route(m.getCheckpoint))
+
+	user.RegisterAPIHandler(e, userService, authFuncs...)
+	e.Any("/*", standbyReply)
+	return e, nil
+}
+
+// standbyReply replies to an HTTP request that only the leader serves that this master is on
+// standby. Clients should retry against the leader, e.g., through a load balancer that routes
+// requests to the master whose /leader endpoint responds with 200 OK.
+func standbyReply(c echo.Context) error {
+	c.Response().Header().Set("Retry-After", "5")
+	return echo.NewHTTPError(http.StatusServiceUnavailable, grpc.StandbyMessage)
+}
+
+// rejectOnStandby rejects the requests that a master on standby cannot serve. Logging in and out
+// is allowed, since sessions are stored in the database. Requests to /api/v1 are passed on to the
+// standby gRPC server, which decides which methods it serves.
+func rejectOnStandby(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		switch {
+		case c.Request().Method == http.MethodGet, c.Path() == "/login", c.Path() == "/logout",
+			strings.HasPrefix(c.Path(), "/api/v1/"):
+			return next(c)
+		default:
+			return standbyReply(c)
+		}
+	}
+}
+
+// holdLeadership returns an error once this master may no longer lead the cluster.
+func (m *Master) holdLeadership() error {
+	return m.election().hold(m.leaderLock)
+}
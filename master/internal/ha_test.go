@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/grpc"
+)
+
+func TestRejectOnStandby(t *testing.T) {
+	e := echo.New()
+	e.Use(rejectOnStandby)
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/experiments", ok)
+	e.POST("/experiments", ok)
+	e.POST("/login", ok)
+	e.Any("/api/v1/*", ok)
+	e.Any("/*", standbyReply)
+
+	for _, tc := range []struct {
+		method, path string
+		code         int
+	}{
+		{http.MethodGet, "/experiments", http.StatusOK},
+		{http.MethodPost, "/experiments", http.StatusServiceUnavailable},
+		{http.MethodPost, "/login", http.StatusOK},
+		{http.MethodPost, "/agents", http.StatusServiceUnavailable},
+		// The standby gRPC server decides which /api/v1 requests it serves.
+		{http.MethodGet, "/api/v1/experiments", http.StatusOK},
+		{http.MethodPost, "/api/v1/auth/login", http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		assert.Equal(t, rec.Code, tc.code, "%s %s", tc.method, tc.path)
+		if tc.code == http.StatusServiceUnavailable {
+			assert.Assert(t, strings.Contains(rec.Body.String(), grpc.StandbyMessage), rec.Body.String())
+			assert.Equal(t, rec.Header().Get("Retry-After"), "5")
+		}
+	}
+}
+
+// fakeLockDB stands in for the advisory lock of the database that the masters share.
+type fakeLockDB struct {
+	mu     sync.Mutex
+	holder *fakeLock
+}
+
+type fakeLock struct {
+	db   *fakeLockDB
+	slow bool
+}
+
+func (d *fakeLockDB) tryLock(context.Context) (leaderLock, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.holder != nil {
+		return nil, nil
+	}
+	d.holder = &fakeLock{db: d}
+	return d.holder, nil
+}
+
+// dropSession releases the lock as Postgres does when the session of the leader is lost.
+func (d *fakeLockDB) dropSession() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.holder = nil
+}
+
+func (l *fakeLock) Check(ctx context.Context) error {
+	l.db.mu.Lock()
+	held, slow := l.db.holder == l, l.slow
+	l.db.mu.Unlock()
+	if slow {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if !held {
+		return errors.New("leader lock is no longer held")
+	}
+	return nil
+}
+
+func (l *fakeLock) Release() error {
+	l.db.dropSession()
+	return nil
+}
+
+func noStandBy(t *testing.T) func() (func(context.Context) error, <-chan error, error) {
+	return func() (func(context.Context) error, <-chan error, error) {
+		t.Error("the master stood by")
+		return nil, nil, errors.New("unexpected standby")
+	}
+}
+
+func TestElectionFailover(t *testing.T) {
+	db := &fakeLockDB{}
+	e := election{tryLock: db.tryLock, interval: 100 * time.Millisecond}
+
+	// The first master becomes the leader.
+	lock, err := e.await(noStandBy(t))
+	assert.NilError(t, err)
+	held := make(chan time.Time, 1)
+	go func() {
+		assert.ErrorContains(t, e.hold(lock), "lost leadership")
+		held <- time.Now()
+	}()
+
+	// The second master stands by.
+	stoodBy, stopped := make(chan struct{}), make(chan struct{})
+	type result struct {
+		lock leaderLock
+		err  error
+		at   time.Time
+	}
+	leader := make(chan result, 1)
+	go func() {
+		lock, err := e.await(func() (func(context.Context) error, <-chan error, error) {
+			close(stoodBy)
+			return func(context.Context) error {
+				close(stopped)
+				return nil
+			}, make(chan error), nil
+		})
+		leader <- result{lock, err, time.Now()}
+	}()
+	<-stoodBy
+	time.Sleep(3 * e.interval)
+	select {
+	case <-leader:
+		t.Fatal("the master on standby became the leader while the leader holds the lock")
+	case <-held:
+		t.Fatal("the leader gave up leadership while it holds the lock")
+	default:
+	}
+
+	// The session of the leader is lost: it stops leading before the other master takes over.
+	db.dropSession()
+	var stoppedAt time.Time
+	select {
+	case stoppedAt = <-held:
+	case <-time.After(10 * e.interval):
+		t.Fatal("the leader did not notice that it lost the lock")
+	}
+	select {
+	case r := <-leader:
+		assert.NilError(t, r.err)
+		assert.Assert(t, r.lock != nil)
+		assert.Assert(t, r.at.After(stoppedAt), "the new leader took over before the old one stopped")
+	case <-time.After(10 * e.interval):
+		t.Fatal("the master on standby did not take over")
+	}
+	<-stopped
+}
+
+func TestElectionHoldTimesOut(t *testing.T) {
+	db := &fakeLockDB{}
+	e := election{tryLock: db.tryLock, interval: 50 * time.Millisecond}
+	lock, err := e.await(noStandBy(t))
+	assert.NilError(t, err)
+
+	// A leader that cannot reach the database gives up leadership.
+	lock.(*fakeLock).db.mu.Lock()
+	lock.(*fakeLock).slow = true
+	lock.(*fakeLock).db.mu.Unlock()
+	assert.ErrorContains(t, e.hold(lock), context.DeadlineExceeded.Error())
+}
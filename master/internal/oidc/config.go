@@ -0,0 +1,72 @@
+package oidc
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// Config configures single sign-on through an OpenID Connect identity provider.
+type Config struct {
+	// ProviderURL is the issuer URL of the identity provider; its discovery document must be
+	// served at <provider_url>/.well-known/openid-configuration.
+	ProviderURL  string `json:"provider_url"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	// RedirectURL is the URL of the callback endpoint of the master, e.g.,
+	// https://determined.example.com:8443/oidc/callback, as registered with the identity provider.
+	// It must use HTTPS unless the master is on localhost.
+	RedirectURL string   `json:"redirect_url"`
+	Scopes      []string `json:"scopes"`
+	// UsernameClaim is the ID token claim whose value is used as the Determined username.
+	UsernameClaim string `json:"username_claim"`
+	// GroupsClaim is the ID token claim that lists the groups of the user in the identity provider.
+	GroupsClaim string `json:"groups_claim"`
+	// AdminGroups lists the groups whose members are admins. If it is empty, the admin status of
+	// users is managed in Determined as usual.
+	AdminGroups []string `json:"admin_groups"`
+	// LinkExistingUsers allows users that sign in for the first time to be linked to the existing
+	// user with the same username, e.g., users that were added before single sign-on was set up.
+	// Otherwise, they are refused, since anyone who can choose the value of the username claim
+	// could take over the account.
+	LinkExistingUsers bool `json:"link_existing_users"`
+}
+
+var defaultConfig = Config{
+	Scopes:        []string{"openid", "profile", "email"},
+	UsernameClaim: "preferred_username",
+	GroupsClaim:   "groups",
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	*c = defaultConfig
+	type DefaultParser *Config
+	return json.Unmarshal(data, DefaultParser(c))
+}
+
+// Validate implements the check.Validatable interface.
+func (c Config) Validate() []error {
+	var redirectErr error
+	switch u, err := url.Parse(c.RedirectURL); {
+	case err != nil || !u.IsAbs():
+		redirectErr = errors.Errorf("redirect_url must be an absolute URL: %q", c.RedirectURL)
+	case u.Scheme != "https" && u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1":
+		// The session and login cookies are only sent over HTTPS.
+		redirectErr = errors.Errorf("redirect_url must use https: %q", c.RedirectURL)
+	}
+	hasOpenIDScope := false
+	for _, scope := range c.Scopes {
+		hasOpenIDScope = hasOpenIDScope || scope == "openid"
+	}
+	return []error{
+		check.NotEmpty(c.ProviderURL, "provider_url must be non-empty"),
+		check.NotEmpty(c.ClientID, "client_id must be non-empty"),
+		check.NotEmpty(c.UsernameClaim, "username_claim must be non-empty"),
+		check.True(hasOpenIDScope, "scopes must include openid"),
+		redirectErr,
+	}
+}
@@ -0,0 +1,372 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const (
+	// loginTimeout is how long a user has to sign in with the identity provider.
+	loginTimeout = 10 * time.Minute
+	// defaultRelayState is the page that users are sent to after signing in.
+	defaultRelayState = "/det/"
+	// loginCookieName is the name of the cookie that binds a pending login to the browser that
+	// started it, so that the callback of a login that someone else started is rejected.
+	loginCookieName = "oidc_login"
+)
+
+// pendingLogin is a login that has been sent to the identity provider and awaits its callback.
+type pendingLogin struct {
+	codeVerifier string
+	nonce        string
+	relayState   string
+	expiry       time.Time
+	// bindingHash is the hash of the value of the login cookie of the browser that started it.
+	bindingHash string
+}
+
+// identity is the user identified by the identity provider.
+type identity struct {
+	// issuer and subject identify the user; unlike the username, the user cannot change them.
+	issuer   string
+	subject  string
+	username string
+	admin    *bool
+}
+
+// Service signs users in through an OpenID Connect identity provider using the authorization
+// code flow with PKCE. Users that sign in for the first time are added to Determined and linked to
+// the subject of their ID token.
+type Service struct {
+	config   Config
+	db       *db.PgDB
+	provider *oidc.Provider
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	// secureCookies is whether cookies are only sent over HTTPS, which is true unless the master is
+	// reached over HTTP on localhost.
+	secureCookies bool
+
+	mu      sync.Mutex
+	pending map[string]pendingLogin
+}
+
+// New creates a new OpenID Connect service, fetching the discovery document of the provider.
+func New(db *db.PgDB, config Config) (*Service, error) {
+	provider, err := oidc.NewProvider(context.Background(), config.ProviderURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error discovering OpenID provider %s", config.ProviderURL)
+	}
+	return &Service{
+		config:   config,
+		db:       db,
+		provider: provider,
+		oauth2: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  config.RedirectURL,
+			Scopes:       config.Scopes,
+		},
+		verifier:      provider.Verifier(&oidc.Config{ClientID: config.ClientID}),
+		secureCookies: strings.HasPrefix(config.RedirectURL, "https:"),
+		pending:       make(map[string]pendingLogin),
+	}, nil
+}
+
+// randomString returns a URL-safe encoding of n random bytes.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "error generating random bytes")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hash returns a URL-safe encoding of the SHA-256 hash of the value.
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// codeChallenge returns the S256 PKCE code challenge for the verifier.
+func codeChallenge(verifier string) string {
+	return hash(verifier)
+}
+
+// safeRelayState returns the relay state if it is a path on the master, so that the login flow
+// cannot be used to redirect users to other sites.
+func safeRelayState(relayState string) string {
+	if !strings.HasPrefix(relayState, "/") || strings.HasPrefix(relayState, "//") ||
+		strings.Contains(relayState, `\`) {
+		return defaultRelayState
+	}
+	return relayState
+}
+
+// authCodeURL starts a login and returns the URL of the identity provider to send the user to,
+// along with the value of the login cookie that binds the login to the browser.
+func (s *Service) authCodeURL(relayState string) (authURL, binding string, err error) {
+	state, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	if binding, err = randomString(32); err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for key, login := range s.pending {
+		if now.After(login.expiry) {
+			delete(s.pending, key)
+		}
+	}
+	s.pending[state] = pendingLogin{
+		codeVerifier: verifier,
+		nonce:        nonce,
+		relayState:   safeRelayState(relayState),
+		expiry:       now.Add(loginTimeout),
+		bindingHash:  hash(binding),
+	}
+	s.mu.Unlock()
+
+	return s.oauth2.AuthCodeURL(state,
+		oidc.Nonce(nonce),
+		oauth2.SetAuthURLParam("code_challenge", codeChallenge(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	), binding, nil
+}
+
+// takePendingLogin removes and returns the unexpired login with the given state, if it was started
+// by the browser with the given login cookie.
+func (s *Service) takePendingLogin(state, binding string) (pendingLogin, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	login, ok := s.pending[state]
+	delete(s.pending, state)
+	bound := subtle.ConstantTimeCompare([]byte(hash(binding)), []byte(login.bindingHash)) == 1
+	return login, ok && bound && time.Now().Before(login.expiry)
+}
+
+// loginCookie returns the cookie that binds a login to the browser. It is only sent to the
+// callback, which the identity provider redirects to from another site, so it cannot be strict.
+func (s *Service) loginCookie(binding string, maxAge time.Duration) *http.Cookie {
+	return &http.Cookie{
+		Name:     loginCookieName,
+		Value:    binding,
+		Path:     "/oidc/",
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   s.secureCookies,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// exchange redeems the authorization code for an ID token and returns the identity in it.
+func (s *Service) exchange(ctx context.Context, code string, login pendingLogin) (
+	*identity, error,
+) {
+	token, err := s.oauth2.Exchange(ctx, code,
+		oauth2.SetAuthURLParam("code_verifier", login.codeVerifier))
+	if err != nil {
+		return nil, errors.Wrap(err, "error redeeming authorization code")
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("identity provider did not return an ID token")
+	}
+	idToken, err := s.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "error verifying ID token")
+	}
+	if idToken.Nonce != login.nonce {
+		return nil, errors.New("ID token nonce does not match")
+	}
+
+	var claims map[string]interface{}
+	if err = idToken.Claims(&claims); err != nil {
+		return nil, errors.Wrap(err, "error parsing ID token claims")
+	}
+	id, err := s.identityFromClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+	id.issuer, id.subject = idToken.Issuer, idToken.Subject
+	return id, nil
+}
+
+// identityFromClaims maps the claims of an ID token to a Determined user.
+func (s *Service) identityFromClaims(claims map[string]interface{}) (*identity, error) {
+	username, ok := claims[s.config.UsernameClaim].(string)
+	if !ok || username == "" {
+		return nil, errors.Errorf("ID token has no %s claim", s.config.UsernameClaim)
+	}
+	id := identity{username: strings.ToLower(username)}
+
+	if len(s.config.AdminGroups) == 0 {
+		return &id, nil
+	}
+	var groups []string
+	switch claim := claims[s.config.GroupsClaim].(type) {
+	case string:
+		groups = []string{claim}
+	case []interface{}:
+		for _, group := range claim {
+			if group, ok := group.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+	}
+	admin := false
+	for _, group := range groups {
+		for _, adminGroup := range s.config.AdminGroups {
+			admin = admin || group == adminGroup
+		}
+	}
+	id.admin = &admin
+	return &id, nil
+}
+
+// provision returns the user that is linked to the identity, adding or linking them if there is
+// none, and updates their admin status from the groups of the identity provider.
+func (s *Service) provision(id identity) (*model.User, error) {
+	user, err := s.db.UserByOIDCIdentity(id.issuer, id.subject)
+	switch {
+	case err == db.ErrNotFound:
+		if user, err = s.link(id); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	}
+
+	if id.admin != nil && *id.admin != user.Admin {
+		user.Admin = *id.admin
+		if err = s.db.UpdateUser(user, []string{"admin"}, nil); err != nil {
+			return nil, err
+		}
+		log.Infof("set admin status of user %s to %t from their groups", user.Username, user.Admin)
+	}
+	return user, nil
+}
+
+// link adds a user for an identity that signs in for the first time, or links the existing user
+// with its username if the configuration allows it.
+func (s *Service) link(id identity) (*model.User, error) {
+	user, err := s.db.UserByUsername(id.username)
+	switch {
+	case err == db.ErrNotFound:
+		user = &model.User{Username: id.username, Active: true, Admin: id.admin != nil && *id.admin}
+		if err = s.db.AddOIDCUser(user, id.issuer, id.subject); err != nil {
+			return nil, errors.Wrapf(err, "error adding user %s", id.username)
+		}
+		log.Infof("added user %s on their first single sign-on", id.username)
+		return s.db.UserByUsername(id.username)
+	case err != nil:
+		return nil, err
+	case !s.config.LinkExistingUsers:
+		log.Warnf("refused single sign-on of %s as existing user %s", id.subject, id.username)
+		return nil, echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf(
+			"user %s already exists and is not linked to single sign-on", id.username))
+	}
+
+	switch err = s.db.LinkOIDCIdentity(user.ID, id.issuer, id.subject); {
+	case err == db.ErrDuplicateRecord:
+		log.Warnf("refused single sign-on of %s as user %s, which is linked to another subject",
+			id.subject, id.username)
+		return nil, echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf(
+			"user %s is linked to another single sign-on identity", id.username))
+	case err != nil:
+		return nil, err
+	}
+	log.Infof("linked existing user %s on their first single sign-on", id.username)
+	return user, nil
+}
+
+func (s *Service) getLogin(c echo.Context) error {
+	url, binding, err := s.authCodeURL(c.QueryParam("relayState"))
+	if err != nil {
+		return err
+	}
+	c.SetCookie(s.loginCookie(binding, loginTimeout))
+	return c.Redirect(http.StatusSeeOther, url)
+}
+
+func (s *Service) getCallback(c echo.Context) error {
+	if errCode := c.QueryParam("error"); errCode != "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, fmt.Sprintf(
+			"identity provider returned an error: %s %s", errCode, c.QueryParam("error_description")))
+	}
+	var binding string
+	if cookie, err := c.Cookie(loginCookieName); err == nil {
+		binding = cookie.Value
+	}
+	// A negative max age deletes the cookie.
+	c.SetCookie(s.loginCookie("", -time.Second))
+	login, ok := s.takePendingLogin(c.QueryParam("state"), binding)
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "unknown or expired login; please try again")
+	}
+
+	id, err := s.exchange(c.Request().Context(), c.QueryParam("code"), login)
+	if err != nil {
+		log.WithError(err).Warn("single sign-on failed")
+		return echo.NewHTTPError(http.StatusUnauthorized, "single sign-on failed")
+	}
+	user, err := s.provision(*id)
+	if err != nil {
+		return err
+	}
+	if !user.Active {
+		return echo.NewHTTPError(http.StatusForbidden, "user is not active")
+	}
+
+	token, err := s.db.StartUserSession(user)
+	if err != nil {
+		return err
+	}
+	// The WebUI reads the token from the cookie, as it does after a login with a password, so it
+	// cannot be HttpOnly.
+	c.SetCookie(&http.Cookie{
+		Name:     "auth",
+		Value:    token,
+		Path:     "/",
+		Expires:  time.Now().Add(db.SessionDuration),
+		Secure:   s.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.Redirect(http.StatusSeeOther, login.relayState)
+}
+
+// RegisterAPIHandler registers the endpoints that start a login with the identity provider and
+// receive its callback.
+func RegisterAPIHandler(echo *echo.Echo, s *Service) {
+	echo.GET("/oidc/login", s.getLogin)
+	echo.GET("/oidc/callback", s.getCallback)
+}
@@ -0,0 +1,252 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// fakeProvider is an OpenID Connect identity provider that issues ID tokens with fixed claims.
+type fakeProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]interface{}
+
+	// Recorded from the authorization request, as a real provider would store them with the code.
+	nonce     string
+	challenge string
+}
+
+func newFakeProvider(t *testing.T, claims map[string]interface{}) *fakeProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	p := &fakeProvider{key: key, claims: claims}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		p.writeJSON(w, map[string]interface{}{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/auth",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, _ *http.Request) {
+		p.writeJSON(w, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+			Key: &key.PublicKey, KeyID: "test", Algorithm: string(jose.RS256), Use: "sig",
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if codeChallenge(r.FormValue("code_verifier")) != p.challenge {
+			http.Error(w, `{"error": "invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		p.writeJSON(w, map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     p.idToken(t),
+		})
+	})
+	p.server = httptest.NewServer(mux)
+	return p
+}
+
+func (p *fakeProvider) writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (p *fakeProvider) idToken(t *testing.T) string {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: p.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "test"))
+	assert.NilError(t, err)
+	claims := map[string]interface{}{
+		"iss":   p.server.URL,
+		"sub":   "1234",
+		"aud":   "determined",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": p.nonce,
+	}
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	assert.NilError(t, err)
+	return token
+}
+
+// authorize records the parameters of the authorization request and returns its state.
+func (p *fakeProvider) authorize(t *testing.T, authURL string) string {
+	u, err := url.Parse(authURL)
+	assert.NilError(t, err)
+	query := u.Query()
+	assert.Equal(t, query.Get("code_challenge_method"), "S256")
+	p.nonce = query.Get("nonce")
+	p.challenge = query.Get("code_challenge")
+	return query.Get("state")
+}
+
+func newTestService(t *testing.T, p *fakeProvider, adminGroups ...string) *Service {
+	config := defaultConfig
+	config.ProviderURL = p.server.URL
+	config.ClientID = "determined"
+	config.ClientSecret = "secret"
+	config.RedirectURL = "https://determined.example.com/oidc/callback"
+	config.AdminGroups = adminGroups
+	s, err := New(nil, config)
+	assert.NilError(t, err)
+	return s
+}
+
+func TestLogin(t *testing.T) {
+	p := newFakeProvider(t, map[string]interface{}{
+		"preferred_username": "Alice",
+		"groups":             []string{"ml", "ml-admins"},
+	})
+	defer p.server.Close()
+	s := newTestService(t, p, "ml-admins")
+
+	authURL, binding, err := s.authCodeURL("/det/experiments")
+	assert.NilError(t, err)
+	state := p.authorize(t, authURL)
+	login, ok := s.takePendingLogin(state, binding)
+	assert.Assert(t, ok)
+	assert.Equal(t, login.relayState, "/det/experiments")
+
+	id, err := s.exchange(context.Background(), "code", login)
+	assert.NilError(t, err)
+	assert.Equal(t, id.username, "alice")
+	assert.Equal(t, id.issuer, p.server.URL)
+	assert.Equal(t, id.subject, "1234")
+	assert.Assert(t, id.admin != nil && *id.admin)
+
+	// A state can only be used once.
+	_, ok = s.takePendingLogin(state, binding)
+	assert.Assert(t, !ok)
+}
+
+func TestLoginRejectsWrongVerifier(t *testing.T) {
+	p := newFakeProvider(t, map[string]interface{}{"preferred_username": "alice"})
+	defer p.server.Close()
+	s := newTestService(t, p)
+
+	authURL, binding, err := s.authCodeURL("")
+	assert.NilError(t, err)
+	login, ok := s.takePendingLogin(p.authorize(t, authURL), binding)
+	assert.Assert(t, ok)
+	login.codeVerifier = "forged"
+
+	_, err = s.exchange(context.Background(), "code", login)
+	assert.ErrorContains(t, err, "error redeeming authorization code")
+}
+
+func TestLoginRejectsWrongNonce(t *testing.T) {
+	p := newFakeProvider(t, map[string]interface{}{"preferred_username": "alice"})
+	defer p.server.Close()
+	s := newTestService(t, p)
+
+	authURL, binding, err := s.authCodeURL("")
+	assert.NilError(t, err)
+	login, ok := s.takePendingLogin(p.authorize(t, authURL), binding)
+	assert.Assert(t, ok)
+	p.nonce = "replayed"
+
+	_, err = s.exchange(context.Background(), "code", login)
+	assert.ErrorContains(t, err, "nonce does not match")
+}
+
+func TestLoginRejectsOtherBrowser(t *testing.T) {
+	p := newFakeProvider(t, map[string]interface{}{"preferred_username": "alice"})
+	defer p.server.Close()
+	s := newTestService(t, p)
+
+	// The callback of a login that an attacker started is sent to a victim, whose browser does not
+	// have the login cookie of the attacker.
+	authURL, _, err := s.authCodeURL("")
+	assert.NilError(t, err)
+	state := p.authorize(t, authURL)
+	_, otherBinding, err := s.authCodeURL("")
+	assert.NilError(t, err)
+	_, ok := s.takePendingLogin(state, otherBinding)
+	assert.Assert(t, !ok)
+	_, ok = s.takePendingLogin(state, "")
+	assert.Assert(t, !ok)
+}
+
+func TestLoginCookies(t *testing.T) {
+	p := newFakeProvider(t, map[string]interface{}{"preferred_username": "alice"})
+	defer p.server.Close()
+	s := newTestService(t, p)
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/oidc/login", nil)
+	assert.NilError(t, s.getLogin(e.NewContext(req, rec)))
+	assert.Equal(t, rec.Code, http.StatusSeeOther)
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+	cookie := cookies[0]
+	assert.Equal(t, cookie.Name, loginCookieName)
+	assert.Assert(t, cookie.Secure && cookie.HttpOnly)
+	assert.Equal(t, cookie.SameSite, http.SameSiteLaxMode)
+
+	// The callback without the cookie is rejected.
+	state := p.authorize(t, rec.Header().Get("Location"))
+	req = httptest.NewRequest(http.MethodGet, "/oidc/callback?code=code&state="+state, nil)
+	err := s.getCallback(e.NewContext(req, httptest.NewRecorder()))
+	assert.ErrorContains(t, err, "unknown or expired login")
+}
+
+func TestIdentityFromClaims(t *testing.T) {
+	s := &Service{config: defaultConfig}
+	id, err := s.identityFromClaims(map[string]interface{}{"preferred_username": "bob"})
+	assert.NilError(t, err)
+	assert.Assert(t, id.admin == nil)
+
+	s.config.AdminGroups = []string{"admins"}
+	id, err = s.identityFromClaims(map[string]interface{}{
+		"preferred_username": "bob", "groups": []interface{}{"users"},
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, id.admin != nil && !*id.admin)
+
+	_, err = s.identityFromClaims(map[string]interface{}{"email": "bob@example.com"})
+	assert.ErrorContains(t, err, "no preferred_username claim")
+}
+
+func TestSafeRelayState(t *testing.T) {
+	assert.Equal(t, safeRelayState("/det/experiments/1"), "/det/experiments/1")
+	assert.Equal(t, safeRelayState(""), defaultRelayState)
+	assert.Equal(t, safeRelayState("https://evil.example.com"), defaultRelayState)
+	assert.Equal(t, safeRelayState("//evil.example.com"), defaultRelayState)
+	assert.Equal(t, safeRelayState(`/\evil.example.com`), defaultRelayState)
+}
+
+func TestConfig(t *testing.T) {
+	var config Config
+	assert.NilError(t, json.Unmarshal([]byte(`{
+		"provider_url": "https://idp.example.com",
+		"client_id": "determined",
+		"redirect_url": "http://determined.example.com/oidc/callback"
+	}`), &config))
+	assert.Assert(t, !config.LinkExistingUsers)
+	assert.ErrorContains(t, check.Validate(config), "redirect_url must use https")
+
+	config.RedirectURL = "http://localhost:8080/oidc/callback"
+	assert.NilError(t, check.Validate(config))
+}
@@ -0,0 +1,99 @@
+package prom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+var (
+	experimentsDesc = prometheus.NewDesc("det_experiments",
+		"Number of experiments, by state.", []string{"state"}, nil)
+	trialsDesc = prometheus.NewDesc("det_trials",
+		"Number of trials, by state.", []string{"state"}, nil)
+
+	inboxMessagesDesc = prometheus.NewDesc("det_actor_inbox_messages",
+		"Number of messages waiting in the inboxes of actors, by type of actor.",
+		[]string{"actor_type"}, nil)
+	maxInboxMessagesDesc = prometheus.NewDesc("det_actor_max_inbox_messages",
+		"Largest number of messages waiting in the inbox of an actor, by type of actor.",
+		[]string{"actor_type"}, nil)
+)
+
+// StateCounter counts experiments and trials by their state; it is implemented by the database.
+type StateCounter interface {
+	CountExperimentsByState() (map[model.State]int, error)
+	CountTrialsByState() (map[model.State]int, error)
+}
+
+// stateCollector collects the number of experiments and trials by state from the database when
+// the metrics are scraped.
+type stateCollector struct {
+	counter StateCounter
+}
+
+// NewStateCollector returns a collector of the number of experiments and trials by state.
+func NewStateCollector(counter StateCounter) prometheus.Collector {
+	return &stateCollector{counter: counter}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- experimentsDesc
+	ch <- trialsDesc
+}
+
+// Collect implements the prometheus.Collector interface. Metrics whose counts cannot be read are
+// omitted.
+func (c *stateCollector) Collect(ch chan<- prometheus.Metric) {
+	collect := func(desc *prometheus.Desc, count func() (map[model.State]int, error)) {
+		counts, err := count()
+		if err != nil {
+			log.WithError(err).Warnf("failed to collect %s", desc)
+			return
+		}
+		for state, n := range counts {
+			ch <- prometheus.MustNewConstMetric(
+				desc, prometheus.GaugeValue, float64(n), string(state))
+		}
+	}
+	collect(experimentsDesc, c.counter.CountExperimentsByState)
+	collect(trialsDesc, c.counter.CountTrialsByState)
+}
+
+// inboxCollector collects the depths of the inboxes of the actors in a system when the metrics
+// are scraped. Depths are aggregated by type of actor, since there may be many actors of a type.
+type inboxCollector struct {
+	system *actor.System
+}
+
+// NewInboxCollector returns a collector of the depths of the inboxes of the actors in the system.
+func NewInboxCollector(system *actor.System) prometheus.Collector {
+	return &inboxCollector{system: system}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *inboxCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- inboxMessagesDesc
+	ch <- maxInboxMessagesDesc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *inboxCollector) Collect(ch chan<- prometheus.Metric) {
+	totals := make(map[string]int)
+	maxes := make(map[string]int)
+	for _, inbox := range c.system.InboxLengths() {
+		totals[inbox.ActorType] += inbox.Length
+		if inbox.Length > maxes[inbox.ActorType] {
+			maxes[inbox.ActorType] = inbox.Length
+		}
+	}
+	for actorType, n := range totals {
+		ch <- prometheus.MustNewConstMetric(
+			inboxMessagesDesc, prometheus.GaugeValue, float64(n), actorType)
+		ch <- prometheus.MustNewConstMetric(
+			maxInboxMessagesDesc, prometheus.GaugeValue, float64(maxes[actorType]), actorType)
+	}
+}
@@ -0,0 +1,129 @@
+package prom
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+var (
+	// SchedulerPendingTasks is the number of tasks in each resource pool that wait for resources.
+	SchedulerPendingTasks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "det_scheduler_pending_tasks",
+		Help: "Number of tasks waiting for resources, by resource pool.",
+	}, []string{"resource_pool"})
+
+	// SchedulerAllocatedTasks is the number of tasks in each resource pool that hold resources.
+	SchedulerAllocatedTasks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "det_scheduler_allocated_tasks",
+		Help: "Number of tasks that have been allocated resources, by resource pool.",
+	}, []string{"resource_pool"})
+
+	// SchedulerWaitSeconds is how long tasks waited for resources before they were allocated them.
+	SchedulerWaitSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "det_scheduler_wait_seconds",
+		Help:    "Time that tasks waited for resources before being allocated them, by resource pool.",
+		Buckets: []float64{1, 5, 15, 30, 60, 300, 900, 1800, 3600, 3 * 3600, 12 * 3600},
+	}, []string{"resource_pool"})
+
+	// AgentSlots is the number of slots of each agent.
+	AgentSlots = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "det_agent_slots",
+		Help: "Number of slots of the agent.",
+	}, []string{"resource_pool", "agent", "label"})
+
+	// AgentSlotsUsed is the number of slots of each agent that are allocated to containers.
+	AgentSlotsUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "det_agent_slots_used",
+		Help: "Number of slots of the agent that are allocated to containers.",
+	}, []string{"resource_pool", "agent", "label"})
+
+	// HTTPRequestSeconds is the latency of the requests that the HTTP server handles.
+	HTTPRequestSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "det_http_request_duration_seconds",
+		Help: "Latency of HTTP requests, by method, route and status code.",
+	}, []string{"method", "route", "code"})
+
+	// GRPCRequestSeconds is the latency of the calls that the gRPC server handles.
+	GRPCRequestSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "det_grpc_request_duration_seconds",
+		Help: "Latency of gRPC calls, by method and status code.",
+	}, []string{"method", "code"})
+
+	// DBQuerySeconds is the latency of the queries and statements that are sent to the database.
+	DBQuerySeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "det_db_query_duration_seconds",
+		Help:    "Latency of database queries and statements, by operation.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation"})
+
+	// TrialLogs is the number of trial log lines that have been saved to the database or that
+	// could not be saved, by outcome.
+	TrialLogs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "det_trial_logs_total",
+		Help: "Number of trial log lines written to the database, by outcome.",
+	}, []string{"outcome"})
+)
+
+// Outcomes of writing trial logs to the database.
+const (
+	TrialLogsSaved  = "saved"
+	TrialLogsFailed = "failed"
+)
+
+// registry holds the collectors whose metrics the master exports. A dedicated registry is used
+// rather than the default one so that only the metrics of the master are exported.
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		SchedulerPendingTasks,
+		SchedulerAllocatedTasks,
+		SchedulerWaitSeconds,
+		AgentSlots,
+		AgentSlotsUsed,
+		HTTPRequestSeconds,
+		GRPCRequestSeconds,
+		DBQuerySeconds,
+		TrialLogs,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+}
+
+// ObserveDBQuery records the latency of a database operation that started at the given time.
+func ObserveDBQuery(operation string, start time.Time) {
+	DBQuerySeconds.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// Middleware records the latency of every request that the HTTP server handles. Requests are
+// labeled by their route rather than their path to bound the number of series.
+func Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+		code := c.Response().Status
+		if he, ok := err.(*echo.HTTPError); ok {
+			code = he.Code
+		} else if err != nil {
+			code = http.StatusInternalServerError
+		}
+		HTTPRequestSeconds.WithLabelValues(
+			c.Request().Method, c.Path(), strconv.Itoa(code),
+		).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// RegisterAPIHandler registers the endpoint that Prometheus scrapes the metrics of the master from,
+// along with the collectors of the state of the database and of the actor system.
+func RegisterAPIHandler(e *echo.Echo, counter StateCounter, system *actor.System) {
+	registry.MustRegister(NewStateCollector(counter), NewInboxCollector(system))
+	e.GET("/prom/metrics", echo.WrapHandler(promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
+}
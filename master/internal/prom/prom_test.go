@@ -0,0 +1,140 @@
+package prom
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+type fakeStateCounter struct {
+	experiments map[model.State]int
+	trialsErr   error
+}
+
+func (c fakeStateCounter) CountExperimentsByState() (map[model.State]int, error) {
+	return c.experiments, nil
+}
+
+func (c fakeStateCounter) CountTrialsByState() (map[model.State]int, error) {
+	return map[model.State]int{model.ActiveState: 3}, c.trialsErr
+}
+
+// gauges returns the text format of a gauge with the given samples.
+func gauges(name, help string, samples ...string) string {
+	return fmt.Sprintf("# HELP %s %s\n# TYPE %s gauge\n%s\n",
+		name, help, name, strings.Join(samples, "\n"))
+}
+
+func TestStateCollector(t *testing.T) {
+	counter := fakeStateCounter{experiments: map[model.State]int{
+		model.ActiveState:    2,
+		model.CompletedState: 5,
+	}}
+	experiments := gauges("det_experiments", "Number of experiments, by state.",
+		`det_experiments{state="ACTIVE"} 2`,
+		`det_experiments{state="COMPLETED"} 5`)
+	trials := gauges("det_trials", "Number of trials, by state.",
+		`det_trials{state="ACTIVE"} 3`)
+	assert.NilError(t, testutil.CollectAndCompare(
+		NewStateCollector(counter), strings.NewReader(experiments+trials)))
+
+	// Counts that cannot be read are left out rather than failing the scrape.
+	counter.trialsErr = errors.New("database is unavailable")
+	assert.NilError(t, testutil.CollectAndCompare(
+		NewStateCollector(counter), strings.NewReader(experiments)))
+}
+
+// blockingActor blocks on the first message it receives until it is released, so that the
+// messages sent to it after that wait in its inbox.
+type blockingActor struct {
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (a *blockingActor) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart, actor.PostStop:
+	case string:
+		if a.blocked != nil {
+			close(a.blocked)
+			a.blocked = nil
+			<-a.release
+		}
+	}
+	return nil
+}
+
+func TestInboxCollector(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	release := make(chan struct{})
+	defer close(release)
+
+	for i, queued := range []int{2, 1} {
+		blocked := make(chan struct{})
+		ref, _ := system.ActorOf(actor.Addr(fmt.Sprintf("blocking-%d", i)), &blockingActor{
+			blocked: blocked, release: release,
+		})
+		assert.Assert(t, ref != nil)
+		system.Tell(ref, "block")
+		<-blocked
+		for j := 0; j < queued; j++ {
+			system.Tell(ref, "queued")
+		}
+	}
+
+	// The root actor of the system is counted as well.
+	expected := gauges("det_actor_inbox_messages",
+		"Number of messages waiting in the inboxes of actors, by type of actor.",
+		`det_actor_inbox_messages{actor_type="blockingActor"} 3`,
+		`det_actor_inbox_messages{actor_type="rootActor"} 0`,
+	) + gauges("det_actor_max_inbox_messages",
+		"Largest number of messages waiting in the inbox of an actor, by type of actor.",
+		`det_actor_max_inbox_messages{actor_type="blockingActor"} 2`,
+		`det_actor_max_inbox_messages{actor_type="rootActor"} 0`)
+	assert.NilError(t, testutil.CollectAndCompare(
+		NewInboxCollector(system), strings.NewReader(expected)))
+}
+
+func TestMiddleware(t *testing.T) {
+	HTTPRequestSeconds.Reset()
+	e := echo.New()
+	e.Use(Middleware)
+	e.GET("/experiments/:id", func(c echo.Context) error {
+		if c.Param("id") == "0" {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/error", func(c echo.Context) error {
+		return errors.New("unexpected")
+	})
+
+	paths := []string{"/experiments/1", "/experiments/2", "/experiments/0", "/error"}
+	for _, path := range paths {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Requests are labeled by route rather than by path.
+	requests := func(route, code string) uint64 {
+		var m dto.Metric
+		observer := HTTPRequestSeconds.WithLabelValues(http.MethodGet, route, code)
+		assert.NilError(t, observer.(prometheus.Metric).Write(&m))
+		return m.GetHistogram().GetSampleCount()
+	}
+	assert.Equal(t, testutil.CollectAndCount(HTTPRequestSeconds), 3)
+	assert.Equal(t, requests("/experiments/:id", "200"), uint64(2))
+	assert.Equal(t, requests("/experiments/:id", "404"), uint64(1))
+	assert.Equal(t, requests("/error", "500"), uint64(1))
+}
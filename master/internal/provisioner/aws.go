@@ -48,7 +48,7 @@ type awsCluster struct {
 	*AWSClusterConfig
 	resourcePool string
 	masterURL    url.URL
-	ec2UserData  []byte
+	agentSetup   agentSetupScriptConfig
 	client       *ec2.EC2
 
 	// State that is only used if spot instances are enabled
@@ -115,14 +115,13 @@ func newAWSCluster(
 		AWSClusterConfig: config.AWS,
 		masterURL:        *masterURL,
 		client:           ec2.New(sess),
-		ec2UserData: mustMakeAgentSetupScript(agentSetupScriptConfig{
+		agentSetup: agentSetupScriptConfig{
 			MasterHost:                   masterURL.Hostname(),
 			MasterPort:                   masterURL.Port(),
 			MasterCertName:               config.MasterCertName,
 			StartupScriptBase64:          startupScriptBase64,
 			ContainerStartupScriptBase64: containerScriptBase64,
 			MasterCertBase64:             masterCertBase64,
-			AgentUseGPUs:                 config.AWS.InstanceType.slots() > 0,
 			AgentDockerRuntime:           config.AgentDockerRuntime,
 			AgentNetwork:                 config.AgentDockerNetwork,
 			AgentDockerImage:             config.AgentDockerImage,
@@ -130,12 +129,13 @@ func newAWSCluster(
 			AgentID:                      `$(ec2metadata --instance-id)`,
 			ResourcePool:                 resourcePool,
 			LogOptions:                   config.AWS.buildDockerLogString(),
-		}),
+		},
 	}
 
-	if cluster.SpotEnabled {
+	if cluster.usesSpot() {
 		cluster.spot = &spotState{
 			trackedReqs:          newSetOfSpotRequests(),
+			failedTypes:          make(map[string]bool),
 			approximateClockSkew: time.Second * 0,
 			launchTimeOffset:     time.Second * 10,
 		}
@@ -144,8 +144,36 @@ func newAWSCluster(
 	return cluster, nil
 }
 
-func (c *awsCluster) instanceType() instanceType {
-	return c.InstanceType
+func (c *awsCluster) instanceTypes() []instanceType {
+	spot := c.SpotEnabled
+	if len(c.InstanceTypes) == 0 {
+		return []instanceType{ec2InstanceTypeChoice{Type: c.InstanceType, Spot: &spot}}
+	}
+	types := make([]instanceType, 0, len(c.InstanceTypes))
+	for _, t := range c.InstanceTypes {
+		if t.Spot == nil {
+			t.Spot = &spot
+		}
+		types = append(types, t)
+	}
+	return types
+}
+
+// usesOnDemand returns whether any of the instance types is launched as on-demand instances.
+func (c *awsCluster) usesOnDemand() bool {
+	for _, t := range c.instanceTypes() {
+		if !t.(ec2InstanceTypeChoice).spot() {
+			return true
+		}
+	}
+	return false
+}
+
+// ec2UserData returns the user data that sets up the agent on an instance of the instance type.
+func (c *awsCluster) ec2UserData(instanceType ec2InstanceType) []byte {
+	agentSetup := c.agentSetup
+	agentSetup.AgentUseGPUs = instanceType.slots() > 0
+	return mustMakeAgentSetupScript(agentSetup)
 }
 
 func (c *awsCluster) agentNameFromInstance(inst *ec2.Instance) string {
@@ -169,27 +197,36 @@ func (c *awsCluster) stateFromEC2State(state *ec2.InstanceState) InstanceState {
 }
 
 func (c *awsCluster) prestart(ctx *actor.Context) {
-	if c.SpotEnabled {
+	if c.spot != nil {
 		c.attemptToApproximateClockSkew(ctx)
 	}
 }
 
 func (c *awsCluster) list(ctx *actor.Context) ([]*Instance, error) {
-	if c.SpotEnabled {
-		return c.listSpot(ctx)
+	if c.spot == nil {
+		return c.listOnDemand(ctx)
+	}
+	instances, err := c.listSpot(ctx)
+	if err != nil || !c.usesOnDemand() {
+		return instances, err
+	}
+	onDemand, err := c.listOnDemand(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return c.listOnDemand(ctx)
+	return append(instances, onDemand...), nil
 }
 
 func (c *awsCluster) launch(
 	ctx *actor.Context,
+	instanceType instanceType,
 	instanceNum int,
-) {
-	if c.SpotEnabled {
-		c.launchSpot(ctx, instanceNum)
-	} else {
-		c.launchOnDemand(ctx, instanceNum)
+) error {
+	choice := instanceType.(ec2InstanceTypeChoice)
+	if choice.spot() {
+		return c.launchSpot(ctx, choice.Type, instanceNum)
 	}
+	return c.launchOnDemand(ctx, choice.Type, instanceNum)
 }
 
 func (c *awsCluster) terminate(ctx *actor.Context, instanceIDs []string) {
@@ -199,7 +236,7 @@ func (c *awsCluster) terminate(ctx *actor.Context, instanceIDs []string) {
 		ids = append(ids, &idCopy)
 	}
 
-	if c.SpotEnabled {
+	if c.spot != nil {
 		c.terminateSpot(ctx, ids)
 	} else {
 		c.terminateOnDemand(ctx, ids)
@@ -220,14 +257,15 @@ func (c *awsCluster) listOnDemand(ctx *actor.Context) ([]*Instance, error) {
 	return res, nil
 }
 
-func (c *awsCluster) launchOnDemand(ctx *actor.Context, instanceNum int) {
+func (c *awsCluster) launchOnDemand(
+	ctx *actor.Context, instanceType ec2InstanceType, instanceNum int,
+) error {
 	if instanceNum <= 0 {
-		return
+		return nil
 	}
-	instances, err := c.launchInstances(instanceNum, false)
+	instances, err := c.launchInstances(instanceType, instanceNum, false)
 	if err != nil {
-		ctx.Log().WithError(err).Error("cannot launch EC2 instances")
-		return
+		return errors.Wrap(err, "cannot launch EC2 instances")
 	}
 	launched := c.newInstances(instances.Instances)
 	ctx.Log().Infof(
@@ -236,6 +274,7 @@ func (c *awsCluster) launchOnDemand(ctx *actor.Context, instanceNum int) {
 		instanceNum,
 		fmtInstances(launched),
 	)
+	return nil
 }
 
 func (c *awsCluster) terminateOnDemand(ctx *actor.Context, instanceIDs []*string) {
@@ -265,6 +304,8 @@ func (c *awsCluster) newInstances(input []*ec2.Instance) []*Instance {
 			LaunchTime: *inst.LaunchTime,
 			AgentName:  c.agentNameFromInstance(inst),
 			State:      c.stateFromEC2State(inst.State),
+			Type: ec2InstanceTypeName(aws.StringValue(inst.InstanceType),
+				aws.StringValue(inst.InstanceLifecycle) == ec2.InstanceLifecycleTypeSpot),
 		})
 	}
 	return output
@@ -342,7 +383,9 @@ func (c *awsCluster) describeInstancesByID(
 	return instances, nil
 }
 
-func (c *awsCluster) launchInstances(instanceNum int, dryRun bool) (*ec2.Reservation, error) {
+func (c *awsCluster) launchInstances(
+	instanceType ec2InstanceType, instanceNum int, dryRun bool,
+) (*ec2.Reservation, error) {
 	input := &ec2.RunInstancesInput{
 		BlockDeviceMappings: []*ec2.BlockDeviceMapping{
 			{
@@ -356,7 +399,7 @@ func (c *awsCluster) launchInstances(instanceNum int, dryRun bool) (*ec2.Reserva
 		},
 		DryRun:       aws.Bool(dryRun),
 		ImageId:      aws.String(c.ImageID),
-		InstanceType: aws.String(c.AWSClusterConfig.InstanceType.name()),
+		InstanceType: aws.String(instanceType.name()),
 		KeyName:      aws.String(c.SSHKeyName),
 		MaxCount:     aws.Int64(int64(instanceNum)),
 		MinCount:     aws.Int64(1),
@@ -383,7 +426,7 @@ func (c *awsCluster) launchInstances(instanceNum int, dryRun bool) (*ec2.Reserva
 				},
 			},
 		},
-		UserData: aws.String(base64.StdEncoding.EncodeToString(c.ec2UserData)),
+		UserData: aws.String(base64.StdEncoding.EncodeToString(c.ec2UserData(instanceType))),
 	}
 
 	if c.CustomTags != nil {
@@ -29,7 +29,8 @@ type AWSClusterConfig struct {
 	NetworkInterface      ec2NetworkInterface `json:"network_interface"`
 	IamInstanceProfileArn string              `json:"iam_instance_profile_arn"`
 
-	InstanceType ec2InstanceType `json:"instance_type"`
+	InstanceType  ec2InstanceType         `json:"instance_type"`
+	InstanceTypes []ec2InstanceTypeChoice `json:"instance_types"`
 
 	LogGroup  string `json:"log_group"`
 	LogStream string `json:"log_stream"`
@@ -123,7 +124,7 @@ func (c *AWSClusterConfig) UnmarshalJSON(data []byte) error {
 // Validate implements the check.Validatable interface.
 func (c AWSClusterConfig) Validate() []error {
 	var spotPriceIsNotValidNumberErr error
-	if c.SpotEnabled && c.SpotMaxPrice != spotPriceNotSetPlaceholder {
+	if c.usesSpot() && c.SpotMaxPrice != spotPriceNotSetPlaceholder {
 		spotPriceIsNotValidNumberErr = validateMaxSpotPrice(c.SpotMaxPrice)
 	}
 	return []error{
@@ -133,6 +134,19 @@ func (c AWSClusterConfig) Validate() []error {
 	}
 }
 
+// usesSpot returns whether any of the instance types is launched as spot instances.
+func (c AWSClusterConfig) usesSpot() bool {
+	if len(c.InstanceTypes) == 0 {
+		return c.SpotEnabled
+	}
+	for _, t := range c.InstanceTypes {
+		if (t.Spot == nil && c.SpotEnabled) || (t.Spot != nil && *t.Spot) {
+			return true
+		}
+	}
+	return false
+}
+
 func validateMaxSpotPrice(spotMaxPriceInput string) error {
 	// Must have 1 or 0 decimalPoints. All other characters must be digits
 	numDecimalPoints := strings.Count(spotMaxPriceInput, ".")
@@ -202,6 +216,10 @@ func (t ec2InstanceType) slots() int {
 	return 0
 }
 
+func (t ec2InstanceType) cost() float64 {
+	return 0
+}
+
 func (t ec2InstanceType) Validate() []error {
 	if _, ok := ec2InstanceSlots[t]; ok {
 		return nil
@@ -214,3 +232,39 @@ func (t ec2InstanceType) Validate() []error {
 		errors.Errorf("ec2 instance type must be valid type: %s", strings.Join(strs, ", ")),
 	}
 }
+
+// ec2InstanceTypeChoice is one of the instance types that a resource pool may launch. The spot
+// instances of an EC2 instance type are a separate choice from its on-demand instances, so that
+// either can be the fallback for the other.
+type ec2InstanceTypeChoice struct {
+	Type ec2InstanceType `json:"type"`
+	// Spot is whether instances of the type are launched as spot instances. If it is not set, the
+	// spot setting of the resource pool applies.
+	Spot *bool   `json:"spot"`
+	Cost float64 `json:"cost"`
+}
+
+func (t ec2InstanceTypeChoice) name() string {
+	return ec2InstanceTypeName(t.Type.name(), t.spot())
+}
+
+func (t ec2InstanceTypeChoice) spot() bool {
+	return t.Spot != nil && *t.Spot
+}
+
+func (t ec2InstanceTypeChoice) slots() int {
+	return t.Type.slots()
+}
+
+func (t ec2InstanceTypeChoice) cost() float64 {
+	return t.Cost
+}
+
+// ec2InstanceTypeName returns the name of the instance type of spot or on-demand instances of the
+// EC2 instance type.
+func ec2InstanceTypeName(instanceType string, spot bool) string {
+	if spot {
+		return instanceType + "-spot"
+	}
+	return instanceType
+}
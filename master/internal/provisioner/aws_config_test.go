@@ -95,3 +95,32 @@ func TestAWSClusterConfigMissingFields(t *testing.T) {
 	err = check.Validate(&config)
 	assert.ErrorContains(t, err, "non-empty")
 }
+
+func TestEC2InstanceTypeChoices(t *testing.T) {
+	config := AWSClusterConfig{}
+	err := yaml.Unmarshal([]byte(`
+spot: true
+instance_types:
+  - type: p3.8xlarge
+    cost: 7.5
+  - type: p3.8xlarge
+    spot: false
+    cost: 12.24
+`), &config, yaml.DisallowUnknownFields)
+	assert.NilError(t, err)
+	assert.Assert(t, config.usesSpot())
+
+	cluster := &awsCluster{AWSClusterConfig: &config}
+	var names []string
+	for _, t := range cluster.instanceTypes() {
+		names = append(names, t.name())
+	}
+	assert.DeepEqual(t, names, []string{"p3.8xlarge-spot", "p3.8xlarge"})
+	assert.Assert(t, cluster.usesOnDemand())
+	assert.Equal(t, cluster.spotInstanceType(), ec2InstanceType("p3.8xlarge"))
+
+	config.SpotEnabled = false
+	config.InstanceTypes = config.InstanceTypes[:1]
+	assert.Assert(t, !config.usesSpot())
+	assert.Equal(t, cluster.instanceTypes()[0].name(), "p3.8xlarge")
+}
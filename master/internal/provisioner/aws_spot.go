@@ -3,6 +3,7 @@ package provisioner
 import (
 	"encoding/base64"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -25,6 +26,14 @@ type spotRequest struct {
 	StatusMessage *string
 	InstanceID    *string
 	CreationTime  time.Time
+	InstanceType  string
+}
+
+func spotRequestInstanceType(req *ec2.SpotInstanceRequest) string {
+	if req.LaunchSpecification == nil {
+		return ""
+	}
+	return aws.StringValue(req.LaunchSpecification.InstanceType)
 }
 
 // How Spot Works:
@@ -102,6 +111,10 @@ type spotState struct {
 	// far in the future and AWS won't try to fulfill it until that time is reached. This is
 	// why the approximateClockSkew measurement is needed.
 	launchTimeOffset time.Duration
+
+	// failedTypes holds the names of the instance types of spot requests that could not be
+	// fulfilled since the provisioner last asked for them.
+	failedTypes map[string]bool
 }
 
 // listSpot lists all unfulfilled and fulfilled spot requests. If the spot request has been
@@ -167,6 +180,9 @@ func (c *awsCluster) listSpot(ctx *actor.Context) ([]*Instance, error) {
 	}
 
 	for _, req := range reqsToNotifyUserAbout.asListInChronologicalOrder() {
+		if req.InstanceType != "" {
+			c.spot.failedTypes[ec2InstanceTypeName(req.InstanceType, true)] = true
+		}
 		ctx.Log().
 			WithField("spot-request-status-code", *req.StatusCode).
 			WithField("spot-request-status-message", *req.StatusMessage).
@@ -214,6 +230,20 @@ func (c *awsCluster) listSpot(ctx *actor.Context) ([]*Instance, error) {
 	return instances, nil
 }
 
+// failedInstanceTypes implements the asyncLauncher interface.
+func (c *awsCluster) failedInstanceTypes() []string {
+	if c.spot == nil {
+		return nil
+	}
+	names := make([]string, 0, len(c.spot.failedTypes))
+	for name := range c.spot.failedTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	c.spot.failedTypes = make(map[string]bool)
+	return names
+}
+
 func (c *awsCluster) terminateSpot(ctx *actor.Context, instanceIDs []*string) {
 	if len(instanceIDs) == 0 {
 		return
@@ -267,19 +297,20 @@ func (c *awsCluster) terminateSpot(ctx *actor.Context, instanceIDs []*string) {
 
 func (c *awsCluster) launchSpot(
 	ctx *actor.Context,
+	instanceType ec2InstanceType,
 	instanceNum int,
-) {
+) error {
 	if instanceNum < 0 {
-		return
+		return nil
 	}
 
 	ctx.Log().
 		WithField("log-type", "launchSpot.start").
 		Infof("launching %d EC2 spot requests", instanceNum)
-	resp, err := c.createSpotInstanceRequestsCorrectingForClockSkew(ctx, instanceNum, false)
+	resp, err := c.createSpotInstanceRequestsCorrectingForClockSkew(
+		ctx, instanceType, instanceNum, false)
 	if err != nil {
-		ctx.Log().WithError(err).Error("cannot launch EC2 spot requests")
-		return
+		return errors.Wrap(err, "cannot launch EC2 spot requests")
 	}
 
 	// Update the internal spotRequest tracker because there can be a large delay
@@ -293,6 +324,7 @@ func (c *awsCluster) launchSpot(
 			StatusMessage: request.Status.Message,
 			CreationTime:  *request.CreateTime,
 			InstanceID:    nil,
+			InstanceType:  instanceType.name(),
 		})
 
 		ctx.Log().
@@ -303,6 +335,7 @@ func (c *awsCluster) launchSpot(
 				*request.State,
 			)
 	}
+	return nil
 }
 
 func (c *awsCluster) setTagsOnInstances(ctx *actor.Context, activeReqs *setOfSpotRequests) error {
@@ -343,8 +376,7 @@ func (c *awsCluster) attemptToApproximateClockSkew(ctx *actor.Context) {
 	ctx.Log().Debug("new AWS spot provisioner. launching spot request to determined approximate " +
 		"clock skew between local machine and AWS API.")
 	localCreateTime := time.Now()
-	resp, err := c.createSpotInstanceRequest(ctx, 1, c.AWSClusterConfig.InstanceType,
-		time.Hour*100, false)
+	resp, err := c.createSpotInstanceRequest(ctx, 1, c.spotInstanceType(), time.Hour*100, false)
 	if err != nil {
 		ctx.Log().
 			WithError(err).
@@ -384,6 +416,16 @@ func (c *awsCluster) attemptToApproximateClockSkew(ctx *actor.Context) {
 	c.spot.approximateClockSkew = clockSkewRoundedUp
 }
 
+// spotInstanceType returns the most preferred EC2 instance type that is launched as spot instances.
+func (c *awsCluster) spotInstanceType() ec2InstanceType {
+	for _, t := range c.instanceTypes() {
+		if choice := t.(ec2InstanceTypeChoice); choice.spot() {
+			return choice.Type
+		}
+	}
+	return c.InstanceType
+}
+
 // Convert c.spot.trackedReqs to a list of Instances. For the requests that have
 // been fulfilled, this requires querying the EC2 API to find the instance state.
 func (c *awsCluster) buildInstanceListFromTrackedReqs(ctx *actor.Context) ([]*Instance, error) {
@@ -399,6 +441,7 @@ func (c *awsCluster) buildInstanceListFromTrackedReqs(ctx *actor.Context) ([]*In
 				LaunchTime: activeRequest.CreationTime,
 				AgentName:  activeRequest.SpotRequestID,
 				State:      SpotRequestPendingAWS,
+				Type:       ec2InstanceTypeName(activeRequest.InstanceType, true),
 			})
 		}
 	}
@@ -455,13 +498,14 @@ func roundDurationUp(d time.Duration) time.Duration {
 // function doesn't block for too long.
 func (c *awsCluster) createSpotInstanceRequestsCorrectingForClockSkew(
 	ctx *actor.Context,
+	instanceType ec2InstanceType,
 	numInstances int,
 	dryRun bool,
 ) (resp *ec2.RequestSpotInstancesOutput, err error) {
 	maxRetries := 5
 	for numRetries := 0; numRetries <= maxRetries; numRetries++ {
 		offset := c.spot.approximateClockSkew + c.spot.launchTimeOffset
-		resp, err = c.createSpotInstanceRequest(ctx, numInstances, c.InstanceType, offset, dryRun)
+		resp, err = c.createSpotInstanceRequest(ctx, numInstances, instanceType, offset, dryRun)
 		if err == nil {
 			return resp, nil
 		}
@@ -518,7 +562,7 @@ func (c *awsCluster) createSpotInstanceRequest(
 			InstanceType: aws.String(instanceType.name()),
 			KeyName:      aws.String(c.SSHKeyName),
 
-			UserData: aws.String(base64.StdEncoding.EncodeToString(c.ec2UserData)),
+			UserData: aws.String(base64.StdEncoding.EncodeToString(c.ec2UserData(instanceType))),
 		},
 		TagSpecifications: []*ec2.TagSpecification{
 			{
@@ -614,6 +658,7 @@ func (c *awsCluster) listCanceledButInstanceRunningSpotRequests(
 			StatusMessage: req.Status.Message,
 			InstanceID:    req.InstanceId,
 			CreationTime:  *req.CreateTime,
+			InstanceType:  spotRequestInstanceType(req),
 		})
 	}
 
@@ -661,6 +706,7 @@ func (c *awsCluster) listActiveSpotInstanceRequests(
 			StatusMessage: req.Status.Message,
 			InstanceID:    req.InstanceId,
 			CreationTime:  *req.CreateTime,
+			InstanceType:  spotRequestInstanceType(req),
 		})
 	}
 
@@ -715,6 +761,7 @@ func (c *awsCluster) listSpotRequestsByID(
 			StatusMessage: req.Status.Message,
 			InstanceID:    req.InstanceId,
 			CreationTime:  *req.CreateTime,
+			InstanceType:  spotRequestInstanceType(req),
 		})
 	}
 
@@ -0,0 +1,137 @@
+package provisioner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+const clockLayout = "15:04"
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// CapacitySchedule overrides the minimum and maximum number of instances of the provisioner during
+// a recurring time window, e.g., to keep agents warm during business hours. The window starts at
+// StartTime on each of Days and ends at EndTime; a window whose end is not after its start ends on
+// the next day.
+type CapacitySchedule struct {
+	Name string `json:"name"`
+	// Days are the days of the week, e.g., "mon", that the window starts on; every day if empty.
+	Days []string `json:"days"`
+	// StartTime and EndTime are times of day in the form "HH:MM"; they default to midnight.
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	// Timezone is the IANA name of the time zone of the window, e.g., "America/New_York".
+	Timezone string `json:"timezone"`
+	// MinInstances and MaxInstances override the static limits of the provisioner when set.
+	MinInstances *int `json:"min_instances"`
+	MaxInstances *int `json:"max_instances"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c CapacitySchedule) Validate() []error {
+	errs := []error{
+		check.True(c.MinInstances != nil || c.MaxInstances != nil,
+			"capacity schedule must set min_instances or max_instances"),
+		check.GreaterThanOrEqualTo(c.MinInstances, 0,
+			"capacity schedule min instances must be greater than or equal to 0"),
+		check.GreaterThanOrEqualTo(c.MaxInstances, 0,
+			"capacity schedule max instances must be greater than or equal to 0"),
+	}
+	for _, day := range c.Days {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			errs = append(errs, errors.Errorf("invalid capacity schedule day: %s", day))
+		}
+	}
+	for _, clock := range []string{c.StartTime, c.EndTime} {
+		if _, err := parseClock(clock); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		errs = append(errs, errors.Wrap(err, "invalid capacity schedule timezone"))
+	}
+	return errs
+}
+
+// String returns the name of the schedule, or a description of its window if it has no name.
+func (c CapacitySchedule) String() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	days := "daily"
+	if len(c.Days) > 0 {
+		days = strings.Join(c.Days, ",")
+	}
+	return fmt.Sprintf("%s %s-%s %s", days, c.StartTime, c.EndTime, c.Timezone)
+}
+
+// active returns whether the window of the schedule contains the given time. The schedule must be
+// valid.
+func (c CapacitySchedule) active(now time.Time) bool {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return false
+	}
+	start, _ := parseClock(c.StartTime)
+	end, _ := parseClock(c.EndTime)
+	now = now.In(loc)
+	clock := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute +
+		time.Duration(now.Second())*time.Second
+	wraps := end <= start
+
+	// A window that started today.
+	if c.onDay(now.Weekday()) && clock >= start && (wraps || clock < end) {
+		return true
+	}
+	// A window that started yesterday and wraps into today.
+	yesterday := (now.Weekday() + 6) % 7
+	return wraps && c.onDay(yesterday) && clock < end
+}
+
+func (c CapacitySchedule) onDay(day time.Weekday) bool {
+	if len(c.Days) == 0 {
+		return true
+	}
+	for _, d := range c.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock parses a time of day in the form "HH:MM" into the time since midnight.
+func parseClock(clock string) (time.Duration, error) {
+	if clock == "" {
+		return 0, nil
+	}
+	t, err := time.Parse(clockLayout, clock)
+	if err != nil {
+		return 0, errors.Errorf("invalid capacity schedule time (expecting HH:MM): %s", clock)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// instanceLimits returns the minimum and maximum number of instances while the schedule is active.
+func (c CapacitySchedule) instanceLimits(minInstances, maxInstances int) (int, int) {
+	if c.MinInstances != nil {
+		minInstances = *c.MinInstances
+	}
+	if c.MaxInstances != nil {
+		maxInstances = *c.MaxInstances
+	}
+	return minInstances, maxInstances
+}
@@ -0,0 +1,84 @@
+package provisioner
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+func TestCapacityScheduleActive(t *testing.T) {
+	// 2020-10-19 is a Monday.
+	monday := func(clock string) time.Time {
+		t, err := time.Parse(time.RFC3339, "2020-10-19T"+clock+":00Z")
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	businessHours := CapacitySchedule{
+		Days: []string{"mon", "tue", "wed", "thu", "fri"}, StartTime: "09:00", EndTime: "17:00",
+	}
+	nights := CapacitySchedule{StartTime: "22:00", EndTime: "06:00"}
+	weekends := CapacitySchedule{Days: []string{"sat", "sun"}}
+	newYork := CapacitySchedule{StartTime: "09:00", EndTime: "17:00", Timezone: "America/New_York"}
+
+	testCases := []struct {
+		name     string
+		schedule CapacitySchedule
+		now      time.Time
+		active   bool
+	}{
+		{"within window", businessHours, monday("12:00"), true},
+		{"at start of window", businessHours, monday("09:00"), true},
+		{"at end of window", businessHours, monday("17:00"), false},
+		{"before window", businessHours, monday("08:59"), false},
+		{"other day", businessHours, monday("12:00").AddDate(0, 0, -1), false},
+		{"wrapping window before midnight", nights, monday("23:00"), true},
+		{"wrapping window after midnight", nights, monday("05:59"), true},
+		{"outside wrapping window", nights, monday("12:00"), false},
+		{"whole day", weekends, monday("00:00").AddDate(0, 0, -1), true},
+		{"after whole day", weekends, monday("00:00"), false},
+		{"in time zone", newYork, monday("14:00"), true},
+		{"outside time zone", newYork, monday("10:00"), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.schedule.active(tc.now), tc.active)
+		})
+	}
+}
+
+func TestCapacityScheduleValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule string
+		err      string
+	}{
+		{"valid", `{"days": ["Mon"], "start_time": "09:00", "min_instances": 4}`, ""},
+		{"no limits", `{"start_time": "09:00"}`, "must set min_instances or max_instances"},
+		{"invalid day", `{"days": ["monday"], "min_instances": 1}`, "invalid capacity schedule day"},
+		{"invalid time", `{"start_time": "9am", "min_instances": 1}`, "expecting HH:MM"},
+		{"invalid timezone", `{"timezone": "Mars/Olympus", "min_instances": 1}`, "timezone"},
+		{"min above max", `{"min_instances": 6}`, "max instance must be greater than or equal"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var config Config
+			assert.NilError(t, json.Unmarshal([]byte(`{
+"provider": "external",
+"webhook_url": "http://test.provisioner",
+"capacity_schedules": [`+tc.schedule+`]
+}`), &config))
+			err := check.Validate(&config)
+			if tc.err == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+}
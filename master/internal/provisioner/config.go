@@ -44,20 +44,24 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 
 // Config describes config for provisioner.
 type Config struct {
-	MasterURL              string            `json:"master_url"`
-	MasterCertName         string            `json:"master_cert_name"`
-	StartupScript          string            `json:"startup_script"`
-	ContainerStartupScript string            `json:"container_startup_script"`
-	AgentDockerNetwork     string            `json:"agent_docker_network"`
-	AgentDockerRuntime     string            `json:"agent_docker_runtime"`
-	AgentDockerImage       string            `json:"agent_docker_image"`
-	AgentFluentImage       string            `json:"agent_fluent_image"`
-	AWS                    *AWSClusterConfig `union:"provider,aws" json:"-"`
-	GCP                    *GCPClusterConfig `union:"provider,gcp" json:"-"`
-	MaxIdleAgentPeriod     Duration          `json:"max_idle_agent_period"`
-	MaxAgentStartingPeriod Duration          `json:"max_agent_starting_period"`
-	MinInstances           int               `json:"min_instances"`
-	MaxInstances           int               `json:"max_instances"`
+	MasterURL              string                 `json:"master_url"`
+	MasterCertName         string                 `json:"master_cert_name"`
+	StartupScript          string                 `json:"startup_script"`
+	ContainerStartupScript string                 `json:"container_startup_script"`
+	AgentDockerNetwork     string                 `json:"agent_docker_network"`
+	AgentDockerRuntime     string                 `json:"agent_docker_runtime"`
+	AgentDockerImage       string                 `json:"agent_docker_image"`
+	AgentFluentImage       string                 `json:"agent_fluent_image"`
+	AWS                    *AWSClusterConfig      `union:"provider,aws" json:"-"`
+	GCP                    *GCPClusterConfig      `union:"provider,gcp" json:"-"`
+	External               *ExternalClusterConfig `union:"provider,external" json:"-"`
+	MaxIdleAgentPeriod     Duration               `json:"max_idle_agent_period"`
+	MaxAgentStartingPeriod Duration               `json:"max_agent_starting_period"`
+	MinInstances           int                    `json:"min_instances"`
+	MaxInstances           int                    `json:"max_instances"`
+	// CapacitySchedules override MinInstances and MaxInstances during their time windows; the
+	// first schedule that is active takes effect.
+	CapacitySchedules []CapacitySchedule `json:"capacity_schedules"`
 }
 
 // DefaultConfig returns the default configuration of the provisioner.
@@ -88,6 +92,16 @@ func (c Config) MarshalJSON() ([]byte, error) {
 	return union.Marshal(c)
 }
 
+// Printable returns a copy of the configuration in which secrets are replaced with the hidden
+// value.
+func (c Config) Printable(hiddenValue string) Config {
+	if c.External != nil {
+		external := c.External.Printable(hiddenValue)
+		c.External = &external
+	}
+	return c
+}
+
 // Validate implements the check.Validatable interface.
 func (c Config) Validate() []error {
 	var errs []error
@@ -102,11 +116,21 @@ func (c Config) Validate() []error {
 		errs = append(errs, check.In(masterURL.Scheme, []string{"http", "https"},
 			"master url scheme must be within [http, https]"))
 	}
+	var clusters int
+	for _, configured := range []bool{c.AWS != nil, c.GCP != nil, c.External != nil} {
+		if configured {
+			clusters++
+		}
+	}
+	var agentDockerImageErr error
+	if c.External == nil {
+		agentDockerImageErr = check.NotEmpty(c.AgentDockerImage, "must configure an agent docker image")
+	}
 	errs = append(errs, []error{
 		masterURLErr,
-		check.NotEmpty(c.AgentDockerImage, "must configure an agent docker image"),
-		check.False(c.AWS != nil && c.GCP != nil, "must configure only one cluster"),
-		check.False(c.AWS == nil && c.GCP == nil, "must configure aws or gcp cluster"),
+		agentDockerImageErr,
+		check.LessThanOrEqualTo(clusters, 1, "must configure only one cluster"),
+		check.GreaterThan(clusters, 0, "must configure aws, gcp or external cluster"),
 		check.GreaterThan(
 			int64(c.MaxIdleAgentPeriod), int64(0), "max idle agent period must be greater than 0"),
 		check.GreaterThan(
@@ -117,6 +141,12 @@ func (c Config) Validate() []error {
 		check.GreaterThanOrEqualTo(int64(c.MaxInstances), int64(c.MinInstances),
 			"max instance must be greater than or equal to min instance"),
 	}...)
+	for _, schedule := range c.CapacitySchedules {
+		minInstances, maxInstances := schedule.instanceLimits(c.MinInstances, c.MaxInstances)
+		errs = append(errs, check.GreaterThanOrEqualTo(int64(maxInstances), int64(minInstances),
+			"max instance must be greater than or equal to min instance in capacity schedule %s",
+			schedule))
+	}
 	return errs
 }
 
@@ -0,0 +1,289 @@
+package provisioner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// externalAction is the action that the provisioner asks the external cluster to take.
+type externalAction string
+
+const (
+	externalList      externalAction = "list"
+	externalLaunch    externalAction = "launch"
+	externalTerminate externalAction = "terminate"
+)
+
+// externalRequest is the JSON document that is written to the standard input of the configured
+// command, or posted to the webhook.
+type externalRequest struct {
+	Action       externalAction        `json:"action"`
+	ResourcePool string                `json:"resource_pool"`
+	InstanceType *externalInstanceType `json:"instance_type,omitempty"`
+	LaunchCount  int                   `json:"launch_count,omitempty"`
+	InstanceIDs  []string              `json:"instance_ids,omitempty"`
+	Agent        externalAgentConfig   `json:"agent"`
+}
+
+// externalAgentConfig describes how the agents on launched instances should be started.
+type externalAgentConfig struct {
+	MasterHost     string `json:"master_host"`
+	MasterPort     string `json:"master_port"`
+	MasterCertName string `json:"master_cert_name,omitempty"`
+	DockerImage    string `json:"docker_image,omitempty"`
+	DockerNetwork  string `json:"docker_network,omitempty"`
+	DockerRuntime  string `json:"docker_runtime,omitempty"`
+	FluentImage    string `json:"fluent_image,omitempty"`
+	StartupScript  string `json:"startup_script,omitempty"`
+}
+
+// externalResponse is the JSON document that the configured command writes to its standard
+// output, or that the webhook responds with. Launch and terminate actions may respond with nothing.
+type externalResponse struct {
+	Instances []externalInstance `json:"instances"`
+}
+
+type externalInstance struct {
+	ID           string     `json:"id"`
+	AgentName    string     `json:"agent_name"`
+	State        string     `json:"state"`
+	LaunchTime   *time.Time `json:"launch_time"`
+	InstanceType string     `json:"instance_type"`
+}
+
+var externalInstanceStates = map[string]InstanceState{
+	"starting":    Starting,
+	"running":     Running,
+	"stopping":    Stopping,
+	"stopped":     Stopped,
+	"terminating": Terminating,
+}
+
+// externalCluster delegates listing, launching, and terminating instances to user-configured
+// executables or an HTTP webhook. Agents on the instances must connect to the master with the
+// agent name reported by the list action, which defaults to the instance ID.
+type externalCluster struct {
+	*ExternalClusterConfig
+	resourcePool string
+	agent        externalAgentConfig
+
+	client *http.Client
+	// firstSeen records when instances without a launch time were first listed.
+	firstSeen map[string]time.Time
+}
+
+func newExternalCluster(resourcePool string, config *Config) (*externalCluster, error) {
+	masterURL, err := url.Parse(config.MasterURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse master url")
+	}
+
+	return &externalCluster{
+		ExternalClusterConfig: config.External,
+		resourcePool:          resourcePool,
+		agent: externalAgentConfig{
+			MasterHost:     masterURL.Hostname(),
+			MasterPort:     masterURL.Port(),
+			MasterCertName: config.MasterCertName,
+			DockerImage:    config.AgentDockerImage,
+			DockerNetwork:  config.AgentDockerNetwork,
+			DockerRuntime:  config.AgentDockerRuntime,
+			FluentImage:    config.AgentFluentImage,
+			StartupScript:  config.StartupScript,
+		},
+		client:    &http.Client{},
+		firstSeen: make(map[string]time.Time),
+	}, nil
+}
+
+func (c *externalCluster) instanceTypes() []instanceType {
+	if len(c.InstanceTypes) == 0 {
+		return []instanceType{c.InstanceType}
+	}
+	types := make([]instanceType, 0, len(c.InstanceTypes))
+	for _, t := range c.InstanceTypes {
+		types = append(types, t)
+	}
+	return types
+}
+
+func (c *externalCluster) prestart(ctx *actor.Context) {}
+
+func (c *externalCluster) list(ctx *actor.Context) ([]*Instance, error) {
+	resp, err := c.call(c.ListCommand, externalRequest{Action: externalList})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list external instances")
+	}
+	res := c.newInstances(resp.Instances, time.Now())
+	listed := make(map[string]bool, len(res))
+	for _, inst := range res {
+		listed[inst.ID] = true
+	}
+	for id := range c.firstSeen {
+		if !listed[id] {
+			delete(c.firstSeen, id)
+		}
+	}
+	for i, inst := range res {
+		if inst.State == Unknown {
+			ctx.Log().Errorf("unknown instance state for instance %v: %v",
+				inst.ID, resp.Instances[i].State)
+		}
+	}
+	return res, nil
+}
+
+func (c *externalCluster) launch(
+	ctx *actor.Context, instanceType instanceType, instanceNum int,
+) error {
+	if instanceNum <= 0 {
+		return nil
+	}
+	resp, err := c.call(c.LaunchCommand, externalRequest{
+		Action: externalLaunch,
+		InstanceType: &externalInstanceType{
+			Name:  instanceType.name(),
+			Slots: instanceType.slots(),
+			Cost:  instanceType.cost(),
+		},
+		LaunchCount: instanceNum,
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot launch external instances")
+	}
+	launched := c.newInstances(resp.Instances, time.Now())
+	ctx.Log().Infof("launched %d/%d external instances: %s",
+		len(launched), instanceNum, fmtInstances(launched))
+	return nil
+}
+
+func (c *externalCluster) terminate(ctx *actor.Context, instanceIDs []string) {
+	if len(instanceIDs) == 0 {
+		return
+	}
+	if _, err := c.call(c.TerminateCommand, externalRequest{
+		Action:      externalTerminate,
+		InstanceIDs: instanceIDs,
+	}); err != nil {
+		ctx.Log().WithError(err).Error("cannot terminate external instances")
+		return
+	}
+	ctx.Log().Infof("terminated %d external instances: %s",
+		len(instanceIDs), strings.Join(instanceIDs, ", "))
+}
+
+// call sends the request to the webhook if one is configured, or otherwise runs the command for
+// the action, and parses the response.
+func (c *externalCluster) call(command []string, req externalRequest) (*externalResponse, error) {
+	req.ResourcePool = c.resourcePool
+	req.Agent = c.agent
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.Timeout))
+	defer cancel()
+
+	var out []byte
+	if len(c.WebhookURL) > 0 {
+		out, err = c.post(ctx, body)
+	} else {
+		out, err = c.run(ctx, command, body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var resp externalResponse
+	if len(bytes.TrimSpace(out)) == 0 {
+		return &resp, nil
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse response to %s action", req.Action)
+	}
+	return &resp, nil
+}
+
+func (c *externalCluster) run(ctx context.Context, command []string, body []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...) // #nosec G204
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "command %s failed: %s",
+			command[0], strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func (c *externalCluster) post(ctx context.Context, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range c.WebhookHeaders {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "webhook request failed")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	out, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read webhook response")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.Errorf("webhook responded with %s: %s",
+			resp.Status, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+func (c *externalCluster) newInstances(input []externalInstance, now time.Time) []*Instance {
+	output := make([]*Instance, 0, len(input))
+	for _, inst := range input {
+		launchTime, ok := c.firstSeen[inst.ID]
+		switch {
+		case inst.LaunchTime != nil:
+			launchTime = *inst.LaunchTime
+		case !ok:
+			launchTime = now
+			c.firstSeen[inst.ID] = now
+		}
+
+		agentName := inst.AgentName
+		if len(agentName) == 0 {
+			agentName = inst.ID
+		}
+		state, ok := externalInstanceStates[strings.ToLower(inst.State)]
+		if !ok {
+			state = Unknown
+		}
+		output = append(output, &Instance{
+			ID:         inst.ID,
+			LaunchTime: launchTime,
+			AgentName:  agentName,
+			State:      state,
+			Type:       inst.InstanceType,
+		})
+	}
+	return output
+}
@@ -0,0 +1,110 @@
+package provisioner
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// ExternalClusterConfig describes the configuration for a cluster whose instances are managed by
+// user-provided executables or an HTTP webhook, such as an on-premise OpenStack or bare-metal pool.
+type ExternalClusterConfig struct {
+	ListCommand      []string `json:"list_command"`
+	LaunchCommand    []string `json:"launch_command"`
+	TerminateCommand []string `json:"terminate_command"`
+
+	WebhookURL     string            `json:"webhook_url"`
+	WebhookHeaders map[string]string `json:"webhook_headers"`
+
+	InstanceType  externalInstanceType   `json:"instance_type"`
+	InstanceTypes []externalInstanceType `json:"instance_types"`
+
+	Timeout Duration `json:"timeout"`
+}
+
+// DefaultExternalClusterConfig returns the default configuration of the external cluster.
+func DefaultExternalClusterConfig() *ExternalClusterConfig {
+	return &ExternalClusterConfig{
+		InstanceType: externalInstanceType{
+			Name: "external",
+		},
+		Timeout: Duration(time.Minute),
+	}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (c *ExternalClusterConfig) UnmarshalJSON(data []byte) error {
+	*c = *DefaultExternalClusterConfig()
+	type DefaultParser *ExternalClusterConfig
+	return json.Unmarshal(data, DefaultParser(c))
+}
+
+// Printable returns a copy of the configuration in which the values of the webhook headers, which
+// usually carry credentials, are replaced with the hidden value.
+func (c ExternalClusterConfig) Printable(hiddenValue string) ExternalClusterConfig {
+	if len(c.WebhookHeaders) > 0 {
+		headers := make(map[string]string, len(c.WebhookHeaders))
+		for name := range c.WebhookHeaders {
+			headers[name] = hiddenValue
+		}
+		c.WebhookHeaders = headers
+	}
+	return c
+}
+
+// Validate implements the check.Validatable interface.
+func (c ExternalClusterConfig) Validate() []error {
+	hasCommands := len(c.ListCommand) > 0 || len(c.LaunchCommand) > 0 || len(c.TerminateCommand) > 0
+	errs := []error{
+		check.False(hasCommands && len(c.WebhookURL) > 0,
+			"must configure either commands or a webhook url for the external cluster"),
+		check.True(hasCommands || len(c.WebhookURL) > 0,
+			"must configure commands or a webhook url for the external cluster"),
+		check.GreaterThan(int64(c.Timeout), int64(0), "external timeout must be greater than 0"),
+	}
+	if hasCommands {
+		errs = append(errs,
+			check.GreaterThan(len(c.ListCommand), 0, "external list command must be non-empty"),
+			check.GreaterThan(len(c.LaunchCommand), 0, "external launch command must be non-empty"),
+			check.GreaterThan(len(c.TerminateCommand), 0, "external terminate command must be non-empty"),
+		)
+	}
+	if len(c.WebhookURL) > 0 {
+		if webhookURL, err := url.Parse(c.WebhookURL); err != nil {
+			errs = append(errs, errors.Wrap(err, "cannot parse webhook url"))
+		} else {
+			errs = append(errs, check.In(webhookURL.Scheme, []string{"http", "https"},
+				"webhook url scheme must be within [http, https]"))
+		}
+	}
+	return errs
+}
+
+type externalInstanceType struct {
+	Name  string  `json:"name"`
+	Slots int     `json:"slots"`
+	Cost  float64 `json:"cost"`
+}
+
+func (t externalInstanceType) name() string {
+	return t.Name
+}
+
+func (t externalInstanceType) slots() int {
+	return t.Slots
+}
+
+func (t externalInstanceType) cost() float64 {
+	return t.Cost
+}
+
+func (t externalInstanceType) Validate() []error {
+	return []error{
+		check.NotEmpty(t.Name, "external instance type name must be non-empty"),
+		check.GreaterThanOrEqualTo(t.Slots, 0, "external instance type slots must be >= 0"),
+	}
+}
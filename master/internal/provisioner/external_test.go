@@ -0,0 +1,119 @@
+package provisioner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+func TestUnmarshalExternalProvisionerConfig(t *testing.T) {
+	var config Config
+	err := json.Unmarshal([]byte(`{
+"master_url": "http://test.master:8080",
+"provider": "external",
+"list_command": ["/opt/pool/list"],
+"launch_command": ["/opt/pool/launch", "--flavor", "gpu"],
+"terminate_command": ["/opt/pool/terminate"],
+"instance_type": {"name": "gpu", "slots": 4}
+}`), &config)
+	assert.NilError(t, err)
+	assert.NilError(t, check.Validate(&config))
+
+	expected := DefaultExternalClusterConfig()
+	expected.ListCommand = []string{"/opt/pool/list"}
+	expected.LaunchCommand = []string{"/opt/pool/launch", "--flavor", "gpu"}
+	expected.TerminateCommand = []string{"/opt/pool/terminate"}
+	expected.InstanceType = externalInstanceType{Name: "gpu", Slots: 4}
+	assert.DeepEqual(t, config.External, expected)
+}
+
+func TestValidateExternalClusterConfig(t *testing.T) {
+	config := DefaultExternalClusterConfig()
+	assert.ErrorContains(t, check.Validate(config), "must configure commands or a webhook url")
+
+	config.WebhookURL = "http://pool.local/hook"
+	assert.NilError(t, check.Validate(config))
+
+	config.ListCommand = []string{"/opt/pool/list"}
+	assert.ErrorContains(t, check.Validate(config), "either commands or a webhook url")
+
+	config.WebhookURL = ""
+	assert.ErrorContains(t, check.Validate(config), "external launch command must be non-empty")
+}
+
+func TestExternalClusterCommand(t *testing.T) {
+	cluster := &externalCluster{
+		ExternalClusterConfig: DefaultExternalClusterConfig(),
+		resourcePool:          "default",
+		firstSeen:             make(map[string]time.Time),
+	}
+	cluster.ListCommand = []string{"sh", "-c", `grep -q '"action":"list"' && echo '{"instances": [
+{"id": "node-1", "state": "running", "launch_time": "2020-10-01T00:00:00Z"},
+{"id": "node-2", "agent_name": "agent-2", "state": "Starting", "instance_type": "gpu"},
+{"id": "node-3", "state": "rebooting"}]}'`}
+
+	instances, err := cluster.call(cluster.ListCommand, externalRequest{Action: externalList})
+	assert.NilError(t, err)
+	now := time.Now()
+	actual := cluster.newInstances(instances.Instances, now)
+	assert.DeepEqual(t, actual, []*Instance{
+		{
+			ID:         "node-1",
+			LaunchTime: time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC),
+			AgentName:  "node-1",
+			State:      Running,
+		},
+		{ID: "node-2", LaunchTime: now, AgentName: "agent-2", State: Starting, Type: "gpu"},
+		{ID: "node-3", LaunchTime: now, AgentName: "node-3", State: Unknown},
+	})
+
+	// Instances without a launch time keep the time they were first seen.
+	actual = cluster.newInstances(instances.Instances, now.Add(time.Minute))
+	assert.Equal(t, actual[1].LaunchTime, now)
+
+	_, err = cluster.call([]string{"sh", "-c", "echo failed >&2; exit 1"}, externalRequest{})
+	assert.ErrorContains(t, err, "failed")
+}
+
+func TestExternalClusterWebhook(t *testing.T) {
+	var received externalRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get("Authorization"), "Bearer token")
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := DefaultExternalClusterConfig()
+	config.WebhookURL = server.URL
+	config.WebhookHeaders = map[string]string{"Authorization": "Bearer token"}
+	cluster := &externalCluster{
+		ExternalClusterConfig: config,
+		resourcePool:          "default",
+		agent:                 externalAgentConfig{MasterHost: "test.master", MasterPort: "8080"},
+		client:                &http.Client{},
+		firstSeen:             make(map[string]time.Time),
+	}
+
+	gpu := &externalInstanceType{Name: "gpu", Slots: 4, Cost: 2.5}
+	resp, err := cluster.call(nil, externalRequest{
+		Action:       externalLaunch,
+		InstanceType: gpu,
+		LaunchCount:  2,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(resp.Instances), 0)
+	assert.DeepEqual(t, received, externalRequest{
+		Action:       externalLaunch,
+		ResourcePool: "default",
+		InstanceType: gpu,
+		LaunchCount:  2,
+		Agent:        externalAgentConfig{MasterHost: "test.master", MasterPort: "8080"},
+	})
+}
@@ -31,7 +31,7 @@ type gcpCluster struct {
 	*GCPClusterConfig
 	resourcePool string
 	masterURL    url.URL
-	metadata     []*compute.MetadataItems
+	agentSetup   agentSetupScriptConfig
 
 	client *compute.Service
 }
@@ -78,36 +78,24 @@ func newGCPCluster(
 	}
 	masterCertBase64 := base64.StdEncoding.EncodeToString(certBytes)
 
-	startupScript := string(mustMakeAgentSetupScript(agentSetupScriptConfig{
-		MasterHost:                   masterURL.Hostname(),
-		MasterPort:                   masterURL.Port(),
-		MasterCertName:               config.MasterCertName,
-		AgentUseGPUs:                 config.GCP.InstanceType.slots() > 0,
-		AgentNetwork:                 config.AgentDockerNetwork,
-		AgentDockerRuntime:           config.AgentDockerRuntime,
-		AgentDockerImage:             config.AgentDockerImage,
-		AgentFluentImage:             config.AgentFluentImage,
-		StartupScriptBase64:          startupScriptBase64,
-		ContainerStartupScriptBase64: containerScriptBase64,
-		MasterCertBase64:             masterCertBase64,
-		AgentID: `$(curl "http://metadata.google.internal/computeMetadata/v1/instance/` +
-			`name" -H "Metadata-Flavor: Google")`,
-		ResourcePool: resourcePool,
-	}))
-
 	cluster := &gcpCluster{
 		resourcePool:     resourcePool,
 		GCPClusterConfig: config.GCP,
 		masterURL:        *masterURL,
-		metadata: []*compute.MetadataItems{
-			{
-				Key:   "startup-script",
-				Value: &startupScript,
-			},
-			{
-				Key:   "determined-master-address",
-				Value: &masterURL.Host,
-			},
+		agentSetup: agentSetupScriptConfig{
+			MasterHost:                   masterURL.Hostname(),
+			MasterPort:                   masterURL.Port(),
+			MasterCertName:               config.MasterCertName,
+			AgentNetwork:                 config.AgentDockerNetwork,
+			AgentDockerRuntime:           config.AgentDockerRuntime,
+			AgentDockerImage:             config.AgentDockerImage,
+			AgentFluentImage:             config.AgentFluentImage,
+			StartupScriptBase64:          startupScriptBase64,
+			ContainerStartupScriptBase64: containerScriptBase64,
+			MasterCertBase64:             masterCertBase64,
+			AgentID: `$(curl "http://metadata.google.internal/computeMetadata/v1/instance/` +
+				`name" -H "Metadata-Flavor: Google")`,
+			ResourcePool: resourcePool,
 		},
 		client: computeService,
 	}
@@ -115,8 +103,49 @@ func newGCPCluster(
 	return cluster, nil
 }
 
-func (c *gcpCluster) instanceType() instanceType {
-	return c.InstanceType
+func (c *gcpCluster) instanceTypes() []instanceType {
+	if len(c.InstanceTypes) == 0 {
+		return []instanceType{c.InstanceType}
+	}
+	types := make([]instanceType, 0, len(c.InstanceTypes))
+	for _, t := range c.InstanceTypes {
+		types = append(types, t)
+	}
+	return types
+}
+
+func (c *gcpCluster) metadata(instanceType gceInstanceType) []*compute.MetadataItems {
+	agentSetup := c.agentSetup
+	agentSetup.AgentUseGPUs = instanceType.slots() > 0
+	startupScript := string(mustMakeAgentSetupScript(agentSetup))
+	masterAddress := c.masterURL.Host
+	return []*compute.MetadataItems{
+		{
+			Key:   "startup-script",
+			Value: &startupScript,
+		},
+		{
+			Key:   "determined-master-address",
+			Value: &masterAddress,
+		},
+	}
+}
+
+// instanceTypeFromInstance returns the name of the instance type of the instance.
+func (c *gcpCluster) instanceTypeFromInstance(inst *compute.Instance) string {
+	lastSegment := func(s string) string {
+		segments := strings.Split(s, "/")
+		return segments[len(segments)-1]
+	}
+	instanceType := gceInstanceType{MachineType: lastSegment(inst.MachineType)}
+	if len(inst.GuestAccelerators) > 0 {
+		instanceType.GPUType = lastSegment(inst.GuestAccelerators[0].AcceleratorType)
+		instanceType.GPUNum = int(inst.GuestAccelerators[0].AcceleratorCount)
+	}
+	if inst.Scheduling != nil {
+		instanceType.Preemptible = inst.Scheduling.Preemptible
+	}
+	return instanceType.name()
 }
 
 func (c *gcpCluster) idFromInstance(inst *compute.Instance) string {
@@ -184,16 +213,23 @@ func (c *gcpCluster) list(ctx *actor.Context) ([]*Instance, error) {
 	return res, nil
 }
 
-func (c *gcpCluster) launch(ctx *actor.Context, instanceNum int) {
+func (c *gcpCluster) launch(
+	ctx *actor.Context, instanceType instanceType, instanceNum int,
+) error {
 	if instanceNum <= 0 {
-		return
+		return nil
+	}
+	gceType, ok := instanceType.(gceInstanceType)
+	if !ok {
+		return errors.Errorf("unexpected instance type %s", instanceType.name())
 	}
 
 	var ops []*compute.Operation
+	var err error
 	for i := 0; i < instanceNum; i++ {
 		clientCtx := context.Background()
 
-		rb := c.merge()
+		rb := c.merge(gceType)
 		rb.Name = c.generateInstanceName()
 		if rb.Labels == nil {
 			rb.Labels = make(map[string]string)
@@ -204,11 +240,12 @@ func (c *gcpCluster) launch(ctx *actor.Context, instanceNum int) {
 		if rb.Metadata == nil {
 			rb.Metadata = &compute.Metadata{}
 		}
-		rb.Metadata.Items = append(c.metadata, rb.Metadata.Items...)
+		rb.Metadata.Items = append(c.metadata(gceType), rb.Metadata.Items...)
 
 		rb.MinCpuPlatform = getCPUPlatform(rb.MachineType)
 
-		resp, err := c.client.Instances.Insert(c.Project, c.Zone, rb).Context(clientCtx).Do()
+		var resp *compute.Operation
+		resp, err = c.client.Instances.Insert(c.Project, c.Zone, rb).Context(clientCtx).Do()
 		if err != nil {
 			ctx.Log().WithError(err).Errorf("cannot insert GCE instance")
 		} else {
@@ -217,7 +254,7 @@ func (c *gcpCluster) launch(ctx *actor.Context, instanceNum int) {
 	}
 
 	if len(ops) == 0 {
-		return
+		return errors.Wrap(err, "cannot insert GCE instances")
 	}
 	if _, ok := ctx.ActorOf(
 		fmt.Sprintf("track-batch-operation-%s", uuid.New()),
@@ -237,8 +274,8 @@ func (c *gcpCluster) launch(ctx *actor.Context, instanceNum int) {
 		},
 	); !ok {
 		ctx.Log().Error("internal error tracking GCP operation batch")
-		return
 	}
+	return nil
 }
 
 func (c *gcpCluster) terminate(ctx *actor.Context, instances []string) {
@@ -297,6 +334,7 @@ func (c *gcpCluster) newInstances(input []*compute.Instance) []*Instance {
 			LaunchTime: t,
 			AgentName:  c.agentNameFromInstance(inst),
 			State:      c.stateFromInstance(inst),
+			Type:       c.instanceTypeFromInstance(inst),
 		})
 	}
 	return output
@@ -39,7 +39,8 @@ type GCPClusterConfig struct {
 	NetworkTags      []string            `json:"network_tags"`
 	ServiceAccount   gceServiceAccount   `json:"service_account"`
 
-	InstanceType gceInstanceType `json:"instance_type"`
+	InstanceType  gceInstanceType   `json:"instance_type"`
+	InstanceTypes []gceInstanceType `json:"instance_types"`
 
 	OperationTimeoutPeriod Duration `json:"operation_timeout_period"`
 }
@@ -117,25 +118,25 @@ func (c *GCPClusterConfig) initDefaultValues() error {
 	return nil
 }
 
-func (c *GCPClusterConfig) merge() *compute.Instance {
+func (c *GCPClusterConfig) merge(instanceType gceInstanceType) *compute.Instance {
 	rb := &compute.Instance{}
 	if c.BaseConfig != nil {
 		*rb = *c.BaseConfig
 	}
 
-	if len(c.InstanceType.MachineType) > 0 {
+	if len(instanceType.MachineType) > 0 {
 		rb.MachineType = fmt.Sprintf(
-			"zones/%s/machineTypes/%s", c.Zone, c.InstanceType.MachineType,
+			"zones/%s/machineTypes/%s", c.Zone, instanceType.MachineType,
 		)
 	}
 
-	if len(c.InstanceType.GPUType) > 0 {
+	if len(instanceType.GPUType) > 0 {
 		rb.GuestAccelerators = []*compute.AcceleratorConfig{
 			{
 				AcceleratorType: fmt.Sprintf(
-					"zones/%s/acceleratorTypes/%s", c.Zone, c.InstanceType.GPUType,
+					"zones/%s/acceleratorTypes/%s", c.Zone, instanceType.GPUType,
 				),
-				AcceleratorCount: int64(c.InstanceType.GPUNum),
+				AcceleratorCount: int64(instanceType.GPUNum),
 			},
 		}
 	}
@@ -190,7 +191,7 @@ func (c *GCPClusterConfig) merge() *compute.Instance {
 
 	rb.Scheduling = &compute.Scheduling{
 		OnHostMaintenance: "TERMINATE",
-		Preemptible:       c.InstanceType.Preemptible,
+		Preemptible:       instanceType.Preemptible,
 	}
 	return rb
 }
@@ -248,13 +249,17 @@ var gceCPUPlatforms = map[string]string{
 }
 
 type gceInstanceType struct {
-	MachineType string `json:"machine_type"`
-	GPUType     string `json:"gpu_type"`
-	GPUNum      int    `json:"gpu_num"`
-	Preemptible bool   `json:"preemptible"`
+	MachineType string  `json:"machine_type"`
+	GPUType     string  `json:"gpu_type"`
+	GPUNum      int     `json:"gpu_num"`
+	Preemptible bool    `json:"preemptible"`
+	Cost        float64 `json:"cost"`
 }
 
 func (t gceInstanceType) name() string {
+	if t.Preemptible {
+		return fmt.Sprintf("%s-%s-%d-preemptible", t.MachineType, t.GPUType, t.GPUNum)
+	}
 	return fmt.Sprintf("%s-%s-%d", t.MachineType, t.GPUType, t.GPUNum)
 }
 
@@ -262,6 +267,10 @@ func (t gceInstanceType) slots() int {
 	return t.GPUNum
 }
 
+func (t gceInstanceType) cost() float64 {
+	return t.Cost
+}
+
 func (t gceInstanceType) Validate() []error {
 	var checkMachineType = errors.Errorf("gce VM machine type must be within: %v",
 		strings.Join(gceMachineTypes, ", "))
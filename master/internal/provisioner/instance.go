@@ -10,6 +10,8 @@ import (
 type instanceType interface {
 	name() string
 	slots() int
+	// cost is the relative cost of an instance of this type, e.g., its hourly price.
+	cost() float64
 }
 
 // InstanceState is an enum type that describes an instance state.
@@ -38,6 +40,8 @@ type Instance struct {
 	LaunchTime time.Time
 	AgentName  string
 	State      InstanceState
+	// Type is the name of the instance type of the instance, if it is known.
+	Type string
 }
 
 func (inst Instance) String() string {
@@ -49,7 +53,7 @@ func (inst Instance) String() string {
 
 func (inst Instance) equals(other Instance) bool {
 	return inst.ID == other.ID && inst.LaunchTime.Equal(other.LaunchTime) &&
-		inst.AgentName == other.AgentName && inst.State == other.State
+		inst.AgentName == other.AgentName && inst.State == other.State && inst.Type == other.Type
 }
 
 func fmtInstances(instances []*Instance) string {
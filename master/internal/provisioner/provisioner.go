@@ -19,6 +19,16 @@ const (
 // provisionerTick periodically triggers the provisioner to act.
 type provisionerTick struct{}
 
+// Summary describes the state of a provisioner. The provisioner sends its summary to its parent
+// whenever it changes.
+type Summary struct {
+	MinInstances int `json:"min_instances"`
+	MaxInstances int `json:"max_instances"`
+	// CapacitySchedule is the name of the capacity schedule in effect, or empty if none is.
+	CapacitySchedule string `json:"capacity_schedule"`
+	Instances        int    `json:"instances"`
+}
+
 // Provisioner implements an actor to provision and terminate agent instances.
 // It is composed of three parts: a provisioner actor, a scaling decision maker, and a provider.
 // 1. The provisioner actor accepts actor messages with pending tasks and idle agents.
@@ -32,16 +42,29 @@ type provisionerTick struct{}
 type Provisioner struct {
 	provider     provider
 	scaleDecider *scaleDecider
+	summary      *Summary
 }
 
 type provider interface {
-	instanceType() instanceType
+	// instanceTypes returns the instance types that the provider may launch, in order of
+	// preference.
+	instanceTypes() []instanceType
 	prestart(ctx *actor.Context)
 	list(ctx *actor.Context) ([]*Instance, error)
-	launch(ctx *actor.Context, instanceNum int)
+	// launch launches instances of the given type. It returns an error if the instances cannot be
+	// launched, e.g., because the provider has no capacity for the instance type.
+	launch(ctx *actor.Context, instanceType instanceType, instanceNum int) error
 	terminate(ctx *actor.Context, instanceIDs []string)
 }
 
+// asyncLauncher is implemented by providers whose launches can fail after launch returned, e.g.,
+// spot requests that cannot be fulfilled.
+type asyncLauncher interface {
+	// failedInstanceTypes returns the names of the instance types that failed to launch since the
+	// last call.
+	failedInstanceTypes() []string
+}
+
 // New creates a new Provisioner.
 func New(resourcePool string, config *Config, cert *tls.Certificate) (*Provisioner, error) {
 	if err := config.initMasterAddress(); err != nil {
@@ -59,6 +82,11 @@ func New(resourcePool string, config *Config, cert *tls.Certificate) (*Provision
 		if cluster, err = newGCPCluster(resourcePool, config, cert); err != nil {
 			return nil, errors.Wrap(err, "cannot create a GCP cluster")
 		}
+	case config.External != nil:
+		var err error
+		if cluster, err = newExternalCluster(resourcePool, config); err != nil {
+			return nil, errors.Wrap(err, "cannot create an external cluster")
+		}
 	}
 
 	return &Provisioner{
@@ -69,6 +97,8 @@ func New(resourcePool string, config *Config, cert *tls.Certificate) (*Provision
 			maxDisconnectPeriod,
 			config.MinInstances,
 			config.MaxInstances,
+			config.CapacitySchedules,
+			cluster.instanceTypes(),
 		),
 	}, nil
 }
@@ -95,12 +125,17 @@ func (p *Provisioner) Receive(ctx *actor.Context) error {
 	return nil
 }
 
-// SlotsPerInstance returns the number of slots per instance the provisioner launches.
-func (p *Provisioner) SlotsPerInstance() int {
-	return p.provider.instanceType().slots()
-}
-
 func (p *Provisioner) provision(ctx *actor.Context) {
+	if p.scaleDecider.updateInstanceLimits(time.Now()) {
+		if schedule := p.scaleDecider.activeSchedule; schedule != nil {
+			ctx.Log().Infof("capacity schedule %s is in effect: min instances %d, max instances %d",
+				schedule, p.scaleDecider.minInstanceNum, p.scaleDecider.maxInstanceNum)
+		} else {
+			ctx.Log().Infof("no capacity schedule is in effect: min instances %d, max instances %d",
+				p.scaleDecider.minInstanceNum, p.scaleDecider.maxInstanceNum)
+		}
+	}
+
 	instances, err := p.provider.list(ctx)
 	if err != nil {
 		ctx.Log().WithError(err).Error("cannot list instances")
@@ -110,8 +145,17 @@ func (p *Provisioner) provision(ctx *actor.Context) {
 		ctx.Log().Infof("found state changes in %d instances: %s",
 			len(instances), fmtInstances(instances))
 	}
+	if launcher, ok := p.provider.(asyncLauncher); ok {
+		for _, name := range launcher.failedInstanceTypes() {
+			ctx.Log().Errorf(
+				"instances (type %s) failed to launch; falling back to other instance types for %s",
+				name, instanceTypeUnavailablePeriod)
+			p.scaleDecider.markUnavailable(name)
+		}
+	}
 
 	p.scaleDecider.calculateInstanceStates()
+	p.updateSummary(ctx)
 
 	if toTerminate := p.scaleDecider.findInstancesToTerminate(); len(toTerminate.InstanceIDs) > 0 {
 		ctx.Log().Infof("decided to terminate %d instances: %s",
@@ -119,9 +163,30 @@ func (p *Provisioner) provision(ctx *actor.Context) {
 		p.provider.terminate(ctx, toTerminate.InstanceIDs)
 	}
 
-	if numToLaunch := p.scaleDecider.calculateNumInstancesToLaunch(); numToLaunch > 0 {
+	for _, toLaunch := range p.scaleDecider.calculateInstancesToLaunch() {
 		ctx.Log().Infof("decided to launch %d instances (type %s)",
-			numToLaunch, p.provider.instanceType().name())
-		p.provider.launch(ctx, numToLaunch)
+			toLaunch.num, toLaunch.instanceType.name())
+		if err := p.provider.launch(ctx, toLaunch.instanceType, toLaunch.num); err != nil {
+			ctx.Log().WithError(err).Errorf(
+				"cannot launch instances (type %s); falling back to other instance types for %s",
+				toLaunch.instanceType.name(), instanceTypeUnavailablePeriod)
+			p.scaleDecider.markUnavailable(toLaunch.instanceType.name())
+		}
+	}
+}
+
+// updateSummary sends the summary of the provisioner to its parent if it changed.
+func (p *Provisioner) updateSummary(ctx *actor.Context) {
+	summary := Summary{
+		MinInstances: p.scaleDecider.minInstanceNum,
+		MaxInstances: p.scaleDecider.maxInstanceNum,
+		Instances:    len(p.scaleDecider.instances),
+	}
+	if schedule := p.scaleDecider.activeSchedule; schedule != nil {
+		summary.CapacitySchedule = schedule.String()
+	}
+	if p.summary == nil || *p.summary != summary {
+		p.summary = &summary
+		ctx.Tell(ctx.Self().Parent(), summary)
 	}
 }
@@ -14,6 +14,7 @@ import (
 type TestInstanceType struct {
 	Name  string
 	Slots int
+	Cost  float64
 }
 
 func (t TestInstanceType) name() string {
@@ -22,6 +23,9 @@ func (t TestInstanceType) name() string {
 func (t TestInstanceType) slots() int {
 	return t.Slots
 }
+func (t TestInstanceType) cost() float64 {
+	return t.Cost
+}
 
 func newInstanceIDSet(instanceIDs []string) map[string]bool {
 	set := make(map[string]bool)
@@ -56,6 +60,8 @@ func newMockEnvironment(t *testing.T, setup *mockConfig) *mockEnvironment {
 			setup.maxDisconnectPeriod,
 			setup.MinInstances,
 			setup.MaxInstances,
+			setup.CapacitySchedules,
+			[]instanceType{setup.instanceType},
 		),
 	}
 	provisioner, created := system.ActorOf(actor.Addr("provisioner"), p)
@@ -87,6 +93,7 @@ type mockProvider struct {
 	mockInstanceType instanceType
 	maxInstances     int
 	instances        map[string]*Instance
+	failedTypes      []string
 	history          []mockFuncCall
 }
 
@@ -103,8 +110,8 @@ func newMockProvider(config *mockConfig) (*mockProvider, error) {
 	return cluster, nil
 }
 
-func (c *mockProvider) instanceType() instanceType {
-	return c.mockInstanceType
+func (c *mockProvider) instanceTypes() []instanceType {
+	return []instanceType{c.mockInstanceType}
 }
 
 func (c *mockProvider) list(ctx *actor.Context) ([]*Instance, error) {
@@ -119,8 +126,10 @@ func (c *mockProvider) list(ctx *actor.Context) ([]*Instance, error) {
 
 func (c *mockProvider) prestart(ctx *actor.Context) {}
 
-func (c *mockProvider) launch(ctx *actor.Context, instanceNum int) {
-	c.history = append(c.history, newMockFuncCall("launch", c.mockInstanceType, instanceNum))
+func (c *mockProvider) launch(
+	ctx *actor.Context, instanceType instanceType, instanceNum int,
+) error {
+	c.history = append(c.history, newMockFuncCall("launch", instanceType, instanceNum))
 	for i := 0; i < instanceNum; i++ {
 		name := uuid.New().String()
 		inst := Instance{
@@ -128,9 +137,17 @@ func (c *mockProvider) launch(ctx *actor.Context, instanceNum int) {
 			AgentName:  name,
 			LaunchTime: time.Now(),
 			State:      Running,
+			Type:       instanceType.name(),
 		}
 		c.instances[inst.ID] = &inst
 	}
+	return nil
+}
+
+func (c *mockProvider) failedInstanceTypes() []string {
+	failed := c.failedTypes
+	c.failedTypes = nil
+	return failed
 }
 
 func (c *mockProvider) terminate(ctx *actor.Context, instanceIDs []string) {
@@ -153,7 +170,7 @@ func TestProvisionerScaleUp(t *testing.T) {
 		initInstances: []*Instance{},
 	}
 	mock := newMockEnvironment(t, setup)
-	mock.system.Ask(mock.provisioner, sproto.ScalingInfo{DesiredNewInstances: 4}).Get()
+	mock.system.Ask(mock.provisioner, sproto.ScalingInfo{PendingSlots: []int{4, 4, 4, 4}}).Get()
 	mock.system.Ask(mock.provisioner, provisionerTick{}).Get()
 	assert.NilError(t, mock.system.StopAndAwaitTermination())
 	assert.DeepEqual(t, mock.cluster.history, []mockFuncCall{
@@ -165,6 +182,28 @@ func TestProvisionerScaleUp(t *testing.T) {
 	})
 }
 
+func TestProvisionerScaleUpNotFailedType(t *testing.T) {
+	setup := &mockConfig{
+		maxDisconnectPeriod: 5 * time.Minute,
+		instanceType: TestInstanceType{
+			Name:  "test.instanceType",
+			Slots: 4,
+		},
+		Config: &Config{
+			MaxInstances: 100,
+		},
+		initInstances: []*Instance{},
+	}
+	mock := newMockEnvironment(t, setup)
+	mock.cluster.failedTypes = []string{"test.instanceType"}
+	mock.system.Ask(mock.provisioner, sproto.ScalingInfo{PendingSlots: []int{4, 4}}).Get()
+	mock.system.Ask(mock.provisioner, provisionerTick{}).Get()
+	assert.NilError(t, mock.system.StopAndAwaitTermination())
+	assert.DeepEqual(t, mock.cluster.history, []mockFuncCall{
+		newMockFuncCall("list"),
+	})
+}
+
 func TestProvisionerScaleUpNotPastMax(t *testing.T) {
 	setup := &mockConfig{
 		maxDisconnectPeriod: 5 * time.Minute,
@@ -178,7 +217,7 @@ func TestProvisionerScaleUpNotPastMax(t *testing.T) {
 		initInstances: []*Instance{},
 	}
 	mock := newMockEnvironment(t, setup)
-	mock.system.Ask(mock.provisioner, sproto.ScalingInfo{DesiredNewInstances: 3}).Get()
+	mock.system.Ask(mock.provisioner, sproto.ScalingInfo{PendingSlots: []int{4, 4, 4}}).Get()
 	mock.system.Ask(mock.provisioner, provisionerTick{}).Get()
 	assert.NilError(t, mock.system.StopAndAwaitTermination())
 	assert.DeepEqual(t, mock.cluster.history, []mockFuncCall{
@@ -219,7 +258,6 @@ func TestProvisionerScaleDown(t *testing.T) {
 	mock := newMockEnvironment(t, setup)
 
 	mock.system.Ask(mock.provisioner, sproto.ScalingInfo{
-		DesiredNewInstances: 0,
 		Agents: map[string]sproto.AgentSummary{
 			"agent1": {Name: "agent1", IsIdle: true},
 			"agent2": {Name: "agent2", IsIdle: true},
@@ -272,7 +310,6 @@ func TestProvisionerNotProvisionExtraInstances(t *testing.T) {
 	// Start the master.
 	mock.system.Ask(mock.provisioner,
 		sproto.ScalingInfo{
-			DesiredNewInstances: 0,
 			Agents: map[string]sproto.AgentSummary{
 				"agent1": {Name: "agent1", IsIdle: true},
 				"agent2": {Name: "agent2", IsIdle: true},
@@ -283,7 +320,7 @@ func TestProvisionerNotProvisionExtraInstances(t *testing.T) {
 
 	// Submit jobs.
 	mock.system.Ask(mock.provisioner, sproto.ScalingInfo{
-		DesiredNewInstances: 2,
+		PendingSlots: []int{4, 4},
 		Agents: map[string]sproto.AgentSummary{
 			"agent1": {Name: "agent1", IsIdle: true},
 			"agent2": {Name: "agent2", IsIdle: true},
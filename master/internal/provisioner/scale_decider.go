@@ -9,8 +9,16 @@ import (
 
 const (
 	maxDisconnectPeriod = 10 * time.Minute
+	// instanceTypeUnavailablePeriod is how long an instance type that failed to launch is skipped.
+	instanceTypeUnavailablePeriod = 10 * time.Minute
 )
 
+// launchDecision is the number of instances of an instance type to launch.
+type launchDecision struct {
+	instanceType instanceType
+	num          int
+}
+
 // scaleDecider makes decisions based on the following assumptions:
 // 1. All pending tasks cannot fit into all agents when receiving the snapshots from
 //    the scheduler, i.e. we need to launch new agents to fit the pending tasks.
@@ -30,11 +38,27 @@ type scaleDecider struct {
 	maxDisconnectPeriod time.Duration
 	minInstanceNum      int
 	maxInstanceNum      int
+	// staticMinInstanceNum and staticMaxInstanceNum are the limits outside of the capacity
+	// schedules; minInstanceNum and maxInstanceNum are the limits in effect.
+	staticMinInstanceNum int
+	staticMaxInstanceNum int
+	capacitySchedules    []CapacitySchedule
+	// activeSchedule is the capacity schedule in effect, or nil if none is.
+	activeSchedule *CapacitySchedule
+	// busyMaxInstanceNum is the number of instances above which busy instances are terminated.
+	// When a capacity schedule lowers maxInstanceNum, it keeps the previous limit, so that the
+	// instances above the new limit are only terminated once they are idle; it follows
+	// maxInstanceNum again as the number of instances comes down.
+	busyMaxInstanceNum int
+	// instanceTypes are the instance types that may be launched, in order of preference.
+	instanceTypes []instanceType
+	// unavailable records until when instance types that failed to launch are skipped.
+	unavailable map[string]time.Time
 
 	instanceSnapshot       map[string]*Instance
 	connectedAgentSnapshot map[string]sproto.AgentSummary
 	idleAgentSnapshot      map[string]sproto.AgentSummary
-	desiredNewInstances    int
+	pendingSlots           []int
 
 	instances        map[string]*Instance
 	pending          map[string]bool
@@ -51,6 +75,8 @@ func newScaleDecider(
 	maxDisconnectPeriod time.Duration,
 	minInstanceNum int,
 	maxInstanceNum int,
+	capacitySchedules []CapacitySchedule,
+	instanceTypes []instanceType,
 ) *scaleDecider {
 	return &scaleDecider{
 		maxStartingPeriod:      maxStartingPeriod,
@@ -58,6 +84,12 @@ func newScaleDecider(
 		maxDisconnectPeriod:    maxDisconnectPeriod,
 		minInstanceNum:         minInstanceNum,
 		maxInstanceNum:         maxInstanceNum,
+		staticMinInstanceNum:   minInstanceNum,
+		staticMaxInstanceNum:   maxInstanceNum,
+		busyMaxInstanceNum:     maxInstanceNum,
+		capacitySchedules:      capacitySchedules,
+		instanceTypes:          instanceTypes,
+		unavailable:            make(map[string]time.Time),
 		instanceSnapshot:       make(map[string]*Instance),
 		connectedAgentSnapshot: make(map[string]sproto.AgentSummary),
 		idleAgentSnapshot:      make(map[string]sproto.AgentSummary),
@@ -72,8 +104,27 @@ func newScaleDecider(
 	}
 }
 
+// updateInstanceLimits applies the first capacity schedule that is active at the given time, or the
+// static limits if none is, and returns whether the limits in effect changed.
+func (s *scaleDecider) updateInstanceLimits(now time.Time) bool {
+	var active *CapacitySchedule
+	minNum, maxNum := s.staticMinInstanceNum, s.staticMaxInstanceNum
+	for i := range s.capacitySchedules {
+		if s.capacitySchedules[i].active(now) {
+			active = &s.capacitySchedules[i]
+			minNum, maxNum = active.instanceLimits(minNum, maxNum)
+			break
+		}
+	}
+	changed := active != s.activeSchedule ||
+		minNum != s.minInstanceNum || maxNum != s.maxInstanceNum
+	s.busyMaxInstanceNum = max(s.busyMaxInstanceNum, max(s.maxInstanceNum, maxNum))
+	s.activeSchedule, s.minInstanceNum, s.maxInstanceNum = active, minNum, maxNum
+	return changed
+}
+
 func (s *scaleDecider) updateScalingInfo(info *sproto.ScalingInfo) {
-	s.desiredNewInstances = info.DesiredNewInstances
+	s.pendingSlots = info.PendingSlots
 	s.idleAgentSnapshot = make(map[string]sproto.AgentSummary)
 	s.connectedAgentSnapshot = make(map[string]sproto.AgentSummary)
 	for _, agent := range info.Agents {
@@ -190,9 +241,21 @@ func (s *scaleDecider) findInstancesToTerminate() sproto.TerminateDecision {
 		}
 	}
 
+	// Cancel pending launches of instance types that failed to launch, so that their tasks go to
+	// other instance types. Without another instance type to fall back to, they are kept.
+	if now := time.Now(); len(s.availableTypes(now)) > 0 {
+		for id := range s.pending {
+			if !s.isAvailable(s.instances[id].Type, now) {
+				toTerminate[id] = sproto.TerminateUnavailableInstanceType
+				delete(s.pending, id)
+			}
+		}
+	}
+
 	// Terminate instances to keep the number of instances less than than the desired size.
 	// We start by terminating unfulfilled spot requests, then idle instances, then
-	// disconnected instances, then the most recently provisioned instances
+	// disconnected instances, then the most recently provisioned instances. Busy instances above a
+	// limit that a capacity schedule lowered are kept until they are idle.
 	for id := range s.pending {
 		if len(s.instances)-len(toTerminate) > s.maxInstanceNum {
 			toTerminate[id] = sproto.InstanceNumberExceedsMaximum
@@ -224,9 +287,11 @@ func (s *scaleDecider) findInstancesToTerminate() sproto.TerminateDecision {
 	sort.Slice(instances, func(i, j int) bool {
 		return instances[i].LaunchTime.After(instances[j].LaunchTime)
 	})
-	for i := 0; i < len(instances) && len(instances)-len(toTerminate) > s.maxInstanceNum; i++ {
+	busyMaxNum := max(s.maxInstanceNum, s.busyMaxInstanceNum)
+	for i := 0; i < len(instances) && len(instances)-len(toTerminate) > busyMaxNum; i++ {
 		toTerminate[instances[i].ID] = sproto.InstanceNumberExceedsMaximum
 	}
+	s.busyMaxInstanceNum = max(s.maxInstanceNum, min(busyMaxNum, len(instances)-len(toTerminate)))
 
 	res := sproto.TerminateDecision{}
 	res.Reasons = toTerminate
@@ -237,11 +302,149 @@ func (s *scaleDecider) findInstancesToTerminate() sproto.TerminateDecision {
 	return res
 }
 
-func (s *scaleDecider) calculateNumInstancesToLaunch() int {
-	desiredNum := s.desiredNewInstances - len(s.recentlyLaunched)
-	desiredNum = min(desiredNum, s.maxInstanceNum-len(s.instances))
-	desiredNum = max(desiredNum, s.minInstanceNum-len(s.instances))
-	return max(0, desiredNum)
+// calculateInstancesToLaunch decides how many instances of each type to launch to fit the pending
+// tasks. The free slots of recently launched instances are used first. Each task that does not
+// fit goes on the instance type that is cheapest per slot that the task, and the smaller tasks
+// after it, would use; a task that needs more slots than an instance has is spread over several
+// instances.
+// Instance types that recently failed to launch are skipped, and the pending launches of them are
+// not counted on. Instances are only launched for whole tasks within the maximum number of
+// instances.
+func (s *scaleDecider) calculateInstancesToLaunch() []launchDecision {
+	now := time.Now()
+	available := s.availableTypes(now)
+
+	// freeSlots holds the free slots of each recently launched or planned instance.
+	var freeSlots []int
+	for id := range s.recentlyLaunched {
+		inst := s.instances[id]
+		if s.pending[id] && !s.isAvailable(inst.Type, now) {
+			continue
+		}
+		freeSlots = append(freeSlots, s.slotsOf(inst))
+	}
+	pending := append([]int(nil), s.pendingSlots...)
+	sort.Sort(sort.Reverse(sort.IntSlice(pending)))
+	maxNum := max(0, s.maxInstanceNum-len(s.instances))
+	var planned []instanceType
+	for i, slots := range pending {
+		if fitInFreeSlots(freeSlots, slots) {
+			continue
+		}
+		t, num := cheapestFit(available, slots, pending[i+1:])
+		if t == nil || len(planned)+num > maxNum {
+			continue
+		}
+		for j := 0; j < num; j++ {
+			planned = append(planned, t)
+		}
+		if num == 1 {
+			freeSlots = append(freeSlots, t.slots()-slots)
+		}
+	}
+
+	for len(available) > 0 && len(s.instances)+len(planned) < s.minInstanceNum {
+		planned = append(planned, available[0])
+	}
+
+	var res []launchDecision
+	index := make(map[string]int)
+	for _, t := range planned {
+		if i, ok := index[t.name()]; ok {
+			res[i].num++
+			continue
+		}
+		index[t.name()] = len(res)
+		res = append(res, launchDecision{instanceType: t, num: 1})
+	}
+	return res
+}
+
+// markUnavailable skips the instance type with the given name for a while, so that other instance
+// types are launched instead.
+func (s *scaleDecider) markUnavailable(name string) {
+	s.unavailable[name] = time.Now().Add(instanceTypeUnavailablePeriod)
+}
+
+// isAvailable returns whether the instance type with the given name may be launched.
+func (s *scaleDecider) isAvailable(name string, now time.Time) bool {
+	until, ok := s.unavailable[name]
+	return !ok || now.After(until)
+}
+
+// availableTypes returns the instance types that may be launched, in order of preference.
+func (s *scaleDecider) availableTypes(now time.Time) []instanceType {
+	var available []instanceType
+	for _, t := range s.instanceTypes {
+		if s.isAvailable(t.name(), now) {
+			available = append(available, t)
+		}
+	}
+	return available
+}
+
+// slotsOf returns the number of slots of the instance. Instances of unknown types are assumed to be
+// of the most preferred type.
+func (s *scaleDecider) slotsOf(inst *Instance) int {
+	if len(s.instanceTypes) == 0 {
+		return 0
+	}
+	if inst != nil {
+		for _, t := range s.instanceTypes {
+			if t.name() == inst.Type {
+				return t.slots()
+			}
+		}
+	}
+	return s.instanceTypes[0].slots()
+}
+
+// fitInFreeSlots places a task on the instance with the fewest free slots that fits it. Tasks that
+// need no slots fit on any instance.
+func fitInFreeSlots(freeSlots []int, slots int) bool {
+	best := -1
+	for i, free := range freeSlots {
+		if free >= slots && (best < 0 || free < freeSlots[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return false
+	}
+	freeSlots[best] -= slots
+	return true
+}
+
+// cheapestFit returns the instance type, and the number of instances of it, that fits a task at the
+// lowest cost per used slot. The slots of an instance that the task does not use count as used if
+// the remaining tasks can fill them. Ties go to the type that leaves fewer slots unused, and then
+// to the type that comes first.
+func cheapestFit(types []instanceType, slots int, remaining []int) (instanceType, int) {
+	var best instanceType
+	var bestNum, bestUnused int
+	var bestCost float64
+	for _, t := range types {
+		var num, used int
+		switch {
+		case t.slots() >= slots:
+			num, used = 1, slots
+			for _, other := range remaining {
+				if used+other <= t.slots() {
+					used += other
+				}
+			}
+		case slots > 0 && t.slots() > 0 && slots%t.slots() == 0:
+			num, used = slots/t.slots(), slots
+		default:
+			continue
+		}
+		cost := t.cost() * float64(num) / float64(max(used, 1))
+		unused := num*t.slots() - used
+		if best == nil || cost < bestCost || (cost == bestCost && unused < bestUnused) {
+			best, bestNum, bestCost, bestUnused = t, num, cost, unused
+		}
+	}
+	return best, bestNum
 }
 
 func max(a, b int) int {
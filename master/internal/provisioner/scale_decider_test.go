@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"gotest.tools/assert"
 
 	"github.com/determined-ai/determined/master/internal/sproto"
@@ -124,7 +125,7 @@ func TestCalculateInstanceStates(t *testing.T) {
 					"new idle":  {Name: "new idle", IsIdle: true},
 					"long idle": {Name: "long idle", IsIdle: true},
 				},
-				desiredNewInstances: 1,
+				pendingSlots: []int{1},
 				disconnected: map[string]time.Time{
 					"past disconnected": time.Now().Add(-time.Hour),
 					"long disconnected": time.Now().Add(-time.Hour),
@@ -290,6 +291,37 @@ func TestFindInstancesToTerminate(t *testing.T) {
 				"long disconnected",
 			},
 		},
+		{
+			name: "cancel pending launches of unavailable types",
+			scaleDecider: scaleDecider{
+				instances: map[string]*Instance{
+					"sir-1": {ID: "sir-1", Type: "1-gpu-spot", State: SpotRequestPendingAWS},
+				},
+				pending: map[string]bool{"sir-1": true},
+				instanceTypes: []instanceType{
+					TestInstanceType{Name: "1-gpu-spot", Slots: 1},
+					TestInstanceType{Name: "1-gpu", Slots: 1},
+				},
+				unavailable:    map[string]time.Time{"1-gpu-spot": time.Now().Add(time.Minute)},
+				maxInstanceNum: 10,
+			},
+			toTerminate: []string{"sir-1"},
+		},
+		{
+			name: "keep pending launches without a fallback",
+			scaleDecider: scaleDecider{
+				instances: map[string]*Instance{
+					"sir-1": {ID: "sir-1", Type: "1-gpu-spot", State: SpotRequestPendingAWS},
+				},
+				pending: map[string]bool{"sir-1": true},
+				instanceTypes: []instanceType{
+					TestInstanceType{Name: "1-gpu-spot", Slots: 1},
+				},
+				unavailable:    map[string]time.Time{"1-gpu-spot": time.Now().Add(time.Minute)},
+				maxInstanceNum: 10,
+			},
+			toTerminate: []string{},
+		},
 	}
 	for idx := range tcs {
 		tc := tcs[idx]
@@ -310,10 +342,10 @@ func TestCalculateNumInstancesToLaunch(t *testing.T) {
 		{
 			name: "keep above min instance num",
 			scaleDecider: scaleDecider{
-				maxStartingPeriod:   time.Minute,
-				minInstanceNum:      1,
-				maxInstanceNum:      10,
-				desiredNewInstances: 1,
+				maxStartingPeriod: time.Minute,
+				minInstanceNum:    1,
+				maxInstanceNum:    10,
+				pendingSlots:      []int{1},
 			},
 			numToLaunch: 1,
 		},
@@ -330,7 +362,7 @@ func TestCalculateNumInstancesToLaunch(t *testing.T) {
 						State:      Running,
 					},
 				},
-				desiredNewInstances: 4,
+				pendingSlots: []int{1, 1, 1, 1},
 			},
 			numToLaunch: 1,
 		},
@@ -356,7 +388,7 @@ func TestCalculateNumInstancesToLaunch(t *testing.T) {
 				recentlyLaunched: map[string]bool{
 					"starting instance": true,
 				},
-				desiredNewInstances: 4,
+				pendingSlots: []int{1, 1, 1, 1},
 			},
 			numToLaunch: 3,
 		},
@@ -383,7 +415,7 @@ func TestCalculateNumInstancesToLaunch(t *testing.T) {
 					"instance1": true,
 					"instance2": true,
 				},
-				desiredNewInstances: 1,
+				pendingSlots: []int{1},
 			},
 			numToLaunch: 0,
 		},
@@ -392,8 +424,215 @@ func TestCalculateNumInstancesToLaunch(t *testing.T) {
 	for idx := range tcs {
 		tc := tcs[idx]
 		t.Run(tc.name, func(t *testing.T) {
-			actual := tc.scaleDecider.calculateNumInstancesToLaunch()
+			tc.scaleDecider.instanceTypes = []instanceType{
+				TestInstanceType{Name: "test.instanceType", Slots: 1},
+			}
+			actual := 0
+			for _, toLaunch := range tc.scaleDecider.calculateInstancesToLaunch() {
+				actual += toLaunch.num
+			}
 			assert.Equal(t, actual, tc.numToLaunch)
 		})
 	}
 }
+
+func TestCalculateInstancesToLaunchWithInstanceTypes(t *testing.T) {
+	eightGPUs := TestInstanceType{Name: "8-gpu", Slots: 8, Cost: 24}
+	fourGPUs := TestInstanceType{Name: "4-gpu", Slots: 4, Cost: 12.5}
+	oneGPU := TestInstanceType{Name: "1-gpu", Slots: 1, Cost: 3.5}
+	cpu := TestInstanceType{Name: "cpu", Slots: 0, Cost: 0.5}
+	types := []instanceType{eightGPUs, fourGPUs, oneGPU, cpu}
+
+	type testcase struct {
+		name         string
+		pendingSlots []int
+		types        []instanceType
+		unavailable  map[string]time.Time
+		recent       map[string]*Instance
+		maxInstances int
+		toLaunch     []launchDecision
+	}
+	tcs := []testcase{
+		{
+			name:         "single slot task",
+			pendingSlots: []int{1},
+			toLaunch:     []launchDecision{{oneGPU, 1}},
+		},
+		{
+			name:         "many single slot tasks share an instance",
+			pendingSlots: []int{1, 1, 1, 1, 1, 1, 1, 1},
+			toLaunch:     []launchDecision{{eightGPUs, 1}},
+		},
+		{
+			name:         "mixed tasks",
+			pendingSlots: []int{4, 1},
+			toLaunch:     []launchDecision{{fourGPUs, 1}, {oneGPU, 1}},
+		},
+		{
+			name:         "distributed task",
+			pendingSlots: []int{16},
+			toLaunch:     []launchDecision{{eightGPUs, 2}},
+		},
+		{
+			name:         "zero slot task",
+			pendingSlots: []int{0, 0},
+			toLaunch:     []launchDecision{{cpu, 1}},
+		},
+		{
+			name:         "unschedulable task",
+			pendingSlots: []int{3},
+			types:        []instanceType{eightGPUs, fourGPUs},
+			toLaunch:     []launchDecision{{fourGPUs, 1}},
+		},
+		{
+			name:         "fall back from unavailable type",
+			pendingSlots: []int{1},
+			unavailable:  map[string]time.Time{"1-gpu": time.Now().Add(time.Minute)},
+			toLaunch:     []launchDecision{{fourGPUs, 1}},
+		},
+		{
+			name:         "use recently launched instances",
+			pendingSlots: []int{4, 4, 1},
+			recent: map[string]*Instance{
+				"starting": {ID: "starting", Type: "8-gpu", LaunchTime: time.Now()},
+			},
+			toLaunch: []launchDecision{{oneGPU, 1}},
+		},
+		{
+			name:         "do not count on pending launches of unavailable types",
+			pendingSlots: []int{1},
+			unavailable:  map[string]time.Time{"1-gpu": time.Now().Add(time.Minute)},
+			recent: map[string]*Instance{
+				"sir-1": {ID: "sir-1", Type: "1-gpu", State: SpotRequestPendingAWS},
+			},
+			toLaunch: []launchDecision{{fourGPUs, 1}},
+		},
+		{
+			name:         "only launch for whole tasks",
+			pendingSlots: []int{16, 1},
+			maxInstances: 1,
+			toLaunch:     []launchDecision{{oneGPU, 1}},
+		},
+		{
+			name:         "same costs prefer the smaller instance",
+			pendingSlots: []int{1},
+			types: []instanceType{
+				TestInstanceType{Name: "8-gpu", Slots: 8},
+				TestInstanceType{Name: "1-gpu", Slots: 1},
+			},
+			toLaunch: []launchDecision{{TestInstanceType{Name: "1-gpu", Slots: 1}, 1}},
+		},
+	}
+	for idx := range tcs {
+		tc := tcs[idx]
+		t.Run(tc.name, func(t *testing.T) {
+			s := scaleDecider{
+				maxInstanceNum:   10,
+				instanceTypes:    types,
+				unavailable:      tc.unavailable,
+				pendingSlots:     tc.pendingSlots,
+				instances:        tc.recent,
+				pending:          make(map[string]bool),
+				recentlyLaunched: make(map[string]bool),
+			}
+			if tc.types != nil {
+				s.instanceTypes = tc.types
+			}
+			if tc.maxInstances != 0 {
+				s.maxInstanceNum = tc.maxInstances
+			}
+			for id, inst := range tc.recent {
+				s.recentlyLaunched[id] = true
+				s.pending[id] = inst.State == SpotRequestPendingAWS
+			}
+			assert.DeepEqual(t, s.calculateInstancesToLaunch(), tc.toLaunch,
+				cmp.AllowUnexported(launchDecision{}))
+		})
+	}
+}
+
+func TestUpdateInstanceLimits(t *testing.T) {
+	four, zero := 4, 0
+	s := newScaleDecider(time.Minute, time.Minute, time.Minute, 1, 10, []CapacitySchedule{
+		{Name: "business hours", StartTime: "09:00", EndTime: "17:00", MinInstances: &four},
+		{Name: "nights", StartTime: "22:00", EndTime: "06:00", MaxInstances: &zero},
+		{Name: "afternoons", StartTime: "12:00", EndTime: "18:00", MaxInstances: &zero},
+	}, nil)
+	at := func(clock string) time.Time {
+		t, err := time.Parse(time.RFC3339, "2020-10-19T"+clock+":00Z")
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	assert.Assert(t, !s.updateInstanceLimits(at("08:00")))
+	assert.Assert(t, s.activeSchedule == nil)
+	assert.Equal(t, s.minInstanceNum, 1)
+	assert.Equal(t, s.maxInstanceNum, 10)
+
+	// The first active schedule takes effect.
+	assert.Assert(t, s.updateInstanceLimits(at("12:00")))
+	assert.Equal(t, s.activeSchedule.Name, "business hours")
+	assert.Equal(t, s.minInstanceNum, 4)
+	assert.Equal(t, s.maxInstanceNum, 10)
+	assert.Assert(t, !s.updateInstanceLimits(at("13:00")))
+
+	assert.Assert(t, s.updateInstanceLimits(at("23:00")))
+	assert.Equal(t, s.activeSchedule.Name, "nights")
+	assert.Equal(t, s.minInstanceNum, 1)
+	assert.Equal(t, s.maxInstanceNum, 0)
+
+	assert.Assert(t, s.updateInstanceLimits(at("20:00")))
+	assert.Assert(t, s.activeSchedule == nil)
+	assert.Equal(t, s.minInstanceNum, 1)
+	assert.Equal(t, s.maxInstanceNum, 10)
+}
+
+func TestFindInstancesToTerminateWithScheduledMaximum(t *testing.T) {
+	one := 1
+	s := newScaleDecider(time.Minute, time.Minute, time.Minute, 0, 3, []CapacitySchedule{
+		{Name: "nights", StartTime: "22:00", EndTime: "06:00", MaxInstances: &one},
+	}, nil)
+	at := func(clock string) time.Time {
+		t, err := time.Parse(time.RFC3339, "2020-10-19T"+clock+":00Z")
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	launched := at("08:00")
+	s.instances = map[string]*Instance{
+		"busy-1": {ID: "busy-1", LaunchTime: launched},
+		"busy-2": {ID: "busy-2", LaunchTime: launched.Add(time.Minute)},
+		"idle":   {ID: "idle", LaunchTime: launched.Add(2 * time.Minute)},
+	}
+	s.idle = map[string]time.Time{"idle": at("21:00")}
+
+	// When the schedule lowers the maximum, only the idle instance is terminated.
+	assert.Assert(t, s.updateInstanceLimits(at("23:00")))
+	toTerminate := s.findInstancesToTerminate()
+	assert.DeepEqual(t,
+		newInstanceIDSet(toTerminate.InstanceIDs), newInstanceIDSet([]string{"idle"}))
+
+	// Busy instances are terminated once they are idle.
+	delete(s.instances, "idle")
+	toTerminate = s.findInstancesToTerminate()
+	assert.Equal(t, len(toTerminate.InstanceIDs), 0)
+	s.idle = map[string]time.Time{"busy-2": at("23:30")}
+	toTerminate = s.findInstancesToTerminate()
+	assert.DeepEqual(t,
+		newInstanceIDSet(toTerminate.InstanceIDs), newInstanceIDSet([]string{"busy-2"}))
+	delete(s.instances, "busy-2")
+	assert.Equal(t, s.busyMaxInstanceNum, 1)
+
+	// Busy instances above the static maximum are still terminated.
+	s = newScaleDecider(time.Minute, time.Minute, time.Minute, 0, 1, nil, nil)
+	s.instances = map[string]*Instance{
+		"busy-1": {ID: "busy-1", LaunchTime: launched},
+		"busy-2": {ID: "busy-2", LaunchTime: launched.Add(time.Minute)},
+	}
+	toTerminate = s.findInstancesToTerminate()
+	assert.DeepEqual(t,
+		newInstanceIDSet(toTerminate.InstanceIDs), newInstanceIDSet([]string{"busy-2"}))
+}
@@ -22,6 +22,9 @@ func Setup(
 	if config.GCP != nil {
 		ctx.Log().Info("connecting to GCP")
 	}
+	if config.External != nil {
+		ctx.Log().Info("delegating to an external cluster")
+	}
 	provisioner, err := New(resourcePool, config, cert)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "error creating provisioner")
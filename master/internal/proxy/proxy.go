@@ -39,10 +39,13 @@ type (
 )
 
 // Service represents a registered service. The LastRequested field is used by
-// the Tensorboard manager to spin down idle instances of Tensorboard.
+// the Tensorboard manager to spin down idle instances of Tensorboard, and along with Connections,
+// the number of open WebSocket and CONNECT connections, by notebooks and shells to detect that
+// they are idle.
 type Service struct {
 	URL           *url.URL
 	LastRequested time.Time
+	Connections   int
 }
 
 // Proxy is an actor that proxies requests to registered services.
@@ -63,7 +66,7 @@ func (p *Proxy) Receive(ctx *actor.Context) error {
 		p.lock.Lock()
 		defer p.lock.Unlock()
 		ctx.Log().Infof("registering service: %s (%v)", msg.ServiceID, msg.URL)
-		p.services[msg.ServiceID] = &Service{msg.URL, time.Now()}
+		p.services[msg.ServiceID] = &Service{URL: msg.URL, LastRequested: time.Now()}
 
 		if ctx.ExpectingResponse() {
 			ctx.Respond(nil)
@@ -101,6 +104,24 @@ func (p *Proxy) getTargetURL(serviceName string) *url.URL {
 	return &sURL
 }
 
+// trackConnection records that a long-lived connection to the service was opened, and returns a
+// function that records that it was closed.
+func (p *Proxy) trackConnection(serviceName string) func() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	service := p.services[serviceName]
+	if service == nil {
+		return func() {}
+	}
+	service.Connections++
+	return func() {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		service.Connections--
+		service.LastRequested = time.Now()
+	}
+}
+
 // Service a normal (non-CONNECT) HTTP request through the /proxy/:service/* route.
 func (p *Proxy) newProxyHandler(serviceID string) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -127,6 +148,7 @@ func (p *Proxy) newProxyHandler(serviceID string) echo.HandlerFunc {
 		// Proxy the request to the target host.
 		var proxy http.Handler
 		if c.IsWebSocket() {
+			defer p.trackConnection(serviceName)()
 			proxy = newSingleHostReverseWebSocketProxy(c, serviceURL)
 		} else {
 			proxy = httputil.NewSingleHostReverseProxy(serviceURL)
@@ -153,6 +175,7 @@ func (p *Proxy) newConnectHandler() echo.HandlerFunc {
 				fmt.Sprintf("service not found: %s", serviceName))
 		}
 
+		defer p.trackConnection(serviceName)()
 		proxy := newSingleHostReverseTCPProxy(c, target)
 
 		proxy.ServeHTTP(c.Response(), c.Request())
@@ -168,7 +191,7 @@ func (p *Proxy) getSummary() map[string]Service {
 
 	for id, service := range p.services {
 		sURL := *service.URL
-		snapshot[id] = Service{&sURL, service.LastRequested}
+		snapshot[id] = Service{&sURL, service.LastRequested, service.Connections}
 	}
 
 	return snapshot
@@ -0,0 +1,295 @@
+package rbac
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// RegisterAPIHandler initializes and registers the API handlers for managing workspaces, groups
+// and role assignments.
+func RegisterAPIHandler(echo *echo.Echo, a *Authorizer, middleware ...echo.MiddlewareFunc) {
+	workspacesGroup := echo.Group("/workspaces", middleware...)
+	workspacesGroup.GET("", api.Route(a.getWorkspaces))
+	workspacesGroup.POST("", api.Route(a.postWorkspace))
+	workspacesGroup.PUT("/:workspace/experiments/:experiment_id",
+		api.Route(a.putWorkspaceExperiment))
+	workspacesGroup.DELETE("/:workspace/experiments/:experiment_id",
+		api.Route(a.deleteWorkspaceExperiment))
+
+	groupsGroup := echo.Group("/groups", middleware...)
+	groupsGroup.GET("", api.Route(a.getGroups))
+	groupsGroup.POST("", api.Route(a.postGroup))
+	groupsGroup.PUT("/:group/users/:username", api.Route(a.putGroupMember))
+	groupsGroup.DELETE("/:group/users/:username", api.Route(a.deleteGroupMember))
+
+	roleAssignmentsGroup := echo.Group("/role-assignments", middleware...)
+	roleAssignmentsGroup.GET("", api.Route(a.getRoleAssignments))
+	roleAssignmentsGroup.POST("", api.Route(a.postRoleAssignment))
+	roleAssignmentsGroup.DELETE("/:assignment_id", api.Route(a.deleteRoleAssignment))
+}
+
+// requireAdmin returns an error unless the authenticated user is an admin.
+func requireAdmin(c echo.Context) error {
+	if !c.(*context.DetContext).MustGetUser().Admin {
+		return echo.NewHTTPError(http.StatusForbidden, "insufficient permissions")
+	}
+	return nil
+}
+
+// bindBody unmarshals the JSON body of the request into params.
+func bindBody(c echo.Context, params interface{}) error {
+	body, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(body, params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "bad request")
+	}
+	return nil
+}
+
+// notFoundOr returns an HTTP 400 error naming the missing record if the error is ErrNotFound.
+func notFoundOr(err error, format string, args ...interface{}) error {
+	if errors.Cause(err) == db.ErrNotFound {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(format, args...))
+	}
+	return err
+}
+
+func (a *Authorizer) getWorkspaces(c echo.Context) (interface{}, error) {
+	return a.db.Workspaces()
+}
+
+func (a *Authorizer) postWorkspace(c echo.Context) (interface{}, error) {
+	if err := requireAdmin(c); err != nil {
+		return nil, err
+	}
+	var workspace model.Workspace
+	if err := bindBody(c, &workspace); err != nil {
+		return nil, err
+	}
+	if workspace.Name == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "name is required")
+	}
+	switch err := a.db.AddWorkspace(&workspace); {
+	case err == db.ErrDuplicateRecord:
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "workspace already exists")
+	case err != nil:
+		return nil, err
+	}
+	return workspace, nil
+}
+
+func (a *Authorizer) putWorkspaceExperiment(c echo.Context) (interface{}, error) {
+	if err := requireAdmin(c); err != nil {
+		return nil, err
+	}
+	args := struct {
+		Workspace    string `path:"workspace"`
+		ExperimentID int    `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	workspace, err := a.db.WorkspaceByName(args.Workspace)
+	if err != nil {
+		return nil, notFoundOr(err, "workspace not found: %s", args.Workspace)
+	}
+	if err = a.db.SetExperimentWorkspace(args.ExperimentID, &workspace.ID); err != nil {
+		return nil, notFoundOr(err, "experiment not found: %d", args.ExperimentID)
+	}
+	return nil, c.NoContent(http.StatusNoContent)
+}
+
+func (a *Authorizer) deleteWorkspaceExperiment(c echo.Context) (interface{}, error) {
+	if err := requireAdmin(c); err != nil {
+		return nil, err
+	}
+	args := struct {
+		Workspace    string `path:"workspace"`
+		ExperimentID int    `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	access, err := a.db.ExperimentAccessByID(args.ExperimentID)
+	if err != nil {
+		return nil, notFoundOr(err, "experiment not found: %d", args.ExperimentID)
+	}
+	workspace, err := a.db.WorkspaceByName(args.Workspace)
+	if err != nil {
+		return nil, notFoundOr(err, "workspace not found: %s", args.Workspace)
+	}
+	if access.WorkspaceID == nil || *access.WorkspaceID != workspace.ID {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"experiment %d is not in workspace %s", args.ExperimentID, args.Workspace))
+	}
+	if err = a.db.SetExperimentWorkspace(args.ExperimentID, nil); err != nil {
+		return nil, err
+	}
+	return nil, c.NoContent(http.StatusNoContent)
+}
+
+func (a *Authorizer) getGroups(c echo.Context) (interface{}, error) {
+	if err := requireAdmin(c); err != nil {
+		return nil, err
+	}
+	return a.db.Groups()
+}
+
+func (a *Authorizer) postGroup(c echo.Context) (interface{}, error) {
+	if err := requireAdmin(c); err != nil {
+		return nil, err
+	}
+	var group model.Group
+	if err := bindBody(c, &group); err != nil {
+		return nil, err
+	}
+	if group.Name == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "name is required")
+	}
+	switch err := a.db.AddGroup(&group); {
+	case err == db.ErrDuplicateRecord:
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "group already exists")
+	case err != nil:
+		return nil, err
+	}
+	group.Usernames = []string{}
+	return group, nil
+}
+
+// groupMember looks up the group and the user named in the path of the request.
+func (a *Authorizer) groupMember(c echo.Context) (*model.Group, *model.User, error) {
+	args := struct {
+		Group    string `path:"group"`
+		Username string `path:"username"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, nil, err
+	}
+	group, err := a.db.GroupByName(args.Group)
+	if err != nil {
+		return nil, nil, notFoundOr(err, "group not found: %s", args.Group)
+	}
+	user, err := a.db.UserByUsername(args.Username)
+	if err != nil {
+		return nil, nil, notFoundOr(err, "user not found: %s", args.Username)
+	}
+	return group, user, nil
+}
+
+func (a *Authorizer) putGroupMember(c echo.Context) (interface{}, error) {
+	if err := requireAdmin(c); err != nil {
+		return nil, err
+	}
+	group, user, err := a.groupMember(c)
+	if err != nil {
+		return nil, err
+	}
+	if err = a.db.AddGroupMember(group.ID, user.ID); err != nil {
+		return nil, err
+	}
+	return nil, c.NoContent(http.StatusNoContent)
+}
+
+func (a *Authorizer) deleteGroupMember(c echo.Context) (interface{}, error) {
+	if err := requireAdmin(c); err != nil {
+		return nil, err
+	}
+	group, user, err := a.groupMember(c)
+	if err != nil {
+		return nil, err
+	}
+	if err = a.db.DeleteGroupMember(group.ID, user.ID); err != nil {
+		return nil, err
+	}
+	return nil, c.NoContent(http.StatusNoContent)
+}
+
+func (a *Authorizer) getRoleAssignments(c echo.Context) (interface{}, error) {
+	if err := requireAdmin(c); err != nil {
+		return nil, err
+	}
+	return a.db.RoleAssignments()
+}
+
+func (a *Authorizer) postRoleAssignment(c echo.Context) (interface{}, error) {
+	if err := requireAdmin(c); err != nil {
+		return nil, err
+	}
+	var params struct {
+		Role      string  `json:"role"`
+		Workspace *string `json:"workspace"`
+		Username  *string `json:"username"`
+		Group     *string `json:"group"`
+	}
+	if err := bindBody(c, &params); err != nil {
+		return nil, err
+	}
+
+	role, err := model.ParseRole(params.Role)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	assignment := model.RoleAssignment{
+		Role:      role,
+		Workspace: params.Workspace,
+		Username:  params.Username,
+		GroupName: params.Group,
+	}
+	if params.Workspace != nil {
+		workspace, wErr := a.db.WorkspaceByName(*params.Workspace)
+		if wErr != nil {
+			return nil, notFoundOr(wErr, "workspace not found: %s", *params.Workspace)
+		}
+		assignment.WorkspaceID = &workspace.ID
+	}
+	switch {
+	case (params.Username == nil) == (params.Group == nil):
+		return nil, echo.NewHTTPError(
+			http.StatusBadRequest, "exactly one of username and group is required")
+	case params.Username != nil:
+		user, uErr := a.db.UserByUsername(*params.Username)
+		if uErr != nil {
+			return nil, notFoundOr(uErr, "user not found: %s", *params.Username)
+		}
+		assignment.UserID = &user.ID
+	default:
+		group, gErr := a.db.GroupByName(*params.Group)
+		if gErr != nil {
+			return nil, notFoundOr(gErr, "group not found: %s", *params.Group)
+		}
+		assignment.GroupID = &group.ID
+	}
+
+	if err = a.db.AddRoleAssignment(&assignment); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+func (a *Authorizer) deleteRoleAssignment(c echo.Context) (interface{}, error) {
+	if err := requireAdmin(c); err != nil {
+		return nil, err
+	}
+	args := struct {
+		AssignmentID int `path:"assignment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	if err := a.db.DeleteRoleAssignment(args.AssignmentID); err != nil {
+		return nil, notFoundOr(err, "role assignment not found: %d", args.AssignmentID)
+	}
+	return nil, c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,410 @@
+package rbac
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+var (
+	// ErrNotVisible notifies that the user has no role for the resource, so it is hidden from them.
+	ErrNotVisible = errors.New("resource not found")
+	// ErrPermissionDenied notifies that the role of the user does not permit the action.
+	ErrPermissionDenied = errors.New("user does not have permission")
+)
+
+// Authorizer decides which actions users may take on the resources of the cluster.
+//
+// A user's role in a workspace is the highest role bound to them, directly or through their
+// groups, in the workspace or in the whole cluster. Users without any role bound in the whole
+// cluster are editors of the resources outside of workspaces, so that clusters that bind no roles
+// behave as if there were no access control. Admins have every permission, and owners are editors
+// of their own experiments and commands.
+//
+// Requests that are authenticated with an API token are further restricted by its scope: a
+// read-only token grants at most the viewer role, and a token that is scoped to a workspace grants
+// no access to experiments outside of it nor to any resource that is not in a workspace, such as
+// users, agents and commands.
+type Authorizer struct {
+	db *db.PgDB
+}
+
+// New creates a new authorizer.
+func New(db *db.PgDB) *Authorizer {
+	return &Authorizer{db: db}
+}
+
+// Role returns the role of the user in the workspace, or in the whole cluster if workspaceID is
+// nil.
+func (a *Authorizer) Role(user model.User, workspaceID *int) (model.Role, error) {
+	if user.Admin {
+		return model.RoleAdmin, nil
+	}
+	roles, err := a.db.UserRoles(user.ID, workspaceID)
+	if err != nil {
+		return model.RoleNone, err
+	}
+	if len(roles) == 0 && workspaceID == nil {
+		return model.RoleEditor, nil
+	}
+	return model.MaxRole(roles...), nil
+}
+
+// ExperimentRole returns the role of the user for the experiment.
+func (a *Authorizer) ExperimentRole(
+	user model.User, access model.ExperimentAccess,
+) (model.Role, error) {
+	role, err := a.Role(user, access.WorkspaceID)
+	if err != nil {
+		return model.RoleNone, err
+	}
+	if access.OwnerID != nil && *access.OwnerID == user.ID {
+		return model.MaxRole(role, model.RoleEditor), nil
+	}
+	return role, nil
+}
+
+// CheckAPIToken returns ErrPermissionDenied if the API token is read-only and the role is needed
+// for more than viewing.
+func CheckAPIToken(token *model.APIToken, required model.Role) error {
+	if token != nil && token.ReadOnly && !model.RoleViewer.Includes(required) {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// tokenAllowsWorkspace returns false if the API token is scoped to a workspace other than the
+// given one, which is nil for resources outside of workspaces.
+func tokenAllowsWorkspace(token *model.APIToken, workspaceID *int) bool {
+	return token == nil || token.WorkspaceID == nil ||
+		(workspaceID != nil && *workspaceID == *token.WorkspaceID)
+}
+
+// CheckTokenScope returns ErrPermissionDenied if the API token is scoped to a workspace, for
+// actions on resources that are not in a workspace.
+func CheckTokenScope(token *model.APIToken) error {
+	if token != nil && token.WorkspaceID != nil {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// CheckWorkspace returns an error if the user, authenticated with the API token if it is not nil,
+// may not take actions that require the role in the workspace, or in the whole cluster if
+// workspaceID is nil.
+func (a *Authorizer) CheckWorkspace(
+	user model.User, token *model.APIToken, workspaceID *int, required model.Role,
+) error {
+	if !tokenAllowsWorkspace(token, workspaceID) {
+		return ErrNotVisible
+	}
+	if err := CheckAPIToken(token, required); err != nil {
+		return err
+	}
+	role, err := a.Role(user, workspaceID)
+	if err != nil {
+		return err
+	}
+	return CheckRole(role, required)
+}
+
+// CheckExperiment returns an error if the user, authenticated with the API token if it is not nil,
+// may not take actions that require the role on the experiment. Experiments that do not exist are
+// left to the caller to report.
+func (a *Authorizer) CheckExperiment(
+	user model.User, token *model.APIToken, experimentID int, required model.Role,
+) error {
+	access, err := a.db.ExperimentAccessByID(experimentID)
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil
+	case err != nil:
+		return err
+	}
+	if !tokenAllowsWorkspace(token, access.WorkspaceID) {
+		return ErrNotVisible
+	}
+	if err = CheckAPIToken(token, required); err != nil {
+		return err
+	}
+	role, err := a.ExperimentRole(user, *access)
+	if err != nil {
+		return err
+	}
+	return CheckRole(role, required)
+}
+
+// CheckTrial returns an error if the user may not take actions that require the role on the
+// experiment of the trial.
+func (a *Authorizer) CheckTrial(
+	user model.User, token *model.APIToken, trialID int, required model.Role,
+) error {
+	experimentID, err := a.db.ExperimentIDByTrialID(trialID)
+	switch {
+	case errors.Cause(err) == sql.ErrNoRows:
+		return nil
+	case err != nil:
+		return err
+	}
+	return a.CheckExperiment(user, token, experimentID, required)
+}
+
+// CheckCheckpoint returns an error if the user may not take actions that require the role on the
+// experiment of the checkpoint. Checkpoints that do not exist are left to the caller to report.
+func (a *Authorizer) CheckCheckpoint(
+	user model.User, token *model.APIToken, checkpointUUID string, required model.Role,
+) error {
+	if _, err := uuid.Parse(checkpointUUID); err != nil {
+		return nil
+	}
+	experimentID, err := a.db.ExperimentIDByCheckpointUUID(checkpointUUID)
+	switch {
+	case errors.Cause(err) == sql.ErrNoRows:
+		return nil
+	case err != nil:
+		return err
+	}
+	return a.CheckExperiment(user, token, experimentID, required)
+}
+
+// CheckTensorboard returns an error if the user may not view any of the experiments and trials
+// that a TensorBoard is launched for.
+func (a *Authorizer) CheckTensorboard(
+	user model.User, token *model.APIToken, experimentIDs, trialIDs []int,
+) error {
+	for _, id := range experimentIDs {
+		if err := a.CheckExperiment(user, token, id, model.RoleViewer); err != nil {
+			return err
+		}
+	}
+	for _, id := range trialIDs {
+		if err := a.CheckTrial(user, token, id, model.RoleViewer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckCommand returns an error if the user may not take actions that require the role on a
+// command, notebook, shell or TensorBoard owned by the given user.
+func (a *Authorizer) CheckCommand(
+	user model.User, token *model.APIToken, owner string, required model.Role,
+) error {
+	if err := CheckTokenScope(token); err != nil {
+		return err
+	}
+	if err := CheckAPIToken(token, required); err != nil {
+		return err
+	}
+	if user.Username == owner {
+		return nil
+	}
+	role, err := a.Role(user, nil)
+	if err != nil {
+		return err
+	}
+	return CheckRole(role, required)
+}
+
+// CheckCluster returns an error if the user does not have the role in the whole cluster.
+func (a *Authorizer) CheckCluster(
+	user model.User, token *model.APIToken, required model.Role,
+) error {
+	if err := CheckTokenScope(token); err != nil {
+		return err
+	}
+	if err := CheckAPIToken(token, required); err != nil {
+		return err
+	}
+	role, err := a.Role(user, nil)
+	if err != nil {
+		return err
+	}
+	return CheckRole(role, required)
+}
+
+// CheckUser returns an error if the user may not change the account of the user with the given
+// username: users may change their own account and admins any account.
+func (a *Authorizer) CheckUser(user model.User, token *model.APIToken, username string) error {
+	if user.Username == username {
+		if err := CheckTokenScope(token); err != nil {
+			return err
+		}
+		return CheckAPIToken(token, model.RoleEditor)
+	}
+	return a.CheckCluster(user, token, model.RoleAdmin)
+}
+
+// VisibleExperiments returns a function that reports whether the experiment with the given ID is
+// visible to the user, authenticated with the API token if it is not nil.
+func (a *Authorizer) VisibleExperiments(
+	user model.User, token *model.APIToken,
+) (func(experimentID int) bool, error) {
+	scoped := token != nil && token.WorkspaceID != nil
+	if user.Admin && !scoped {
+		return func(int) bool { return true }, nil
+	}
+	accesses, err := a.db.WorkspaceExperimentAccess()
+	if err != nil {
+		return nil, err
+	}
+	if scoped {
+		// Only the experiments in the workspace of the token are visible.
+		role, rErr := a.Role(user, token.WorkspaceID)
+		if rErr != nil {
+			return nil, rErr
+		}
+		visible := make(map[int]bool)
+		for _, access := range accesses {
+			owned := access.OwnerID != nil && *access.OwnerID == user.ID
+			visible[access.ID] = *access.WorkspaceID == *token.WorkspaceID &&
+				(role != model.RoleNone || owned)
+		}
+		return func(experimentID int) bool { return visible[experimentID] }, nil
+	}
+	roles := make(map[int]model.Role)
+	hidden := make(map[int]bool)
+	for _, access := range accesses {
+		workspaceID := *access.WorkspaceID
+		role, ok := roles[workspaceID]
+		if !ok {
+			if role, err = a.Role(user, &workspaceID); err != nil {
+				return nil, err
+			}
+			roles[workspaceID] = role
+		}
+		owned := access.OwnerID != nil && *access.OwnerID == user.ID
+		hidden[access.ID] = role == model.RoleNone && !owned
+	}
+	return func(experimentID int) bool { return !hidden[experimentID] }, nil
+}
+
+// CheckRole returns ErrNotVisible if the user has no role and ErrPermissionDenied if their role
+// does not include the required role.
+func CheckRole(role model.Role, required model.Role) error {
+	switch {
+	case role.Includes(required):
+		return nil
+	case role == model.RoleNone:
+		return ErrNotVisible
+	default:
+		return ErrPermissionDenied
+	}
+}
+
+// requiredRole returns the role needed to make the HTTP request.
+func requiredRole(method string) model.Role {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return model.RoleViewer
+	default:
+		return model.RoleEditor
+	}
+}
+
+// scopedTokenRoutes are the routes that do not refer to an experiment or a trial but may be
+// requested with API tokens that are scoped to a workspace, because their responses only include
+// what is visible to the token or, for creating experiments, because the handler checks the
+// workspace of the new experiment.
+var scopedTokenRoutes = map[string]bool{
+	"GET /experiments":          true,
+	"POST /experiments":         true,
+	"GET /experiment-list":      true,
+	"GET /experiment-summaries": true,
+	"GET /users/me":             true,
+}
+
+// checkRoute returns an error if the user may not make the request to a route that refers to an
+// experiment, a trial or a checkpoint, either in the path or in the query, or that launches a
+// TensorBoard.
+func (a *Authorizer) checkRoute(
+	c echo.Context, user model.User, token *model.APIToken, required model.Role,
+) error {
+	param := func(name string) string {
+		if value := c.Param(name); value != "" {
+			return value
+		}
+		return c.QueryParam(name)
+	}
+	if id, err := strconv.Atoi(param("experiment_id")); err == nil {
+		return a.CheckExperiment(user, token, id, required)
+	}
+	if id, err := strconv.Atoi(param("trial_id")); err == nil {
+		return a.CheckTrial(user, token, id, required)
+	}
+	if id := c.Param("checkpoint_uuid"); id != "" {
+		return a.CheckCheckpoint(user, token, id, required)
+	}
+	if err := CheckTokenScope(token); err != nil &&
+		!scopedTokenRoutes[c.Request().Method+" "+c.Path()] {
+		return err
+	}
+	if c.Request().Method == http.MethodPost && strings.HasPrefix(c.Path(), "/tensorboard") {
+		// Read the experiments and trials from the body and leave it for the handler.
+		body, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		c.Request().Body = ioutil.NopCloser(bytes.NewReader(body))
+		var params struct {
+			ExperimentIDs []int `json:"experiment_ids"`
+			TrialIDs      []int `json:"trial_ids"`
+		}
+		if err = json.Unmarshal(body, &params); err != nil {
+			// The handler reports invalid requests.
+			return nil
+		}
+		return a.CheckTensorboard(user, token, params.ExperimentIDs, params.TrialIDs)
+	}
+	return nil
+}
+
+// ProcessAuthorization is a middleware processing function that checks whether the authenticated
+// user may make the request, for routes that refer to an experiment, a trial or a checkpoint and
+// for launching TensorBoards. Viewing requires the viewer role and any other method the editor
+// role; read-only API tokens may only view, and API tokens scoped to a workspace may only request
+// routes of its experiments and trials. It also records the role of the user in the whole cluster
+// on the request context. It must be used after the authentication middleware.
+func (a *Authorizer) ProcessAuthorization(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		detContext := c.(*context.DetContext)
+		user := detContext.MustGetUser()
+		token := detContext.APIToken()
+		required := requiredRole(c.Request().Method)
+
+		err := CheckAPIToken(token, required)
+		if err == nil {
+			err = a.checkRoute(c, user, token, required)
+		}
+		switch {
+		case err == ErrNotVisible:
+			return echo.NewHTTPError(http.StatusNotFound)
+		case err == ErrPermissionDenied:
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		case err != nil:
+			return err
+		}
+
+		role, err := a.Role(user, nil)
+		if err != nil {
+			return err
+		}
+		if token != nil && token.ReadOnly && role.Includes(model.RoleViewer) {
+			role = model.RoleViewer
+		}
+		detContext.SetClusterRole(role)
+		return next(c)
+	}
+}
@@ -0,0 +1,90 @@
+package rbac
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestCheckRole(t *testing.T) {
+	assert.NilError(t, CheckRole(model.RoleEditor, model.RoleViewer))
+	assert.Equal(t, CheckRole(model.RoleViewer, model.RoleEditor), ErrPermissionDenied)
+	assert.Equal(t, CheckRole(model.RoleNone, model.RoleViewer), ErrNotVisible)
+}
+
+func TestCheckAPIToken(t *testing.T) {
+	assert.NilError(t, CheckAPIToken(nil, model.RoleAdmin))
+	assert.NilError(t, CheckAPIToken(&model.APIToken{}, model.RoleEditor))
+
+	readOnly := &model.APIToken{ReadOnly: true}
+	assert.NilError(t, CheckAPIToken(readOnly, model.RoleViewer))
+	assert.Equal(t, CheckAPIToken(readOnly, model.RoleEditor), ErrPermissionDenied)
+}
+
+func TestTokenAllowsWorkspace(t *testing.T) {
+	one, two := 1, 2
+	assert.Assert(t, tokenAllowsWorkspace(nil, &one))
+	assert.Assert(t, tokenAllowsWorkspace(&model.APIToken{}, nil))
+
+	scoped := &model.APIToken{WorkspaceID: &one}
+	assert.Assert(t, tokenAllowsWorkspace(scoped, &one))
+	assert.Assert(t, !tokenAllowsWorkspace(scoped, &two))
+	assert.Assert(t, !tokenAllowsWorkspace(scoped, nil))
+}
+
+func TestCheckTokenScope(t *testing.T) {
+	one := 1
+	assert.NilError(t, CheckTokenScope(nil))
+	assert.NilError(t, CheckTokenScope(&model.APIToken{}))
+	assert.Equal(t, CheckTokenScope(&model.APIToken{WorkspaceID: &one}), ErrPermissionDenied)
+}
+
+func TestScopedTokenOutsideWorkspaces(t *testing.T) {
+	one := 1
+	a := New(nil)
+	admin := model.User{Username: "admin", Admin: true}
+	scoped := &model.APIToken{WorkspaceID: &one}
+
+	assert.NilError(t, a.CheckCluster(admin, &model.APIToken{}, model.RoleAdmin))
+	assert.Equal(t, a.CheckCluster(admin, scoped, model.RoleAdmin), ErrPermissionDenied)
+	assert.Equal(t, a.CheckCluster(admin, scoped, model.RoleViewer), ErrPermissionDenied)
+	assert.Equal(t, a.CheckCommand(admin, scoped, "admin", model.RoleEditor), ErrPermissionDenied)
+	assert.NilError(t, a.CheckUser(admin, nil, "someone"))
+	assert.Equal(t, a.CheckUser(admin, scoped, "someone"), ErrPermissionDenied)
+	assert.Equal(t, a.CheckUser(admin, scoped, "admin"), ErrPermissionDenied)
+}
+
+func TestCheckRoute(t *testing.T) {
+	one := 1
+	a := New(nil)
+	admin := model.User{Username: "admin", Admin: true}
+	scoped := &model.APIToken{WorkspaceID: &one}
+	e := echo.New()
+	newContext := func(method, path, body string) echo.Context {
+		c := e.NewContext(httptest.NewRequest(method, path, strings.NewReader(body)), nil)
+		c.SetPath(path)
+		return c
+	}
+
+	assert.NilError(t, a.checkRoute(newContext(http.MethodGet, "/experiments", ""),
+		admin, scoped, model.RoleViewer))
+	assert.Equal(t, a.checkRoute(newContext(http.MethodGet, "/agents", ""),
+		admin, scoped, model.RoleViewer), ErrPermissionDenied)
+	assert.Equal(t, a.checkRoute(newContext(http.MethodPost, "/tensorboard*", "{}"),
+		admin, scoped, model.RoleEditor), ErrPermissionDenied)
+
+	// The body of TensorBoard launches is left for the handler.
+	body := `{"experiment_ids": [], "trial_ids": []}`
+	c := newContext(http.MethodPost, "/tensorboard*", body)
+	assert.NilError(t, a.checkRoute(c, admin, nil, model.RoleEditor))
+	left, err := ioutil.ReadAll(c.Request().Body)
+	assert.NilError(t, err)
+	assert.Equal(t, string(left), body)
+}
@@ -15,6 +15,12 @@ type agentState struct {
 	devices map[device.Device]*cproto.ID
 	label   string
 
+	// topology is the slash-separated network topology domain of the agent, from the widest
+	// level to the narrowest, e.g. "zone-a/rack-3". It is empty if the agent did not report one.
+	topology string
+	// draining is true if no tasks are scheduled on the agent because it is being drained.
+	draining bool
+
 	// Since we only model GPUs as devices/slots and assume each slot can be allocated with
 	// one container, we add one additional field to keep track of zero-slot containers.
 	// We need this field to know if the agent is idle.
@@ -26,6 +32,7 @@ func newAgentState(msg sproto.AddAgent) *agentState {
 	return &agentState{
 		handler:            msg.Agent,
 		label:              msg.Label,
+		topology:           msg.Topology,
 		devices:            make(map[device.Device]*cproto.ID),
 		zeroSlotContainers: make(map[cproto.ID]bool),
 	}
@@ -69,3 +76,42 @@ func (a *agentState) allocateFreeDevices(slots int, id cproto.ID) []device.Devic
 	check.Panic(check.True(len(devices) == slots, "not enough devices"))
 	return devices
 }
+
+// deepCopy returns a copy of the agent state whose devices can be allocated and freed without
+// affecting the original; it is used to plan scheduling decisions.
+func (a *agentState) deepCopy() *agentState {
+	copied := &agentState{
+		handler:            a.handler,
+		label:              a.label,
+		topology:           a.topology,
+		draining:           a.draining,
+		devices:            make(map[device.Device]*cproto.ID, len(a.devices)),
+		zeroSlotContainers: make(map[cproto.ID]bool, len(a.zeroSlotContainers)),
+	}
+	for d, id := range a.devices {
+		copied.devices[d] = id
+	}
+	for id := range a.zeroSlotContainers {
+		copied.zeroSlotContainers[id] = true
+	}
+	return copied
+}
+
+// deallocateContainer frees the devices that are allocated to the container.
+func (a *agentState) deallocateContainer(id cproto.ID) {
+	delete(a.zeroSlotContainers, id)
+	for d, cid := range a.devices {
+		if cid != nil && *cid == id {
+			a.devices[d] = nil
+		}
+	}
+}
+
+// copyAgentStates returns copies of all of the agent states, keyed by the same handlers.
+func copyAgentStates(agents map[*actor.Ref]*agentState) map[*actor.Ref]*agentState {
+	copied := make(map[*actor.Ref]*agentState, len(agents))
+	for handler, agent := range agents {
+		copied[handler] = agent.deepCopy()
+	}
+	return copied
+}
@@ -2,6 +2,7 @@ package resourcemanagers
 
 import (
 	"crypto/tls"
+	"sort"
 
 	"github.com/pkg/errors"
 
@@ -43,6 +44,8 @@ func (a *agentResourceManager) Receive(ctx *actor.Context) error {
 			msg.ResourcePool = a.getDefaultResourcePool(msg)
 		}
 		a.forwardToPool(ctx, msg.ResourcePool, msg)
+	case RestoreAllocation:
+		a.forwardToPool(ctx, msg.ResourcePool, msg)
 	case ResourcesReleased:
 		a.forwardToAllPools(ctx, msg)
 
@@ -55,8 +58,12 @@ func (a *agentResourceManager) Receive(ctx *actor.Context) error {
 		}
 	case GetTaskSummaries:
 		ctx.Respond(a.aggregateTaskSummaries(a.forwardToAllPools(ctx, msg)))
-	case SetTaskName:
+	case SetTaskName, TaskCheckpointed:
 		a.forwardToAllPools(ctx, msg)
+	case GetQuotaSummaries:
+		ctx.Respond(a.aggregateQuotaSummaries(a.forwardToAllPools(ctx, msg)))
+	case GetResourcePoolSummaries:
+		ctx.Respond(a.aggregateResourcePoolSummaries(a.forwardToAllPools(ctx, msg)))
 
 	default:
 		return actor.ErrUnexpectedMessage(ctx)
@@ -82,7 +89,7 @@ func (a *agentResourceManager) createResourcePool(
 	rp := NewResourcePool(
 		&config,
 		cert,
-		MakeScheduler(config.Scheduler.getType()),
+		MakeScheduler(config.Scheduler),
 		MakeFitFunction(config.Scheduler.FittingPolicy),
 	)
 	ref, ok := ctx.ActorOf(config.PoolName, rp)
@@ -156,3 +163,29 @@ func (a *agentResourceManager) aggregateTaskSummaries(
 	}
 	return summaries
 }
+
+func (a *agentResourceManager) aggregateQuotaSummaries(
+	resps map[*actor.Ref]actor.Message,
+) []QuotaSummary {
+	summaries := make([]QuotaSummary, 0)
+	for _, resp := range resps {
+		if resp != nil {
+			summaries = append(summaries, resp.([]QuotaSummary)...)
+		}
+	}
+	sortQuotaSummaries(summaries)
+	return summaries
+}
+
+func (a *agentResourceManager) aggregateResourcePoolSummaries(
+	resps map[*actor.Ref]actor.Message,
+) []ResourcePoolSummary {
+	summaries := make([]ResourcePoolSummary, 0, len(resps))
+	for _, resp := range resps {
+		if resp != nil {
+			summaries = append(summaries, resp.(ResourcePoolSummary))
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries
+}
@@ -0,0 +1,168 @@
+package resourcemanagers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+)
+
+type backfillScheduler struct{}
+
+// NewBackfillScheduler creates a new scheduler that schedules tasks in order of the priority of
+// their groups, like the priority scheduler, but reserves agents for the first pending task that
+// does not fit so that it is not starved by smaller tasks. The reserved agents are those that the
+// task would be placed on once enough running tasks complete, according to their estimated
+// durations. Later tasks only backfill the reserved agents if they are expected to complete before
+// the reservation starts.
+func NewBackfillScheduler() Scheduler {
+	return &backfillScheduler{}
+}
+
+func (b *backfillScheduler) Schedule(rp *ResourcePool) ([]*AllocateRequest, []*actor.Ref) {
+	return backfillSchedule(
+		rp.taskList, rp.groups, rp.agents, rp.fittingMethod, rp.taskList.now()), nil
+}
+
+// backfillReservation holds the agents that a pending task will be placed on and the time at
+// which they are expected to be free, or nil if it is not known.
+type backfillReservation struct {
+	agents map[*actor.Ref]bool
+	start  *time.Time
+}
+
+// allows returns true if the task is expected to complete before the reservation starts, so that
+// it can run on the reserved agents without delaying the reserved task.
+func (r *backfillReservation) allows(req *AllocateRequest, now time.Time) bool {
+	return r.start != nil && req.EstimatedDuration > 0 &&
+		!now.Add(req.EstimatedDuration).After(*r.start)
+}
+
+// runningTask holds the containers of a task that has been allocated resources and the time at
+// which it is expected to complete, or nil if it is not known.
+type runningTask struct {
+	containers []cproto.ID
+	end        *time.Time
+}
+
+func backfillSchedule(
+	taskList *taskList,
+	groups map[*actor.Ref]*group,
+	agents map[*actor.Ref]*agentState,
+	fittingMethod SoftConstraint,
+	now time.Time,
+) []*AllocateRequest {
+	toAllocate := make([]*AllocateRequest, 0)
+
+	// Zero-slot tasks do not compete for slots, so they are scheduled regardless of priority.
+	for it := taskList.iterator(); it.next(); {
+		req := it.value()
+		if req.SlotsNeeded == 0 && taskList.GetAllocations(req.TaskActor) == nil {
+			if fits := findFits(req, agents, fittingMethod); len(fits) != 0 {
+				toAllocate = append(toAllocate, req)
+			}
+		}
+	}
+
+	var running []runningTask
+	for it := taskList.iterator(); it.next(); {
+		req := it.value()
+		allocated := taskList.GetAllocations(req.TaskActor)
+		if req.SlotsNeeded == 0 || allocated == nil || len(allocated.Allocations) == 0 {
+			continue
+		}
+		task := runningTask{containers: containerIDs(allocated)}
+		if end, ok := taskList.EstimatedEnd(req); ok {
+			task.end = &end
+		}
+		running = append(running, task)
+	}
+
+	agents = copyAgentStates(agents)
+	priorities, pending, _ := sortTasksByPriority(taskList, groups)
+
+	var reservation *backfillReservation
+	for _, priority := range priorities {
+		for _, req := range pending[priority] {
+			if req.SlotsNeeded == 0 {
+				continue
+			}
+
+			available := agents
+			if reservation != nil && !reservation.allows(req, now) {
+				available = make(map[*actor.Ref]*agentState, len(agents))
+				for handler, agent := range agents {
+					if !reservation.agents[handler] {
+						available[handler] = agent
+					}
+				}
+			}
+			if fits := findFits(req, available, fittingMethod); len(fits) != 0 {
+				for _, fit := range fits {
+					fit.Agent.allocateFreeDevices(fit.Slots, cproto.ID(req.ID))
+				}
+				toAllocate = append(toAllocate, req)
+
+				task := runningTask{containers: []cproto.ID{cproto.ID(req.ID)}}
+				if req.EstimatedDuration > 0 {
+					end := now.Add(req.EstimatedDuration)
+					task.end = &end
+				}
+				running = append(running, task)
+				continue
+			}
+
+			if reservation == nil {
+				if reservation = reserve(req, running, agents, fittingMethod); reservation != nil {
+					taskList.SetReservation(req.TaskActor, reservation.start)
+				}
+			}
+		}
+	}
+	if reservation == nil {
+		taskList.SetReservation(nil, nil)
+	}
+
+	return toAllocate
+}
+
+// reserve finds the agents that the pending task will be placed on by freeing the resources of
+// running tasks in order of their estimated completion, where tasks without an estimate complete
+// last. It returns nil if the task does not fit even once all of the running tasks complete.
+func reserve(
+	req *AllocateRequest,
+	running []runningTask,
+	agents map[*actor.Ref]*agentState,
+	fittingMethod SoftConstraint,
+) *backfillReservation {
+	running = append([]runningTask(nil), running...)
+	sort.SliceStable(running, func(i, j int) bool {
+		a, b := running[i].end, running[j].end
+		switch {
+		case a == nil:
+			return false
+		case b == nil:
+			return true
+		default:
+			return a.Before(*b)
+		}
+	})
+
+	freed := copyAgentStates(agents)
+	for _, task := range running {
+		for _, id := range task.containers {
+			for _, agent := range freed {
+				agent.deallocateContainer(id)
+			}
+		}
+		if fits := findFits(req, freed, fittingMethod); len(fits) != 0 {
+			reserved := make(map[*actor.Ref]bool, len(fits))
+			for _, fit := range fits {
+				reserved[fit.Agent.handler] = true
+			}
+			return &backfillReservation{agents: reserved, start: task.end}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package resourcemanagers
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// setEstimate makes the task expect to run for the given duration, starting now if it has been
+// allocated resources.
+func setEstimate(taskList *taskList, task *mockTask, now time.Time, estimate time.Duration) {
+	req, _ := taskList.GetTaskByID(task.id)
+	req.EstimatedDuration = estimate
+	if taskList.GetAllocations(req.TaskActor) != nil {
+		taskList.allocationTimes[req.TaskActor] = now
+	}
+}
+
+func TestBackfillOnlyIfReservationIsNotDelayed(t *testing.T) {
+	agents := []*mockAgent{
+		{id: "agent1", slots: 4},
+		{id: "agent2", slots: 4},
+	}
+	tasks := []*mockTask{
+		{id: "running1", slotsNeeded: 4, allocatedAgent: agents[0], containerStarted: true},
+		{id: "running2", slotsNeeded: 2, allocatedAgent: agents[1], containerStarted: true},
+		{id: "large", slotsNeeded: 8},
+		{id: "short", slotsNeeded: 2},
+		{id: "long", slotsNeeded: 2},
+		{id: "unknown", slotsNeeded: 2},
+	}
+
+	system := actor.NewSystem(t.Name())
+	taskList, groupMap, agentMap := setupSchedulerStates(t, system, tasks, nil, agents)
+	now := time.Now()
+	setEstimate(taskList, tasks[0], now, time.Hour)
+	setEstimate(taskList, tasks[1], now, 2*time.Hour)
+	setEstimate(taskList, tasks[3], now, 30*time.Minute)
+	setEstimate(taskList, tasks[4], now, 3*time.Hour)
+
+	toAllocate := backfillSchedule(taskList, groupMap, agentMap, BestFit, now)
+	assertEqualToAllocate(t, toAllocate, []*mockTask{tasks[3]})
+
+	large, _ := taskList.GetTaskByID(tasks[2].id)
+	start, ok := taskList.Reservation(large.TaskActor)
+	assert.Assert(t, ok)
+	assert.Assert(t, start != nil)
+	assert.Equal(t, *start, now.Add(2*time.Hour))
+}
+
+func TestBackfillUnknownReservationStart(t *testing.T) {
+	agents := []*mockAgent{
+		{id: "agent1", slots: 4},
+		{id: "agent2", slots: 4},
+	}
+	tasks := []*mockTask{
+		{id: "running1", slotsNeeded: 4, allocatedAgent: agents[0], containerStarted: true},
+		{id: "running2", slotsNeeded: 2, allocatedAgent: agents[1], containerStarted: true},
+		{id: "too-large", slotsNeeded: 16},
+		{id: "large", slotsNeeded: 4},
+		{id: "short", slotsNeeded: 2},
+	}
+
+	system := actor.NewSystem(t.Name())
+	taskList, groupMap, agentMap := setupSchedulerStates(t, system, tasks, nil, agents)
+	now := time.Now()
+	setEstimate(taskList, tasks[4], now, time.Minute)
+
+	// The task that can never fit does not hold any agents, so the next task is reserved the
+	// agent of the first task to complete. Since the running tasks have no estimates, the start of
+	// the reservation is unknown and the short task only runs on the other agent.
+	toAllocate := backfillSchedule(taskList, groupMap, agentMap, BestFit, now)
+	assertEqualToAllocate(t, toAllocate, []*mockTask{tasks[4]})
+
+	tooLarge, _ := taskList.GetTaskByID(tasks[2].id)
+	_, ok := taskList.Reservation(tooLarge.TaskActor)
+	assert.Assert(t, !ok)
+
+	large, _ := taskList.GetTaskByID(tasks[3].id)
+	start, ok := taskList.Reservation(large.TaskActor)
+	assert.Assert(t, ok)
+	assert.Assert(t, start == nil)
+}
+
+func TestBackfillReservationIsCleared(t *testing.T) {
+	agents := []*mockAgent{
+		{id: "agent", slots: 4},
+	}
+	tasks := []*mockTask{
+		{id: "running", slotsNeeded: 4, allocatedAgent: agents[0], containerStarted: true},
+		{id: "large", slotsNeeded: 4},
+	}
+
+	system := actor.NewSystem(t.Name())
+	taskList, groupMap, agentMap := setupSchedulerStates(t, system, tasks, nil, agents)
+	now := time.Now()
+
+	toAllocate := backfillSchedule(taskList, groupMap, agentMap, BestFit, now)
+	assertEqualToAllocate(t, toAllocate, []*mockTask{})
+	large, _ := taskList.GetTaskByID(tasks[1].id)
+	_, ok := taskList.Reservation(large.TaskActor)
+	assert.Assert(t, ok)
+	assert.Equal(t, newTaskSummary(taskList, large).State, SchedulingStateReserved)
+
+	taskList.RemoveTaskByHandler(large.TaskActor)
+	_, ok = taskList.Reservation(large.TaskActor)
+	assert.Assert(t, !ok)
+}
@@ -3,7 +3,9 @@ package resourcemanagers
 import (
 	"crypto/md5" // #nosec
 	"encoding/binary"
+	"reflect"
 	"sort"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
@@ -66,21 +68,39 @@ func (c candidateList) Swap(i, j int) {
 func findFits(
 	req *AllocateRequest, agents map[*actor.Ref]*agentState, fittingMethod SoftConstraint,
 ) []*fittingState {
-	// TODO(DET-4035): Some of this code is duplicated in calculateDesiredNewInstanceNum()
-	//    to prevent the provisioner from scaling up for jobs that can never be scheduled in
-	//    the current cluster configuration.
+	// TODO(DET-4035): Some of this code is duplicated in the provisioner's scaleDecider
+	//    to prevent it from scaling up for jobs that can never be scheduled in the current
+	//    cluster configuration.
 	if fit := findSharedAgentFit(req, agents, fittingMethod); fit != nil {
 		return []*fittingState{fit}
 	}
 	if req.FittingRequirements.SingleAgent || req.SlotsNeeded <= 1 {
 		return nil
 	}
-	if fits := findDedicatedAgentFits(req, agents, fittingMethod); len(fits) != 0 {
+	if fits := findDedicatedAgentFits(req, agents, fittingMethod); isGangFit(req, fits) {
 		return fits
 	}
 	return nil
 }
 
+// isGangFit returns true if the fits place all of the slots of the task on distinct agents, so
+// that a distributed task is either placed completely or not at all.
+func isGangFit(req *AllocateRequest, fits []*fittingState) bool {
+	if len(fits) == 0 {
+		return false
+	}
+	slots := 0
+	seen := make(map[*agentState]bool, len(fits))
+	for _, fit := range fits {
+		if seen[fit.Agent] || fit.Slots > fit.Agent.numEmptySlots() {
+			return false
+		}
+		seen[fit.Agent] = true
+		slots += fit.Slots
+	}
+	return slots == req.SlotsNeeded
+}
+
 func isViable(req *AllocateRequest, agent *agentState, constraints ...HardConstraint) bool {
 	for _, constraint := range constraints {
 		if !constraint(req, agent) {
@@ -108,7 +128,7 @@ func findDedicatedAgentFits(
 			continue
 		}
 
-		constraints := []HardConstraint{labelSatisfied}
+		constraints := []HardConstraint{labelSatisfied, notDraining}
 		if isViable(req, agent, constraints...) {
 			agentsByNumSlots[agent.numEmptySlots()] = append(agentsByNumSlots[agent.numEmptySlots()], agent)
 		}
@@ -163,6 +183,9 @@ func findDedicatedAgentFits(
 	numContainers := req.SlotsNeeded / candidateNumSlots
 	slotsPerContainer := req.SlotsNeeded / numContainers
 	fits := candidates[:numContainers]
+	if isTopologyFit(fittingMethod) {
+		fits = selectByTopology(candidates, numContainers)
+	}
 	for _, c := range fits {
 		c.Slots = slotsPerContainer
 	}
@@ -170,12 +193,71 @@ func findDedicatedAgentFits(
 	return fits
 }
 
+// isTopologyFit returns true if the fitting method is TopologyFit. Only the topology fitting policy
+// places distributed tasks by the topology of the agents; the other policies place them by their
+// scores alone.
+func isTopologyFit(fittingMethod SoftConstraint) bool {
+	return reflect.ValueOf(fittingMethod).Pointer() == reflect.ValueOf(TopologyFit).Pointer()
+}
+
+// selectByTopology selects n of the sorted candidates, preferring candidates that share the
+// narrowest topology domain. At each level of the topology, starting from the narrowest, the
+// smallest domain that contains enough candidates is chosen, so that large domains are not
+// fragmented; the candidates within a domain are taken in order. If no domain contains enough
+// candidates, or the agents do not report a topology, the first n candidates are selected.
+func selectByTopology(candidates candidateList, n int) candidateList {
+	depth := 0
+	for _, c := range candidates {
+		if d := len(topologyLevels(c.Agent.topology)); d > depth {
+			depth = d
+		}
+	}
+
+	for ; depth > 0; depth-- {
+		var domains []string
+		members := make(map[string]candidateList)
+		for _, c := range candidates {
+			levels := topologyLevels(c.Agent.topology)
+			if len(levels) < depth {
+				continue
+			}
+			domain := strings.Join(levels[:depth], "/")
+			if _, ok := members[domain]; !ok {
+				domains = append(domains, domain)
+			}
+			members[domain] = append(members[domain], c)
+		}
+
+		var selected candidateList
+		for _, domain := range domains {
+			if m := members[domain]; len(m) >= n && (selected == nil || len(m) < len(selected)) {
+				selected = m
+			}
+		}
+		if selected != nil {
+			return selected[:n]
+		}
+	}
+	return candidates[:n]
+}
+
+// topologyLevels splits a topology into its levels, from the widest to the narrowest.
+func topologyLevels(topology string) []string {
+	var levels []string
+	for _, level := range strings.Split(topology, "/") {
+		if level != "" {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
 func findSharedAgentFit(
 	req *AllocateRequest, agents map[*actor.Ref]*agentState, fittingMethod SoftConstraint,
 ) *fittingState {
 	var candidates candidateList
 	for _, agent := range agents {
-		if !isViable(req, agent, slotsSatisfied, labelSatisfied) {
+		if !isViable(req, agent, slotsSatisfied, labelSatisfied, notDraining) {
 			continue
 		}
 
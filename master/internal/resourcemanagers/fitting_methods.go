@@ -12,6 +12,10 @@ func labelSatisfied(req *AllocateRequest, agent *agentState) bool {
 	return req.Label == agent.label
 }
 
+func notDraining(req *AllocateRequest, agent *agentState) bool {
+	return !agent.draining
+}
+
 // Soft Constraints
 
 // BestFit returns a float affinity score between 0 and 1 for the affinity between the task and
@@ -29,6 +33,19 @@ func WorstFit(_ *AllocateRequest, agent *agentState) float64 {
 	return float64(agent.numEmptySlots()) / float64(agent.numSlots())
 }
 
+// TopologyFit returns a float affinity score between 0 and 1 for the affinity between the task and
+// the agent. Like BestFit, this method prefers the agents that offer the fewest slots, but it
+// always prefers agents that are already partially used over idle agents, so that idle agents are
+// kept whole for distributed tasks. This method should be used together with agents that report
+// their network topology.
+func TopologyFit(_ *AllocateRequest, agent *agentState) float64 {
+	score := 0.5 / (1.0 + float64(agent.numEmptySlots()))
+	if agent.numUsedSlots() != 0 || len(agent.zeroSlotContainers) != 0 {
+		score += 0.5
+	}
+	return score
+}
+
 // MakeFitFunction returns the corresponding fitting function.
 func MakeFitFunction(fittingPolicy string) func(*AllocateRequest, *agentState) float64 {
 	switch fittingPolicy {
@@ -36,6 +53,8 @@ func MakeFitFunction(fittingPolicy string) func(*AllocateRequest, *agentState) f
 		return WorstFit
 	case best:
 		return BestFit
+	case topology:
+		return TopologyFit
 	default:
 		panic(fmt.Sprintf("invalid scheduler fit: %s", fittingPolicy))
 	}
@@ -33,3 +33,13 @@ func TestWorstFit(t *testing.T) {
 	assert.Equal(t, WorstFit(nil, consumeSlots(newMockAgent(t, system, "agent3", 10, ""), 0)), 1.0)
 	assert.Equal(t, WorstFit(nil, consumeSlots(newMockAgent(t, system, "agent4", 10, ""), 5)), 0.5)
 }
+
+func TestTopologyFit(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	assert.Equal(t, TopologyFit(nil, newMockAgent(t, system, "agent1", 1, "")), 0.25)
+	assert.Equal(t, TopologyFit(nil, newMockAgent(t, system, "agent2", 9, "")), 0.05)
+	assert.Equal(t, TopologyFit(nil, consumeSlots(newMockAgent(t, system, "agent3", 4, ""), 3)), 0.75)
+	assert.Equal(t, TopologyFit(nil, consumeSlots(newMockAgent(t, system, "agent4", 8, ""), 8)), 1.0)
+	// Agents running zero-slot containers are not idle.
+	assert.Equal(t, TopologyFit(nil, consumeSlots(newMockAgent(t, system, "agent5", 1, ""), 0)), 0.75)
+}
@@ -343,3 +343,98 @@ func byHandler(handlers ...*agentState) (map[*actor.Ref]*agentState, []*agentSta
 	}
 	return agents, index
 }
+
+func TestFindDedicatedAgentFitsTopology(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+
+	type testCase struct {
+		Name             string
+		SlotsNeeded      int
+		AgentTopologies  []string
+		ExpectedAgentFit []int
+	}
+
+	testCases := []testCase{
+		{
+			Name:             "Prefer the same rack",
+			SlotsNeeded:      8,
+			AgentTopologies:  []string{"z1/r1", "z1/r2", "z2/r3", "z1/r2"},
+			ExpectedAgentFit: []int{1, 3},
+		},
+		{
+			Name:             "Prefer the same zone",
+			SlotsNeeded:      12,
+			AgentTopologies:  []string{"z1/r1", "z2/r2", "z1/r3", "z2/r4", "z1/r5"},
+			ExpectedAgentFit: []int{0, 2, 4},
+		},
+		{
+			Name:             "Prefer the smallest domain that fits",
+			SlotsNeeded:      8,
+			AgentTopologies:  []string{"z1/r1", "z1/r1", "z1/r1", "z1/r2", "z1/r2"},
+			ExpectedAgentFit: []int{3, 4},
+		},
+		{
+			Name:             "Span domains if needed",
+			SlotsNeeded:      12,
+			AgentTopologies:  []string{"z1", "z2", "z3"},
+			ExpectedAgentFit: []int{0, 1, 2},
+		},
+	}
+
+	for idx := range testCases {
+		tc := testCases[idx]
+
+		t.Run(tc.Name, func(t *testing.T) {
+			var index []*agentState
+			for i, topology := range tc.AgentTopologies {
+				agent := newMockAgent(t, system, fmt.Sprintf("%s-agent-%d", tc.Name, i), 4, "")
+				agent.topology = topology
+				index = append(index, agent)
+			}
+			agents, index := byHandler(index...)
+			agentIndex := make(map[*agentState]int)
+			for idx, agent := range index {
+				agentIndex[agent] = idx
+			}
+
+			agentFit := func(fits []*fittingState) []int {
+				var agentFit sort.IntSlice
+				for _, fit := range fits {
+					assert.Equal(t, fit.Slots, 4)
+					agentFit = append(agentFit, agentIndex[fit.Agent])
+				}
+				sort.Sort(agentFit)
+				return agentFit
+			}
+
+			req := &AllocateRequest{SlotsNeeded: tc.SlotsNeeded}
+			assert.DeepEqual(t, tc.ExpectedAgentFit, agentFit(findFits(req, agents, TopologyFit)))
+
+			// Other fitting policies ignore the topology.
+			bestFit := agentFit(findFits(req, agents, BestFit))
+			for _, agent := range index {
+				agent.topology = ""
+			}
+			assert.DeepEqual(t, bestFit, agentFit(findFits(req, agents, BestFit)))
+		})
+	}
+}
+
+func TestIsGangFit(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agent1 := newMockAgent(t, system, "agent1", 4, "")
+	agent2 := newMockAgent(t, system, "agent2", 4, "")
+	req := &AllocateRequest{SlotsNeeded: 8}
+
+	assert.Assert(t, isGangFit(req, []*fittingState{
+		{Agent: agent1, Slots: 4}, {Agent: agent2, Slots: 4},
+	}))
+	assert.Assert(t, !isGangFit(req, nil))
+	assert.Assert(t, !isGangFit(req, []*fittingState{{Agent: agent1, Slots: 4}}))
+	assert.Assert(t, !isGangFit(req, []*fittingState{
+		{Agent: agent1, Slots: 4}, {Agent: agent1, Slots: 4},
+	}))
+	assert.Assert(t, !isGangFit(req, []*fittingState{
+		{Agent: agent1, Slots: 4}, {Agent: consumeSlots(agent2, 1), Slots: 4},
+	}))
+}
@@ -64,6 +64,7 @@ func (k *kubernetesResourceManager) Receive(ctx *actor.Context) error {
 		sproto.SetGroupWeight,
 		sproto.SetGroupPriority,
 		SetTaskName,
+		TaskCheckpointed,
 		AllocateRequest,
 		ResourcesReleased:
 		return k.receiveRequestMsg(ctx)
@@ -78,6 +79,16 @@ func (k *kubernetesResourceManager) Receive(ctx *actor.Context) error {
 		reschedule = false
 		ctx.Respond(getTaskSummaries(k.reqList))
 
+	case GetQuotaSummaries:
+		// Slot quotas are not supported by the Kubernetes RM.
+		reschedule = false
+		ctx.Respond(make([]QuotaSummary, 0))
+
+	case GetResourcePoolSummaries:
+		// Resource pools are not supported by the Kubernetes RM.
+		reschedule = false
+		ctx.Respond(make([]ResourcePoolSummary, 0))
+
 	case schedulerTick:
 		if k.reschedule {
 			k.schedulePendingTasks(ctx)
@@ -104,8 +115,8 @@ func (k *kubernetesResourceManager) receiveRequestMsg(ctx *actor.Context) error
 	case sproto.SetGroupMaxSlots:
 		k.getOrCreateGroup(ctx, msg.Handler).maxSlots = msg.MaxSlots
 
-	case sproto.SetGroupWeight, sproto.SetGroupPriority:
-		// SetGroupWeight and SetGroupPriority are not supported by the Kubernetes RP.
+	case sproto.SetGroupWeight, sproto.SetGroupPriority, TaskCheckpointed:
+		// SetGroupWeight, SetGroupPriority and preemption are not supported by the Kubernetes RP.
 
 	case SetTaskName:
 		k.receiveSetTaskName(ctx, msg)
@@ -190,6 +201,7 @@ func (k *kubernetesResourceManager) assignResources(ctx *actor.Context, req *All
 	assigned := ResourcesAllocated{ID: req.ID, Allocations: allocations}
 	k.reqList.SetAllocations(req.TaskActor, &assigned)
 	req.TaskActor.System().Tell(req.TaskActor, assigned)
+	recordSlotsAllocated(ctx, req, "", nil)
 
 	ctx.Log().
 		WithField("task-id", req.ID).
@@ -199,6 +211,11 @@ func (k *kubernetesResourceManager) assignResources(ctx *actor.Context, req *All
 
 func (k *kubernetesResourceManager) resourcesReleased(ctx *actor.Context, handler *actor.Ref) {
 	ctx.Log().Infof("resources are released for %s", handler.Address())
+	if k.reqList.GetAllocations(handler) != nil {
+		if req, ok := k.reqList.GetTaskByHandler(handler); ok {
+			recordSlotsReleased(ctx, req)
+		}
+	}
 	k.reqList.RemoveTaskByHandler(handler)
 
 	if req, ok := k.reqList.GetTaskByHandler(handler); ok {
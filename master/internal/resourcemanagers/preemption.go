@@ -0,0 +1,94 @@
+package resourcemanagers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+)
+
+// planPreemption chooses running tasks to preempt so that the pending task fits on the agents
+// within the max slots of its quotas, minimizing the work that the preempted tasks lose since their
+// latest checkpoints. It returns nil if the task does not fit even if all of the candidates are
+// preempted.
+//
+// Victims are added in order of increasing lost work until the task fits; afterwards, victims that
+// turn out not to be needed are dropped, starting with those that would lose the most work.
+func planPreemption(
+	req *AllocateRequest,
+	candidates []*AllocateRequest,
+	taskList *taskList,
+	agents map[*actor.Ref]*agentState,
+	fittingMethod SoftConstraint,
+	quotas *QuotaConfig,
+	usage map[quotaKey]int,
+	now time.Time,
+) []*AllocateRequest {
+	lostWork := make(map[*AllocateRequest]time.Duration, len(candidates))
+	for _, candidate := range candidates {
+		lostWork[candidate] = taskList.LostWork(candidate, now)
+	}
+	candidates = append([]*AllocateRequest(nil), candidates...)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return lostWork[candidates[i]] < lostWork[candidates[j]]
+	})
+
+	fitsWithout := func(victims []*AllocateRequest) bool {
+		if !withinQuotasWithout(quotas, usage, req, victims) {
+			return false
+		}
+		freed := copyAgentStates(agents)
+		for _, victim := range victims {
+			for _, id := range containerIDs(taskList.GetAllocations(victim.TaskActor)) {
+				for _, agent := range freed {
+					agent.deallocateContainer(id)
+				}
+			}
+		}
+		return len(findFits(req, freed, fittingMethod)) != 0
+	}
+
+	var victims []*AllocateRequest
+	for _, candidate := range candidates {
+		if candidate.SlotsNeeded == 0 {
+			continue
+		}
+		victims = append(victims, candidate)
+		if fitsWithout(victims) {
+			break
+		}
+	}
+	if len(victims) == 0 || !fitsWithout(victims) {
+		return nil
+	}
+
+	for i := len(victims) - 1; i >= 0; i-- {
+		rest := append(append([]*AllocateRequest(nil), victims[:i]...), victims[i+1:]...)
+		if fitsWithout(rest) {
+			victims = rest
+		}
+	}
+	return victims
+}
+
+// containerIDs returns the IDs of the containers that the task was allocated.
+func containerIDs(allocated *ResourcesAllocated) []cproto.ID {
+	if allocated == nil {
+		return nil
+	}
+	var ids []cproto.ID
+	for _, allocation := range allocated.Allocations {
+		switch a := allocation.(type) {
+		case *containerAllocation:
+			if a.container != nil {
+				ids = append(ids, a.container.id)
+			}
+		case containerAllocation:
+			if a.container != nil {
+				ids = append(ids, a.container.id)
+			}
+		}
+	}
+	return ids
+}
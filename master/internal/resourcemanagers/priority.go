@@ -0,0 +1,158 @@
+package resourcemanagers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+)
+
+type priorityScheduler struct {
+	preemptionEnabled bool
+}
+
+// NewPriorityScheduler creates a new scheduler that schedules tasks in order of the priority of
+// their groups, where lower values are scheduled first, and in the order in which they arrived
+// within a priority. If preemption is enabled, running tasks of lower priority are asked to
+// release their resources when a pending task does not fit or would exceed a quota, choosing the
+// tasks that would lose the least work since their latest checkpoints.
+func NewPriorityScheduler(config *PrioritySchedulerConfig) Scheduler {
+	return &priorityScheduler{preemptionEnabled: config.Preemption}
+}
+
+func (p *priorityScheduler) Schedule(rp *ResourcePool) ([]*AllocateRequest, []*actor.Ref) {
+	return prioritySchedule(
+		rp.taskList, rp.groups, rp.agents, rp.fittingMethod, rp.config.Quotas, p.preemptionEnabled,
+		rp.taskList.now())
+}
+
+func prioritySchedule(
+	taskList *taskList,
+	groups map[*actor.Ref]*group,
+	agents map[*actor.Ref]*agentState,
+	fittingMethod SoftConstraint,
+	quotas *QuotaConfig,
+	preemptionEnabled bool,
+	now time.Time,
+) ([]*AllocateRequest, []*actor.Ref) {
+	toAllocate := make([]*AllocateRequest, 0)
+	toRelease := make([]*actor.Ref, 0)
+
+	// Zero-slot tasks do not compete for slots, so they are scheduled regardless of priority.
+	for it := taskList.iterator(); it.next(); {
+		req := it.value()
+		if req.SlotsNeeded == 0 && taskList.GetAllocations(req.TaskActor) == nil {
+			if fits := findFits(req, agents, fittingMethod); len(fits) != 0 {
+				toAllocate = append(toAllocate, req)
+			}
+		}
+	}
+
+	// Scheduling decisions are made against copies of the agents, so that the slots taken by
+	// tasks that are allocated in this call are not offered to tasks of lower priority.
+	// The same goes for the usage of the quotas.
+	agents = copyAgentStates(agents)
+	usage := quotaUsage(taskList)
+	priorities, pending, running := sortTasksByPriority(taskList, groups)
+
+	for _, priority := range priorities {
+		for _, req := range pending[priority] {
+			if req.SlotsNeeded == 0 {
+				continue
+			}
+			fits := findFits(req, agents, fittingMethod)
+			hardQuota, _ := checkQuotas(quotas, usage, req)
+			if len(fits) != 0 && hardQuota == nil {
+				for _, fit := range fits {
+					fit.Agent.allocateFreeDevices(fit.Slots, cproto.ID(req.ID))
+				}
+				for _, key := range quotaKeys(req) {
+					usage[key] += req.SlotsNeeded
+				}
+				toAllocate = append(toAllocate, req)
+				continue
+			}
+
+			// A task that fits on the agents but exceeds a quota is still passed on when no
+			// tasks are preempted for it, so that the resource pool records the quota that
+			// holds it back.
+			if !preemptionEnabled {
+				if len(fits) != 0 {
+					toAllocate = append(toAllocate, req)
+				}
+				continue
+			}
+			// While tasks are releasing resources for a pending task, later tasks are not
+			// scheduled, so that they do not take the released slots before it can.
+			if taskList.IsPreempting(req.ID) {
+				return toAllocate, toRelease
+			}
+
+			// Only running tasks of strictly lower priority that are not already releasing their
+			// resources can be preempted.
+			var candidates []*AllocateRequest
+			for _, other := range priorities {
+				if other <= priority {
+					continue
+				}
+				for _, victim := range running[other] {
+					if _, ok := taskList.PreemptedBy(victim.TaskActor); !ok {
+						candidates = append(candidates, victim)
+					}
+				}
+			}
+
+			victims := planPreemption(
+				req, candidates, taskList, agents, fittingMethod, quotas, usage, now)
+			for _, victim := range victims {
+				taskList.SetPreempted(victim.TaskActor, req.ID)
+				toRelease = append(toRelease, victim.TaskActor)
+			}
+			if len(victims) != 0 {
+				return toAllocate, toRelease
+			}
+			if len(fits) != 0 {
+				toAllocate = append(toAllocate, req)
+			}
+		}
+	}
+
+	return toAllocate, toRelease
+}
+
+// sortTasksByPriority returns the priorities of all tasks in scheduling order, along with the
+// pending and running tasks of each priority in the order in which they arrived.
+func sortTasksByPriority(
+	taskList *taskList, groups map[*actor.Ref]*group,
+) ([]int, map[int][]*AllocateRequest, map[int][]*AllocateRequest) {
+	pending := make(map[int][]*AllocateRequest)
+	running := make(map[int][]*AllocateRequest)
+	seen := make(map[int]bool)
+	var priorities []int
+	for it := taskList.iterator(); it.next(); {
+		req := it.value()
+		priority := groupPriority(groups[req.Group])
+		if !seen[priority] {
+			seen[priority] = true
+			priorities = append(priorities, priority)
+		}
+
+		assigned := taskList.GetAllocations(req.TaskActor)
+		switch {
+		case assigned == nil || len(assigned.Allocations) == 0:
+			pending[priority] = append(pending[priority], req)
+		case !req.NonPreemptible:
+			running[priority] = append(running[priority], req)
+		}
+	}
+	sort.Ints(priorities)
+	return priorities, pending, running
+}
+
+func groupPriority(g *group) int {
+	if g == nil || g.priority == nil {
+		return defaultSchedulingPriority
+	}
+	return *g.priority
+}
@@ -0,0 +1,228 @@
+package resourcemanagers
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+func newPriority(priority int) *int {
+	return &priority
+}
+
+// setLostWork makes the task appear to have last saved its progress the given time ago.
+func setLostWork(taskList *taskList, task *mockTask, now time.Time, sinceCheckpoint time.Duration) {
+	req, _ := taskList.GetTaskByID(task.id)
+	taskList.progressTimes[req.TaskActor] = now.Add(-sinceCheckpoint)
+}
+
+func TestPrioritySchedulingOrder(t *testing.T) {
+	agents := []*mockAgent{
+		{id: "agent", slots: 2},
+	}
+	groups := []*mockGroup{
+		{id: "group-low", priority: newPriority(50)},
+		{id: "group-high", priority: newPriority(10)},
+	}
+	tasks := []*mockTask{
+		{id: "low1", slotsNeeded: 1, group: groups[0]},
+		{id: "low2", slotsNeeded: 1, group: groups[0]},
+		{id: "high1", slotsNeeded: 1, group: groups[1]},
+		{id: "high2", slotsNeeded: 1, group: groups[1]},
+		{id: "cpu", slotsNeeded: 0, group: groups[0]},
+	}
+
+	expectedToAllocate := []*mockTask{tasks[2], tasks[3], tasks[4]}
+	expectedToRelease := []*mockTask{}
+
+	system := actor.NewSystem(t.Name())
+	taskList, groupMap, agentMap := setupSchedulerStates(t, system, tasks, groups, agents)
+	toAllocate, toRelease := prioritySchedule(
+		taskList, groupMap, agentMap, BestFit, nil, false, time.Now())
+	assertEqualToAllocate(t, toAllocate, expectedToAllocate)
+	assertEqualToRelease(t, taskList, toRelease, expectedToRelease)
+}
+
+func TestPriorityPreemptionMinimizesLostWork(t *testing.T) {
+	agents := []*mockAgent{
+		{id: "agent", slots: 4},
+	}
+	groups := []*mockGroup{
+		{id: "group-low", priority: newPriority(50)},
+		{id: "group-lower", priority: newPriority(60)},
+		{id: "group-high", priority: newPriority(10)},
+	}
+	tasks := []*mockTask{
+		{id: "low1", slotsNeeded: 1, group: groups[0], allocatedAgent: agents[0],
+			containerStarted: true},
+		{id: "low2", slotsNeeded: 1, group: groups[0], allocatedAgent: agents[0],
+			containerStarted: true},
+		{id: "lower1", slotsNeeded: 1, group: groups[1], allocatedAgent: agents[0],
+			containerStarted: true},
+		{id: "lower2", slotsNeeded: 1, group: groups[1], allocatedAgent: agents[0],
+			containerStarted: true},
+		{id: "high", slotsNeeded: 2, group: groups[2]},
+	}
+
+	system := actor.NewSystem(t.Name())
+	taskList, groupMap, agentMap := setupSchedulerStates(t, system, tasks, groups, agents)
+	now := time.Now()
+	setLostWork(taskList, tasks[0], now, time.Minute)
+	setLostWork(taskList, tasks[1], now, 2*time.Hour)
+	setLostWork(taskList, tasks[2], now, 3*time.Hour)
+	setLostWork(taskList, tasks[3], now, 10*time.Minute)
+
+	toAllocate, toRelease := prioritySchedule(taskList, groupMap, agentMap, BestFit, nil, true, now)
+	assertEqualToAllocate(t, toAllocate, []*mockTask{})
+	assertEqualToRelease(t, taskList, toRelease, []*mockTask{tasks[0], tasks[3]})
+
+	summaries := getTaskSummaries(taskList)
+	assert.Equal(t, summaries["low1"].State, SchedulingStatePreempted)
+	assert.Equal(t, *summaries["low1"].PreemptedBy, TaskID("high"))
+	assert.Equal(t, summaries["low2"].State, SchedulingStateAssigned)
+	assert.Assert(t, summaries["low2"].PreemptedBy == nil)
+	assert.Equal(t, summaries["high"].State, SchedulingStatePending)
+
+	// No more tasks are preempted while the victims release their resources.
+	toAllocate, toRelease = prioritySchedule(taskList, groupMap, agentMap, BestFit, nil, true, now)
+	assertEqualToAllocate(t, toAllocate, []*mockTask{})
+	assertEqualToRelease(t, taskList, toRelease, []*mockTask{})
+}
+
+func TestPriorityPreemptionDropsUnneededVictims(t *testing.T) {
+	agents := []*mockAgent{
+		{id: "agent1", slots: 2},
+		{id: "agent2", slots: 2},
+	}
+	groups := []*mockGroup{
+		{id: "group-low", priority: newPriority(50)},
+		{id: "group-high", priority: newPriority(10)},
+	}
+	tasks := []*mockTask{
+		{id: "small1", slotsNeeded: 1, group: groups[0], allocatedAgent: agents[0],
+			containerStarted: true},
+		{id: "small2", slotsNeeded: 1, group: groups[0], allocatedAgent: agents[0],
+			containerStarted: true},
+		{id: "large", slotsNeeded: 2, group: groups[0], allocatedAgent: agents[1],
+			containerStarted: true},
+		{id: "high", slotsNeeded: 2, group: groups[1]},
+	}
+
+	system := actor.NewSystem(t.Name())
+	taskList, groupMap, agentMap := setupSchedulerStates(t, system, tasks, groups, agents)
+	now := time.Now()
+	setLostWork(taskList, tasks[0], now, time.Minute)
+	setLostWork(taskList, tasks[1], now, 10*time.Minute)
+	setLostWork(taskList, tasks[2], now, 4*time.Minute)
+
+	// Preempting small1 alone is not enough, and preempting large loses less work than
+	// preempting both small tasks.
+	toAllocate, toRelease := prioritySchedule(taskList, groupMap, agentMap, BestFit, nil, true, now)
+	assertEqualToAllocate(t, toAllocate, []*mockTask{})
+	assertEqualToRelease(t, taskList, toRelease, []*mockTask{tasks[2]})
+}
+
+func TestPriorityPreemptionRespectsNonPreemptible(t *testing.T) {
+	agents := []*mockAgent{
+		{id: "agent", slots: 2},
+	}
+	groups := []*mockGroup{
+		{id: "group-low", priority: newPriority(50)},
+		{id: "group-high", priority: newPriority(10)},
+	}
+	tasks := []*mockTask{
+		{id: "low1", slotsNeeded: 1, group: groups[0], allocatedAgent: agents[0],
+			containerStarted: true, nonPreemptible: true},
+		{id: "low2", slotsNeeded: 1, group: groups[0], allocatedAgent: agents[0],
+			containerStarted: true},
+		{id: "high", slotsNeeded: 2, group: groups[1]},
+		{id: "high-small", slotsNeeded: 1, group: groups[1]},
+	}
+
+	system := actor.NewSystem(t.Name())
+	taskList, groupMap, agentMap := setupSchedulerStates(t, system, tasks, groups, agents)
+
+	// The large task cannot fit even if every preemptible task is preempted, so only the small
+	// task preempts a task.
+	toAllocate, toRelease := prioritySchedule(
+		taskList, groupMap, agentMap, BestFit, nil, true, time.Now())
+	assertEqualToAllocate(t, toAllocate, []*mockTask{})
+	assertEqualToRelease(t, taskList, toRelease, []*mockTask{tasks[1]})
+}
+
+func TestPriorityPreemptionRespectsQuotas(t *testing.T) {
+	agents := []*mockAgent{
+		{id: "agent", slots: 4},
+	}
+	groups := []*mockGroup{
+		{id: "group-low", priority: newPriority(50)},
+		{id: "group-high", priority: newPriority(10)},
+	}
+	tasks := []*mockTask{
+		{id: "alice1", slotsNeeded: 1, group: groups[0], allocatedAgent: agents[0],
+			containerStarted: true},
+		{id: "alice2", slotsNeeded: 1, group: groups[0], allocatedAgent: agents[0],
+			containerStarted: true},
+		{id: "bob", slotsNeeded: 1, group: groups[0], allocatedAgent: agents[0],
+			containerStarted: true},
+		{id: "alice-high", slotsNeeded: 1, group: groups[1]},
+	}
+	users := []string{"alice", "alice", "bob", "alice"}
+	quotas := &QuotaConfig{Users: map[string]SlotQuota{"alice": {MaxSlots: 2}}}
+
+	system := actor.NewSystem(t.Name())
+	taskList, groupMap, agentMap := setupSchedulerStates(t, system, tasks, groups, agents)
+	for i, task := range tasks {
+		req, _ := taskList.GetTaskByID(task.id)
+		req.User = users[i]
+	}
+	now := time.Now()
+	setLostWork(taskList, tasks[0], now, time.Hour)
+	setLostWork(taskList, tasks[1], now, 10*time.Minute)
+	setLostWork(taskList, tasks[2], now, time.Minute)
+
+	// The high priority task fits on the free slot but would exceed the quota of its user, so one
+	// of the tasks of the same user is preempted rather than the task that would lose the least
+	// work.
+	toAllocate, toRelease := prioritySchedule(
+		taskList, groupMap, agentMap, BestFit, quotas, true, now)
+	assertEqualToAllocate(t, toAllocate, []*mockTask{})
+	assertEqualToRelease(t, taskList, toRelease, []*mockTask{tasks[1]})
+
+	// Without preemption, the task is passed on so that the resource pool records the quota.
+	system = actor.NewSystem(t.Name() + "-disabled")
+	taskList, groupMap, agentMap = setupSchedulerStates(t, system, tasks, groups, agents)
+	for i, task := range tasks {
+		req, _ := taskList.GetTaskByID(task.id)
+		req.User = users[i]
+	}
+	toAllocate, toRelease = prioritySchedule(
+		taskList, groupMap, agentMap, BestFit, quotas, false, now)
+	assertEqualToAllocate(t, toAllocate, []*mockTask{tasks[3]})
+	assertEqualToRelease(t, taskList, toRelease, []*mockTask{})
+}
+
+func TestPriorityPreemptionDisabled(t *testing.T) {
+	agents := []*mockAgent{
+		{id: "agent", slots: 1},
+	}
+	groups := []*mockGroup{
+		{id: "group-low", priority: newPriority(50)},
+		{id: "group-high", priority: newPriority(10)},
+	}
+	tasks := []*mockTask{
+		{id: "low", slotsNeeded: 1, group: groups[0], allocatedAgent: agents[0],
+			containerStarted: true},
+		{id: "high", slotsNeeded: 1, group: groups[1]},
+	}
+
+	system := actor.NewSystem(t.Name())
+	taskList, groupMap, agentMap := setupSchedulerStates(t, system, tasks, groups, agents)
+	toAllocate, toRelease := prioritySchedule(
+		taskList, groupMap, agentMap, BestFit, nil, false, time.Now())
+	assertEqualToAllocate(t, toAllocate, []*mockTask{})
+	assertEqualToRelease(t, taskList, toRelease, []*mockTask{})
+}
@@ -0,0 +1,219 @@
+package resourcemanagers
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	poolQuota  = "pool"
+	userQuota  = "user"
+	labelQuota = "label"
+)
+
+// GetQuotaSummaries returns the usage of the slot quotas of all resource pools.
+type GetQuotaSummaries struct{}
+
+// QuotaSummary contains the usage of a slot quota for external display.
+type QuotaSummary struct {
+	ResourcePool string `json:"resource_pool"`
+	Kind         string `json:"kind"`
+	Name         string `json:"name"`
+	UsedSlots    int    `json:"used_slots"`
+	MaxSlots     int    `json:"max_slots"`
+	BurstSlots   int    `json:"burst_slots"`
+}
+
+// quotaKey identifies the set of tasks that a quota applies to.
+type quotaKey struct {
+	kind string
+	name string
+}
+
+func (k quotaKey) String() string {
+	if k.kind == poolQuota {
+		return "pool quota"
+	}
+	return fmt.Sprintf("%s quota of %s", k.kind, k.name)
+}
+
+// quotaKeys returns the keys of the quotas that the task is subject to.
+func quotaKeys(req *AllocateRequest) []quotaKey {
+	keys := []quotaKey{{kind: poolQuota}}
+	if req.User != "" {
+		keys = append(keys, quotaKey{kind: userQuota, name: req.User})
+	}
+	for _, label := range req.Labels {
+		keys = append(keys, quotaKey{kind: labelQuota, name: label})
+	}
+	return keys
+}
+
+// quota returns the quota for the key, if there is one.
+func (q *QuotaConfig) quota(key quotaKey) (SlotQuota, bool) {
+	if q == nil {
+		return SlotQuota{}, false
+	}
+	switch key.kind {
+	case poolQuota:
+		if q.Pool != nil {
+			return *q.Pool, true
+		}
+	case userQuota:
+		if quota, ok := q.Users[key.name]; ok {
+			return quota, true
+		}
+		if q.DefaultUser != nil {
+			return *q.DefaultUser, true
+		}
+	case labelQuota:
+		quota, ok := q.Labels[key.name]
+		return quota, ok
+	}
+	return SlotQuota{}, false
+}
+
+// quotaUsage returns the number of slots allocated to the tasks under each quota.
+func quotaUsage(taskList *taskList) map[quotaKey]int {
+	usage := make(map[quotaKey]int)
+	for it := taskList.iterator(); it.next(); {
+		req := it.value()
+		if taskList.GetAllocations(req.TaskActor) == nil {
+			continue
+		}
+		for _, key := range quotaKeys(req) {
+			usage[key] += req.SlotsNeeded
+		}
+	}
+	return usage
+}
+
+// exceededQuota returns the quota that the task would exceed if it were allocated its slots, or
+// nil if it can be allocated. The task only bursts past the max slots of a quota if no other
+// pending task that is within its quotas fits on the agents.
+func (rp *ResourcePool) exceededQuota(req *AllocateRequest) *quotaKey {
+	if rp.config.Quotas == nil || req.SlotsNeeded == 0 {
+		return nil
+	}
+	usage := quotaUsage(rp.taskList)
+	hard, burst := checkQuotas(rp.config.Quotas, usage, req)
+	if hard != nil || burst == nil {
+		return hard
+	}
+
+	for it := rp.taskList.iterator(); it.next(); {
+		other := it.value()
+		if other == req || other.SlotsNeeded == 0 ||
+			rp.taskList.GetAllocations(other.TaskActor) != nil {
+			continue
+		}
+		if hard, burst := checkQuotas(rp.config.Quotas, usage, other); hard != nil || burst != nil {
+			continue
+		}
+		if len(findFits(other, rp.agents, rp.fittingMethod)) != 0 {
+			return burst
+		}
+	}
+	return nil
+}
+
+// checkQuotas returns the first quota whose burst slots the task would exceed and the first quota
+// whose max slots the task would exceed.
+func checkQuotas(
+	config *QuotaConfig, usage map[quotaKey]int, req *AllocateRequest,
+) (hard *quotaKey, burst *quotaKey) {
+	for _, key := range quotaKeys(req) {
+		quota, ok := config.quota(key)
+		if !ok {
+			continue
+		}
+		key := key
+		slots := usage[key] + req.SlotsNeeded
+		switch {
+		case slots > quota.burstSlots():
+			return &key, burst
+		case slots > quota.MaxSlots && burst == nil:
+			burst = &key
+		}
+	}
+	return nil, burst
+}
+
+// withinQuotasWithout returns whether the task can be allocated its slots without exceeding the
+// max slots of any of its quotas once the victims have released theirs. Tasks are never preempted
+// so that another task can burst.
+func withinQuotasWithout(
+	config *QuotaConfig, usage map[quotaKey]int, req *AllocateRequest, victims []*AllocateRequest,
+) bool {
+	if config == nil || req.SlotsNeeded == 0 {
+		return true
+	}
+	freed := make(map[quotaKey]int, len(usage))
+	for key, slots := range usage {
+		freed[key] = slots
+	}
+	for _, victim := range victims {
+		for _, key := range quotaKeys(victim) {
+			freed[key] -= victim.SlotsNeeded
+		}
+	}
+	hard, burst := checkQuotas(config, freed, req)
+	return hard == nil && burst == nil
+}
+
+// getQuotaSummaries returns the usage of the configured quotas, along with the usage of the
+// default user quota by each user with allocated tasks.
+func (rp *ResourcePool) getQuotaSummaries() []QuotaSummary {
+	config := rp.config.Quotas
+	if config == nil {
+		return nil
+	}
+	usage := quotaUsage(rp.taskList)
+
+	keys := make(map[quotaKey]bool)
+	if config.Pool != nil {
+		keys[quotaKey{kind: poolQuota}] = true
+	}
+	for user := range config.Users {
+		keys[quotaKey{kind: userQuota, name: user}] = true
+	}
+	for label := range config.Labels {
+		keys[quotaKey{kind: labelQuota, name: label}] = true
+	}
+	if config.DefaultUser != nil {
+		for key := range usage {
+			if key.kind == userQuota {
+				keys[key] = true
+			}
+		}
+	}
+
+	summaries := make([]QuotaSummary, 0, len(keys))
+	for key := range keys {
+		quota, _ := config.quota(key)
+		summaries = append(summaries, QuotaSummary{
+			ResourcePool: rp.config.PoolName,
+			Kind:         key.kind,
+			Name:         key.name,
+			UsedSlots:    usage[key],
+			MaxSlots:     quota.MaxSlots,
+			BurstSlots:   quota.burstSlots(),
+		})
+	}
+	sortQuotaSummaries(summaries)
+	return summaries
+}
+
+func sortQuotaSummaries(summaries []QuotaSummary) {
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		switch {
+		case a.ResourcePool != b.ResourcePool:
+			return a.ResourcePool < b.ResourcePool
+		case a.Kind != b.Kind:
+			return a.Kind < b.Kind
+		default:
+			return a.Name < b.Name
+		}
+	})
+}
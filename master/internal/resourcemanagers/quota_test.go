@@ -0,0 +1,132 @@
+package resourcemanagers
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+func newSlots(slots int) *int {
+	return &slots
+}
+
+// setupQuotaPool creates a resource pool with the given quotas, where each task belongs to the
+// user of the same index.
+func setupQuotaPool(
+	t *testing.T, quotas *QuotaConfig, tasks []*mockTask, users []string, agents []*mockAgent,
+) *ResourcePool {
+	system := actor.NewSystem(t.Name())
+	taskList, groupMap, agentMap := setupSchedulerStates(t, system, tasks, nil, agents)
+	for i, task := range tasks {
+		req, _ := taskList.GetTaskByID(task.id)
+		req.User = users[i]
+		req.Labels = []string{"label-" + users[i]}
+	}
+	return &ResourcePool{
+		config:        &ResourcePoolConfig{PoolName: "pool", Quotas: quotas},
+		fittingMethod: BestFit,
+		agents:        agentMap,
+		taskList:      taskList,
+		groups:        groupMap,
+	}
+}
+
+func assertExceededQuota(t *testing.T, rp *ResourcePool, task *mockTask, expected string) {
+	req, _ := rp.taskList.GetTaskByID(task.id)
+	quota := rp.exceededQuota(req)
+	if expected == "" {
+		assert.Assert(t, quota == nil, "unexpected %s", quota)
+	} else {
+		assert.Assert(t, quota != nil)
+		assert.Equal(t, quota.String(), expected)
+	}
+}
+
+func TestQuotaHardLimits(t *testing.T) {
+	agents := []*mockAgent{{id: "agent", slots: 8}}
+	tasks := []*mockTask{
+		{id: "task1", slotsNeeded: 2, allocatedAgent: agents[0], containerStarted: true},
+		{id: "task2", slotsNeeded: 2},
+		{id: "task3", slotsNeeded: 2},
+		{id: "task4", slotsNeeded: 4},
+		{id: "task5", slotsNeeded: 0},
+	}
+	quotas := &QuotaConfig{
+		Pool:        &SlotQuota{MaxSlots: 5},
+		DefaultUser: &SlotQuota{MaxSlots: 2},
+		Users:       map[string]SlotQuota{"bob": {MaxSlots: 4}},
+		Labels:      map[string]SlotQuota{"label-carol": {MaxSlots: 1}},
+	}
+	rp := setupQuotaPool(t, quotas, tasks,
+		[]string{"alice", "alice", "bob", "bob", "alice"}, agents)
+
+	assertExceededQuota(t, rp, tasks[1], "user quota of alice")
+	assertExceededQuota(t, rp, tasks[2], "")
+	assertExceededQuota(t, rp, tasks[3], "pool quota")
+	// Zero-slot tasks are not subject to quotas.
+	assertExceededQuota(t, rp, tasks[4], "")
+
+	rp.config.Quotas.Pool = nil
+	assertExceededQuota(t, rp, tasks[3], "")
+
+	req, _ := rp.taskList.GetTaskByID(tasks[2].id)
+	req.Labels = []string{"label-carol"}
+	assertExceededQuota(t, rp, tasks[2], "label quota of label-carol")
+}
+
+func TestQuotaBurst(t *testing.T) {
+	agents := []*mockAgent{{id: "agent", slots: 4}}
+	tasks := []*mockTask{
+		{id: "task1", slotsNeeded: 2, allocatedAgent: agents[0], containerStarted: true},
+		{id: "task2", slotsNeeded: 1},
+		{id: "task3", slotsNeeded: 2},
+		{id: "task4", slotsNeeded: 1},
+	}
+	quotas := &QuotaConfig{
+		DefaultUser: &SlotQuota{MaxSlots: 2, BurstSlots: newSlots(3)},
+	}
+	rp := setupQuotaPool(t, quotas, tasks, []string{"alice", "alice", "alice", "bob"}, agents)
+
+	// The task may not burst while another user's task within its quota is waiting.
+	assertExceededQuota(t, rp, tasks[1], "user quota of alice")
+	// The burst slots are a hard limit.
+	assertExceededQuota(t, rp, tasks[2], "user quota of alice")
+
+	req, _ := rp.taskList.GetTaskByID(tasks[3].id)
+	rp.taskList.RemoveTaskByHandler(req.TaskActor)
+	assertExceededQuota(t, rp, tasks[1], "")
+}
+
+func TestQuotaSummaries(t *testing.T) {
+	agents := []*mockAgent{{id: "agent", slots: 4}}
+	tasks := []*mockTask{
+		{id: "task1", slotsNeeded: 2, allocatedAgent: agents[0], containerStarted: true},
+		{id: "task2", slotsNeeded: 1, allocatedAgent: agents[0], containerStarted: true},
+		{id: "task3", slotsNeeded: 1},
+	}
+	quotas := &QuotaConfig{
+		Pool:        &SlotQuota{MaxSlots: 4},
+		DefaultUser: &SlotQuota{MaxSlots: 2, BurstSlots: newSlots(3)},
+		Users:       map[string]SlotQuota{"carol": {MaxSlots: 1}},
+	}
+	rp := setupQuotaPool(t, quotas, tasks, []string{"alice", "bob", "dave"}, agents)
+
+	assert.DeepEqual(t, rp.getQuotaSummaries(), []QuotaSummary{
+		{ResourcePool: "pool", Kind: poolQuota, UsedSlots: 3, MaxSlots: 4, BurstSlots: 4},
+		{ResourcePool: "pool", Kind: userQuota, Name: "alice", UsedSlots: 2, MaxSlots: 2,
+			BurstSlots: 3},
+		{ResourcePool: "pool", Kind: userQuota, Name: "bob", UsedSlots: 1, MaxSlots: 2,
+			BurstSlots: 3},
+		{ResourcePool: "pool", Kind: userQuota, Name: "carol", MaxSlots: 1, BurstSlots: 1},
+	})
+}
+
+func TestSlotQuotaValidate(t *testing.T) {
+	assert.NilError(t, check.Validate(SlotQuota{MaxSlots: 2, BurstSlots: newSlots(4)}))
+	assert.ErrorContains(t, check.Validate(SlotQuota{MaxSlots: -1}), "max_slots must be >= 0")
+	assert.ErrorContains(t, check.Validate(SlotQuota{MaxSlots: 2, BurstSlots: newSlots(1)}),
+		"burst_slots must be >= max_slots")
+}
@@ -0,0 +1,79 @@
+package resourcemanagers
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+)
+
+// Reattachment reattaches a task that was restored after a master restart to the containers that
+// it was running when the master stopped, once the agents that kept them running report them.
+type Reattachment struct {
+	req        AllocateRequest
+	containers []cproto.ID
+	reported   map[cproto.ID]RestoredContainer
+}
+
+// NewReattachment returns a reattachment of the task of the request to the given containers, in
+// the order of their ranks. The task actor must have the address of the one that started them.
+func NewReattachment(req AllocateRequest, containers []cproto.ID) *Reattachment {
+	return &Reattachment{
+		req:        req,
+		containers: containers,
+		reported:   make(map[cproto.ID]RestoredContainer),
+	}
+}
+
+// Start asks the agents that registered already to report the containers of the task; agents
+// that register later report them on their own.
+func (r *Reattachment) Start(ctx *actor.Context) {
+	ctx.Self().System().TellAt(sproto.AgentsAddr, sproto.ReportTaskContainers{TaskActor: ctx.Self()})
+}
+
+// Reported records a container that an agent reported, and returns whether all the containers of
+// the task are reported.
+func (r *Reattachment) Reported(msg sproto.TaskContainerReported) bool {
+	for _, id := range r.containers {
+		if id == msg.Container.ID {
+			r.reported[id] = RestoredContainer{Agent: msg.Agent, Container: msg.Container}
+		}
+	}
+	return len(r.reported) == len(r.containers)
+}
+
+// Reattach claims the reported containers from their agents and rebinds the task to them in its
+// resource pool. If that fails, e.g., because a container exited in the meantime, the containers
+// that were claimed are killed.
+func (r *Reattachment) Reattach(ctx *actor.Context, rm *actor.Ref) (*ResourcesAllocated, error) {
+	var err error
+	restored := make([]RestoredContainer, 0, len(r.containers))
+	for _, id := range r.containers {
+		c := r.reported[id]
+		claim := sproto.ClaimTaskContainer{TaskActor: ctx.Self(), ContainerID: id}
+		if claimed, _ := ctx.Ask(c.Agent, claim).Get().(bool); !claimed {
+			err = errors.Errorf("container %s could not be claimed from agent %s",
+				id, c.Agent.Address().Local())
+			break
+		}
+		restored = append(restored, c)
+	}
+
+	if err == nil {
+		resp := ctx.Ask(rm, RestoreAllocation{AllocateRequest: r.req, Containers: restored}).Get()
+		switch resp := resp.(type) {
+		case ResourcesAllocated:
+			return &resp, nil
+		case error:
+			err = resp
+		default:
+			err = errors.Errorf("unexpected response to restoring the allocation: %v", resp)
+		}
+	}
+
+	for _, c := range restored {
+		ctx.Tell(c.Agent, sproto.KillTaskContainer{ContainerID: c.Container.ID})
+	}
+	return nil, err
+}
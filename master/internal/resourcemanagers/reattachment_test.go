@@ -0,0 +1,181 @@
+package resourcemanagers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/provisioner"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+	image "github.com/determined-ai/determined/master/pkg/tasks"
+)
+
+type (
+	startReattachment struct{ containers []cproto.ID }
+	getAllocated      struct{}
+)
+
+// reattachingTask is a task that starts its containers when it is allocated resources and
+// reattaches to them when it is restored after a master restart.
+type reattachingTask struct {
+	rm           *actor.Ref
+	req          AllocateRequest
+	reattachment *Reattachment
+	allocated    *ResourcesAllocated
+}
+
+func (t *reattachingTask) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart, actor.PostStop:
+	case AllocateRequest:
+		msg.TaskActor = ctx.Self()
+		t.req = msg
+		ctx.Tell(t.rm, msg)
+	case ResourcesAllocated:
+		t.allocated = &msg
+		for _, a := range msg.Allocations {
+			a.Start(ctx, image.TaskSpec{StartCommand: &image.StartCommand{
+				AgentUserGroup: &model.AgentUserGroup{},
+			}})
+		}
+	case startReattachment:
+		t.req.TaskActor = ctx.Self()
+		t.reattachment = NewReattachment(t.req, msg.containers)
+		t.reattachment.Start(ctx)
+	case sproto.TaskContainerReported:
+		if t.reattachment != nil && t.reattachment.Reported(msg) {
+			allocated, err := t.reattachment.Reattach(ctx, t.rm)
+			if err != nil {
+				return err
+			}
+			t.allocated, t.reattachment = allocated, nil
+		}
+	case getAllocated:
+		ctx.Respond(t.allocated)
+	case sproto.TaskContainerStateChanged, sproto.ContainerLog:
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+// startMaster starts the agent resource manager of a master that serves agents at the returned
+// websocket URL.
+func startMaster(
+	t *testing.T, reconnectWait time.Duration,
+) (*actor.System, *actor.Ref, string, func()) {
+	system := actor.NewSystem(t.Name())
+	e := echo.New()
+	wait := provisioner.Duration(reconnectWait)
+	rm := Setup(system, e, &ResourceManagerConfig{AgentRM: &AgentResourceManagerConfig{
+		Scheduler:              defaultSchedulerConfig(),
+		DefaultCPUResourcePool: "default",
+		DefaultGPUResourcePool: "default",
+		AgentReconnectWait:     &wait,
+	}}, &ResourcePoolsConfig{ResourcePools: []ResourcePoolConfig{{PoolName: "default"}}}, nil)
+	server := httptest.NewServer(e)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/agents?id=agent&resource_pool=default"
+	return system, rm, url, server.Close
+}
+
+func connectAgent(t *testing.T, url string, started aproto.AgentStarted) *websocket.Conn {
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NilError(t, err)
+	assert.NilError(t, resp.Body.Close())
+	assert.NilError(t, conn.WriteJSON(aproto.MasterMessage{AgentStarted: &started}))
+	return conn
+}
+
+func awaitAllocated(t *testing.T, system *actor.System, task *actor.Ref) *ResourcesAllocated {
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); {
+		if allocated := system.Ask(task, getAllocated{}).Get(); allocated != nil {
+			if allocated := allocated.(*ResourcesAllocated); allocated != nil {
+				return allocated
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("the task was not allocated resources in time")
+	return nil
+}
+
+func TestReattachAfterMasterRestart(t *testing.T) {
+	gpus := []device.Device{{ID: 0, Type: device.GPU}, {ID: 1, Type: device.GPU}}
+	taskAddr := actor.Addr("task")
+	req := AllocateRequest{ID: "task", Name: "task", SlotsNeeded: 1, ResourcePool: "default"}
+
+	// The master allocates a slot to the task and the agent starts its container.
+	system, rm, url, stop := startMaster(t, time.Hour)
+	conn := connectAgent(t, url, aproto.AgentStarted{Devices: gpus})
+	task := system.MustActorOf(taskAddr, &reattachingTask{rm: rm})
+	system.Tell(task, req)
+	allocated := awaitAllocated(t, system, task)
+	assert.Equal(t, len(allocated.Allocations), 1)
+
+	var msg aproto.AgentMessage
+	assert.NilError(t, conn.ReadJSON(&msg))
+	assert.Assert(t, msg.StartContainer != nil)
+	running := msg.StartContainer.Container
+	assert.Equal(t, running.Parent, taskAddr)
+	running.State = cproto.Running
+	assert.NilError(t, conn.WriteJSON(aproto.MasterMessage{
+		ContainerStateChanged: &aproto.ContainerStateChanged{Container: running},
+	}))
+
+	// The master restarts while the container keeps running. The agent reconnects with the
+	// container and with one whose task was not restored.
+	assert.NilError(t, conn.Close())
+	stop()
+	orphan := cproto.Container{Parent: actor.Addr("lost-task"), ID: "orphan", State: cproto.Running}
+	for _, d := range gpus {
+		if d != running.Devices[0] {
+			orphan.Devices = append(orphan.Devices, d)
+		}
+	}
+	reconnectWait := 200 * time.Millisecond
+	system, rm, url, stop = startMaster(t, reconnectWait)
+	defer stop()
+	task = system.MustActorOf(taskAddr, &reattachingTask{rm: rm, req: req})
+	conn = connectAgent(t, url, aproto.AgentStarted{
+		Devices: gpus,
+		Containers: []aproto.ContainerStateChanged{
+			{Container: running}, {Container: orphan},
+		},
+	})
+	defer func() { assert.NilError(t, conn.Close()) }()
+
+	// The restored task reattaches to its container, which is allocated to it again.
+	system.Tell(task, startReattachment{containers: []cproto.ID{running.ID}})
+	allocated = awaitAllocated(t, system, task)
+	assert.Equal(t, allocated.ID, req.ID)
+	assert.Equal(t, len(allocated.Allocations), 1)
+	assert.Equal(t, allocated.Allocations[0].Summary().ID, running.ID)
+
+	summaries := system.Ask(rm, GetTaskSummaries{}).Get().(map[TaskID]TaskSummary)
+	assert.Equal(t, len(summaries), 1)
+	assert.Equal(t, summaries[req.ID].State, SchedulingStateAssigned)
+	assert.Equal(t, len(summaries[req.ID].Containers), 1)
+	assert.Equal(t, summaries[req.ID].Containers[0].ID, running.ID)
+
+	// Once the agent's reconnect grace period ends, only the container that was not claimed is
+	// killed.
+	assert.NilError(t, conn.ReadJSON(&msg))
+	assert.DeepEqual(t, msg, aproto.AgentMessage{SignalContainer: &aproto.SignalContainer{
+		ContainerID: orphan.ID, Signal: syscall.SIGKILL,
+	}})
+	assert.NilError(t, conn.SetReadDeadline(time.Now().Add(2*reconnectWait)))
+	err := conn.ReadJSON(&msg)
+	assert.Assert(t, err != nil, "unexpected message to the agent: %v", msg)
+	assert.Assert(t, strings.Contains(err.Error(), "timeout"), err)
+}
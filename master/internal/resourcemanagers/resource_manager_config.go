@@ -2,6 +2,7 @@ package resourcemanagers
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -10,7 +11,12 @@ import (
 	"github.com/determined-ai/determined/master/pkg/union"
 )
 
-const defaultResourcePoolName = "default"
+const (
+	defaultResourcePoolName = "default"
+	// defaultAgentReconnectWait is how long agents whose connection to the master drops have to
+	// reconnect before their containers are considered lost.
+	defaultAgentReconnectWait = provisioner.Duration(time.Minute)
+)
 
 // ResolveConfig applies backwards compatibility for the old scheduler
 // and provisioner configuration.
@@ -66,6 +72,8 @@ func ResolveConfig(
 				}
 			case roundRobinScheduling:
 				schedulerPolicyConf.RoundRobin = &RoundRobinSchedulerConfig{}
+			case backfillScheduling:
+				schedulerPolicyConf.Backfill = &BackfillSchedulerConfig{}
 			default:
 				return nil, nil, errors.Errorf(
 					"unsupported scheduler type %s; "+
@@ -94,6 +102,10 @@ func ResolveConfig(
 		if resourceManagerConf.AgentRM.Scheduler == nil {
 			resourceManagerConf.AgentRM.Scheduler = defaultSchedulerConfig()
 		}
+		if resourceManagerConf.AgentRM.AgentReconnectWait == nil {
+			wait := defaultAgentReconnectWait
+			resourceManagerConf.AgentRM.AgentReconnectWait = &wait
+		}
 
 		// Fill in default fitting policy and default priority if unspecified.
 		fillInSchedulerDefaults(resourceManagerConf.AgentRM.Scheduler)
@@ -124,10 +136,12 @@ func DefaultRMConfig() *ResourceManagerConfig {
 
 // DefaultAgentRMConfig returns the default determined resource manager configuration.
 func DefaultAgentRMConfig() *AgentResourceManagerConfig {
+	wait := defaultAgentReconnectWait
 	return &AgentResourceManagerConfig{
 		Scheduler:              defaultSchedulerConfig(),
 		DefaultGPUResourcePool: defaultResourcePoolName,
 		DefaultCPUResourcePool: defaultResourcePoolName,
+		AgentReconnectWait:     &wait,
 	}
 }
 
@@ -156,14 +170,32 @@ type AgentResourceManagerConfig struct {
 	Scheduler              *SchedulerConfig `json:"scheduler"`
 	DefaultCPUResourcePool string           `json:"default_cpu_resource_pool"`
 	DefaultGPUResourcePool string           `json:"default_gpu_resource_pool"`
+	// AgentReconnectWait is how long agents whose connection to the master drops have to reconnect
+	// before their containers are considered lost; zero disables reconnection.
+	AgentReconnectWait *provisioner.Duration `json:"agent_reconnect_wait"`
+}
+
+// AgentReconnectWait returns how long agents have to reconnect to the master, which is also how
+// long tasks that are restored after a master restart wait for the agents to report their
+// containers. It is zero unless the agent resource manager is used.
+func (r ResourceManagerConfig) AgentReconnectWait() time.Duration {
+	if r.AgentRM == nil || r.AgentRM.AgentReconnectWait == nil {
+		return 0
+	}
+	return time.Duration(*r.AgentRM.AgentReconnectWait)
 }
 
 // Validate implements the check.Validatable interface.
 func (a AgentResourceManagerConfig) Validate() []error {
-	return []error{
+	errs := []error{
 		check.NotEmpty(a.DefaultCPUResourcePool, "default_cpu_resource_pool should be non-empty"),
 		check.NotEmpty(a.DefaultGPUResourcePool, "default_gpu_resource_pool should be non-empty"),
 	}
+	if a.AgentReconnectWait != nil {
+		errs = append(errs, check.GreaterThanOrEqualTo(int64(*a.AgentReconnectWait), int64(0),
+			"agent_reconnect_wait must be >= 0"))
+	}
+	return errs
 }
 
 // KubernetesResourceManagerConfig hosts configuration fields for the kubernetes resource manager.
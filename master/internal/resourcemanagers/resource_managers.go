@@ -56,7 +56,9 @@ func (rm *ResourceManagers) Receive(ctx *actor.Context) error {
 		AllocateRequest, ResourcesReleased,
 		sproto.SetGroupMaxSlots, sproto.SetGroupWeight,
 		sproto.SetGroupPriority, GetTaskSummary,
-		GetTaskSummaries, SetTaskName:
+		GetTaskSummaries, SetTaskName, TaskCheckpointed,
+		GetQuotaSummaries, GetResourcePoolSummaries,
+		RestoreAllocation:
 		rm.forward(ctx, msg)
 
 	default:
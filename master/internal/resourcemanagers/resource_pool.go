@@ -2,10 +2,12 @@ package resourcemanagers
 
 import (
 	"crypto/tls"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/prom"
 	"github.com/determined-ai/determined/master/internal/provisioner"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
@@ -22,10 +24,11 @@ type ResourcePool struct {
 	config *ResourcePoolConfig
 	cert   *tls.Certificate
 
-	scheduler        Scheduler
-	fittingMethod    SoftConstraint
-	provisioner      *actor.Ref
-	slotsPerInstance int
+	scheduler     Scheduler
+	fittingMethod SoftConstraint
+	provisioner   *actor.Ref
+	// provisionerSummary is the latest state that the provisioner reported.
+	provisionerSummary *provisioner.Summary
 
 	agents      map[*actor.Ref]*agentState
 	taskList    *taskList
@@ -68,11 +71,10 @@ func (rp *ResourcePool) setupProvisioner(ctx *actor.Context) error {
 		ctx.Log().Infof("not enabling provisioner for resource pool: %s", rp.config.PoolName)
 		return nil
 	}
-	p, pRef, err := provisioner.Setup(ctx, rp.config.Provider, rp.config.PoolName, rp.cert)
+	_, pRef, err := provisioner.Setup(ctx, rp.config.Provider, rp.config.PoolName, rp.cert)
 	if err != nil {
 		return errors.Wrapf(err, "cannot create resource pool: %s", rp.config.PoolName)
 	}
-	rp.slotsPerInstance = p.SlotsPerInstance()
 	rp.provisioner = pRef
 	return nil
 }
@@ -107,10 +109,40 @@ func (rp *ResourcePool) receiveSetTaskName(ctx *actor.Context, msg SetTaskName)
 // allocateResources assigns resources based on a request and notifies the request
 // handler of the assignment. It returns true if it is successfully allocated.
 func (rp *ResourcePool) allocateResources(ctx *actor.Context, req *AllocateRequest) bool {
+	_, wasExceeded := rp.taskList.QuotaExceeded(req.TaskActor)
+	allocated, quota := rp.assignResources(req)
+	if quota != nil && !wasExceeded {
+		ctx.Log().Infof("%s of %s is exceeded for %s",
+			quota, rp.config.PoolName, req.TaskActor.Address())
+	}
+	if allocated == nil {
+		return false
+	}
+
+	req.TaskActor.System().Tell(req.TaskActor, *allocated)
+	ctx.Log().Infof("allocated resources to %s", req.TaskActor.Address())
+	recordSlotsAllocated(ctx, req, rp.config.PoolName, rp.config.SlotHourlyRate)
+	if requestTime, ok := rp.taskList.RequestTime(req.TaskActor); ok {
+		prom.SchedulerWaitSeconds.WithLabelValues(rp.config.PoolName).Observe(
+			time.Since(requestTime).Seconds())
+	}
+
+	return true
+}
+
+// assignResources assigns resources to the task in the state of the resource pool without
+// notifying the task. If the task would exceed a quota, it is not assigned resources and the
+// quota is returned.
+func (rp *ResourcePool) assignResources(req *AllocateRequest) (*ResourcesAllocated, *quotaKey) {
+	if quota := rp.exceededQuota(req); quota != nil {
+		rp.taskList.SetQuotaExceeded(req.TaskActor, quota.String())
+		return nil, quota
+	}
+
 	fits := findFits(req, rp.agents, rp.fittingMethod)
 
 	if len(fits) == 0 {
-		return false
+		return nil, nil
 	}
 
 	allocations := make([]Allocation, 0, len(fits))
@@ -128,10 +160,49 @@ func (rp *ResourcePool) allocateResources(ctx *actor.Context, req *AllocateReque
 		ID: req.ID, ResourcePool: rp.config.PoolName, Allocations: allocations,
 	}
 	rp.taskList.SetAllocations(req.TaskActor, &allocated)
-	req.TaskActor.System().Tell(req.TaskActor, allocated)
-	ctx.Log().Infof("allocated resources to %s", req.TaskActor.Address())
+	return &allocated, nil
+}
 
-	return true
+// restoreAllocation adds a task that was restored after a master restart with the containers that
+// it claimed from the agents, whose devices are assigned to them, as if they were allocated now.
+func (rp *ResourcePool) restoreAllocation(
+	ctx *actor.Context, msg RestoreAllocation,
+) (*ResourcesAllocated, error) {
+	for _, c := range msg.Containers {
+		if _, ok := rp.agents[c.Agent]; !ok {
+			return nil, errors.Errorf("cannot restore container %s: agent %s is not registered",
+				c.Container.ID, c.Agent.Address().Local())
+		}
+	}
+
+	rp.addTask(ctx, msg.AllocateRequest)
+	req, _ := rp.taskList.GetTaskByHandler(msg.TaskActor)
+	allocations := make([]Allocation, 0, len(msg.Containers))
+	for _, c := range msg.Containers {
+		agent, id := rp.agents[c.Agent], c.Container.ID
+		if len(c.Container.Devices) == 0 {
+			agent.zeroSlotContainers[id] = true
+		}
+		for _, d := range c.Container.Devices {
+			agent.devices[d] = &id
+		}
+		allocations = append(allocations, &containerAllocation{
+			req:   req,
+			agent: agent,
+			container: &container{
+				req: req, id: id, slots: len(c.Container.Devices), agent: agent,
+			},
+			devices: c.Container.Devices,
+		})
+	}
+
+	allocated := ResourcesAllocated{
+		ID: req.ID, ResourcePool: rp.config.PoolName, Allocations: allocations,
+	}
+	rp.taskList.SetAllocations(req.TaskActor, &allocated)
+	ctx.Log().Infof("restored the resources allocated to %s", req.TaskActor.Address())
+	recordSlotsAllocated(ctx, req, rp.config.PoolName, rp.config.SlotHourlyRate)
+	return &allocated, nil
 }
 
 func (rp *ResourcePool) releaseResource(ctx *actor.Context, handler *actor.Ref) {
@@ -141,6 +212,11 @@ func (rp *ResourcePool) releaseResource(ctx *actor.Context, handler *actor.Ref)
 
 func (rp *ResourcePool) resourcesReleased(ctx *actor.Context, handler *actor.Ref) {
 	ctx.Log().Infof("resources are released for %s", handler.Address())
+	if rp.taskList.GetAllocations(handler) != nil {
+		if req, ok := rp.taskList.GetTaskByHandler(handler); ok {
+			recordSlotsReleased(ctx, req)
+		}
+	}
 	rp.taskList.RemoveTaskByHandler(handler)
 }
 
@@ -176,13 +252,13 @@ func (rp *ResourcePool) notifyOnStop(
 }
 
 func (rp *ResourcePool) updateScalingInfo() bool {
-	desiredInstanceNum := calculateDesiredNewInstanceNum(rp.taskList, rp.slotsPerInstance)
+	pendingSlots := calculatePendingSlots(rp.taskList)
 	agents := make(map[string]sproto.AgentSummary)
 	for _, agentState := range rp.agents {
 		summary := newAgentSummary(agentState)
 		agents[summary.Name] = summary
 	}
-	return rp.scalingInfo.Update(desiredInstanceNum, agents)
+	return rp.scalingInfo.Update(pendingSlots, agents)
 }
 
 func (rp *ResourcePool) sendScalingInfo(ctx *actor.Context) {
@@ -191,6 +267,29 @@ func (rp *ResourcePool) sendScalingInfo(ctx *actor.Context) {
 	}
 }
 
+// updateMetrics exports the length of the queue of the resource pool and the slot utilization of
+// its agents.
+func (rp *ResourcePool) updateMetrics() {
+	pending, allocated := 0, 0
+	for it := rp.taskList.iterator(); it.next(); {
+		if rp.taskList.GetAllocations(it.value().TaskActor) == nil {
+			pending++
+		} else {
+			allocated++
+		}
+	}
+	prom.SchedulerPendingTasks.WithLabelValues(rp.config.PoolName).Set(float64(pending))
+	prom.SchedulerAllocatedTasks.WithLabelValues(rp.config.PoolName).Set(float64(allocated))
+
+	for _, state := range rp.agents {
+		name := state.handler.Address().Local()
+		prom.AgentSlots.WithLabelValues(rp.config.PoolName, name, state.label).Set(
+			float64(state.numSlots()))
+		prom.AgentSlotsUsed.WithLabelValues(rp.config.PoolName, name, state.label).Set(
+			float64(state.numUsedSlots()))
+	}
+}
+
 // Receive implements the actor.Actor interface.
 func (rp *ResourcePool) Receive(ctx *actor.Context) error {
 	ctx.AddLabel("resource-pool", rp.config.PoolName)
@@ -213,7 +312,9 @@ func (rp *ResourcePool) Receive(ctx *actor.Context) error {
 		sproto.AddDevice,
 		sproto.FreeDevice,
 		sproto.RemoveDevice,
-		sproto.RemoveAgent:
+		sproto.RemoveAgent,
+		sproto.DrainAgent,
+		sproto.StopDrainingAgent:
 		return rp.receiveAgentMsg(ctx)
 
 	case
@@ -222,7 +323,9 @@ func (rp *ResourcePool) Receive(ctx *actor.Context) error {
 		sproto.SetGroupWeight,
 		sproto.SetGroupPriority,
 		SetTaskName,
+		TaskCheckpointed,
 		AllocateRequest,
+		RestoreAllocation,
 		ResourcesReleased:
 		return rp.receiveRequestMsg(ctx)
 
@@ -236,6 +339,18 @@ func (rp *ResourcePool) Receive(ctx *actor.Context) error {
 		reschedule = false
 		ctx.Respond(getTaskSummaries(rp.taskList))
 
+	case GetQuotaSummaries:
+		reschedule = false
+		ctx.Respond(rp.getQuotaSummaries())
+
+	case GetResourcePoolSummaries:
+		reschedule = false
+		ctx.Respond(getResourcePoolSummary(rp))
+
+	case provisioner.Summary:
+		reschedule = false
+		rp.provisionerSummary = &msg
+
 	case schedulerTick:
 		if rp.reschedule {
 			toAllocate, toRelease := rp.scheduler.Schedule(rp)
@@ -247,6 +362,7 @@ func (rp *ResourcePool) Receive(ctx *actor.Context) error {
 			}
 			rp.sendScalingInfo(ctx)
 		}
+		rp.updateMetrics()
 		rp.reschedule = false
 		reschedule = false
 		actors.NotifyAfter(ctx, actionCoolDown, schedulerTick{})
@@ -293,8 +409,34 @@ func (rp *ResourcePool) receiveAgentMsg(ctx *actor.Context) error {
 
 	case sproto.RemoveAgent:
 		ctx.Log().Infof("removing agent: %s", msg.Agent.Address().Local())
+		if state, ok := rp.agents[msg.Agent]; ok {
+			name := msg.Agent.Address().Local()
+			prom.AgentSlots.DeleteLabelValues(rp.config.PoolName, name, state.label)
+			prom.AgentSlotsUsed.DeleteLabelValues(rp.config.PoolName, name, state.label)
+		}
 		delete(rp.agents, msg.Agent)
 
+	case sproto.DrainAgent:
+		ctx.Log().Infof("draining agent: %s", msg.Agent.Address().Local())
+		if state, ok := rp.agents[msg.Agent]; ok {
+			state.draining = true
+		}
+		// Non-preemptible tasks, e.g., commands and notebooks, cannot resume elsewhere, so they
+		// keep running on the agent until they exit.
+		for it := rp.taskList.iterator(); it.next(); {
+			req := it.value()
+			if !req.NonPreemptible &&
+				runsOnAgent(rp.taskList.GetAllocations(req.TaskActor), msg.Agent) {
+				rp.releaseResource(ctx, req.TaskActor)
+			}
+		}
+
+	case sproto.StopDrainingAgent:
+		ctx.Log().Infof("stopped draining agent: %s", msg.Agent.Address().Local())
+		if state, ok := rp.agents[msg.Agent]; ok {
+			state.draining = false
+		}
+
 	default:
 		return actor.ErrUnexpectedMessage(ctx)
 	}
@@ -326,9 +468,20 @@ func (rp *ResourcePool) receiveRequestMsg(ctx *actor.Context) error {
 	case SetTaskName:
 		rp.receiveSetTaskName(ctx, msg)
 
+	case TaskCheckpointed:
+		rp.taskList.SetCheckpointed(msg.TaskHandler)
+
 	case AllocateRequest:
 		rp.addTask(ctx, msg)
 
+	case RestoreAllocation:
+		allocated, err := rp.restoreAllocation(ctx, msg)
+		if err != nil {
+			ctx.Respond(err)
+		} else {
+			ctx.Respond(*allocated)
+		}
+
 	case ResourcesReleased:
 		rp.resourcesReleased(ctx, msg.TaskActor)
 
@@ -338,6 +491,19 @@ func (rp *ResourcePool) receiveRequestMsg(ctx *actor.Context) error {
 	return nil
 }
 
+// runsOnAgent returns whether any container of the allocated resources runs on the agent.
+func runsOnAgent(allocated *ResourcesAllocated, agent *actor.Ref) bool {
+	if allocated == nil {
+		return false
+	}
+	for _, allocation := range allocated.Allocations {
+		if allocation.Summary().Agent == agent.Address().Local() {
+			return true
+		}
+	}
+	return false
+}
+
 // containerAllocation contains information for tasks have been allocated but not yet started.
 type containerAllocation struct {
 	req       *AllocateRequest
@@ -20,15 +20,58 @@ type ResourcePoolConfig struct {
 	Description string              `json:"description"`
 	Provider    *provisioner.Config `json:"provider"`
 	Scheduler   *SchedulerConfig    `json:"scheduler,omitempty"`
+	Quotas      *QuotaConfig        `json:"quotas,omitempty"`
+	// SlotHourlyRate is the price of using one slot of the pool for an hour, which usage reports
+	// use to compute the cost of slot usage.
+	SlotHourlyRate *float64 `json:"slot_hourly_rate,omitempty"`
 }
 
 // Validate implements the check.Validatable interface.
 func (r ResourcePoolConfig) Validate() []error {
 	return []error{
 		check.True(len(r.PoolName) != 0, "resource pool name cannot be empty"),
+		check.GreaterThanOrEqualTo(r.SlotHourlyRate, float64(0), "slot_hourly_rate must be >= 0"),
 	}
 }
 
+// QuotaConfig hosts the slot quotas of a resource pool. The pool quota applies to all tasks in the
+// pool, the user quotas to the tasks of each user and the label quotas to the tasks of experiments
+// with each label. Users without a quota of their own are subject to the default user quota.
+type QuotaConfig struct {
+	Pool        *SlotQuota           `json:"pool,omitempty"`
+	DefaultUser *SlotQuota           `json:"default_user,omitempty"`
+	Users       map[string]SlotQuota `json:"users,omitempty"`
+	Labels      map[string]SlotQuota `json:"labels,omitempty"`
+}
+
+// SlotQuota limits the number of slots that a set of tasks can use. The tasks may use up to
+// MaxSlots slots at any time, and up to BurstSlots slots while no other task in the pool that is
+// within its quotas is waiting for the slots. BurstSlots defaults to MaxSlots.
+type SlotQuota struct {
+	MaxSlots   int  `json:"max_slots"`
+	BurstSlots *int `json:"burst_slots,omitempty"`
+}
+
+// Validate implements the check.Validatable interface.
+func (q SlotQuota) Validate() []error {
+	errs := []error{
+		check.GreaterThanOrEqualTo(q.MaxSlots, 0, "max_slots must be >= 0"),
+	}
+	if q.BurstSlots != nil {
+		errs = append(errs, check.GreaterThanOrEqualTo(
+			*q.BurstSlots, q.MaxSlots, "burst_slots must be >= max_slots"))
+	}
+	return errs
+}
+
+// burstSlots returns the number of slots that the tasks may use while bursting.
+func (q SlotQuota) burstSlots() int {
+	if q.BurstSlots == nil {
+		return q.MaxSlots
+	}
+	return *q.BurstSlots
+}
+
 // ResourcePoolsConfig hosts the configuration for resource pools
 type ResourcePoolsConfig struct {
 	ResourcePools []ResourcePoolConfig `json:"resource_pools"`
@@ -2,6 +2,7 @@ package resourcemanagers
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	cproto "github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/model"
 )
 
 func TestCleanUpTaskWhenTaskActorStopsWithError(t *testing.T) {
@@ -108,13 +110,12 @@ func TestScalingInfoAgentSummary(t *testing.T) {
 		{id: "unallocated-gpu-task5", slotsNeeded: 5},
 	}
 	rp, _ := setupResourcePool(t, system, nil, tasks, nil, agents)
-	rp.slotsPerInstance = 4
 
 	// Test basic.
 	updated := rp.updateScalingInfo()
 	assert.Check(t, updated)
 	assert.DeepEqual(t, *rp.scalingInfo, sproto.ScalingInfo{
-		DesiredNewInstances: 1,
+		PendingSlots: []int{5, 1},
 		Agents: map[string]sproto.AgentSummary{
 			"agent1": {Name: "agent1", IsIdle: false},
 			"agent2": {Name: "agent2", IsIdle: false},
@@ -127,7 +128,7 @@ func TestScalingInfoAgentSummary(t *testing.T) {
 	updated = rp.updateScalingInfo()
 	assert.Check(t, updated)
 	assert.DeepEqual(t, *rp.scalingInfo, sproto.ScalingInfo{
-		DesiredNewInstances: 1,
+		PendingSlots: []int{5, 1},
 		Agents: map[string]sproto.AgentSummary{
 			"agent1": {Name: "agent1", IsIdle: false},
 			"agent2": {Name: "agent2", IsIdle: false},
@@ -142,7 +143,7 @@ func TestScalingInfoAgentSummary(t *testing.T) {
 	updated = rp.updateScalingInfo()
 	assert.Check(t, updated)
 	assert.DeepEqual(t, *rp.scalingInfo, sproto.ScalingInfo{
-		DesiredNewInstances: 1,
+		PendingSlots: []int{5, 1},
 		Agents: map[string]sproto.AgentSummary{
 			"agent2": {Name: "agent2", IsIdle: false},
 			"agent3": {Name: "agent3", IsIdle: true},
@@ -163,7 +164,7 @@ func TestScalingInfoAgentSummary(t *testing.T) {
 	updated = rp.updateScalingInfo()
 	assert.Check(t, updated)
 	assert.DeepEqual(t, *rp.scalingInfo, sproto.ScalingInfo{
-		DesiredNewInstances: 1,
+		PendingSlots: []int{5, 1},
 		Agents: map[string]sproto.AgentSummary{
 			"agent2": {Name: "agent2", IsIdle: false},
 			"agent3": {Name: "agent3", IsIdle: false},
@@ -204,3 +205,102 @@ func TestSettingGroupPriority(t *testing.T) {
 	assert.Equal(t, *rp.groups[groupRefOne].priority, updatedPriority)
 	assert.Equal(t, *rp.groups[groupRefTwo].priority, defaultPriority)
 }
+
+type (
+	mockUsageRecorder struct {
+		recorded []actor.Message
+	}
+	getRecordedUsage struct{}
+)
+
+func (r *mockUsageRecorder) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart, actor.PostStop:
+	case sproto.SlotsAllocated, sproto.SlotsReleased:
+		r.recorded = append(r.recorded, msg)
+	case getRecordedUsage:
+		ctx.Respond(r.recorded)
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func TestRecordSlotUsage(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	recorder, created := system.ActorOf(sproto.UsageRecorderAddr, &mockUsageRecorder{})
+	assert.Assert(t, created)
+
+	rate := 2.5
+	config := &ResourcePoolConfig{PoolName: "pool", SlotHourlyRate: &rate}
+	agents := []*mockAgent{{id: "agent", slots: 2}}
+	tasks := []*mockTask{{id: "task", slotsNeeded: 2}, {id: "cpu-task", slotsNeeded: 0}}
+	_, ref := setupResourcePool(t, system, config, tasks, nil, agents)
+
+	for _, task := range tasks {
+		taskRef := system.Get(actor.Addr(task.id))
+		system.Ask(taskRef, SendRequestResourcesToResourceManager{}).Get()
+	}
+	system.Ask(ref, schedulerTick{}).Get()
+	for _, task := range tasks {
+		taskRef := system.Get(actor.Addr(task.id))
+		system.Ask(taskRef, ReleaseResources{}).Get()
+	}
+	system.Ask(ref, actor.Ping{}).Get()
+
+	// Tasks that use no slots are not recorded.
+	recorded := system.Ask(recorder, getRecordedUsage{}).Get().([]actor.Message)
+	assert.Equal(t, len(recorded), 2)
+	allocated := recorded[0].(sproto.SlotsAllocated)
+	assert.Assert(t, !allocated.Usage.StartTime.IsZero())
+	allocated.Usage.StartTime = time.Time{}
+	assert.DeepEqual(t, allocated.Usage, model.SlotUsage{
+		TaskID:         "task",
+		ResourcePool:   "pool",
+		Slots:          2,
+		SlotHourlyRate: &rate,
+	})
+	released := recorded[1].(sproto.SlotsReleased)
+	assert.Equal(t, released.TaskID, "task")
+	assert.Assert(t, !released.Time.Before(allocated.Usage.StartTime))
+}
+
+func TestDrainAgent(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agents := []*mockAgent{{id: "agent1", slots: 3}, {id: "agent2", slots: 3}}
+	tasks := []*mockTask{
+		{id: "trial", slotsNeeded: 1, allocatedAgent: agents[0], containerStarted: true},
+		{
+			id: "command", slotsNeeded: 1, nonPreemptible: true,
+			allocatedAgent: agents[0], containerStarted: true,
+		},
+		{id: "other-trial", slotsNeeded: 1, allocatedAgent: agents[1], containerStarted: true},
+	}
+	rp, ref := setupResourcePool(t, system, nil, tasks, nil, agents)
+	agent1 := system.Get(actor.Addr("agent1"))
+
+	// Only the trial on the drained agent releases its resources; the command keeps running.
+	trial := system.Get(actor.Addr("trial"))
+	req, ok := rp.taskList.GetTaskByHandler(trial)
+	assert.Assert(t, ok)
+	system.Ask(ref, sproto.DrainAgent{Agent: agent1}).Get()
+	system.Ask(trial, actor.Ping{}).Get()
+	system.Ask(ref, actor.Ping{}).Get()
+	_, ok = rp.taskList.GetTaskByHandler(trial)
+	assert.Assert(t, !ok)
+	assert.Assert(t, rp.taskList.GetAllocations(system.Get(actor.Addr("command"))) != nil)
+	assert.Assert(t, rp.taskList.GetAllocations(system.Get(actor.Addr("other-trial"))) != nil)
+
+	// The trial is placed on the other agent when it asks for resources again, although the
+	// drained agent has a free slot and fits it best.
+	assert.Equal(t, rp.agents[agent1].numEmptySlots(), 1)
+	fits := findFits(req, rp.agents, rp.fittingMethod)
+	assert.Equal(t, len(fits), 1)
+	assert.Equal(t, fits[0].Agent.handler.Address().Local(), "agent2")
+
+	// Tasks fit on the agent again once it stops draining.
+	system.Ask(ref, sproto.StopDrainingAgent{Agent: agent1}).Get()
+	fits = findFits(req, rp.agents, rp.fittingMethod)
+	assert.Equal(t, len(fits), 1)
+	assert.Equal(t, fits[0].Agent.handler.Address().Local(), "agent1")
+}
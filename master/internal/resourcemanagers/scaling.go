@@ -1,35 +1,18 @@
 package resourcemanagers
 
-// calculateDesiredNewInstanceNum calculates the new instances based on pending tasks and
-// slots per instance.
-func calculateDesiredNewInstanceNum(taskList *taskList, slotsPerInstance int) int {
-	slotSum := 0
-	zeroSlotTasks := false
+import "sort"
+
+// calculatePendingSlots returns the numbers of slots that the tasks waiting for resources need, in
+// decreasing order. The provisioner decides which instances to launch to fit them.
+func calculatePendingSlots(taskList *taskList) []int {
+	var pendingSlots []int
 	for it := taskList.iterator(); it.next(); {
-		// TODO(DET-4035): This code is duplicated from the fitting functions in the
-		//    scheduler. To determine is a task is schedulable, we would ideally interface
-		//    with the scheduler in some way and not duplicate this logic.
-		switch {
-		case taskList.GetAllocations(it.value().TaskActor) != nil:
-			// If a task is already allocated, skip it.
-			continue
-		case it.value().SlotsNeeded == 0:
-			zeroSlotTasks = true
-		case slotsPerInstance == 0:
+		// If a task is already allocated, skip it.
+		if taskList.GetAllocations(it.value().TaskActor) != nil {
 			continue
-		case it.value().SlotsNeeded <= slotsPerInstance:
-			slotSum += it.value().SlotsNeeded
-		case it.value().SlotsNeeded%slotsPerInstance == 0:
-			slotSum += it.value().SlotsNeeded
 		}
+		pendingSlots = append(pendingSlots, it.value().SlotsNeeded)
 	}
-
-	switch {
-	case zeroSlotTasks && slotSum == 0:
-		return 1
-	case !zeroSlotTasks && slotsPerInstance == 0:
-		return 0
-	default:
-		return (slotSum + slotsPerInstance - 1) / slotsPerInstance
-	}
+	sort.Sort(sort.Reverse(sort.IntSlice(pendingSlots)))
+	return pendingSlots
 }
@@ -13,14 +13,16 @@ type Scheduler interface {
 }
 
 // MakeScheduler returns the corresponding scheduler implementation.
-func MakeScheduler(schedulingPolicy string) Scheduler {
-	switch schedulingPolicy {
+func MakeScheduler(config *SchedulerConfig) Scheduler {
+	switch schedulingPolicy := config.getType(); schedulingPolicy {
 	case priorityScheduling:
-		return NewRoundRobinScheduler()
+		return NewPriorityScheduler(config.Priority)
 	case fairShareScheduling:
 		return NewFairShareScheduler()
 	case roundRobinScheduling:
 		return NewRoundRobinScheduler()
+	case backfillScheduling:
+		return NewBackfillScheduler()
 	default:
 		panic(fmt.Sprintf("invalid scheduler: %s", schedulingPolicy))
 	}
@@ -12,9 +12,11 @@ const (
 	fairShareScheduling  = "fair_share"
 	priorityScheduling   = "priority"
 	roundRobinScheduling = "round_robin"
+	backfillScheduling   = "backfill"
 
 	best             = "best"
 	worst            = "worst"
+	topology         = "topology"
 	defaultFitPolicy = best
 )
 
@@ -30,6 +32,7 @@ type SchedulerConfig struct {
 	FairShare     *FairShareSchedulerConfig  `union:"type,fair_share" json:"-"`
 	Priority      *PrioritySchedulerConfig   `union:"type,priority" json:"-"`
 	RoundRobin    *RoundRobinSchedulerConfig `union:"type,round_robin" json:"-"`
+	Backfill      *BackfillSchedulerConfig   `union:"type,backfill" json:"-"`
 	FittingPolicy string                     `json:"fitting_policy"`
 }
 
@@ -47,7 +50,7 @@ func (s *SchedulerConfig) UnmarshalJSON(data []byte) error {
 func (s SchedulerConfig) Validate() []error {
 	return []error{
 		check.Contains(
-			s.FittingPolicy, []interface{}{best, worst}, "invalid fitting policy",
+			s.FittingPolicy, []interface{}{best, worst, topology}, "invalid fitting policy",
 		),
 	}
 }
@@ -71,6 +74,8 @@ func (s *SchedulerConfig) getType() string {
 		return priorityScheduling
 	case s.RoundRobin != nil:
 		return roundRobinScheduling
+	case s.Backfill != nil:
+		return backfillScheduling
 	default:
 		panic("neither scheduler type configured")
 	}
@@ -88,6 +93,9 @@ type PrioritySchedulerConfig struct {
 // RoundRobinSchedulerConfig holds the configurations for the round robing scheduler.
 type RoundRobinSchedulerConfig struct{}
 
+// BackfillSchedulerConfig holds the configurations for the backfill scheduler.
+type BackfillSchedulerConfig struct{}
+
 // Validate implements the check.Validatable interface.
 func (p PrioritySchedulerConfig) Validate() []error {
 	return model.ValidatePrioritySetting(p.DefaultPriority)
@@ -2,6 +2,7 @@ package resourcemanagers
 
 import (
 	"crypto/tls"
+	"time"
 
 	"github.com/labstack/echo"
 	"github.com/sirupsen/logrus"
@@ -23,7 +24,8 @@ func Setup(
 	var ref *actor.Ref
 	switch {
 	case rmConfig.AgentRM != nil:
-		ref = setupAgentResourceManager(system, echo, rmConfig.AgentRM, poolsConfig, cert)
+		ref = setupAgentResourceManager(
+			system, echo, rmConfig.AgentRM, poolsConfig, cert, rmConfig.AgentReconnectWait())
 	case rmConfig.KubernetesRM != nil:
 		ref = setupKubernetesResourceManager(system, echo, rmConfig.KubernetesRM)
 	default:
@@ -43,6 +45,7 @@ func setupAgentResourceManager(
 	rmConfig *AgentResourceManagerConfig,
 	poolsConfig *ResourcePoolsConfig,
 	cert *tls.Certificate,
+	reconnectWait time.Duration,
 ) *actor.Ref {
 	ref, _ := system.ActorOf(
 		actor.Addr("agentRM"),
@@ -51,7 +54,7 @@ func setupAgentResourceManager(
 	system.Ask(ref, actor.Ping{}).Get()
 
 	logrus.Infof("initializing endpoints for agents")
-	agent.Initialize(system, echo, ref)
+	agent.Initialize(system, echo, ref, reconnectWait)
 	return ref
 }
 
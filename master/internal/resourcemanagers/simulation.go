@@ -0,0 +1,404 @@
+package resourcemanagers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/provisioner"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+// SimulationTrace describes the agents of a resource pool and the tasks that arrive at it.
+type SimulationTrace struct {
+	Agents []SimulatedAgent `json:"agents"`
+	Groups []SimulatedGroup `json:"groups"`
+	Tasks  []SimulatedTask  `json:"tasks"`
+	Quotas *QuotaConfig     `json:"quotas,omitempty"`
+}
+
+// SimulatedAgent describes Count identical agents.
+type SimulatedAgent struct {
+	ID       string `json:"id"`
+	Count    int    `json:"count"`
+	Slots    int    `json:"slots"`
+	Label    string `json:"label"`
+	Topology string `json:"topology"`
+}
+
+// SimulatedGroup describes a group of tasks, such as the trials of an experiment.
+type SimulatedGroup struct {
+	ID       string  `json:"id"`
+	Priority *int    `json:"priority,omitempty"`
+	Weight   float64 `json:"weight"`
+	MaxSlots *int    `json:"max_slots,omitempty"`
+}
+
+// SimulatedTask describes a task that arrives at the resource pool and runs for Duration once it
+// is allocated resources. Tasks that are asked to release their resources are assumed to save
+// their progress first, and continue from it once they are allocated resources again. Estimate is
+// the duration that the scheduler is told to expect, if it is known.
+type SimulatedTask struct {
+	ID             string               `json:"id"`
+	Group          string               `json:"group"`
+	Arrival        provisioner.Duration `json:"arrival"`
+	Duration       provisioner.Duration `json:"duration"`
+	Estimate       provisioner.Duration `json:"estimate"`
+	Slots          int                  `json:"slots"`
+	Label          string               `json:"label"`
+	User           string               `json:"user"`
+	Labels         []string             `json:"labels"`
+	NonPreemptible bool                 `json:"non_preemptible"`
+	SingleAgent    bool                 `json:"single_agent"`
+}
+
+// Validate implements the check.Validatable interface.
+func (t SimulationTrace) Validate() []error {
+	errs := []error{
+		check.GreaterThan(len(t.Agents), 0, "a trace must have agents"),
+	}
+	groups := make(map[string]bool)
+	for _, g := range t.Groups {
+		if groups[g.ID] {
+			errs = append(errs, errors.Errorf("duplicate group: %s", g.ID))
+		}
+		groups[g.ID] = true
+	}
+	tasks := make(map[string]bool)
+	for _, task := range t.Tasks {
+		if tasks[task.ID] {
+			errs = append(errs, errors.Errorf("duplicate task: %s", task.ID))
+		}
+		tasks[task.ID] = true
+		if task.Group != "" && !groups[task.Group] {
+			errs = append(errs, errors.Errorf("task %s has an unknown group: %s", task.ID, task.Group))
+		}
+		errs = append(errs,
+			check.GreaterThanOrEqualTo(task.Slots, 0, "task %s: slots must be >= 0", task.ID),
+			check.True(task.Duration > 0, "task %s: duration must be > 0", task.ID),
+			check.True(task.Estimate >= 0, "task %s: estimate must be >= 0", task.ID),
+		)
+	}
+	return errs
+}
+
+// SimulationReport summarizes how the tasks of a trace were scheduled.
+type SimulationReport struct {
+	Scheduler     string `json:"scheduler"`
+	FittingPolicy string `json:"fitting_policy"`
+
+	Tasks            int `json:"tasks"`
+	CompletedTasks   int `json:"completed_tasks"`
+	UnscheduledTasks int `json:"unscheduled_tasks"`
+	Preemptions      int `json:"preemptions"`
+
+	// Makespan is the time from the first arrival until the last task completes.
+	Makespan time.Duration `json:"makespan"`
+	// The time that tasks waited from their arrival until they were first allocated resources.
+	MeanWait time.Duration `json:"mean_wait"`
+	P95Wait  time.Duration `json:"p95_wait"`
+	MaxWait  time.Duration `json:"max_wait"`
+	// Utilization is the fraction of the slot time of the pool that was allocated to tasks.
+	Utilization float64 `json:"utilization"`
+	// Fairness is Jain's fairness index of the mean slowdown of the tasks of each user, or of
+	// each group for tasks without a user; 1 means that all users were slowed down equally.
+	Fairness float64 `json:"fairness"`
+}
+
+// simulatedTaskState tracks a task through the simulation.
+type simulatedTaskState struct {
+	SimulatedTask
+	req *AllocateRequest
+
+	remaining   time.Duration
+	allocatedAt time.Time
+	firstStart  *time.Time
+	completedAt *time.Time
+}
+
+// simulationActor stands in for the task and group actors, which are only used as identities.
+type simulationActor struct{}
+
+func (simulationActor) Receive(*actor.Context) error { return nil }
+
+// schedulingInterval is the virtual time between scheduling passes while the scheduler is making
+// changes, matching the cool down of the resource pool.
+const schedulingInterval = actionCoolDown
+
+// Simulate replays the trace through a resource pool with the given scheduler configuration in
+// virtual time, and reports how the tasks were scheduled. The defaults of the scheduler
+// configuration are filled in.
+func Simulate(trace SimulationTrace, config *SchedulerConfig) (*SimulationReport, error) {
+	if err := check.Validate(trace); err != nil {
+		return nil, err
+	}
+	fillInSchedulerDefaults(config)
+	if err := check.Validate(config); err != nil {
+		return nil, err
+	}
+
+	system := actor.NewSystem("simulation")
+	defer system.Ref.Stop()
+	start := time.Unix(0, 0).UTC()
+	now := start
+
+	rp := NewResourcePool(
+		&ResourcePoolConfig{PoolName: "simulation", Scheduler: config, Quotas: trace.Quotas},
+		nil,
+		MakeScheduler(config),
+		MakeFitFunction(config.FittingPolicy),
+	)
+	rp.taskList.now = func() time.Time { return now }
+
+	totalSlots := 0
+	for _, a := range trace.Agents {
+		count := a.Count
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			ref, created := system.ActorOf(
+				actor.Addr(fmt.Sprintf("agent-%s-%d", a.ID, i)), simulationActor{})
+			if !created {
+				return nil, errors.Errorf("duplicate agent: %s", a.ID)
+			}
+			state := newAgentState(sproto.AddAgent{Agent: ref, Label: a.Label, Topology: a.Topology})
+			for j := 0; j < a.Slots; j++ {
+				state.devices[device.Device{ID: j}] = nil
+			}
+			rp.agents[ref] = state
+			totalSlots += a.Slots
+		}
+	}
+
+	groups := make(map[string]*actor.Ref)
+	for _, g := range trace.Groups {
+		ref, _ := system.ActorOf(actor.Addr("group-"+g.ID), simulationActor{})
+		group := rp.getOrCreateGroup(nil, ref)
+		if g.Priority != nil {
+			group.priority = g.Priority
+		}
+		group.maxSlots = g.MaxSlots
+		if g.Weight != 0 {
+			group.weight = g.Weight
+		}
+		groups[g.ID] = ref
+	}
+
+	arrivals := make([]*simulatedTaskState, 0, len(trace.Tasks))
+	for _, t := range trace.Tasks {
+		arrivals = append(arrivals, &simulatedTaskState{
+			SimulatedTask: t,
+			remaining:     time.Duration(t.Duration),
+		})
+	}
+	sort.SliceStable(arrivals, func(i, j int) bool {
+		return arrivals[i].Arrival < arrivals[j].Arrival
+	})
+	tasks := append([]*simulatedTaskState(nil), arrivals...)
+	running := make(map[*actor.Ref]*simulatedTaskState)
+	byHandler := make(map[*actor.Ref]*simulatedTaskState)
+
+	var slotTime time.Duration
+	preemptions := 0
+	stop := func(t *simulatedTaskState) {
+		allocated := rp.taskList.GetAllocations(t.req.TaskActor)
+		for _, allocation := range allocated.Allocations {
+			a := allocation.(*containerAllocation)
+			a.agent.deallocateContainer(a.container.id)
+		}
+		ran := now.Sub(t.allocatedAt)
+		t.remaining -= ran
+		slotTime += time.Duration(t.Slots) * ran
+		if t.req.EstimatedDuration > ran {
+			t.req.EstimatedDuration -= ran
+		} else {
+			t.req.EstimatedDuration = 0
+		}
+		rp.taskList.RemoveTaskByHandler(t.req.TaskActor)
+		delete(running, t.req.TaskActor)
+	}
+
+	dirty := false
+	for {
+		// Complete the tasks that have finished running and add the tasks that have arrived.
+		for _, t := range running {
+			if !t.allocatedAt.Add(t.remaining).After(now) {
+				stop(t)
+				completedAt := now
+				t.completedAt = &completedAt
+				dirty = true
+			}
+		}
+		for len(arrivals) > 0 && !start.Add(time.Duration(arrivals[0].Arrival)).After(now) {
+			t := arrivals[0]
+			arrivals = arrivals[1:]
+			ref, created := system.ActorOf(actor.Addr("task-"+t.ID), simulationActor{})
+			if !created {
+				return nil, errors.Errorf("duplicate task: %s", t.ID)
+			}
+			group := ref
+			if t.Group != "" {
+				group = groups[t.Group]
+			}
+			t.req = &AllocateRequest{
+				ID:                  TaskID(t.ID),
+				Name:                t.ID,
+				Group:               group,
+				SlotsNeeded:         t.Slots,
+				NonPreemptible:      t.NonPreemptible,
+				Label:               t.Label,
+				ResourcePool:        rp.config.PoolName,
+				FittingRequirements: FittingRequirements{SingleAgent: t.SingleAgent},
+				TaskActor:           ref,
+				User:                t.User,
+				Labels:              t.Labels,
+				EstimatedDuration:   time.Duration(t.Estimate),
+			}
+			rp.getOrCreateGroup(nil, group)
+			rp.taskList.AddTask(t.req)
+			byHandler[ref] = t
+			dirty = true
+		}
+
+		if dirty {
+			dirty = false
+			toAllocate, toRelease := rp.scheduler.Schedule(rp)
+			for _, req := range toAllocate {
+				if allocated, _ := rp.assignResources(req); allocated != nil {
+					t := byHandler[req.TaskActor]
+					t.allocatedAt = now
+					if t.firstStart == nil {
+						firstStart := now
+						t.firstStart = &firstStart
+					}
+					running[req.TaskActor] = t
+					dirty = true
+				}
+			}
+			for _, ref := range toRelease {
+				t, ok := running[ref]
+				if !ok {
+					continue
+				}
+				// The task saves its progress and requests resources again.
+				stop(t)
+				rp.taskList.AddTask(t.req)
+				preemptions++
+				dirty = true
+			}
+		}
+
+		next, ok := nextSimulationEvent(start, arrivals, running)
+		if dirty && (!ok || now.Add(schedulingInterval).Before(next)) {
+			next, ok = now.Add(schedulingInterval), true
+		}
+		if !ok {
+			break
+		}
+		now = next
+	}
+
+	return newSimulationReport(config, tasks, start, slotTime, totalSlots, preemptions), nil
+}
+
+// nextSimulationEvent returns the time of the next arrival or completion, if there is one.
+func nextSimulationEvent(
+	start time.Time, arrivals []*simulatedTaskState, running map[*actor.Ref]*simulatedTaskState,
+) (time.Time, bool) {
+	var next time.Time
+	ok := false
+	if len(arrivals) > 0 {
+		next, ok = start.Add(time.Duration(arrivals[0].Arrival)), true
+	}
+	for _, t := range running {
+		if end := t.allocatedAt.Add(t.remaining); !ok || end.Before(next) {
+			next, ok = end, true
+		}
+	}
+	return next, ok
+}
+
+func newSimulationReport(
+	config *SchedulerConfig,
+	tasks []*simulatedTaskState,
+	start time.Time,
+	slotTime time.Duration,
+	totalSlots int,
+	preemptions int,
+) *SimulationReport {
+	report := &SimulationReport{
+		Scheduler:     config.getType(),
+		FittingPolicy: config.FittingPolicy,
+		Tasks:         len(tasks),
+		Preemptions:   preemptions,
+	}
+
+	var waits []time.Duration
+	var end time.Time
+	firstArrival := start
+	if len(tasks) > 0 {
+		firstArrival = start.Add(time.Duration(tasks[0].Arrival))
+	}
+	slowdowns := make(map[string][]float64)
+	for _, t := range tasks {
+		arrival := start.Add(time.Duration(t.Arrival))
+		if t.firstStart == nil {
+			report.UnscheduledTasks++
+			continue
+		}
+		waits = append(waits, t.firstStart.Sub(arrival))
+		if t.completedAt == nil {
+			continue
+		}
+		report.CompletedTasks++
+		if t.completedAt.After(end) {
+			end = *t.completedAt
+		}
+		owner := t.User
+		if owner == "" {
+			owner = "group " + t.Group
+		}
+		slowdown := float64(t.completedAt.Sub(arrival)) / float64(t.Duration)
+		slowdowns[owner] = append(slowdowns[owner], slowdown)
+	}
+
+	if end.After(firstArrival) {
+		report.Makespan = end.Sub(firstArrival)
+		if totalSlots > 0 {
+			report.Utilization = float64(slotTime) / (float64(report.Makespan) * float64(totalSlots))
+		}
+	}
+
+	if len(waits) > 0 {
+		sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+		var total time.Duration
+		for _, w := range waits {
+			total += w
+		}
+		report.MeanWait = total / time.Duration(len(waits))
+		report.P95Wait = waits[int(math.Ceil(0.95*float64(len(waits))))-1]
+		report.MaxWait = waits[len(waits)-1]
+	}
+
+	var sum, sumOfSquares float64
+	for _, s := range slowdowns {
+		mean := 0.0
+		for _, v := range s {
+			mean += v
+		}
+		mean /= float64(len(s))
+		sum += mean
+		sumOfSquares += mean * mean
+	}
+	if sumOfSquares > 0 {
+		report.Fairness = sum * sum / (float64(len(slowdowns)) * sumOfSquares)
+	}
+	return report
+}
@@ -0,0 +1,92 @@
+package resourcemanagers
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/provisioner"
+)
+
+func simulatedTask(id, group string, arrival, duration time.Duration, slots int) SimulatedTask {
+	return SimulatedTask{
+		ID:       id,
+		Group:    group,
+		Arrival:  provisioner.Duration(arrival),
+		Duration: provisioner.Duration(duration),
+		Slots:    slots,
+	}
+}
+
+func TestSimulateFIFO(t *testing.T) {
+	trace := SimulationTrace{
+		Agents: []SimulatedAgent{{ID: "agent", Slots: 2}},
+		Tasks: []SimulatedTask{
+			simulatedTask("task1", "", 0, time.Hour, 2),
+			simulatedTask("task2", "", time.Minute, time.Hour, 2),
+			simulatedTask("task3", "", 0, time.Hour, 4),
+		},
+	}
+	report, err := Simulate(trace, &SchedulerConfig{RoundRobin: &RoundRobinSchedulerConfig{}})
+	assert.NilError(t, err)
+
+	assert.Equal(t, report.Scheduler, roundRobinScheduling)
+	assert.Equal(t, report.FittingPolicy, best)
+	assert.Equal(t, report.Tasks, 3)
+	assert.Equal(t, report.CompletedTasks, 2)
+	assert.Equal(t, report.UnscheduledTasks, 1)
+	assert.Equal(t, report.Makespan, 2*time.Hour)
+	assert.Equal(t, report.MeanWait, 59*time.Minute/2)
+	assert.Equal(t, report.MaxWait, 59*time.Minute)
+	assert.Equal(t, report.Utilization, 1.0)
+}
+
+func TestSimulatePreemption(t *testing.T) {
+	low, high := 50, 10
+	trace := SimulationTrace{
+		Agents: []SimulatedAgent{{ID: "agent", Count: 2, Slots: 1}},
+		Groups: []SimulatedGroup{
+			{ID: "low", Priority: &low},
+			{ID: "high", Priority: &high},
+		},
+		Tasks: []SimulatedTask{
+			simulatedTask("low1", "low", 0, time.Hour, 1),
+			simulatedTask("low2", "low", 0, time.Hour, 1),
+			simulatedTask("high", "high", 30*time.Minute, time.Hour, 1),
+		},
+	}
+
+	report, err := Simulate(trace, &SchedulerConfig{
+		Priority: &PrioritySchedulerConfig{Preemption: true},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, report.CompletedTasks, 3)
+	assert.Equal(t, report.Preemptions, 1)
+	assert.Assert(t, report.MaxWait <= time.Second)
+	// The preempted task continues from its progress once the other low priority task completes.
+	assert.Assert(t, report.Makespan >= 90*time.Minute, report.Makespan)
+	assert.Assert(t, report.Makespan <= 90*time.Minute+time.Second, report.Makespan)
+
+	report, err = Simulate(trace, &SchedulerConfig{
+		Priority: &PrioritySchedulerConfig{Preemption: false},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, report.Preemptions, 0)
+	assert.Equal(t, report.MaxWait, 30*time.Minute)
+	assert.Equal(t, report.Makespan, 2*time.Hour)
+}
+
+func TestSimulationTraceValidate(t *testing.T) {
+	trace := SimulationTrace{
+		Agents: []SimulatedAgent{{ID: "agent", Slots: 1}},
+		Tasks: []SimulatedTask{
+			simulatedTask("task", "missing", 0, time.Hour, 1),
+			simulatedTask("task", "", 0, 0, 1),
+		},
+	}
+	_, err := Simulate(trace, &SchedulerConfig{RoundRobin: &RoundRobinSchedulerConfig{}})
+	assert.ErrorContains(t, err, "unknown group: missing")
+	assert.ErrorContains(t, err, "duplicate task: task")
+	assert.ErrorContains(t, err, "duration must be > 0")
+}
@@ -3,10 +3,27 @@ package resourcemanagers
 import (
 	"time"
 
+	"github.com/determined-ai/determined/master/internal/provisioner"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	cproto "github.com/determined-ai/determined/master/pkg/container"
 )
 
+// SchedulingState is the scheduling state of a task.
+type SchedulingState string
+
+const (
+	// SchedulingStatePending denotes a task that is waiting for resources.
+	SchedulingStatePending SchedulingState = "PENDING"
+	// SchedulingStateAssigned denotes a task that has been allocated resources.
+	SchedulingStateAssigned SchedulingState = "ASSIGNED"
+	// SchedulingStatePreempted denotes a task that has been asked to release its resources so that
+	// a task of higher priority can be scheduled.
+	SchedulingStatePreempted SchedulingState = "PREEMPTED"
+	// SchedulingStateReserved denotes a pending task that resources are being held for, so that
+	// smaller tasks cannot keep it waiting.
+	SchedulingStateReserved SchedulingState = "RESERVED"
+)
+
 // TaskSummary contains information about a task for external display.
 type TaskSummary struct {
 	ID             TaskID             `json:"id"`
@@ -15,16 +32,39 @@ type TaskSummary struct {
 	ResourcePool   string             `json:"resource_pool"`
 	SlotsNeeded    int                `json:"slots_needed"`
 	Containers     []ContainerSummary `json:"containers"`
+	State          SchedulingState    `json:"scheduling_state"`
+	PreemptedBy    *TaskID            `json:"preempted_by"`
+	User           string             `json:"user"`
+	Labels         []string           `json:"labels"`
+	QuotaExceeded  *string            `json:"quota_exceeded"`
+	EstimatedStart *time.Time         `json:"estimated_start"`
 }
 
-func newTaskSummary(request *AllocateRequest, allocated *ResourcesAllocated) TaskSummary {
+func newTaskSummary(reqList *taskList, request *AllocateRequest) TaskSummary {
 	// Summary returns a new immutable view of the task state.
+	allocated := reqList.GetAllocations(request.TaskActor)
+	state := SchedulingStatePending
 	containerSummaries := make([]ContainerSummary, 0)
 	if allocated != nil {
+		state = SchedulingStateAssigned
 		for _, c := range allocated.Allocations {
 			containerSummaries = append(containerSummaries, c.Summary())
 		}
 	}
+	var preemptedBy *TaskID
+	if preemptor, ok := reqList.PreemptedBy(request.TaskActor); ok {
+		state = SchedulingStatePreempted
+		preemptedBy = &preemptor
+	}
+	var estimatedStart *time.Time
+	if start, ok := reqList.Reservation(request.TaskActor); ok {
+		state = SchedulingStateReserved
+		estimatedStart = start
+	}
+	var quotaExceeded *string
+	if quota, ok := reqList.QuotaExceeded(request.TaskActor); ok {
+		quotaExceeded = &quota
+	}
 	return TaskSummary{
 		ID:             request.ID,
 		Name:           request.Name,
@@ -32,9 +72,30 @@ func newTaskSummary(request *AllocateRequest, allocated *ResourcesAllocated) Tas
 		ResourcePool:   request.ResourcePool,
 		SlotsNeeded:    request.SlotsNeeded,
 		Containers:     containerSummaries,
+		State:          state,
+		PreemptedBy:    preemptedBy,
+		User:           request.User,
+		Labels:         request.Labels,
+		QuotaExceeded:  quotaExceeded,
+		EstimatedStart: estimatedStart,
 	}
 }
 
+// GetResourcePoolSummaries returns the state of all resource pools.
+type GetResourcePoolSummaries struct{}
+
+// ResourcePoolSummary contains information about a resource pool for external display.
+type ResourcePoolSummary struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	NumAgents   int    `json:"num_agents"`
+	NumSlots    int    `json:"num_slots"`
+	UsedSlots   int    `json:"used_slots"`
+	// Provisioner is the state of the provisioner of the pool, or nil if the pool has no
+	// provisioner or it has not reported its state yet.
+	Provisioner *provisioner.Summary `json:"provisioner"`
+}
+
 // ContainerSummary contains information about a task container for external display.
 type ContainerSummary struct {
 	TaskID TaskID    `json:"task_id"`
@@ -52,7 +113,7 @@ func newAgentSummary(state *agentState) sproto.AgentSummary {
 
 func getTaskSummary(reqList *taskList, id TaskID) *TaskSummary {
 	if req, ok := reqList.GetTaskByID(id); ok {
-		summary := newTaskSummary(req, reqList.GetAllocations(req.TaskActor))
+		summary := newTaskSummary(reqList, req)
 		return &summary
 	}
 	return nil
@@ -62,7 +123,21 @@ func getTaskSummaries(reqList *taskList) map[TaskID]TaskSummary {
 	ret := make(map[TaskID]TaskSummary)
 	for it := reqList.iterator(); it.next(); {
 		req := it.value()
-		ret[req.ID] = newTaskSummary(req, reqList.GetAllocations(req.TaskActor))
+		ret[req.ID] = newTaskSummary(reqList, req)
 	}
 	return ret
 }
+
+func getResourcePoolSummary(rp *ResourcePool) ResourcePoolSummary {
+	summary := ResourcePoolSummary{
+		Name:        rp.config.PoolName,
+		Description: rp.config.Description,
+		NumAgents:   len(rp.agents),
+		Provisioner: rp.provisionerSummary,
+	}
+	for _, agent := range rp.agents {
+		summary.NumSlots += agent.numSlots()
+		summary.UsedSlots += agent.numUsedSlots()
+	}
+	return summary
+}
@@ -1,9 +1,12 @@
 package resourcemanagers
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 
 	"github.com/determined-ai/determined/master/pkg/actor"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
 )
 
 // Task-related cluster level messages.
@@ -19,6 +22,13 @@ type (
 		ResourcePool        string
 		FittingRequirements FittingRequirements
 		TaskActor           *actor.Ref
+		User                string
+		Labels              []string
+		// ExperimentID is the ID of the experiment that the task belongs to, if any.
+		ExperimentID *int
+		// EstimatedDuration is how long the task is expected to run once it is allocated
+		// resources, or zero if it is unknown.
+		EstimatedDuration time.Duration
 	}
 	// ResourcesReleased notifies resource providers to return resources from a task.
 	ResourcesReleased struct {
@@ -33,8 +43,26 @@ type (
 		Name        string
 		TaskHandler *actor.Ref
 	}
+	// TaskCheckpointed notifies resource managers that a task has saved its progress, so that
+	// preempting it now would lose little work.
+	TaskCheckpointed struct {
+		TaskHandler *actor.Ref
+	}
+	// RestoreAllocation rebinds a task that was restored after a master restart to the containers
+	// that it claimed from the agents, in the order of their ranks, without starting new ones. The
+	// resource pool of the request responds with the ResourcesAllocated of the task or an error.
+	RestoreAllocation struct {
+		AllocateRequest
+		Containers []RestoredContainer
+	}
 )
 
+// RestoredContainer is a container that a task claimed from an agent after a master restart.
+type RestoredContainer struct {
+	Agent     *actor.Ref
+	Container cproto.Container
+}
+
 // Incoming task actor messages; task actors must accept these messages.
 type (
 	// ResourcesAllocated notifies the task actor of assigned resources.
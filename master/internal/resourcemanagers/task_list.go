@@ -2,6 +2,7 @@ package resourcemanagers
 
 import (
 	"strings"
+	"time"
 
 	"github.com/determined-ai/determined/master/pkg/actor"
 
@@ -14,14 +15,42 @@ type taskList struct {
 	taskByHandler map[*actor.Ref]*AllocateRequest
 	taskByID      map[TaskID]*AllocateRequest
 	allocations   map[*actor.Ref]*ResourcesAllocated
+
+	// progressTimes holds the time since which each allocated task has not saved its progress:
+	// the time of its latest checkpoint or, if it has not checkpointed, of its allocation.
+	progressTimes map[*actor.Ref]time.Time
+	// preemptedBy maps the tasks that have been asked to release their resources to the pending
+	// tasks that the resources were released for.
+	preemptedBy map[*actor.Ref]TaskID
+	// quotaExceeded maps the pending tasks that could not be allocated resources because of a
+	// slot quota to a description of the quota.
+	quotaExceeded map[*actor.Ref]string
+
+	// requestTimes holds the time at which each task requested resources.
+	requestTimes map[*actor.Ref]time.Time
+	// allocationTimes holds the time at which each allocated task was allocated its resources.
+	allocationTimes map[*actor.Ref]time.Time
+	// reserved is the pending task that the backfill scheduler holds resources for, and
+	// reservedStart the time at which the resources are expected to be free, if it is known.
+	reserved      *actor.Ref
+	reservedStart *time.Time
+
+	// now returns the current time; it is replaced when simulating the scheduler in virtual time.
+	now func() time.Time
 }
 
 func newTaskList() *taskList {
 	return &taskList{
-		taskByTime:    treeset.NewWith(taskComparator),
-		taskByHandler: make(map[*actor.Ref]*AllocateRequest),
-		taskByID:      make(map[TaskID]*AllocateRequest),
-		allocations:   make(map[*actor.Ref]*ResourcesAllocated),
+		taskByTime:      treeset.NewWith(taskComparator),
+		taskByHandler:   make(map[*actor.Ref]*AllocateRequest),
+		taskByID:        make(map[TaskID]*AllocateRequest),
+		allocations:     make(map[*actor.Ref]*ResourcesAllocated),
+		progressTimes:   make(map[*actor.Ref]time.Time),
+		preemptedBy:     make(map[*actor.Ref]TaskID),
+		quotaExceeded:   make(map[*actor.Ref]string),
+		requestTimes:    make(map[*actor.Ref]time.Time),
+		allocationTimes: make(map[*actor.Ref]time.Time),
+		now:             time.Now,
 	}
 }
 
@@ -51,6 +80,7 @@ func (l *taskList) AddTask(req *AllocateRequest) bool {
 	l.taskByTime.Add(req)
 	l.taskByHandler[req.TaskActor] = req
 	l.taskByID[req.ID] = req
+	l.requestTimes[req.TaskActor] = l.now()
 	return true
 }
 
@@ -64,6 +94,14 @@ func (l *taskList) RemoveTaskByHandler(handler *actor.Ref) *AllocateRequest {
 	delete(l.taskByHandler, handler)
 	delete(l.taskByID, req.ID)
 	delete(l.allocations, handler)
+	delete(l.progressTimes, handler)
+	delete(l.preemptedBy, handler)
+	delete(l.quotaExceeded, handler)
+	delete(l.requestTimes, handler)
+	delete(l.allocationTimes, handler)
+	if l.reserved == handler {
+		l.SetReservation(nil, nil)
+	}
 	return req
 }
 
@@ -73,6 +111,110 @@ func (l *taskList) GetAllocations(handler *actor.Ref) *ResourcesAllocated {
 
 func (l *taskList) SetAllocations(handler *actor.Ref, assigned *ResourcesAllocated) {
 	l.allocations[handler] = assigned
+	if assigned == nil {
+		delete(l.progressTimes, handler)
+	} else if _, ok := l.progressTimes[handler]; !ok {
+		l.progressTimes[handler] = l.now()
+	}
+	if assigned == nil {
+		delete(l.allocationTimes, handler)
+	} else {
+		delete(l.quotaExceeded, handler)
+		if _, ok := l.allocationTimes[handler]; !ok {
+			l.allocationTimes[handler] = l.now()
+		}
+		if l.reserved == handler {
+			l.SetReservation(nil, nil)
+		}
+	}
+}
+
+// RequestTime returns the time at which the task requested resources.
+func (l *taskList) RequestTime(handler *actor.Ref) (time.Time, bool) {
+	t, ok := l.requestTimes[handler]
+	return t, ok
+}
+
+// EstimatedEnd returns the time at which the allocated task is expected to complete, if it is
+// known. Tasks that have run for longer than expected are expected to complete now.
+func (l *taskList) EstimatedEnd(req *AllocateRequest) (time.Time, bool) {
+	allocationTime, ok := l.allocationTimes[req.TaskActor]
+	if !ok || req.EstimatedDuration <= 0 {
+		return time.Time{}, false
+	}
+	end := allocationTime.Add(req.EstimatedDuration)
+	if now := l.now(); end.Before(now) {
+		return now, true
+	}
+	return end, true
+}
+
+// SetReservation records that resources are held for the pending task from the given time, or
+// from an unknown time if start is nil. It replaces any previous reservation.
+func (l *taskList) SetReservation(handler *actor.Ref, start *time.Time) {
+	l.reserved = handler
+	l.reservedStart = start
+}
+
+// Reservation returns whether resources are held for the task and, if it is known, the time at
+// which they are expected to be free.
+func (l *taskList) Reservation(handler *actor.Ref) (*time.Time, bool) {
+	if handler == nil || l.reserved != handler {
+		return nil, false
+	}
+	return l.reservedStart, true
+}
+
+// SetCheckpointed records that the task has just saved its progress.
+func (l *taskList) SetCheckpointed(handler *actor.Ref) {
+	if _, ok := l.allocations[handler]; ok {
+		l.progressTimes[handler] = l.now()
+	}
+}
+
+// LostWork estimates the work that the task would lose if it were preempted at the given time, in
+// slot-time since it last saved its progress.
+func (l *taskList) LostWork(req *AllocateRequest, now time.Time) time.Duration {
+	progressTime, ok := l.progressTimes[req.TaskActor]
+	if !ok {
+		return 0
+	}
+	return time.Duration(req.SlotsNeeded) * now.Sub(progressTime)
+}
+
+// SetPreempted records that the task has been asked to release its resources so that the pending
+// task with the given ID can be scheduled.
+func (l *taskList) SetPreempted(handler *actor.Ref, preemptor TaskID) {
+	l.preemptedBy[handler] = preemptor
+}
+
+// PreemptedBy returns the ID of the pending task that the task is releasing its resources for.
+func (l *taskList) PreemptedBy(handler *actor.Ref) (TaskID, bool) {
+	preemptor, ok := l.preemptedBy[handler]
+	return preemptor, ok
+}
+
+// IsPreempting returns whether any tasks are still releasing their resources for the task.
+func (l *taskList) IsPreempting(id TaskID) bool {
+	for _, preemptor := range l.preemptedBy {
+		if preemptor == id {
+			return true
+		}
+	}
+	return false
+}
+
+// SetQuotaExceeded records that the task could not be allocated resources because it would exceed
+// the described quota.
+func (l *taskList) SetQuotaExceeded(handler *actor.Ref, quota string) {
+	l.quotaExceeded[handler] = quota
+}
+
+// QuotaExceeded returns the description of the quota that kept the task from being allocated
+// resources, if any.
+func (l *taskList) QuotaExceeded(handler *actor.Ref) (string, bool) {
+	quota, ok := l.quotaExceeded[handler]
+	return quota, ok
 }
 
 type taskIterator struct{ it treeset.Iterator }
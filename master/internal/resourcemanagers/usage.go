@@ -0,0 +1,44 @@
+package resourcemanagers
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// recordSlotsAllocated notifies the usage recorder that the slots that the task needs were
+// allocated to it in the resource pool, which prices them at the given hourly rate. Tasks that
+// need no slots are not recorded.
+func recordSlotsAllocated(
+	ctx *actor.Context, req *AllocateRequest, resourcePool string, slotHourlyRate *float64,
+) {
+	if req.SlotsNeeded == 0 {
+		return
+	}
+	ctx.Self().System().TellAt(sproto.UsageRecorderAddr, sproto.SlotsAllocated{
+		Usage: model.SlotUsage{
+			TaskID:         string(req.ID),
+			TaskName:       req.Name,
+			Username:       req.User,
+			ExperimentID:   req.ExperimentID,
+			Labels:         req.Labels,
+			ResourcePool:   resourcePool,
+			Slots:          req.SlotsNeeded,
+			SlotHourlyRate: slotHourlyRate,
+			StartTime:      time.Now(),
+		},
+	})
+}
+
+// recordSlotsReleased notifies the usage recorder that the slots of the task were released.
+func recordSlotsReleased(ctx *actor.Context, req *AllocateRequest) {
+	if req.SlotsNeeded == 0 {
+		return
+	}
+	ctx.Self().System().TellAt(sproto.UsageRecorderAddr, sproto.SlotsReleased{
+		TaskID: string(req.ID),
+		Time:   time.Now(),
+	})
+}
@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/searcher"
+)
+
+// searcherCallCompleted tells an experiment the result of a call of its search method.
+type searcherCallCompleted struct {
+	result searcher.CallResult
+}
+
+// searcherCaller makes the calls of an experiment's search method to external services, so that
+// the experiment does not block on them. It makes the calls one at a time, in the order that they
+// were queued, and tells the experiment the result of each.
+type searcherCaller struct {
+	experiment *actor.Ref
+}
+
+func (c *searcherCaller) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart, actor.PostStop:
+	case searcher.Call:
+		ctx.Tell(c.experiment, searcherCallCompleted{result: msg.Run()})
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
@@ -20,8 +20,9 @@ type DeviceID struct {
 type (
 	// AddAgent adds the agent to the cluster.
 	AddAgent struct {
-		Agent *actor.Ref
-		Label string
+		Agent    *actor.Ref
+		Label    string
+		Topology string
 	}
 	// AddDevice makes the device immediately available for scheduling.
 	AddDevice struct {
@@ -41,6 +42,16 @@ type (
 	RemoveAgent struct {
 		Agent *actor.Ref
 	}
+	// DrainAgent stops scheduling tasks on the agent and releases the resources of the
+	// preemptible tasks on it, so that they are rescheduled on other agents. Non-preemptible
+	// tasks keep running until they exit.
+	DrainAgent struct {
+		Agent *actor.Ref
+	}
+	// StopDrainingAgent schedules tasks on the agent again.
+	StopDrainingAgent struct {
+		Agent *actor.Ref
+	}
 )
 
 // Message protocol from the default resource manager to an agent actor.
@@ -56,6 +67,38 @@ type (
 	}
 )
 
+// Message protocol from a task actor to an agent actor.
+type (
+	// ClaimTaskContainer claims a container that an agent kept running across a master restart
+	// for the task whose handler started it. The agent responds with whether the container is
+	// now owned by the task; containers that are not claimed in time are killed. Once claimed, the
+	// agent sends the task the latest state of the container and the logs it wrote since.
+	ClaimTaskContainer struct {
+		TaskActor   *actor.Ref
+		ContainerID cproto.ID
+	}
+	// ReportTaskContainers asks the agents to report to the task the containers that they kept
+	// running across a master restart for it and that are not claimed yet. It is sent to the
+	// actor at AgentsAddr, which forwards it to every agent.
+	ReportTaskContainers struct {
+		TaskActor *actor.Ref
+	}
+)
+
+// Message protocol from an agent actor to a task actor.
+type (
+	// TaskContainerReported notifies a task restored after a master restart that the agent kept
+	// one of its containers running. It is sent when the agent registers and whenever the task
+	// asks with ReportTaskContainers, until the container is claimed.
+	TaskContainerReported struct {
+		Agent     *actor.Ref
+		Container cproto.Container
+	}
+)
+
+// AgentsAddr is the address of the actor that manages the agents of the cluster.
+var AgentsAddr = actor.Addr("agents")
+
 // AgentSummary contains information about an agent for external display.
 type AgentSummary struct {
 	Name   string
@@ -64,16 +107,23 @@ type AgentSummary struct {
 
 // ScalingInfo describes the information that is needed for scaling.
 type ScalingInfo struct {
-	DesiredNewInstances int
-	Agents              map[string]AgentSummary
+	// PendingSlots are the numbers of slots that the pending tasks need.
+	PendingSlots []int
+	Agents       map[string]AgentSummary
 }
 
-// Update updates its desired new instance number and the agent summaries.
-func (s *ScalingInfo) Update(desiredNewInstanceNum int, agents map[string]AgentSummary) bool {
+// Update updates its pending slots and the agent summaries.
+func (s *ScalingInfo) Update(pendingSlots []int, agents map[string]AgentSummary) bool {
 	updated := false
 
-	if desiredNewInstanceNum != s.DesiredNewInstances {
+	if len(pendingSlots) != len(s.PendingSlots) {
 		updated = true
+	} else {
+		for i := range pendingSlots {
+			if pendingSlots[i] != s.PendingSlots[i] {
+				updated = true
+			}
+		}
 	}
 
 	if len(s.Agents) != len(agents) {
@@ -88,7 +138,7 @@ func (s *ScalingInfo) Update(desiredNewInstanceNum int, agents map[string]AgentS
 	}
 
 	if updated {
-		s.DesiredNewInstances = desiredNewInstanceNum
+		s.PendingSlots = pendingSlots
 		s.Agents = agents
 	}
 
@@ -107,6 +157,9 @@ const (
 	// InstanceNumberExceedsMaximum represents the reason for terminating instances because
 	// the instance number exceeding the maximum.
 	InstanceNumberExceedsMaximum = "instance number exceeding maximum"
+	// TerminateUnavailableInstanceType represents the reason for canceling pending launches of an
+	// instance type that failed to launch.
+	TerminateUnavailableInstanceType = "instance type unavailable"
 )
 
 // TerminateDecision describes a terminating decision.
@@ -0,0 +1,25 @@
+package sproto
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// UsageRecorderAddr is the address of the actor that records the slot usage of tasks.
+var UsageRecorderAddr = actor.Addr("usage")
+
+// Incoming usage recorder messages; the resource managers send these messages as they allocate and
+// release slots.
+type (
+	// SlotsAllocated notifies the usage recorder that slots were allocated to a task.
+	SlotsAllocated struct {
+		Usage model.SlotUsage
+	}
+	// SlotsReleased notifies the usage recorder that the slots of a task were released.
+	SlotsReleased struct {
+		TaskID string
+		Time   time.Time
+	}
+)
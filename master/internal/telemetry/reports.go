@@ -33,6 +33,13 @@ func ReportAgentDisconnected(system *actor.System, uuid uuid.UUID) {
 	})
 }
 
+// ReportAgentDrained reports that an agent has finished draining.
+func ReportAgentDrained(system *actor.System, uuid uuid.UUID) {
+	report(system, "agent_drained", map[string]interface{}{
+		"uuid": uuid,
+	})
+}
+
 // ReportExperimentCreated reports that an experiment has been created.
 func ReportExperimentCreated(system *actor.System, e model.Experiment) {
 	report(system, "experiment_created", map[string]interface{}{
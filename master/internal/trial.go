@@ -97,6 +97,11 @@ type (
 	// running containers.
 	terminateTimeout struct{ runID int }
 
+	// When a trial that was restored after a master restart starts to reattach to the containers
+	// it was running, it sends itself a delayed reattachTimeout message. If not all of them are
+	// reported by then, the trial gives up on them and restores from its latest checkpoint.
+	reattachTimeout struct{ runID int }
+
 	containerConnected struct {
 		ContainerID cproto.ID
 		socket      *websocket.Conn
@@ -186,7 +191,8 @@ type trial struct {
 	create searcher.Create
 	close  *searcher.Close
 
-	sequencer *trialWorkloadSequencer
+	sequencer  *trialWorkloadSequencer
+	batchTimes *batchTimeEstimator
 
 	// restarts is essentially a failure count, it increments when the trial fails and we retry it.
 	restarts int
@@ -211,6 +217,10 @@ type trial struct {
 	task        *resourcemanagers.AllocateRequest
 	allocations []resourcemanagers.Allocation
 
+	// The following fields tracks reattaching to containers after a master restart.
+	reattachWait time.Duration
+	reattachment *resourcemanagers.Reattachment
+
 	// The following fields tracks containers and their states.
 	lastContainerConnectedTime time.Time
 	startedContainers          map[cproto.ID]bool
@@ -222,6 +232,7 @@ type trial struct {
 	// tracks if allReady check has passed successfully.
 	allReadySucceeded bool
 
+	owner          string
 	agentUserGroup *model.AgentUserGroup
 	taskSpec       *tasks.TaskSpec
 	privateKey     []byte
@@ -248,10 +259,12 @@ func newTrial(
 		modelDefinition:       exp.modelDefinition,
 		warmStartCheckpointID: warmStartCheckpointID,
 
-		sequencer: newTrialWorkloadSequencer(exp.Experiment, create, firstCheckpoint),
+		sequencer:  newTrialWorkloadSequencer(exp.Experiment, create, firstCheckpoint),
+		batchTimes: exp.batchTimes,
 
-		create:    create,
-		replaying: exp.replaying,
+		create:       create,
+		replaying:    exp.replaying,
+		reattachWait: exp.reattachWait,
 
 		startedContainers:    make(map[cproto.ID]bool),
 		containers:           make(map[cproto.ID]cproto.Container),
@@ -260,6 +273,7 @@ func newTrial(
 		containerSockets:     make(map[cproto.ID]*actor.Ref),
 		terminatedContainers: make(map[cproto.ID]terminatedContainerWithState),
 
+		owner:          exp.owner,
 		agentUserGroup: exp.agentUserGroup,
 		taskSpec:       exp.taskSpec,
 	}
@@ -289,7 +303,9 @@ func (t *trial) Receive(ctx *actor.Context) error {
 		t.processID(ctx, msg.trialID)
 		ctx.Tell(ctx.Self().Parent(), msg)
 	case restoreTrial:
-		t.restore(ctx)
+		if !t.reattach(ctx) {
+			t.restore(ctx)
+		}
 		t.replaying = false
 
 	case sproto.ContainerLog:
@@ -336,27 +352,7 @@ func (t *trial) Receive(ctx *actor.Context) error {
 			ctx.Self().Stop()
 		} else if !t.sequencer.UpToDate() && t.experimentState == model.ActiveState &&
 			!t.replaying {
-			slotsNeeded := t.experiment.Config.Resources.SlotsPerTrial
-			label := t.experiment.Config.Resources.AgentLabel
-			var name string
-			if t.idSet {
-				name = fmt.Sprintf("Trial %d (Experiment %d)", t.id, t.experiment.ID)
-			} else {
-				name = fmt.Sprintf("Trial (Experiment %d)", t.experiment.ID)
-			}
-
-			t.task = &resourcemanagers.AllocateRequest{
-				ID:             resourcemanagers.NewTaskID(),
-				Name:           name,
-				Group:          ctx.Self().Parent(),
-				SlotsNeeded:    slotsNeeded,
-				NonPreemptible: false,
-				Label:          label,
-				FittingRequirements: resourcemanagers.FittingRequirements{
-					SingleAgent: false,
-				},
-				TaskActor: ctx.Self(),
-			}
+			t.task = t.allocateRequest(ctx, resourcemanagers.NewTaskID())
 			ctx.Tell(t.rm, *t.task)
 		}
 	} else if t.experimentState != model.ActiveState {
@@ -366,6 +362,36 @@ func (t *trial) Receive(ctx *actor.Context) error {
 	return nil
 }
 
+func (t *trial) allocateRequest(
+	ctx *actor.Context, id resourcemanagers.TaskID,
+) *resourcemanagers.AllocateRequest {
+	slotsNeeded := t.experiment.Config.Resources.SlotsPerTrial
+	label := t.experiment.Config.Resources.AgentLabel
+	var name string
+	if t.idSet {
+		name = fmt.Sprintf("Trial %d (Experiment %d)", t.id, t.experiment.ID)
+	} else {
+		name = fmt.Sprintf("Trial (Experiment %d)", t.experiment.ID)
+	}
+
+	return &resourcemanagers.AllocateRequest{
+		ID:             id,
+		Name:           name,
+		Group:          ctx.Self().Parent(),
+		SlotsNeeded:    slotsNeeded,
+		NonPreemptible: false,
+		Label:          label,
+		FittingRequirements: resourcemanagers.FittingRequirements{
+			SingleAgent: false,
+		},
+		TaskActor:         ctx.Self(),
+		User:              t.owner,
+		Labels:            t.experiment.Config.Labels.List(),
+		ExperimentID:      &t.experiment.ID,
+		EstimatedDuration: t.batchTimes.estimate(t.sequencer.RemainingBatches()),
+	}
+}
+
 func (t *trial) runningReceive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case resourcemanagers.ResourcesAllocated, resourcemanagers.ReleaseResources:
@@ -378,10 +404,25 @@ func (t *trial) runningReceive(ctx *actor.Context) error {
 		return t.processAPIMsg(ctx)
 
 	case workload.CompletedMessage:
+		if !t.replaying && !t.expectedCompletion(msg.Workload) {
+			// A trial runner that reconnects after a master restart sends its last completed
+			// workload again, in case the master did not record it before it stopped.
+			ctx.Log().Infof("ignoring completed workload that was already recorded: %v", msg.Workload)
+			return t.sendNextWorkload(ctx)
+		}
 		if err := t.processCompletedWorkload(ctx, msg); err != nil {
 			return err
 		}
 
+	case sproto.TaskContainerReported:
+		t.processContainerReported(ctx, msg)
+
+	case reattachTimeout:
+		if msg.runID == t.runID && t.reattachment != nil {
+			ctx.Log().Warnf("not all containers of the trial were reported within %s", t.reattachWait)
+			t.abandonReattachment(ctx)
+		}
+
 	case sendNextWorkload:
 		if msg.runID != t.runID {
 			ctx.Log().Warnf("ignoring sendNextWorkload with stale runID %d", msg.runID)
@@ -533,7 +574,7 @@ func (t *trial) processAllocated(
 	if !t.idSet {
 		modelTrial := model.NewTrial(
 			t.experiment.ID,
-			model.JSONObj(t.create.Hparams),
+			model.JSONObj(t.experiment.Config.Hyperparameters.Unflatten(t.create.Hparams)),
 			t.warmStartCheckpointID,
 			int64(t.create.TrialSeed))
 		if err := t.db.AddTrial(modelTrial); err != nil {
@@ -610,13 +651,15 @@ func (t *trial) processAllocated(
 		),
 	}
 
+	t.recordContainers(ctx, msg)
+
 	for rank, a := range msg.Allocations {
 		t.containerRanks[a.Summary().ID] = rank
 		taskSpec := *t.taskSpec
 		taskSpec.StartContainer = &tasks.StartContainer{
 			ExperimentConfig:    t.experiment.Config,
 			ModelDefinition:     t.modelDefinition,
-			HParams:             t.create.Hparams,
+			HParams:             t.experiment.Config.Hyperparameters.Unflatten(t.create.Hparams),
 			TrialSeed:           t.create.TrialSeed,
 			LatestCheckpoint:    t.sequencer.LatestCheckpoint(),
 			InitialWorkload:     w,
@@ -632,6 +675,107 @@ func (t *trial) processAllocated(
 	return nil
 }
 
+// recordContainers records the containers of the current run of the trial, so that the trial can
+// reattach to them if the master restarts while they are running.
+func (t *trial) recordContainers(ctx *actor.Context, msg resourcemanagers.ResourcesAllocated) {
+	if t.reattachWait == 0 {
+		return
+	}
+	ids := make([]string, 0, len(msg.Allocations))
+	for _, a := range msg.Allocations {
+		ids = append(ids, string(a.Summary().ID))
+	}
+	if err := t.db.SetTrialTaskID(t.id, string(msg.ID)); err != nil {
+		ctx.Log().WithError(err).Error("failed to save the task of the trial")
+	} else if err := t.db.AddTaskContainers(string(msg.ID), msg.ResourcePool, ids); err != nil {
+		ctx.Log().WithError(err).Error("failed to save the containers of the trial")
+	}
+}
+
+// reattach starts to reattach a trial that was restored after a master restart to the containers
+// that it was running, and returns whether it did.
+func (t *trial) reattach(ctx *actor.Context) bool {
+	if !t.idSet || t.reattachWait == 0 || t.experimentState != model.ActiveState {
+		return false
+	}
+	trial, err := t.db.TrialByID(t.id)
+	if err != nil {
+		ctx.Log().Error(err)
+		return false
+	} else if _, ok := model.TerminalStates[trial.State]; ok || trial.TaskID == nil {
+		return false
+	}
+	containers, err := t.db.TaskContainers(*trial.TaskID)
+	if err != nil {
+		ctx.Log().Error(err)
+		return false
+	} else if len(containers) == 0 {
+		return false
+	}
+
+	t.task = t.allocateRequest(ctx, resourcemanagers.TaskID(*trial.TaskID))
+	t.task.ResourcePool = containers[0].ResourcePool
+	ids := make([]cproto.ID, 0, len(containers))
+	for _, c := range containers {
+		ids = append(ids, cproto.ID(c.ID))
+		t.containerRanks[cproto.ID(c.ID)] = c.Rank
+	}
+	ctx.Log().Infof("waiting for the agents to report the %d containers of the trial", len(ids))
+	t.reattachment = resourcemanagers.NewReattachment(*t.task, ids)
+	t.reattachment.Start(ctx)
+	actors.NotifyAfter(ctx, t.reattachWait, reattachTimeout{runID: t.runID})
+	return true
+}
+
+func (t *trial) processContainerReported(ctx *actor.Context, msg sproto.TaskContainerReported) {
+	if t.reattachment == nil || !t.reattachment.Reported(msg) {
+		return
+	}
+	allocated, err := t.reattachment.Reattach(ctx, t.rm)
+	t.reattachment = nil
+	if err != nil {
+		ctx.Log().WithError(err).Warn("failed to reattach to the containers of the trial")
+		t.abandonReattachment(ctx)
+		return
+	}
+	ctx.Log().Infof("reattached to the %d containers of the trial", len(allocated.Allocations))
+	t.allocations = allocated.Allocations
+}
+
+// abandonReattachment gives up on the containers that the trial was running when the master
+// stopped and restores the trial from its latest checkpoint instead.
+func (t *trial) abandonReattachment(ctx *actor.Context) {
+	t.reattachment = nil
+	t.deleteContainers(ctx)
+	t.task = nil
+	t.containerRanks = make(map[cproto.ID]int)
+	t.restore(ctx)
+}
+
+func (t *trial) deleteContainers(ctx *actor.Context) {
+	if t.task == nil || t.reattachWait == 0 {
+		return
+	}
+	if err := t.db.DeleteTaskContainers(string(t.task.ID)); err != nil {
+		ctx.Log().WithError(err).Error("failed to delete the containers of the trial")
+	}
+}
+
+// expectedCompletion returns whether the completed workload is one that the trial runner could
+// have been asked to run last: the current workload of the sequencer or the checkpoint before
+// terminating.
+func (t *trial) expectedCompletion(w workload.Workload) bool {
+	if !t.sequencer.UpToDate() {
+		if current, err := t.sequencer.Workload(); err == nil && current == w {
+			return true
+		}
+	}
+	if ckpt := t.sequencer.PrecloseCheckpointWorkload(); ckpt != nil && *ckpt == w {
+		return true
+	}
+	return false
+}
+
 func (t *trial) processCompletedWorkload(ctx *actor.Context, msg workload.CompletedMessage) error {
 	if !t.replaying && (msg.ExitedReason == nil ||
 		*msg.ExitedReason == workload.UserCanceled || *msg.ExitedReason == workload.InvalidHP) {
@@ -641,10 +785,14 @@ func (t *trial) processCompletedWorkload(ctx *actor.Context, msg workload.Comple
 	}
 
 	ctx.Log().Infof("trial completed workload: %v", msg.Workload)
+	if !t.replaying {
+		t.batchTimes.record(msg)
+	}
 
 	completedSearcherOp := false
 	units := model.UnitsFromBatches(msg.Workload.NumBatches, t.sequencer.unitContext)
 	isBestValidation := ctx.Ask(ctx.Self().Parent(), trialCompletedWorkload{t.id, msg, units})
+	latestCheckpoint := t.sequencer.LatestCheckpoint()
 	op, metrics, err := t.sequencer.WorkloadCompleted(msg, isBestValidation)
 	switch {
 	case err != nil:
@@ -662,6 +810,11 @@ func (t *trial) processCompletedWorkload(ctx *actor.Context, msg workload.Comple
 		completedSearcherOp = true
 	}
 
+	// Let the scheduler know that preempting the trial would no longer lose any work.
+	if !t.replaying && t.sequencer.LatestCheckpoint() != latestCheckpoint {
+		ctx.Tell(t.rm, resourcemanagers.TaskCheckpointed{TaskHandler: ctx.Self()})
+	}
+
 	if msg.ExitedReason != nil {
 		ctx.Log().Infof("exiting trial early: %v", msg.ExitedReason)
 		ctx.Tell(ctx.Self().Parent(), trialExitedEarly{t.id, msg.ExitedReason})
@@ -919,6 +1072,10 @@ func (t *trial) processContainerRunning(
 func (t *trial) processContainerTerminated(
 	ctx *actor.Context, msg sproto.TaskContainerStateChanged,
 ) {
+	if _, ok := t.containerRanks[msg.Container.ID]; !ok {
+		ctx.Log().Infof("ignoring termination of stale container: %s", msg.Container.ID)
+		return
+	}
 	ctx.Log().Infof("found container terminated: %s", msg.Container.ID)
 	t.terminatedContainers[msg.Container.ID] = terminatedContainerWithState{
 		exitStatus:                 *msg.ContainerStopped,
@@ -1085,6 +1242,8 @@ func (t *trial) terminated(ctx *actor.Context) {
 
 	t.runID++
 
+	t.reattachment = nil
+	t.deleteContainers(ctx)
 	t.task = nil
 	t.allocations = nil
 	t.containerRanks = make(map[cproto.ID]int)
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/prom"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -69,6 +70,9 @@ func (l *trialLogger) tryFlushLogs(ctx *actor.Context, forceFlush bool) {
 	if forceFlush || len(l.pending) >= logBuffer {
 		if err := l.db.AddTrialLogs(l.pending); err != nil {
 			ctx.Log().WithError(err).Errorf("failed to save trial logs")
+			prom.TrialLogs.WithLabelValues(prom.TrialLogsFailed).Add(float64(len(l.pending)))
+		} else {
+			prom.TrialLogs.WithLabelValues(prom.TrialLogsSaved).Add(float64(len(l.pending)))
 		}
 		l.pending = l.pending[:0]
 	}
@@ -375,6 +375,26 @@ func (s *trialWorkloadSequencer) UpToDate() bool {
 	return len(s.ops) == s.curOpIdx || s.exitingEarly && !s.postGracefulStopCheckpointNeeded()
 }
 
+// RemainingBatches returns the number of batches that remain to be trained for the searcher
+// operations that have been requested so far.
+func (s *trialWorkloadSequencer) RemainingBatches() int {
+	batches := 0
+	for i := s.curOpIdx; i < len(s.ops); i++ {
+		if tOp, ok := s.ops[i].(searcher.Train); ok {
+			batches += tOp.Length.ToNearestBatch(s.unitContext)
+		}
+	}
+	if s.curOpIdx < len(s.ops) {
+		if _, ok := s.ops[s.curOpIdx].(searcher.Train); ok {
+			batches -= s.batchesTowardsCurrentOp
+		}
+	}
+	if batches < 0 {
+		return 0
+	}
+	return batches
+}
+
 func (s trialWorkloadSequencer) train(numBatches int) workload.Workload {
 	return workload.Workload{
 		Kind:                  workload.RunStep,
@@ -152,6 +152,7 @@ checkpoint_policy: none
 	assert.Assert(t, !s.UpToDate())
 	assert.NilError(t, s.OperationRequested(validate))
 	assert.NilError(t, s.OperationRequested(checkpoint))
+	assert.Equal(t, s.RemainingBatches(), 500)
 
 	// Check that workload() returns an error before setTrialID is set
 	_, err := s.Workload()
@@ -174,6 +175,7 @@ checkpoint_policy: none
 	assert.NilError(t, err)
 	assert.Equal(t, w, trainWorkload2)
 	assert.Equal(t, *s.PrecloseCheckpointWorkload(), checkpointWorkload1)
+	assert.Equal(t, s.RemainingBatches(), 500-schedulingUnit)
 
 	// Complete second RUN_STEP.
 	op, _, err = s.WorkloadCompleted(workload.CompletedMessage{Workload: trainWorkload2}, nil)
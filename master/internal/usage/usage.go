@@ -0,0 +1,53 @@
+package usage
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// recorder records the slots that the resource managers allocate to tasks in the database.
+// Failing to record usage does not affect the tasks, so errors are only logged.
+type recorder struct {
+	db *db.PgDB
+}
+
+// Setup starts the actor that records slot usage. The allocations that were not released when the
+// master last stopped are recorded as released now, since their tasks are allocated slots anew
+// after the master restarts.
+func Setup(system *actor.System, d *db.PgDB) (*actor.Ref, error) {
+	ended, err := d.EndAllSlotUsage(time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot end slot usage from before the master started")
+	}
+	if ended > 0 {
+		log.Infof("ended slot usage of %d allocations from before the master started", ended)
+	}
+	ref, _ := system.ActorOf(sproto.UsageRecorderAddr, &recorder{db: d})
+	return ref, nil
+}
+
+func (r *recorder) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart, actor.PostStop:
+
+	case sproto.SlotsAllocated:
+		if err := r.db.AddSlotUsage(&msg.Usage); err != nil {
+			ctx.Log().WithError(err).Error("failed to record slot usage")
+		}
+
+	case sproto.SlotsReleased:
+		if err := r.db.EndSlotUsage(msg.TaskID, msg.Time); err != nil {
+			ctx.Log().WithError(err).Error("failed to record released slots")
+		}
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
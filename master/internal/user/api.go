@@ -16,4 +16,9 @@ func RegisterAPIHandler(echo *echo.Echo, m *Service, middleware ...echo.Middlewa
 	usersGroup.GET("/me", api.Route(m.getMe))
 	usersGroup.PATCH("/:username", api.Route(m.patchUser))
 	usersGroup.PATCH("/:username/username", api.Route(m.patchUsername))
+
+	apiTokensGroup := echo.Group("/api-tokens", middleware...)
+	apiTokensGroup.GET("", api.Route(m.getAPITokens))
+	apiTokensGroup.POST("", api.Route(m.postAPIToken))
+	apiTokensGroup.DELETE("/:token_id", api.Route(m.deleteAPIToken))
 }
@@ -0,0 +1,114 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func (s *Service) getAPITokens(c echo.Context) (interface{}, error) {
+	user := c.(*context.DetContext).MustGetUser()
+	tokens, err := s.db.APITokens(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tokens == nil {
+		tokens = []model.APIToken{}
+	}
+	return tokens, nil
+}
+
+func (s *Service) postAPIToken(c echo.Context) (interface{}, error) {
+	type (
+		request struct {
+			Name      string     `json:"name"`
+			Expiry    *time.Time `json:"expiry"`
+			ReadOnly  bool       `json:"read_only"`
+			Workspace *string    `json:"workspace"`
+		}
+		response struct {
+			model.APIToken
+			Token string `json:"token"`
+		}
+	)
+
+	// Tokens may only be created interactively, so that a token cannot be used to create a token
+	// with a wider scope or a later expiry.
+	if c.(*context.DetContext).APIToken() != nil {
+		return nil, echo.NewHTTPError(
+			http.StatusForbidden, "API tokens cannot be created with an API token")
+	}
+	user := c.(*context.DetContext).MustGetUser()
+
+	body, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		return nil, err
+	}
+	var params request
+	if err = json.Unmarshal(body, &params); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "bad request")
+	}
+	switch {
+	case params.Name == "":
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "name is required")
+	case params.Expiry != nil && !params.Expiry.After(time.Now()):
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "expiry must be in the future")
+	}
+
+	apiToken := model.APIToken{
+		UserID:    user.ID,
+		Name:      params.Name,
+		Expiry:    params.Expiry,
+		ReadOnly:  params.ReadOnly,
+		Workspace: params.Workspace,
+	}
+	if params.Workspace != nil {
+		workspace, wErr := s.db.WorkspaceByName(*params.Workspace)
+		switch {
+		case errors.Cause(wErr) == db.ErrNotFound:
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("workspace not found: %s", *params.Workspace))
+		case wErr != nil:
+			return nil, wErr
+		}
+		apiToken.WorkspaceID = &workspace.ID
+	}
+
+	token, hash, err := model.NewAPIToken()
+	if err != nil {
+		return nil, err
+	}
+	apiToken.TokenHash = hash
+	if err = s.db.AddAPIToken(&apiToken); err != nil {
+		return nil, err
+	}
+	return response{APIToken: apiToken, Token: token}, nil
+}
+
+func (s *Service) deleteAPIToken(c echo.Context) (interface{}, error) {
+	args := struct {
+		TokenID int `path:"token_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	user := c.(*context.DetContext).MustGetUser()
+	switch err := s.db.DeleteAPIToken(user.ID, args.TokenID); {
+	case err == db.ErrNotFound:
+		return nil, echo.NewHTTPError(
+			http.StatusNotFound, fmt.Sprintf("API token not found: %d", args.TokenID))
+	case err != nil:
+		return nil, err
+	}
+	return nil, c.NoContent(http.StatusNoContent)
+}
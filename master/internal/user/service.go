@@ -63,6 +63,7 @@ func New(db *db.PgDB, system *actor.System) (*Service, error) {
 // 1. The HTTP Authorization header.
 // 2. A cookie named "auth".
 // 3. A Query parameter named "_auth".
+// The token may either be the token of a session or an API token.
 func (s *Service) ProcessAuthentication(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		authRaw := c.Request().Header.Get("Authorization")
@@ -81,7 +82,15 @@ func (s *Service) ProcessAuthentication(next echo.HandlerFunc) echo.HandlerFunc
 			return echo.NewHTTPError(http.StatusUnauthorized)
 		}
 
-		user, userSession, err := s.db.UserByToken(token)
+		var user *model.User
+		var userSession *model.UserSession
+		var apiToken *model.APIToken
+		var err error
+		if model.IsAPIToken(token) {
+			user, apiToken, err = s.db.UserByAPIToken(token)
+		} else {
+			user, userSession, err = s.db.UserByToken(token)
+		}
 		switch err {
 		case nil:
 			if !user.Active {
@@ -90,7 +99,11 @@ func (s *Service) ProcessAuthentication(next echo.HandlerFunc) echo.HandlerFunc
 			// Set data on the request context that might be useful to
 			// event handlers.
 			c.(*context.DetContext).SetUser(*user)
-			c.(*context.DetContext).SetUserSession(*userSession)
+			if apiToken != nil {
+				c.(*context.DetContext).SetAPIToken(*apiToken)
+			} else {
+				c.(*context.DetContext).SetUserSession(*userSession)
+			}
 			return next(c)
 		case db.ErrNotFound:
 			return echo.NewHTTPError(http.StatusUnauthorized)
@@ -108,6 +121,11 @@ func (s *Service) postLogout(c echo.Context) (interface{}, error) {
 		c.SetCookie(cookie)
 	}
 
+	if c.(*context.DetContext).APIToken() != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			"cannot log out of an API token; revoke the token instead")
+	}
+
 	// Delete the user session information from the database.
 	sess := c.(*context.DetContext).MustGetUserSession()
 
@@ -65,12 +65,8 @@ type Ref struct {
 }
 
 func newRef(system *System, parent *Ref, address Address, actor Actor) *Ref {
-	typeName := reflect.TypeOf(actor).String()
-	if strings.Contains(typeName, ".") {
-		typeName = strings.Split(typeName, ".")[1]
-	}
 	ref := &Ref{
-		log: log.WithField("type", typeName).WithField("id", address.Local()).WithField(
+		log: log.WithField("type", actorTypeName(actor)).WithField("id", address.Local()).WithField(
 			"system", system.id),
 
 		address:        address,
@@ -92,6 +88,15 @@ func newRef(system *System, parent *Ref, address Address, actor Actor) *Ref {
 	return ref
 }
 
+// actorTypeName returns the name of the type of the actor without its package.
+func actorTypeName(actor Actor) string {
+	typeName := reflect.TypeOf(actor).String()
+	if strings.Contains(typeName, ".") {
+		typeName = strings.Split(typeName, ".")[1]
+	}
+	return typeName
+}
+
 // Parent returns the reference to the actor's parent.
 func (r *Ref) Parent() *Ref {
 	return r.parent
@@ -84,6 +84,34 @@ func (s *System) Get(address Address) *Ref {
 	return s.refs[address]
 }
 
+// InboxLength is the number of messages waiting in the inbox of an actor.
+type InboxLength struct {
+	Address   Address
+	ActorType string
+	Length    int
+}
+
+// InboxLengths returns the number of messages waiting in the inbox of each actor in the system.
+func (s *System) InboxLengths() []InboxLength {
+	s.refsLock.RLock()
+	refs := make([]*Ref, 0, len(s.refs)+1)
+	refs = append(refs, s.Ref)
+	for _, ref := range s.refs {
+		refs = append(refs, ref)
+	}
+	s.refsLock.RUnlock()
+
+	lengths := make([]InboxLength, 0, len(refs))
+	for _, ref := range refs {
+		lengths = append(lengths, InboxLength{
+			Address:   ref.address,
+			ActorType: actorTypeName(ref.actor),
+			Length:    ref.inbox.len(),
+		})
+	}
+	return lengths
+}
+
 // ActorOf adds the actor with the provided address.
 // The second return value denotes whether a new actor was created or not.
 func (s *System) ActorOf(address Address, actor Actor) (*Ref, bool) {
@@ -36,11 +36,15 @@ type MasterMessage struct {
 	ContainerLog          *ContainerLog
 }
 
-// AgentStarted notifies the master that the agent has started up.
+// AgentStarted notifies the master that the agent has started up or has reconnected.
 type AgentStarted struct {
-	Version string
-	Label   string
-	Devices []device.Device
+	Version  string
+	Label    string
+	Topology string
+	Devices  []device.Device
+	// Containers is the inventory of the containers of the agent: the latest state change of each
+	// container that is running or that terminated while the agent was disconnected.
+	Containers []ContainerStateChanged
 }
 
 // ContainerStateChanged notifies the master that the agent transitioned the container state.
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// APITokenPrefix starts every API token, which distinguishes API tokens from session tokens.
+const APITokenPrefix = "det_"
+
+// APIToken corresponds to a row in the "api_tokens" DB table. An API token authenticates requests
+// as its user, optionally restricted to reading or to the experiments in a workspace.
+type APIToken struct {
+	ID          int        `db:"id" json:"id"`
+	UserID      UserID     `db:"user_id" json:"-"`
+	Name        string     `db:"name" json:"name"`
+	TokenHash   string     `db:"token_hash" json:"-"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	Expiry      *time.Time `db:"expiry" json:"expiry"`
+	LastUsed    *time.Time `db:"last_used" json:"last_used"`
+	ReadOnly    bool       `db:"read_only" json:"read_only"`
+	WorkspaceID *int       `db:"workspace_id" json:"-"`
+
+	Workspace *string `db:"workspace" json:"workspace"`
+}
+
+// NewAPIToken generates a new API token and returns it along with its hash.
+func NewAPIToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", errors.Wrap(err, "error generating API token")
+	}
+	token = APITokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, HashAPIToken(token), nil
+}
+
+// HashAPIToken returns the hash of the API token that is stored in the database.
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIToken returns true if the token is an API token rather than a session token.
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// Expired returns true if the token has expired by the given time.
+func (t APIToken) Expired(now time.Time) bool {
+	return t.Expiry != nil && !now.Before(*t.Expiry)
+}
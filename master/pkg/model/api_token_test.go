@@ -0,0 +1,30 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestNewAPIToken(t *testing.T) {
+	token, hash, err := NewAPIToken()
+	assert.NilError(t, err)
+	assert.Assert(t, IsAPIToken(token))
+	assert.Equal(t, hash, HashAPIToken(token))
+	assert.Assert(t, hash != token)
+
+	other, _, err := NewAPIToken()
+	assert.NilError(t, err)
+	assert.Assert(t, other != token)
+}
+
+func TestAPITokenExpired(t *testing.T) {
+	now := time.Now()
+	assert.Assert(t, !APIToken{}.Expired(now))
+
+	expiry := now.Add(time.Hour)
+	token := APIToken{Expiry: &expiry}
+	assert.Assert(t, !token.Expired(now))
+	assert.Assert(t, token.Expired(expiry))
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Transports over which audited requests are received.
+const (
+	AuditTransportHTTP = "http"
+	AuditTransportGRPC = "grpc"
+)
+
+// Outcomes of audited requests.
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEntry corresponds to a row in the "audit_log" DB table. It records a request that changes
+// the state of the cluster, who made it, what it acted on and how it ended.
+type AuditEntry struct {
+	ID         int64     `db:"id" json:"id"`
+	Time       time.Time `db:"time" json:"time"`
+	UserID     *UserID   `db:"user_id" json:"user_id"`
+	Username   string    `db:"username" json:"username"`
+	APITokenID *int      `db:"api_token_id" json:"api_token_id"`
+	Transport  string    `db:"transport" json:"transport"`
+	Action     string    `db:"action" json:"action"`
+	// ResourceType and ResourceID identify the resource that the request targets, e.g.,
+	// "experiment" and "12"; they are empty if the request does not target a single resource.
+	ResourceType  string `db:"resource_type" json:"resource_type"`
+	ResourceID    string `db:"resource_id" json:"resource_id"`
+	PayloadDigest string `db:"payload_digest" json:"payload_digest"`
+	SourceIP      string `db:"source_ip" json:"source_ip"`
+	Outcome       string `db:"outcome" json:"outcome"`
+	// Status is the HTTP status code or the gRPC status code that the request ended with.
+	Status string `db:"status" json:"status"`
+}
+
+// AuditFilter selects audit entries; empty fields match every entry.
+type AuditFilter struct {
+	Username     string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Outcome      string
+	Since        *time.Time
+	Until        *time.Time
+}
+
+// AuditPayloadDigest returns the digest of a request payload that is recorded in the audit log,
+// so that requests can be matched to payloads without storing secrets such as passwords.
+func AuditPayloadDigest(payload []byte) string {
+	if len(payload) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CommandType is the type of a task that is managed like a command.
+type CommandType string
+
+const (
+	// CommandTypeCommand is the type of commands.
+	CommandTypeCommand CommandType = "COMMAND"
+	// CommandTypeNotebook is the type of notebooks.
+	CommandTypeNotebook CommandType = "NOTEBOOK"
+	// CommandTypeShell is the type of shells.
+	CommandTypeShell CommandType = "SHELL"
+	// CommandTypeTensorBoard is the type of TensorBoards.
+	CommandTypeTensorBoard CommandType = "TENSORBOARD"
+)
+
+// Command corresponds to a row in the "commands" DB table. It records a command, notebook, shell
+// or TensorBoard so that the master can account for it after restarting.
+type Command struct {
+	TaskID         string        `db:"task_id"`
+	Type           CommandType   `db:"type"`
+	OwnerID        UserID        `db:"owner_id"`
+	Config         CommandConfig `db:"config"`
+	RegisteredTime time.Time     `db:"registered_time"`
+	EndTime        *time.Time    `db:"end_time"`
+	ExitStatus     *string       `db:"exit_status"`
+
+	Username string `db:"username"`
+}
+
+// Value implements the driver.Valuer interface.
+func (c CommandConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the db.Scanner interface.
+func (c *CommandConfig) Scan(src interface{}) error {
+	data, ok := src.([]byte)
+	if !ok {
+		return errors.Errorf("unable to convert to []byte: %v", src)
+	}
+	var config CommandConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	*c = config
+	return nil
+}
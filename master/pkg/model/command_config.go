@@ -7,12 +7,13 @@ import (
 // CommandConfig holds the necessary configurations to launch a command task in
 // the cluster.
 type CommandConfig struct {
-	Description     string          `json:"description"`
-	BindMounts      []BindMount     `json:"bind_mounts"`
-	Environment     Environment     `json:"environment"`
-	Resources       ResourcesConfig `json:"resources"`
-	Entrypoint      []string        `json:"entrypoint"`
-	TensorBoardArgs []string        `json:"tensorboard_args"`
+	Description     string           `json:"description"`
+	BindMounts      []BindMount      `json:"bind_mounts"`
+	Environment     Environment      `json:"environment"`
+	Resources       ResourcesConfig  `json:"resources"`
+	Entrypoint      []string         `json:"entrypoint"`
+	TensorBoardArgs []string         `json:"tensorboard_args"`
+	IdlePolicy      IdlePolicyConfig `json:"idle_policy"`
 }
 
 // Validate implements the check.Validatable interface.
@@ -22,3 +23,50 @@ func (c *CommandConfig) Validate() []error {
 		check.GreaterThan(len(c.Entrypoint), 0, "entrypoint must be non-empty"),
 	}
 }
+
+// Signals of the activity of notebooks and shells.
+const (
+	// IdleActivityKernels is activity of the kernels of notebooks: a kernel is busy or ran code.
+	IdleActivityKernels = "kernels"
+	// IdleActivityProxy is requests to the task through the proxy of the master.
+	IdleActivityProxy = "proxy"
+	// IdleActivityConnections is connections to the task through the proxy of the master that are
+	// open, such as SSH sessions and the browser tabs of notebooks.
+	IdleActivityConnections = "connections"
+)
+
+// IdlePolicyConfig configures when notebooks and shells that are idle are terminated.
+type IdlePolicyConfig struct {
+	// Timeout is the number of seconds that a task may be idle before it is terminated; 0 disables
+	// idle termination.
+	Timeout int `json:"timeout"`
+	// WarningPeriod is the number of seconds before terminating an idle task that a warning is
+	// emitted.
+	WarningPeriod int `json:"warning_period"`
+	// Activity lists the signals that count as activity of the task.
+	Activity []string `json:"activity"`
+}
+
+// DefaultIdlePolicyConfig returns the default idle policy, which never terminates tasks.
+func DefaultIdlePolicyConfig() IdlePolicyConfig {
+	return IdlePolicyConfig{
+		WarningPeriod: 10 * 60,
+		Activity: []string{
+			IdleActivityKernels, IdleActivityProxy, IdleActivityConnections,
+		},
+	}
+}
+
+// Validate implements the check.Validatable interface.
+func (i IdlePolicyConfig) Validate() []error {
+	errs := []error{
+		check.GreaterThanOrEqualTo(i.Timeout, 0, "idle_policy.timeout must be >= 0"),
+		check.GreaterThanOrEqualTo(i.WarningPeriod, 0, "idle_policy.warning_period must be >= 0"),
+	}
+	for _, activity := range i.Activity {
+		errs = append(errs, check.In(activity,
+			[]string{IdleActivityKernels, IdleActivityProxy, IdleActivityConnections},
+			"invalid idle_policy.activity"))
+	}
+	return errs
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestCommandConfigRoundTrip(t *testing.T) {
+	config := CommandConfig{
+		Description: "Notebook (test)",
+		Entrypoint:  []string{"/run/determined/jupyter/notebook-entrypoint.sh"},
+		Resources:   ResourcesConfig{Slots: 2, Weight: 1},
+	}
+	value, err := config.Value()
+	assert.NilError(t, err)
+
+	var scanned CommandConfig
+	assert.NilError(t, scanned.Scan(value))
+	assert.DeepEqual(t, scanned, config)
+
+	assert.ErrorContains(t, scanned.Scan("not bytes"), "unable to convert")
+}
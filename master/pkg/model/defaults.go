@@ -83,6 +83,17 @@ func DefaultExperimentConfig(taskContainerDefaults *TaskContainerDefaultsConfig)
 			PBTConfig: &PBTConfig{
 				SmallerIsBetter: true,
 			},
+			TPEConfig: &TPEConfig{
+				SmallerIsBetter:     true,
+				MaxConcurrentTrials: 0,
+				NumStartupTrials:    10,
+				NumCandidates:       24,
+				Gamma:               0.25,
+				PriorWeight:         1.0,
+			},
+			CustomConfig: &CustomConfig{
+				SmallerIsBetter: true,
+			},
 		},
 		Resources: ResourcesConfig{
 			SlotsPerTrial:  1,
@@ -193,6 +193,7 @@ type Experiment struct {
 	GitCommitter         *string    `db:"git_committer"`
 	GitCommitDate        *time.Time `db:"git_commit_date"`
 	OwnerID              *UserID    `db:"owner_id"`
+	WorkspaceID          *int       `db:"workspace_id"`
 }
 
 // ExperimentDescriptor is a minimal description of an experiment.
@@ -263,6 +264,8 @@ type Trial struct {
 	HParams               JSONObj    `db:"hparams"`
 	WarmStartCheckpointID *int       `db:"warm_start_checkpoint_id"`
 	Seed                  int64      `db:"seed"`
+	// TaskID is the task ID of the current run of the trial, if it was ever allocated resources.
+	TaskID *string `db:"task_id"`
 }
 
 // NewTrial creates a new trial in the active state.  Note that the trial ID
@@ -427,3 +430,11 @@ type SearcherEvent struct {
 	EventType    string  `db:"event_type"`
 	Content      JSONObj `db:"content"`
 }
+
+// SearcherSnapshot represents a row from the `searcher_snapshots` table. EventID is the ID of the
+// last searcher event that is reflected in the snapshot.
+type SearcherSnapshot struct {
+	ExperimentID int    `db:"experiment_id"`
+	EventID      int    `db:"event_id"`
+	Content      []byte `db:"content"`
+}
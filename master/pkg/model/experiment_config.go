@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -44,6 +45,7 @@ type ExperimentConfig struct {
 	Internal                 *InternalConfig           `json:"internal"`
 	Entrypoint               string                    `json:"entrypoint"`
 	DataLayer                DataLayerConfig           `json:"data_layer"`
+	Workspace                string                    `json:"workspace,omitempty"`
 }
 
 // Validate implements the check.Validatable interface.
@@ -55,40 +57,22 @@ func (e ExperimentConfig) Validate() []error {
 	gridTrials := 1
 	noCountParams := make([]string, 0)
 	if e.Searcher.GridConfig != nil {
+		var names []string
+		var params []Hyperparameter
+		referenced := make(map[string]bool)
 		e.Hyperparameters.Each(func(name string, param Hyperparameter) {
-			mult := 1
-			switch {
-			case param.IntHyperparameter != nil:
-				p := param.IntHyperparameter
-				switch {
-				case p.Count == nil:
-					noCountParams = append(noCountParams, name)
-				case *p.Count > p.Maxval-p.Minval:
-					// If the count is greater than the number of possible values, grid search will clamp it down.
-					mult = p.Maxval - p.Minval
-				default:
-					mult = *p.Count
-				}
-			case param.DoubleHyperparameter != nil:
-				p := param.DoubleHyperparameter
-				if p.Count == nil {
-					noCountParams = append(noCountParams, name)
-				} else {
-					mult = *p.Count
-				}
-			case param.LogHyperparameter != nil:
-				p := param.LogHyperparameter
-				if p.Count == nil {
-					noCountParams = append(noCountParams, name)
-				} else {
-					mult = *p.Count
-				}
-			case param.CategoricalHyperparameter != nil:
-				p := param.CategoricalHyperparameter
-				mult = len(p.Vals)
+			if count := gridCount(param); count == nil {
+				noCountParams = append(noCountParams, name)
 			}
-			gridTrials *= mult
+			for ref := range param.Condition {
+				referenced[ref] = true
+			}
+			names = append(names, name)
+			params = append(params, param)
 		})
+		if len(noCountParams) == 0 {
+			gridTrials = countGridTrials(names, params, referenced, map[string]interface{}{})
+		}
 	}
 
 	errs := []error{}
@@ -117,6 +101,67 @@ func (e ExperimentConfig) Validate() []error {
 	}...)
 }
 
+// gridCount returns the number of values of the hyperparameter in a grid search, or nil if the
+// hyperparameter does not specify a count.
+func gridCount(param Hyperparameter) *int {
+	count := 1
+	switch {
+	case param.IntHyperparameter != nil:
+		p := param.IntHyperparameter
+		if p.Count == nil {
+			return nil
+		}
+		count = *p.Count
+		// If the count is greater than the number of possible values, grid search will clamp it down.
+		if count > p.Maxval-p.Minval+1 {
+			count = p.Maxval - p.Minval + 1
+		}
+	case param.DoubleHyperparameter != nil:
+		if param.DoubleHyperparameter.Count == nil {
+			return nil
+		}
+		count = *param.DoubleHyperparameter.Count
+	case param.LogHyperparameter != nil:
+		if param.LogHyperparameter.Count == nil {
+			return nil
+		}
+		count = *param.LogHyperparameter.Count
+	case param.CategoricalHyperparameter != nil:
+		count = len(param.CategoricalHyperparameter.Vals)
+	}
+	return &count
+}
+
+// countGridTrials returns the number of points in the grid of the hyperparameters, given in the
+// order of Hyperparameters.Each. Hyperparameters whose conditions are not met do not contribute to
+// the grid, so the values of the hyperparameters that are referred to by conditions are enumerated;
+// the enumeration stops early once the count exceeds MaxAllowedTrials.
+func countGridTrials(
+	names []string, params []Hyperparameter, referenced map[string]bool,
+	sample map[string]interface{},
+) int {
+	if len(names) == 0 {
+		return 1
+	}
+	name, param := names[0], params[0]
+	switch {
+	case !param.ConditionMet(sample):
+		return countGridTrials(names[1:], params[1:], referenced, sample)
+	case !referenced[name]:
+		return *gridCount(param) * countGridTrials(names[1:], params[1:], referenced, sample)
+	}
+	total := 0
+	for _, val := range param.conditionValues() {
+		sample[name] = val
+		total += countGridTrials(names[1:], params[1:], referenced, sample)
+		if total > MaxAllowedTrials {
+			break
+		}
+	}
+	delete(sample, name)
+	return total
+}
+
 // Value implements the driver.Valuer interface.
 func (e ExperimentConfig) Value() (driver.Value, error) {
 	if err := check.Validate(e); err != nil {
@@ -153,6 +198,16 @@ type InUnits interface {
 // Labels holds the set of labels on the experiment.
 type Labels map[string]bool
 
+// List returns the labels in sorted order.
+func (l Labels) List() []string {
+	labels := make([]string, 0, len(l))
+	for label := range l {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
 // MarshalJSON implements the json.Marshaler interface.
 func (l Labels) MarshalJSON() ([]byte, error) {
 	labels := make([]string, 0, len(l))
@@ -194,6 +249,9 @@ type ResourcesConfig struct {
 	ShmSize        *int    `json:"shm_size,omitempty"`
 	AgentLabel     string  `json:"agent_label"`
 	Priority       *int    `json:"priority,omitempty"`
+	// MaxSlotHours is the budget of slot hours that the trials of an experiment may use before the
+	// experiment is paused.
+	MaxSlotHours *float64 `json:"max_slot_hours,omitempty"`
 }
 
 // ValidatePrioritySetting checks that priority if set is within a valid range.
@@ -219,6 +277,7 @@ func (r ResourcesConfig) Validate() []error {
 		check.GreaterThanOrEqualTo(
 			r.MaxSlots, r.SlotsPerTrial, "max_slots must be >= slots_per_trial"),
 		check.GreaterThanOrEqualTo(r.ShmSize, 0, "shm_size must be >= 0"),
+		check.GreaterThan(r.MaxSlotHours, float64(0), "max_slot_hours must be > 0"),
 	}
 	errs = append(errs, ValidatePrioritySetting(r.Priority)...)
 	return errs
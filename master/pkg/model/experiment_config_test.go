@@ -203,6 +203,22 @@ func TestGridValidation(t *testing.T) {
 		assert.NilError(t, check.Validate(config))
 	}
 
+	// Check that hyperparameters whose conditions are not met do not multiply the grid: with
+	// 600 values of log, 2 categories and 2 conditional values of int, there are 600 * (1 + 2)
+	// trials rather than 600 * 2 * 2.
+	{
+		config := validGridSearchConfig()
+		config.Hyperparameters["log"].LogHyperparameter.Count = intP(600)
+		config.Hyperparameters["int"].IntHyperparameter.Count = intP(2)
+		config.Hyperparameters["int"] = Hyperparameter{
+			IntHyperparameter: config.Hyperparameters["int"].IntHyperparameter,
+			Condition:         map[string][]interface{}{"cat": {"a"}},
+		}
+		assert.NilError(t, check.Validate(config))
+		config.Hyperparameters["log"].LogHyperparameter.Count = intP(700)
+		assert.ErrorContains(t, check.Validate(config), "number of trials")
+	}
+
 	// Check that a missing count triggers an error.
 	{
 		config := validGridSearchConfig()
@@ -2,6 +2,7 @@ package model
 
 import (
 	"encoding/json"
+	"reflect"
 	"sort"
 
 	"github.com/pkg/errors"
@@ -13,11 +14,28 @@ import (
 // GlobalBatchSize is the name of the hyperparameter for global_batch_size.
 const GlobalBatchSize = "global_batch_size"
 
-// Hyperparameters holds a mapping from hyperparameter name to its configuration.
+// Hyperparameters holds a mapping from hyperparameter name to its configuration. Hyperparameters
+// may be grouped into nested hyperparameters, whose members are referred to by their full,
+// dot-separated name (e.g., "optimizer.momentum").
 type Hyperparameters map[string]Hyperparameter
 
 // Validate implements the check.Validatable interface.
 func (h Hyperparameters) Validate() []error {
+	errs := h.validateGlobalBatchSize()
+
+	flat, duplicates := h.flatten()
+	for _, name := range duplicates {
+		errs = append(errs, errors.Errorf("hyperparameter %s is defined more than once", name))
+	}
+	h.eachNested("", func(name string, nested map[string]Hyperparameter) {
+		if len(nested) == 0 {
+			errs = append(errs, errors.Errorf("nested hyperparameter %s must not be empty", name))
+		}
+	})
+	return append(errs, validateConditions(flat)...)
+}
+
+func (h Hyperparameters) validateGlobalBatchSize() []error {
 	b, ok := h[GlobalBatchSize]
 	if !ok {
 		return []error{
@@ -25,6 +43,14 @@ func (h Hyperparameters) Validate() []error {
 		}
 	}
 	switch {
+	case b.NestedHyperparameter != nil:
+		return []error{
+			errors.New("global_batch_size hyperparameter must not be nested"),
+		}
+	case b.Condition != nil:
+		return []error{
+			errors.New("global_batch_size hyperparameter must not be conditional"),
+		}
 	case b.ConstHyperparameter != nil:
 		if !isNumeric(b.ConstHyperparameter.Val) {
 			return []error{
@@ -43,35 +69,207 @@ func (h Hyperparameters) Validate() []error {
 	return nil
 }
 
+// validateConditions checks that every condition refers to the possible values of a categorical or
+// const hyperparameter and that no hyperparameter depends, directly or not, on itself.
+func validateConditions(flat map[string]Hyperparameter) []error {
+	var errs []error
+	for _, name := range sortedNames(flat) {
+		param := flat[name]
+		for _, ref := range sortedNames(param.Condition) {
+			refParam, ok := flat[ref]
+			if !ok {
+				errs = append(errs, errors.Errorf(
+					"condition of hyperparameter %s refers to unknown hyperparameter %s", name, ref))
+				continue
+			}
+			possible := refParam.conditionValues()
+			if possible == nil {
+				errs = append(errs, errors.Errorf(
+					"condition of hyperparameter %s must refer to a categorical or const "+
+						"hyperparameter, not %s", name, ref))
+				continue
+			}
+			if len(param.Condition[ref]) == 0 {
+				errs = append(errs, errors.Errorf(
+					"condition of hyperparameter %s must allow at least one value of %s", name, ref))
+			}
+			for _, val := range param.Condition[ref] {
+				if !containsValue(possible, val) {
+					errs = append(errs, errors.Errorf(
+						"condition of hyperparameter %s refers to %v, which is not a value of %s",
+						name, val, ref))
+				}
+			}
+		}
+	}
+
+	// Search for cycles with a depth-first traversal, marking hyperparameters as visiting while
+	// their dependencies are being traversed.
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch state[name] {
+		case visiting:
+			return false
+		case visited:
+			return true
+		}
+		state[name] = visiting
+		for _, ref := range sortedNames(flat[name].Condition) {
+			if _, ok := flat[ref]; ok && !visit(ref) {
+				return false
+			}
+		}
+		state[name] = visited
+		return true
+	}
+	for _, name := range sortedNames(flat) {
+		if !visit(name) {
+			errs = append(errs, errors.Errorf(
+				"condition of hyperparameter %s depends on itself", name))
+			break
+		}
+	}
+	return errs
+}
+
 func isNumeric(val interface{}) bool {
 	_, iOk := val.(int)
 	_, fOk := val.(float64)
 	return iOk || fOk
 }
 
-// Each applies the function to each hyperparameter in string order of the name.
+func containsValue(vals []interface{}, val interface{}) bool {
+	for _, v := range vals {
+		if reflect.DeepEqual(v, val) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNames(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, key.String())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// flatten returns the non-nested hyperparameters keyed by their full names, along with the full
+// names that are defined more than once.
+func (h Hyperparameters) flatten() (map[string]Hyperparameter, []string) {
+	flat := make(map[string]Hyperparameter)
+	var duplicates []string
+	var walk func(prefix string, params map[string]Hyperparameter)
+	walk = func(prefix string, params map[string]Hyperparameter) {
+		for _, name := range sortedNames(params) {
+			param := params[name]
+			if param.NestedHyperparameter != nil {
+				walk(prefix+name+".", *param.NestedHyperparameter)
+				continue
+			}
+			if _, ok := flat[prefix+name]; ok {
+				duplicates = append(duplicates, prefix+name)
+			}
+			flat[prefix+name] = param
+		}
+	}
+	walk("", h)
+	return flat, duplicates
+}
+
+// eachNested applies the function to each nested hyperparameter with its full name.
+func (h Hyperparameters) eachNested(
+	prefix string, f func(name string, nested map[string]Hyperparameter),
+) {
+	for _, name := range sortedNames(h) {
+		if nested := h[name].NestedHyperparameter; nested != nil {
+			f(prefix+name, *nested)
+			Hyperparameters(*nested).eachNested(prefix+name+".", f)
+		}
+	}
+}
+
+// Each applies the function to each non-nested hyperparameter in string order of its full name,
+// except that a conditional hyperparameter always follows the hyperparameters its condition
+// refers to.
 func (h Hyperparameters) Each(f func(name string, param Hyperparameter)) {
-	keys := make([]string, 0, len(h))
-	for k := range h {
-		keys = append(keys, k)
+	flat, _ := h.flatten()
+	visited := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		param := flat[name]
+		for _, ref := range sortedNames(param.Condition) {
+			if _, ok := flat[ref]; ok {
+				visit(ref)
+			}
+		}
+		f(name, param)
+	}
+	for _, name := range sortedNames(flat) {
+		visit(name)
 	}
-	sort.Strings(keys)
-	for _, k := range keys {
-		f(k, h[k])
+}
+
+// Unflatten converts a sample keyed by the full names of hyperparameters, as passed to Each, into
+// the nested form of the hyperparameter configuration. Hyperparameters that are missing from the
+// sample, e.g., because their conditions were not met, are omitted.
+func (h Hyperparameters) Unflatten(sample map[string]interface{}) map[string]interface{} {
+	return h.unflatten("", sample)
+}
+
+func (h Hyperparameters) unflatten(
+	prefix string, sample map[string]interface{},
+) map[string]interface{} {
+	nested := make(map[string]interface{})
+	for name, param := range h {
+		if param.NestedHyperparameter != nil {
+			nested[name] = Hyperparameters(*param.NestedHyperparameter).unflatten(
+				prefix+name+".", sample)
+		} else if val, ok := sample[prefix+name]; ok {
+			nested[name] = val
+		}
 	}
+	return nested
 }
 
-// Hyperparameter is a sum type for hyperparameters.
+// Hyperparameter is a sum type for hyperparameters. A hyperparameter may instead be a nested
+// group of hyperparameters, which is configured as an object without a hyperparameter type.
 type Hyperparameter struct {
 	ConstHyperparameter       *ConstHyperparameter       `union:"type,const" json:"-"`
 	IntHyperparameter         *IntHyperparameter         `union:"type,int" json:"-"`
 	DoubleHyperparameter      *DoubleHyperparameter      `union:"type,double" json:"-"`
 	LogHyperparameter         *LogHyperparameter         `union:"type,log" json:"-"`
 	CategoricalHyperparameter *CategoricalHyperparameter `union:"type,categorical" json:"-"`
+
+	NestedHyperparameter *map[string]Hyperparameter `json:"-"`
+
+	// Condition restricts the hyperparameter to the trials in which each of the referenced
+	// hyperparameters (by full name) takes one of the listed values.
+	Condition map[string][]interface{} `json:"condition,omitempty"`
+}
+
+// hyperparameterTypes are the values of the type field of a non-nested hyperparameter.
+var hyperparameterTypes = map[string]bool{
+	"const": true, "int": true, "double": true, "log": true, "categorical": true,
 }
 
 // MarshalJSON implements the json.Marshaler interface.
 func (h Hyperparameter) MarshalJSON() ([]byte, error) {
+	if h.NestedHyperparameter != nil {
+		return json.Marshal(*h.NestedHyperparameter)
+	}
 	return union.Marshal(h)
 }
 
@@ -81,11 +279,63 @@ func (h *Hyperparameter) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &parsed); err != nil {
 		return err
 	}
-	if _, ok := parsed.(map[string]interface{}); ok {
-		return union.Unmarshal(data, h)
+	obj, ok := parsed.(map[string]interface{})
+	if !ok {
+		h.ConstHyperparameter = &ConstHyperparameter{Val: parsed}
+		return nil
+	}
+	if typ, ok := obj["type"]; !ok || !hyperparameterTypes[typeName(typ)] {
+		// A bare value of type would be read as a hyperparameter definition if it happened to
+		// name a hyperparameter type, so a member named type must be defined by a map.
+		if _, isMap := typ.(map[string]interface{}); ok && !isMap {
+			return errors.Errorf(
+				"type %v is not a hyperparameter type (one of const, int, double, log, or "+
+					"categorical); a member of a nested hyperparameter named type must be "+
+					"defined by a map, such as {type: const, val: %v}", typ, typ)
+		}
+		nested := make(map[string]Hyperparameter)
+		if err := json.Unmarshal(data, &nested); err != nil {
+			return err
+		}
+		h.NestedHyperparameter = &nested
+		return nil
+	}
+	if err := union.Unmarshal(data, h); err != nil {
+		return err
+	}
+	type DefaultParser *Hyperparameter
+	return json.Unmarshal(data, DefaultParser(h))
+}
+
+// typeName returns the value of the type field of a hyperparameter if it is a string.
+func typeName(typ interface{}) string {
+	name, _ := typ.(string)
+	return name
+}
+
+// ConditionMet returns whether the condition of the hyperparameter holds for the sample, which is
+// keyed by the full names of hyperparameters; it always holds for unconditional hyperparameters.
+func (h Hyperparameter) ConditionMet(sample map[string]interface{}) bool {
+	for name, vals := range h.Condition {
+		val, ok := sample[name]
+		if !ok || !containsValue(vals, val) {
+			return false
+		}
+	}
+	return true
+}
+
+// conditionValues returns the possible values of a hyperparameter that may be referred to by a
+// condition, or nil if the hyperparameter cannot be referred to.
+func (h Hyperparameter) conditionValues() []interface{} {
+	switch {
+	case h.ConstHyperparameter != nil:
+		return []interface{}{h.ConstHyperparameter.Val}
+	case h.CategoricalHyperparameter != nil:
+		return h.CategoricalHyperparameter.Vals
+	default:
+		return nil
 	}
-	h.ConstHyperparameter = &ConstHyperparameter{Val: parsed}
-	return nil
 }
 
 // ConstHyperparameter is a constant.
@@ -83,3 +83,160 @@ func TestValidateGlobalBatchSize(t *testing.T) {
 		runTestCase(t, tc)
 	}
 }
+
+const nestedHyperparameters = `{
+	"global_batch_size": 32,
+	"optimizer": {
+		"type": {
+			"type": "categorical",
+			"vals": ["adam", "sgd"]
+		},
+		"momentum": {
+			"type": "double",
+			"minval": 0.5,
+			"maxval": 0.9,
+			"condition": {"optimizer.type": ["sgd"]}
+		},
+		"schedule": {
+			"warmup": 100
+		}
+	}
+}`
+
+func TestNestedHyperparameters(t *testing.T) {
+	var hparams Hyperparameters
+	assert.NilError(t, json.Unmarshal([]byte(nestedHyperparameters), &hparams))
+	assert.NilError(t, check.Validate(hparams))
+
+	optimizer := hparams["optimizer"].NestedHyperparameter
+	assert.Assert(t, optimizer != nil)
+	assert.DeepEqual(t, (*optimizer)["type"].CategoricalHyperparameter.Vals,
+		[]interface{}{"adam", "sgd"})
+	assert.DeepEqual(t, (*optimizer)["momentum"].Condition,
+		map[string][]interface{}{"optimizer.type": {"sgd"}})
+
+	// A conditional hyperparameter follows the hyperparameter its condition refers to.
+	var names []string
+	hparams.Each(func(name string, _ Hyperparameter) {
+		names = append(names, name)
+	})
+	assert.DeepEqual(t, names, []string{
+		"global_batch_size", "optimizer.type", "optimizer.momentum", "optimizer.schedule.warmup",
+	})
+
+	// The configuration survives a round trip through JSON.
+	bytes, err := json.Marshal(hparams)
+	assert.NilError(t, err)
+	var roundTrip Hyperparameters
+	assert.NilError(t, json.Unmarshal(bytes, &roundTrip))
+	assert.DeepEqual(t, hparams, roundTrip)
+
+	sample := map[string]interface{}{
+		"global_batch_size":         32.0,
+		"optimizer.type":            "adam",
+		"optimizer.schedule.warmup": 100.0,
+	}
+	assert.DeepEqual(t, hparams.Unflatten(sample), map[string]interface{}{
+		"global_batch_size": 32.0,
+		"optimizer": map[string]interface{}{
+			"type":     "adam",
+			"schedule": map[string]interface{}{"warmup": 100.0},
+		},
+	})
+}
+
+func TestNestedHyperparameterNamedType(t *testing.T) {
+	// A member named type is allowed when it is defined by a map.
+	var hparams Hyperparameters
+	assert.NilError(t, json.Unmarshal([]byte(`{
+		"global_batch_size": 32,
+		"optimizer": {"type": {"type": "const", "val": "sgd"}, "lr": 0.1}
+	}`), &hparams))
+	assert.NilError(t, check.Validate(hparams))
+	assert.Equal(t, (*hparams["optimizer"].NestedHyperparameter)["type"].ConstHyperparameter.Val,
+		"sgd")
+
+	// A bare value would be ambiguous with a hyperparameter definition, so it is rejected.
+	for _, config := range []string{
+		`{"global_batch_size": 32, "optimizer": {"type": "sgd", "lr": 0.1}}`,
+		`{"global_batch_size": 32, "optimizer": {"type": 1}}`,
+	} {
+		assert.ErrorContains(t, json.Unmarshal([]byte(config), &hparams),
+			"a member of a nested hyperparameter named type must be defined by a map")
+	}
+}
+
+func TestValidateConditions(t *testing.T) {
+	type testCase struct {
+		name         string
+		config       string
+		errorMessage string
+	}
+	testCases := []testCase{
+		{
+			"unknown hyperparameter",
+			`{
+				"global_batch_size": 32,
+				"momentum": {"type": "const", "val": 0.9, "condition": {"optimizer": ["sgd"]}}
+			}`,
+			"refers to unknown hyperparameter optimizer",
+		},
+		{
+			"non-categorical hyperparameter",
+			`{
+				"global_batch_size": 32,
+				"layers": {"type": "int", "minval": 1, "maxval": 4},
+				"width": {"type": "const", "val": 8, "condition": {"layers": [2]}}
+			}`,
+			"must refer to a categorical or const hyperparameter",
+		},
+		{
+			"impossible value",
+			`{
+				"global_batch_size": 32,
+				"optimizer": {"type": "categorical", "vals": ["adam", "sgd"]},
+				"momentum": {"type": "const", "val": 0.9, "condition": {"optimizer": ["rmsprop"]}}
+			}`,
+			"refers to rmsprop, which is not a value of optimizer",
+		},
+		{
+			"cycle",
+			`{
+				"global_batch_size": 32,
+				"a": {"type": "categorical", "vals": [1, 2], "condition": {"b": [1]}},
+				"b": {"type": "categorical", "vals": [1, 2], "condition": {"a": [1]}}
+			}`,
+			"depends on itself",
+		},
+		{
+			"duplicate name",
+			`{
+				"global_batch_size": 32,
+				"optimizer.type": "sgd",
+				"optimizer": {"type": {"type": "const", "val": "sgd"}}
+			}`,
+			"hyperparameter optimizer.type is defined more than once",
+		},
+		{
+			"empty nested hyperparameter",
+			`{"global_batch_size": 32, "optimizer": {}}`,
+			"nested hyperparameter optimizer must not be empty",
+		},
+		{
+			"conditional global_batch_size",
+			`{
+				"global_batch_size": {"type": "const", "val": 32, "condition": {"a": [1]}},
+				"a": {"type": "categorical", "vals": [1, 2]}
+			}`,
+			"global_batch_size hyperparameter must not be conditional",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var hparams Hyperparameters
+			assert.NilError(t, json.Unmarshal([]byte(tc.config), &hparams))
+			assert.ErrorContains(t, check.Validate(hparams), tc.errorMessage)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Role is the level of access that a user has to the resources of a workspace or the cluster.
+// Each role includes the permissions of the roles before it.
+type Role string
+
+const (
+	// RoleNone grants no access; resources that a user has no role for are hidden from them.
+	RoleNone Role = ""
+	// RoleViewer grants read access.
+	RoleViewer Role = "viewer"
+	// RoleEditor grants read access and permission to create and modify resources, e.g., to
+	// create, pause or kill experiments.
+	RoleEditor Role = "editor"
+	// RoleAdmin grants all permissions.
+	RoleAdmin Role = "admin"
+)
+
+var roleLevels = map[Role]int{
+	RoleNone:   0,
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// ParseRole parses a non-empty role name.
+func ParseRole(name string) (Role, error) {
+	role := Role(name)
+	if _, ok := roleLevels[role]; !ok || role == RoleNone {
+		return RoleNone, errors.Errorf(
+			"unknown role %q; role must be `viewer`, `editor` or `admin`", name)
+	}
+	return role, nil
+}
+
+// Includes returns true if the role grants all of the permissions of the other role.
+func (r Role) Includes(other Role) bool {
+	return roleLevels[r] >= roleLevels[other]
+}
+
+// MaxRole returns the role with the most permissions.
+func MaxRole(roles ...Role) Role {
+	max := RoleNone
+	for _, role := range roles {
+		if !max.Includes(role) {
+			max = role
+		}
+	}
+	return max
+}
+
+// Workspace corresponds to a row in the "workspaces" DB table.
+type Workspace struct {
+	ID   int    `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+}
+
+// Group corresponds to a row in the "groups" DB table, along with the usernames of its members.
+type Group struct {
+	ID        int      `db:"id" json:"id"`
+	Name      string   `db:"group_name" json:"name"`
+	Usernames []string `db:"-" json:"usernames"`
+}
+
+// RoleAssignment corresponds to a row in the "role_assignments" DB table, along with the names of
+// the workspace, user and group that it refers to. A nil workspace applies the role to the whole
+// cluster; exactly one of the user and the group is set.
+type RoleAssignment struct {
+	ID          int     `db:"id" json:"id"`
+	Role        Role    `db:"role" json:"role"`
+	WorkspaceID *int    `db:"workspace_id" json:"-"`
+	UserID      *UserID `db:"user_id" json:"-"`
+	GroupID     *int    `db:"group_id" json:"-"`
+
+	Workspace *string `db:"workspace" json:"workspace"`
+	Username  *string `db:"username" json:"username"`
+	GroupName *string `db:"group_name" json:"group"`
+}
+
+// ExperimentAccess holds the fields of an experiment that decide which users may access it.
+type ExperimentAccess struct {
+	ID          int     `db:"id"`
+	OwnerID     *UserID `db:"owner_id"`
+	WorkspaceID *int    `db:"workspace_id"`
+}
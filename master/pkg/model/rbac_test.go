@@ -0,0 +1,32 @@
+package model
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestParseRole(t *testing.T) {
+	role, err := ParseRole("editor")
+	assert.NilError(t, err)
+	assert.Equal(t, role, RoleEditor)
+
+	_, err = ParseRole("")
+	assert.ErrorContains(t, err, "unknown role")
+	_, err = ParseRole("owner")
+	assert.ErrorContains(t, err, "unknown role")
+}
+
+func TestRoleIncludes(t *testing.T) {
+	assert.Assert(t, RoleAdmin.Includes(RoleEditor))
+	assert.Assert(t, RoleEditor.Includes(RoleEditor))
+	assert.Assert(t, RoleViewer.Includes(RoleNone))
+	assert.Assert(t, !RoleViewer.Includes(RoleEditor))
+	assert.Assert(t, !RoleNone.Includes(RoleViewer))
+}
+
+func TestMaxRole(t *testing.T) {
+	assert.Equal(t, MaxRole(), RoleNone)
+	assert.Equal(t, MaxRole(RoleViewer, RoleAdmin, RoleEditor), RoleAdmin)
+	assert.Equal(t, MaxRole(RoleNone, RoleViewer), RoleViewer)
+}
@@ -29,6 +29,8 @@ type SearcherConfig struct {
 	AdaptiveSimpleConfig *AdaptiveSimpleConfig `union:"name,adaptive_simple" json:"-"`
 	AdaptiveASHAConfig   *AdaptiveASHAConfig   `union:"name,adaptive_asha" json:"-"`
 	PBTConfig            *PBTConfig            `union:"name,pbt" json:"-"`
+	TPEConfig            *TPEConfig            `union:"name,tpe" json:"-"`
+	CustomConfig         *CustomConfig         `union:"name,custom" json:"-"`
 }
 
 // MarshalJSON implements the json.Marshaler interface.
@@ -66,6 +68,10 @@ func (s SearcherConfig) Unit() Unit {
 		return s.AdaptiveASHAConfig.Unit()
 	case s.PBTConfig != nil:
 		return s.PBTConfig.Unit()
+	case s.TPEConfig != nil:
+		return s.TPEConfig.Unit()
+	case s.CustomConfig != nil:
+		return s.CustomConfig.Unit()
 	default:
 		panic("no searcher type specified")
 	}
@@ -330,3 +336,57 @@ func (p PBTConfig) Validate() []error {
 func (p PBTConfig) Unit() Unit {
 	return p.LengthPerRound.Unit
 }
+
+// TPEConfig configures a Bayesian optimization search using the tree-structured Parzen estimator.
+type TPEConfig struct {
+	Metric              string  `json:"metric"`
+	SmallerIsBetter     bool    `json:"smaller_is_better"`
+	MaxLength           Length  `json:"max_length"`
+	MaxTrials           int     `json:"max_trials"`
+	MaxConcurrentTrials int     `json:"max_concurrent_trials"`
+	NumStartupTrials    int     `json:"num_startup_trials"`
+	NumCandidates       int     `json:"num_candidates"`
+	Gamma               float64 `json:"gamma"`
+	PriorWeight         float64 `json:"prior_weight"`
+}
+
+// Validate implements the check.Validatable interface.
+func (t TPEConfig) Validate() []error {
+	return []error{
+		check.GreaterThan(t.MaxLength.Units, 0, "max_length must be > 0"),
+		check.GreaterThan(t.MaxTrials, 0, "max_trials must be > 0"),
+		check.GreaterThanOrEqualTo(t.MaxConcurrentTrials, 0, "max_concurrent_trials must be >= 0"),
+		check.GreaterThan(t.NumStartupTrials, 0, "num_startup_trials must be > 0"),
+		check.GreaterThan(t.NumCandidates, 0, "num_candidates must be > 0"),
+		check.GreaterThan(t.Gamma, 0.0, "gamma must be > 0"),
+		check.LessThan(t.Gamma, 1.0, "gamma must be < 1"),
+		check.GreaterThan(t.PriorWeight, 0.0, "prior_weight must be > 0"),
+	}
+}
+
+// Unit implements the model.InUnits interface.
+func (t TPEConfig) Unit() Unit {
+	return t.MaxLength.Unit
+}
+
+// CustomConfig configures a search that is driven by an external search service over gRPC.
+type CustomConfig struct {
+	Metric          string                 `json:"metric"`
+	SmallerIsBetter bool                   `json:"smaller_is_better"`
+	Endpoint        string                 `json:"endpoint"`
+	MaxLength       Length                 `json:"max_length"`
+	Config          map[string]interface{} `json:"config"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c CustomConfig) Validate() []error {
+	return []error{
+		check.NotEmpty(c.Endpoint, "endpoint must be specified"),
+		check.GreaterThan(c.MaxLength.Units, 0, "max_length must be > 0"),
+	}
+}
+
+// Unit implements the model.InUnits interface.
+func (c CustomConfig) Unit() Unit {
+	return c.MaxLength.Unit
+}
@@ -0,0 +1,10 @@
+package model
+
+// TaskContainer corresponds to a row in the "task_containers" DB table. It records a container of
+// a running task so that the master can reattach to it after restarting.
+type TaskContainer struct {
+	ID           string `db:"id"`
+	TaskID       string `db:"task_id"`
+	ResourcePool string `db:"resource_pool"`
+	Rank         int    `db:"rank"`
+}
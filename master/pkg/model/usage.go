@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// SlotUsage corresponds to a row in the "slot_usage" DB table. It records the slots that were
+// allocated to a task from the time they were allocated until they were released.
+type SlotUsage struct {
+	ID           int64    `db:"id" json:"id"`
+	TaskID       string   `db:"task_id" json:"task_id"`
+	TaskName     string   `db:"task_name" json:"task_name"`
+	Username     string   `db:"username" json:"username"`
+	ExperimentID *int     `db:"experiment_id" json:"experiment_id"`
+	Labels       []string `db:"labels" json:"labels"`
+	ResourcePool string   `db:"resource_pool" json:"resource_pool"`
+	Slots        int      `db:"slots" json:"slots"`
+	// SlotHourlyRate is the price of using one slot for an hour in the resource pool at the time
+	// the slots were allocated, or nil if the pool has no price.
+	SlotHourlyRate *float64   `db:"slot_hourly_rate" json:"slot_hourly_rate"`
+	StartTime      time.Time  `db:"start_time" json:"start_time"`
+	EndTime        *time.Time `db:"end_time" json:"end_time"`
+}
+
+// UsageGroupBy is the attribute of slot usage that usage reports are aggregated by.
+type UsageGroupBy string
+
+// Attributes that slot usage can be aggregated by.
+const (
+	UsageGroupByUser         UsageGroupBy = "user"
+	UsageGroupByExperiment   UsageGroupBy = "experiment"
+	UsageGroupByLabel        UsageGroupBy = "label"
+	UsageGroupByResourcePool UsageGroupBy = "resource_pool"
+	UsageGroupByTask         UsageGroupBy = "task"
+)
+
+// UsagePeriod is the length of the periods that usage reports are split into.
+type UsagePeriod string
+
+// Periods that slot usage can be split into. With UsagePeriodNone, the whole time range of a
+// report is a single period.
+const (
+	UsagePeriodNone  UsagePeriod = ""
+	UsagePeriodDay   UsagePeriod = "day"
+	UsagePeriodWeek  UsagePeriod = "week"
+	UsagePeriodMonth UsagePeriod = "month"
+)
+
+// UsageFilter selects the slot usage that a usage report covers. Only the part of each allocation
+// that falls between Since and Until is counted. Since defaults to the start of the earliest
+// allocation and Until to the current time.
+type UsageFilter struct {
+	GroupBy UsageGroupBy
+	Period  UsagePeriod
+	Since   *time.Time
+	Until   *time.Time
+}
+
+// UsageEntry is the slot usage of one group, e.g., one user, in one period of a usage report.
+// Allocations with several labels count towards each of their labels.
+type UsageEntry struct {
+	PeriodStart time.Time `db:"period_start" json:"period_start"`
+	PeriodEnd   time.Time `db:"period_end" json:"period_end"`
+	// Key identifies the group, e.g., the username; it is empty for usage without the attribute,
+	// such as the usage of commands when grouping by experiment.
+	Key       string  `db:"key" json:"key"`
+	SlotHours float64 `db:"slot_hours" json:"slot_hours"`
+	// Cost is the slot hours priced by the hourly rates of their resource pools; slot hours in pools
+	// without a rate cost nothing.
+	Cost float64 `db:"cost" json:"cost"`
+}
@@ -4,7 +4,10 @@
 
 package nprand
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 const (
 	stateLen  int    = 624
@@ -166,3 +169,28 @@ func (state *State) Uniform(low, high float64) float64 {
 	}
 	return low + (high-low)*state.UnitInterval()
 }
+
+// stateJSON is the serialized form of a State.
+type stateJSON struct {
+	Key []uint32 `json:"key"`
+	Pos int      `json:"pos"`
+}
+
+// MarshalJSON serializes the RNG state so that it can be saved and later restored.
+func (state State) MarshalJSON() ([]byte, error) {
+	return json.Marshal(stateJSON{Key: state.key[:], Pos: state.pos})
+}
+
+// UnmarshalJSON restores an RNG state serialized by MarshalJSON.
+func (state *State) UnmarshalJSON(data []byte) error {
+	var s stateJSON
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if len(s.Key) != stateLen || s.Pos < 0 || s.Pos > stateLen {
+		return fmt.Errorf("invalid nprand state: %d keys at position %d", len(s.Key), s.Pos)
+	}
+	copy(state.key[:], s.Key)
+	state.pos = s.Pos
+	return nil
+}
@@ -1,6 +1,7 @@
 package nprand
 
 import (
+	"encoding/json"
 	"testing"
 
 	"gotest.tools/assert"
@@ -424,3 +425,21 @@ func TestChoice(t *testing.T) {
 	}
 	assert.DeepEqual(t, fromNumpy, choices)
 }
+
+func TestStateJSON(t *testing.T) {
+	state := New(42)
+	for i := 0; i < 1000; i++ {
+		state.Bits32()
+	}
+
+	bytes, err := json.Marshal(state)
+	assert.NilError(t, err)
+	var restored State
+	assert.NilError(t, json.Unmarshal(bytes, &restored))
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, state.Bits32(), restored.Bits32())
+	}
+
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"key": [1], "pos": 0}`), &restored),
+		"invalid nprand state")
+}
@@ -1,6 +1,7 @@
 package searcher
 
 import (
+	"encoding/json"
 	"math"
 	"sort"
 
@@ -249,3 +250,42 @@ func (s *asyncHalvingSearch) trialExitedEarly(
 	s.closedTrials[requestID] = true
 	return s.promoteAsync(ctx, requestID, ashaExitedMetricValue), nil
 }
+
+type asyncHalvingSearchSnapshot struct {
+	Rungs           []rungSnapshot     `json:"rungs"`
+	TrialRungs      map[RequestID]int  `json:"trial_rungs"`
+	EarlyExitTrials map[RequestID]bool `json:"early_exit_trials"`
+	ClosedTrials    map[RequestID]bool `json:"closed_trials"`
+	TrialsCompleted int                `json:"trials_completed"`
+}
+
+func (s *asyncHalvingSearch) snapshot() (json.RawMessage, error) {
+	return json.Marshal(asyncHalvingSearchSnapshot{
+		Rungs:           snapshotRungs(s.rungs),
+		TrialRungs:      s.trialRungs,
+		EarlyExitTrials: s.earlyExitTrials,
+		ClosedTrials:    s.closedTrials,
+		TrialsCompleted: s.trialsCompleted,
+	})
+}
+
+func (s *asyncHalvingSearch) restore(_ context, state json.RawMessage) error {
+	var snapshot asyncHalvingSearchSnapshot
+	if err := json.Unmarshal(state, &snapshot); err != nil {
+		return err
+	}
+	if err := restoreRungs(s.rungs, snapshot.Rungs); err != nil {
+		return err
+	}
+	for requestID, rungIndex := range snapshot.TrialRungs {
+		s.trialRungs[requestID] = rungIndex
+	}
+	for requestID, exited := range snapshot.EarlyExitTrials {
+		s.earlyExitTrials[requestID] = exited
+	}
+	for requestID, closed := range snapshot.ClosedTrials {
+		s.closedTrials[requestID] = closed
+	}
+	s.trialsCompleted = snapshot.TrialsCompleted
+	return nil
+}
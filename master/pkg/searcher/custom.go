@@ -0,0 +1,510 @@
+package searcher
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	structpb "github.com/golang/protobuf/ptypes/struct"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/master/pkg/workload"
+	"github.com/determined-ai/determined/proto/pkg/experimentv1"
+	"github.com/determined-ai/determined/proto/pkg/searcherv1"
+)
+
+// customSearcherTimeout bounds each call to an external search service.
+const customSearcherTimeout = time.Minute
+
+// customSearch delegates the search to an external search service that implements the
+// CustomSearcher gRPC service. If the service returns the state of the search, the state is saved
+// in snapshots and the search is continued from it after a master restart; otherwise, the searcher
+// events of the experiment are replayed through a new customSearch, which starts a new search on
+// the service, and the service is expected to respond to the same events with the same operations.
+type customSearch struct {
+	model.CustomConfig
+
+	dial       func(endpoint string) (*grpc.ClientConn, error)
+	conn       *grpc.ClientConn
+	client     searcherv1.CustomSearcherClient
+	searcherID string
+	seed       uint32
+	hparams    map[string]bool
+
+	// state is the latest serialized state of the search that the service returned.
+	state []byte
+	// lastProgress is the progress that the service reported along with its latest response.
+	lastProgress float64
+
+	// While async, calls to the service are queued instead of made; pending counts the calls that
+	// have not completed yet.
+	async   bool
+	queued  []Call
+	pending int
+}
+
+// customSearchState is the serialized state of a customSearch.
+type customSearchState struct {
+	Seed         uint32  `json:"seed"`
+	State        []byte  `json:"state"`
+	LastProgress float64 `json:"last_progress"`
+}
+
+// Call is a call to an external search service that a search method queued instead of blocking on
+// it. The calls of a searcher must be run in the order that they were queued, and the result of
+// each passed to Searcher.CallCompleted.
+type Call struct {
+	run func() CallResult
+}
+
+// Run makes the call. Each request to the service times out after a minute.
+func (c Call) Run() CallResult {
+	return c.run()
+}
+
+// CallResult is the result of a Call.
+type CallResult struct {
+	searcherID string
+	operations []*searcherv1.Operation
+	state      []byte
+	progress   *float64
+	err        error
+}
+
+func newCustomSearch(config model.CustomConfig) SearchMethod {
+	return &customSearch{
+		CustomConfig: config,
+		dial: func(endpoint string) (*grpc.ClientConn, error) {
+			return grpc.Dial(endpoint, grpc.WithInsecure())
+		},
+	}
+}
+
+// Close implements the io.Closer interface.
+func (s *customSearch) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+func (s *customSearch) setAsync(async bool) {
+	s.async = async
+}
+
+func (s *customSearch) calls() []Call {
+	defer func() { s.queued = nil }()
+	return s.queued
+}
+
+func (s *customSearch) callsPending() bool {
+	return s.pending > 0
+}
+
+func (s *customSearch) connect(ctx context) error {
+	if s.client == nil {
+		conn, err := s.dial(s.Endpoint)
+		if err != nil {
+			return errors.Wrapf(err, "error connecting to custom searcher at %s", s.Endpoint)
+		}
+		s.conn = conn
+		s.client = searcherv1.NewCustomSearcherClient(conn)
+	}
+
+	s.hparams = make(map[string]bool)
+	ctx.hparams.Each(func(name string, _ model.Hyperparameter) {
+		s.hparams[name] = true
+	})
+	return nil
+}
+
+func (s *customSearch) searchRequest(ctx context) (*searcherv1.InitialOperationsRequest, error) {
+	maxLength, err := toProtoLength(s.MaxLength)
+	if err != nil {
+		return nil, err
+	}
+	return &searcherv1.InitialOperationsRequest{
+		Hyperparameters: protoutils.ToStruct(ctx.hparams),
+		Metric:          s.Metric,
+		SmallerIsBetter: s.SmallerIsBetter,
+		MaxLength:       maxLength,
+		Config:          protoutils.ToStruct(s.Config),
+		Seed:            s.seed,
+	}, nil
+}
+
+func (s *customSearch) initialOperations(ctx context) ([]Operation, error) {
+	if err := s.connect(ctx); err != nil {
+		return nil, err
+	}
+	s.seed = uint32(ctx.rand.Int64n(1 << 31))
+	req, err := s.searchRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client := s.client
+	return s.do(ctx, func() CallResult {
+		callCtx, cancel := callContext()
+		defer cancel()
+		resp, err := client.InitialOperations(callCtx, req)
+		if err != nil {
+			return CallResult{err: errors.Wrap(err, "error starting custom search")}
+		}
+		return CallResult{
+			searcherID: resp.SearcherId,
+			operations: resp.Operations,
+			state:      resp.State,
+			progress:   fetchProgress(client, resp.SearcherId, 0),
+		}
+	})
+}
+
+func (s *customSearch) trialCreated(ctx context, requestID RequestID) ([]Operation, error) {
+	req := &searcherv1.TrialCreatedRequest{
+		SearcherId: s.searcherID,
+		RequestId:  requestID.String(),
+	}
+	return s.call(ctx, func(
+		client searcherv1.CustomSearcherClient, callCtx gocontext.Context,
+	) (*searcherv1.OperationsResponse, error) {
+		return client.TrialCreated(callCtx, req)
+	})
+}
+
+func (s *customSearch) trainCompleted(
+	ctx context, requestID RequestID, train Train,
+) ([]Operation, error) {
+	length, err := toProtoLength(train.Length)
+	if err != nil {
+		return nil, err
+	}
+	req := &searcherv1.TrainCompletedRequest{
+		SearcherId: s.searcherID,
+		RequestId:  requestID.String(),
+		Length:     length,
+	}
+	return s.call(ctx, func(
+		client searcherv1.CustomSearcherClient, callCtx gocontext.Context,
+	) (*searcherv1.OperationsResponse, error) {
+		return client.TrainCompleted(callCtx, req)
+	})
+}
+
+func (s *customSearch) checkpointCompleted(
+	ctx context, requestID RequestID, _ Checkpoint, metrics workload.CheckpointMetrics,
+) ([]Operation, error) {
+	req := &searcherv1.CheckpointCompletedRequest{
+		SearcherId:     s.searcherID,
+		RequestId:      requestID.String(),
+		CheckpointUuid: metrics.UUID.String(),
+	}
+	return s.call(ctx, func(
+		client searcherv1.CustomSearcherClient, callCtx gocontext.Context,
+	) (*searcherv1.OperationsResponse, error) {
+		return client.CheckpointCompleted(callCtx, req)
+	})
+}
+
+func (s *customSearch) validationCompleted(
+	ctx context, requestID RequestID, _ Validate, metrics workload.ValidationMetrics,
+) ([]Operation, error) {
+	req := &searcherv1.ValidationCompletedRequest{
+		SearcherId: s.searcherID,
+		RequestId:  requestID.String(),
+		Metrics:    protoutils.ToStruct(metrics.Metrics),
+	}
+	return s.call(ctx, func(
+		client searcherv1.CustomSearcherClient, callCtx gocontext.Context,
+	) (*searcherv1.OperationsResponse, error) {
+		return client.ValidationCompleted(callCtx, req)
+	})
+}
+
+func (s *customSearch) trialClosed(ctx context, requestID RequestID) ([]Operation, error) {
+	req := &searcherv1.TrialClosedRequest{
+		SearcherId: s.searcherID,
+		RequestId:  requestID.String(),
+	}
+	return s.call(ctx, func(
+		client searcherv1.CustomSearcherClient, callCtx gocontext.Context,
+	) (*searcherv1.OperationsResponse, error) {
+		return client.TrialClosed(callCtx, req)
+	})
+}
+
+func (s *customSearch) trialExitedEarly(
+	ctx context, requestID RequestID, exitedReason workload.ExitedReason,
+) ([]Operation, error) {
+	req := &searcherv1.TrialExitedEarlyRequest{
+		SearcherId:   s.searcherID,
+		RequestId:    requestID.String(),
+		ExitedReason: toProtoExitedReason(exitedReason),
+	}
+	return s.call(ctx, func(
+		client searcherv1.CustomSearcherClient, callCtx gocontext.Context,
+	) (*searcherv1.OperationsResponse, error) {
+		return client.TrialExitedEarly(callCtx, req)
+	})
+}
+
+// progress returns the progress that the service reported along with its latest response, so that
+// it never blocks on the service.
+func (s *customSearch) progress(float64) float64 {
+	return s.lastProgress
+}
+
+func (s *customSearch) snapshot() (json.RawMessage, error) {
+	if s.state == nil {
+		return nil, ErrSnapshotUnsupported
+	}
+	return json.Marshal(customSearchState{
+		Seed: s.seed, State: s.state, LastProgress: s.lastProgress,
+	})
+}
+
+// restore continues the search on the service from the saved state. Unlike the other calls, it is
+// made synchronously, since the searcher is restored before the experiment starts.
+func (s *customSearch) restore(ctx context, state json.RawMessage) error {
+	var saved customSearchState
+	if err := json.Unmarshal(state, &saved); err != nil {
+		return err
+	}
+	if err := s.connect(ctx); err != nil {
+		return err
+	}
+	s.seed = saved.Seed
+	search, err := s.searchRequest(ctx)
+	if err != nil {
+		return err
+	}
+	callCtx, cancel := callContext()
+	defer cancel()
+	resp, err := s.client.RestoreSearch(callCtx, &searcherv1.RestoreSearchRequest{
+		Search: search,
+		State:  saved.State,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error restoring custom search")
+	}
+	s.searcherID = resp.SearcherId
+	s.state = saved.State
+	s.lastProgress = saved.LastProgress
+	return nil
+}
+
+// call makes a call for a searcher event, which also fetches the progress of the search.
+func (s *customSearch) call(
+	ctx context,
+	call func(searcherv1.CustomSearcherClient, gocontext.Context) (
+		*searcherv1.OperationsResponse, error),
+) ([]Operation, error) {
+	client, searcherID, unitsCompleted := s.client, s.searcherID, ctx.unitsCompleted
+	return s.do(ctx, func() CallResult {
+		callCtx, cancel := callContext()
+		defer cancel()
+		resp, err := call(client, callCtx)
+		if err != nil {
+			return CallResult{err: errors.Wrap(err, "error calling custom searcher")}
+		}
+		return CallResult{
+			operations: resp.Operations,
+			state:      resp.State,
+			progress:   fetchProgress(client, searcherID, unitsCompleted),
+		}
+	})
+}
+
+// do makes the call, or queues it if the search is async.
+func (s *customSearch) do(ctx context, run func() CallResult) ([]Operation, error) {
+	s.pending++
+	if s.async {
+		s.queued = append(s.queued, Call{run: run})
+		return nil, nil
+	}
+	return s.callCompleted(ctx, run())
+}
+
+func (s *customSearch) callCompleted(ctx context, result CallResult) ([]Operation, error) {
+	s.pending--
+	if result.err != nil {
+		return nil, result.err
+	}
+	if result.searcherID != "" {
+		s.searcherID = result.searcherID
+	}
+	if result.state != nil {
+		s.state = result.state
+	}
+	if result.progress != nil {
+		s.lastProgress = *result.progress
+	}
+	return s.fromProtoOperations(ctx, result.operations)
+}
+
+// fetchProgress returns the progress of the search, or nil if the service cannot be reached, in
+// which case the last known progress is kept.
+func fetchProgress(
+	client searcherv1.CustomSearcherClient, searcherID string, unitsCompleted float64,
+) *float64 {
+	callCtx, cancel := callContext()
+	defer cancel()
+	resp, err := client.Progress(callCtx, &searcherv1.ProgressRequest{
+		SearcherId:     searcherID,
+		UnitsCompleted: unitsCompleted,
+	})
+	if err != nil {
+		return nil
+	}
+	return &resp.Progress
+}
+
+func callContext() (gocontext.Context, gocontext.CancelFunc) {
+	return gocontext.WithTimeout(gocontext.Background(), customSearcherTimeout)
+}
+
+// fromProtoOperations converts the operations returned by the service. Trial seeds are drawn from
+// the searcher's random state so that they are reproducible when the events are replayed.
+func (s *customSearch) fromProtoOperations(
+	ctx context, protoOps []*searcherv1.Operation,
+) ([]Operation, error) {
+	var ops []Operation
+	for _, protoOp := range protoOps {
+		op, err := s.fromProtoOperation(ctx, protoOp)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid operation from custom searcher: %v", protoOp)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+func (s *customSearch) fromProtoOperation(
+	ctx context, protoOp *searcherv1.Operation,
+) (Operation, error) {
+	switch o := protoOp.Operation.(type) {
+	case *searcherv1.Operation_Create:
+		return s.fromProtoCreate(ctx, o.Create)
+	case *searcherv1.Operation_Train:
+		requestID, err := Parse(o.Train.RequestId)
+		if err != nil {
+			return nil, err
+		}
+		length, err := fromProtoLength(o.Train.Length)
+		if err != nil {
+			return nil, err
+		}
+		if length.Unit != s.Unit() {
+			return nil, errors.Errorf("train length must be in %s", s.Unit())
+		}
+		return NewTrain(requestID, length), nil
+	case *searcherv1.Operation_Validate:
+		requestID, err := Parse(o.Validate.RequestId)
+		return NewValidate(requestID), err
+	case *searcherv1.Operation_Checkpoint:
+		requestID, err := Parse(o.Checkpoint.RequestId)
+		return NewCheckpoint(requestID), err
+	case *searcherv1.Operation_Close:
+		requestID, err := Parse(o.Close.RequestId)
+		return NewClose(requestID), err
+	case *searcherv1.Operation_Shutdown:
+		return Shutdown{Failure: o.Shutdown.Failure}, nil
+	default:
+		return nil, errors.New("unexpected operation")
+	}
+}
+
+func (s *customSearch) fromProtoCreate(
+	ctx context, protoCreate *searcherv1.CreateOperation,
+) (Create, error) {
+	requestID, err := Parse(protoCreate.RequestId)
+	if err != nil {
+		return Create{}, err
+	}
+	hparams, err := fromProtoStruct(protoCreate.Hparams)
+	if err != nil {
+		return Create{}, err
+	}
+	for name := range hparams {
+		if !s.hparams[name] {
+			return Create{}, errors.Errorf("unknown hyperparameter %s", name)
+		}
+	}
+	if _, ok := hparams[model.GlobalBatchSize]; !ok {
+		return Create{}, errors.Errorf("%s must be specified", model.GlobalBatchSize)
+	}
+
+	create := NewCreate(ctx.rand, hparams, model.TrialWorkloadSequencerType)
+	create.RequestID = requestID
+	if protoCreate.CheckpointRequestId != "" {
+		var checkpointRequestID RequestID
+		if checkpointRequestID, err = Parse(protoCreate.CheckpointRequestId); err != nil {
+			return Create{}, err
+		}
+		checkpoint := NewCheckpoint(checkpointRequestID)
+		create.Checkpoint = &checkpoint
+	}
+	return create, nil
+}
+
+func fromProtoStruct(s *structpb.Struct) (hparamSample, error) {
+	sample := make(hparamSample)
+	if s == nil {
+		return sample, nil
+	}
+	bytes, err := protojson.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(bytes, &sample)
+	return sample, err
+}
+
+func toProtoLength(length model.Length) (*experimentv1.TrainingUnits, error) {
+	var unit experimentv1.Unit
+	switch length.Unit {
+	case model.Records:
+		unit = experimentv1.Unit_UNIT_RECORDS
+	case model.Batches:
+		unit = experimentv1.Unit_UNIT_BATCHES
+	case model.Epochs:
+		unit = experimentv1.Unit_UNIT_EPOCHS
+	default:
+		return nil, errors.Errorf("unexpected unit: %s", length.Unit)
+	}
+	return &experimentv1.TrainingUnits{Unit: unit, Count: int32(length.Units)}, nil
+}
+
+// fromProtoLength converts a training length from its protobuf representation.
+func fromProtoLength(length *experimentv1.TrainingUnits) (model.Length, error) {
+	if length == nil {
+		return model.Length{}, errors.New("missing length")
+	}
+	switch length.Unit {
+	case experimentv1.Unit_UNIT_RECORDS:
+		return model.NewLengthInRecords(int(length.Count)), nil
+	case experimentv1.Unit_UNIT_BATCHES:
+		return model.NewLengthInBatches(int(length.Count)), nil
+	case experimentv1.Unit_UNIT_EPOCHS:
+		return model.NewLengthInEpochs(int(length.Count)), nil
+	default:
+		return model.Length{}, errors.Errorf("unexpected unit: %s", length.Unit)
+	}
+}
+
+func toProtoExitedReason(reason workload.ExitedReason) searcherv1.ExitedReason {
+	switch reason {
+	case workload.Errored:
+		return searcherv1.ExitedReason_EXITED_REASON_ERRORED
+	case workload.UserCanceled:
+		return searcherv1.ExitedReason_EXITED_REASON_USER_CANCELED
+	case workload.InvalidHP:
+		return searcherv1.ExitedReason_EXITED_REASON_INVALID_HP
+	default:
+		return searcherv1.ExitedReason_EXITED_REASON_UNSPECIFIED
+	}
+}
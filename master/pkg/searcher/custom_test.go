@@ -0,0 +1,256 @@
+package searcher
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/nprand"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/proto/pkg/searcherv1"
+)
+
+// stubSearcher is a custom search service that trains max_trials random trials for max_length.
+type stubSearcher struct {
+	searcherv1.UnimplementedCustomSearcherServer
+
+	mu       sync.Mutex
+	searches map[string]*stubSearch
+}
+
+type stubSearch struct {
+	Rand      *nprand.State `json:"rand"`
+	MaxTrials int           `json:"max_trials"`
+	Closed    int           `json:"closed"`
+}
+
+// respond returns the operations along with the state of the search.
+func (s *stubSearcher) respond(
+	searcherID string, ops ...*searcherv1.Operation,
+) (*searcherv1.OperationsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := json.Marshal(s.searches[searcherID])
+	return &searcherv1.OperationsResponse{Operations: ops, State: state}, err
+}
+
+func (s *stubSearcher) InitialOperations(
+	_ gocontext.Context, req *searcherv1.InitialOperationsRequest,
+) (*searcherv1.InitialOperationsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	search := &stubSearch{
+		Rand:      nprand.New(req.Seed),
+		MaxTrials: int(req.Config.Fields["max_trials"].GetNumberValue()),
+	}
+	id := uuid.New().String()
+	s.searches[id] = search
+
+	var ops []*searcherv1.Operation
+	for i := 0; i < search.MaxTrials; i++ {
+		requestID := newRequestID(search.Rand).String()
+		hparams := map[string]interface{}{
+			model.GlobalBatchSize: 32,
+			"x":                   search.Rand.UnitInterval(),
+		}
+		if req.Config.Fields["unknown_hparam"].GetBoolValue() {
+			hparams["unknown"] = 1
+		}
+		ops = append(ops,
+			&searcherv1.Operation{Operation: &searcherv1.Operation_Create{
+				Create: &searcherv1.CreateOperation{
+					RequestId: requestID,
+					Hparams:   protoutils.ToStruct(hparams),
+				},
+			}},
+			&searcherv1.Operation{Operation: &searcherv1.Operation_Train{
+				Train: &searcherv1.TrainOperation{RequestId: requestID, Length: req.MaxLength},
+			}},
+			&searcherv1.Operation{Operation: &searcherv1.Operation_Validate{
+				Validate: &searcherv1.ValidateOperation{RequestId: requestID},
+			}},
+		)
+	}
+	state, err := json.Marshal(search)
+	return &searcherv1.InitialOperationsResponse{
+		SearcherId: id, Operations: ops, State: state,
+	}, err
+}
+
+func (s *stubSearcher) RestoreSearch(
+	_ gocontext.Context, req *searcherv1.RestoreSearchRequest,
+) (*searcherv1.RestoreSearchResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var search stubSearch
+	if err := json.Unmarshal(req.State, &search); err != nil {
+		return nil, err
+	}
+	id := uuid.New().String()
+	s.searches[id] = &search
+	return &searcherv1.RestoreSearchResponse{SearcherId: id}, nil
+}
+
+func (s *stubSearcher) TrialCreated(
+	_ gocontext.Context, req *searcherv1.TrialCreatedRequest,
+) (*searcherv1.OperationsResponse, error) {
+	return s.respond(req.SearcherId)
+}
+
+func (s *stubSearcher) TrainCompleted(
+	_ gocontext.Context, req *searcherv1.TrainCompletedRequest,
+) (*searcherv1.OperationsResponse, error) {
+	return s.respond(req.SearcherId)
+}
+
+func (s *stubSearcher) ValidationCompleted(
+	_ gocontext.Context, req *searcherv1.ValidationCompletedRequest,
+) (*searcherv1.OperationsResponse, error) {
+	return s.respond(req.SearcherId, &searcherv1.Operation{
+		Operation: &searcherv1.Operation_Close{
+			Close: &searcherv1.CloseOperation{RequestId: req.RequestId},
+		},
+	})
+}
+
+func (s *stubSearcher) TrialClosed(
+	_ gocontext.Context, req *searcherv1.TrialClosedRequest,
+) (*searcherv1.OperationsResponse, error) {
+	s.mu.Lock()
+	s.searches[req.SearcherId].Closed++
+	s.mu.Unlock()
+	return s.respond(req.SearcherId)
+}
+
+func (s *stubSearcher) Progress(
+	_ gocontext.Context, req *searcherv1.ProgressRequest,
+) (*searcherv1.ProgressResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	search := s.searches[req.SearcherId]
+	return &searcherv1.ProgressResponse{
+		Progress: float64(search.Closed) / float64(search.MaxTrials),
+	}, nil
+}
+
+// newStubCustomSearch starts a stub search service and returns a generator of search methods that
+// are connected to it.
+func newStubCustomSearch(t *testing.T, config model.CustomConfig) func() SearchMethod {
+	return serveStubCustomSearch(t, &stubSearcher{searches: make(map[string]*stubSearch)}, config)
+}
+
+func serveStubCustomSearch(
+	t *testing.T, stub *stubSearcher, config model.CustomConfig,
+) func() SearchMethod {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	searcherv1.RegisterCustomSearcherServer(server, stub)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	return func() SearchMethod {
+		method := newCustomSearch(config).(*customSearch)
+		method.dial = func(string) (*grpc.ClientConn, error) {
+			return grpc.Dial("bufconn", grpc.WithInsecure(), grpc.WithContextDialer(
+				func(gocontext.Context, string) (net.Conn, error) {
+					return listener.Dial()
+				}))
+		}
+		return method
+	}
+}
+
+func customTestHyperparameters() model.Hyperparameters {
+	return model.Hyperparameters{
+		model.GlobalBatchSize: {ConstHyperparameter: &model.ConstHyperparameter{Val: 32}},
+		"x": {DoubleHyperparameter: &model.DoubleHyperparameter{
+			Minval: 0, Maxval: 1,
+		}},
+	}
+}
+
+func TestCustomSearcherRecords(t *testing.T) {
+	gen := newStubCustomSearch(t, model.CustomConfig{
+		Metric:    defaultMetric,
+		Endpoint:  "stub",
+		MaxLength: model.NewLengthInRecords(19200),
+		Config:    map[string]interface{}{"max_trials": 3},
+	})
+	expected := [][]Runnable{
+		toOps("19200R V"),
+		toOps("19200R V"),
+		toOps("19200R V"),
+	}
+	method := gen()
+	checkSimulation(t, method, customTestHyperparameters(), RandomValidation, expected)
+	assert.Equal(t, method.progress(0), 1.0)
+	assert.NilError(t, method.(*customSearch).Close())
+}
+
+func TestCustomSearcherReproducibility(t *testing.T) {
+	gen := newStubCustomSearch(t, model.CustomConfig{
+		Metric:    defaultMetric,
+		Endpoint:  "stub",
+		MaxLength: model.NewLengthInBatches(300),
+		Config:    map[string]interface{}{"max_trials": 5},
+	})
+	checkReproducibility(t, gen, customTestHyperparameters(), defaultMetric)
+}
+
+func TestCustomSearcherAsync(t *testing.T) {
+	stub := &stubSearcher{searches: make(map[string]*stubSearch)}
+	gen := serveStubCustomSearch(t, stub, model.CustomConfig{
+		Metric:    defaultMetric,
+		Endpoint:  "stub",
+		MaxLength: model.NewLengthInBatches(300),
+		Config:    map[string]interface{}{"max_trials": 3},
+	})
+
+	// Async searchers queue their calls instead of making them.
+	searcher := NewSearcher(7, gen(), customTestHyperparameters())
+	assert.Assert(t, searcher.SetAsync(true))
+	ops, err := searcher.InitialOperations()
+	assert.NilError(t, err)
+	assert.Equal(t, len(ops), 0)
+	assert.Equal(t, len(stub.searches), 0)
+	calls := searcher.Calls()
+	assert.Equal(t, len(calls), 1)
+	_, err = searcher.Snapshot()
+	assert.ErrorContains(t, err, "pending calls")
+
+	ops, err = searcher.CallCompleted(calls[0].Run())
+	assert.NilError(t, err)
+	assert.Equal(t, len(ops), 9)
+	assert.Assert(t, !searcher.CallsPending())
+
+	// They request the same operations as searchers that block on their calls.
+	expected, expectedProgress := simulateWithSnapshots(
+		t, gen, customTestHyperparameters(), false, false)
+	actual, actualProgress := simulateWithSnapshots(
+		t, gen, customTestHyperparameters(), true, true)
+	assert.DeepEqual(t, actual, expected)
+	assert.Equal(t, actualProgress, expectedProgress)
+	assert.Equal(t, actualProgress, 1.0)
+}
+
+func TestCustomSearcherInvalidOperation(t *testing.T) {
+	gen := newStubCustomSearch(t, model.CustomConfig{
+		Metric:    defaultMetric,
+		Endpoint:  "stub",
+		MaxLength: model.NewLengthInBatches(300),
+		Config:    map[string]interface{}{"max_trials": 1, "unknown_hparam": true},
+	})
+	searcher := NewSearcher(0, gen(), customTestHyperparameters())
+	_, err := searcher.InitialOperations()
+	assert.ErrorContains(t, err, "unknown hyperparameter unknown")
+}
@@ -1,6 +1,7 @@
 package searcher
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 
@@ -46,42 +47,44 @@ func (s *gridSearch) trialExitedEarly(
 	return nil, nil
 }
 
+// newHyperparameterGrid returns every combination of the grid values of the hyperparameters.
+// Hyperparameters whose conditions are not met by a combination are left out of it rather than
+// multiplying it.
 func newHyperparameterGrid(params model.Hyperparameters) []hparamSample {
 	var names []string
+	var conditions []model.Hyperparameter
 	var values [][]interface{}
 	params.Each(func(name string, param model.Hyperparameter) {
 		names = append(names, name)
+		conditions = append(conditions, param)
 		values = append(values, grid(param))
 	})
-	return cartesianProduct(names, values)
-}
-
-func cartesianProduct(names []string, valueSets [][]interface{}) []hparamSample {
-	switch {
-	case len(names) == 0:
+	if len(names) == 0 {
 		return nil
-	case len(names) == 1:
-		cross := make([]hparamSample, 0, len(valueSets[0]))
-		for _, value := range valueSets[0] {
-			cross = append(cross, hparamSample{names[0]: value})
-		}
-		return cross
-	default:
-		right := cartesianProduct(names[1:], valueSets[1:])
-		name, left := names[0], valueSets[0]
-		cross := make([]hparamSample, 0, len(left)*len(right))
-		for _, lValue := range left {
-			for _, rValue := range right {
-				duplicate := make(hparamSample)
-				for oKey, oValue := range rValue {
-					duplicate[oKey] = oValue
-				}
-				duplicate[name] = lValue
-				cross = append(cross, duplicate)
+	}
+
+	var cross []hparamSample
+	var product func(i int, partial hparamSample)
+	product = func(i int, partial hparamSample) {
+		switch {
+		case i == len(names):
+			duplicate := make(hparamSample, len(partial))
+			for key, value := range partial {
+				duplicate[key] = value
+			}
+			cross = append(cross, duplicate)
+		case !conditions[i].ConditionMet(partial):
+			product(i+1, partial)
+		default:
+			for _, value := range values[i] {
+				partial[names[i]] = value
+				product(i+1, partial)
 			}
+			delete(partial, names[i])
 		}
-		return cross
 	}
+	product(0, make(hparamSample))
+	return cross
 }
 
 func grid(h model.Hyperparameter) []interface{} {
@@ -142,3 +145,20 @@ func grid(h model.Hyperparameter) []interface{} {
 		panic(fmt.Sprintf("unexpected hyperparameter type %+v", h))
 	}
 }
+
+type gridSearchSnapshot struct {
+	Trials int `json:"trials"`
+}
+
+func (s *gridSearch) snapshot() (json.RawMessage, error) {
+	return json.Marshal(gridSearchSnapshot{Trials: s.trials})
+}
+
+func (s *gridSearch) restore(_ context, state json.RawMessage) error {
+	var snapshot gridSearchSnapshot
+	if err := json.Unmarshal(state, &snapshot); err != nil {
+		return err
+	}
+	s.trials = snapshot.Trials
+	return nil
+}
@@ -161,3 +161,26 @@ func TestGridSearchMethod(t *testing.T) {
 
 	runValueSimulationTestCases(t, testCases)
 }
+
+func TestGridConditional(t *testing.T) {
+	hparams := model.Hyperparameters{
+		"optimizer": model.Hyperparameter{NestedHyperparameter: &map[string]model.Hyperparameter{
+			"type": {CategoricalHyperparameter: &model.CategoricalHyperparameter{
+				Vals: []interface{}{"adam", "sgd"},
+			}},
+			"momentum": {
+				DoubleHyperparameter: &model.DoubleHyperparameter{
+					Minval: 0.5, Maxval: 0.9, Count: intP(2),
+				},
+				Condition: map[string][]interface{}{"optimizer.type": {"sgd"}},
+			},
+		}},
+	}
+	actual := newHyperparameterGrid(hparams)
+	expected := []hparamSample{
+		{"optimizer.type": "adam"},
+		{"optimizer.type": "sgd", "optimizer.momentum": 0.5},
+		{"optimizer.type": "sgd", "optimizer.momentum": 0.9},
+	}
+	assert.DeepEqual(t, actual, expected)
+}
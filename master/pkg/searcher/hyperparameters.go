@@ -21,10 +21,16 @@ func (h hparamSample) GlobalBatchSize() int {
 	return h[model.GlobalBatchSize].(int)
 }
 
+// sampleAll samples every hyperparameter, keyed by its full name. Hyperparameters whose
+// conditions are not met are left out of the sample; they are still sampled so that the random
+// state does not depend on which conditions hold.
 func sampleAll(h model.Hyperparameters, rand *nprand.State) hparamSample {
 	results := make(hparamSample)
 	h.Each(func(name string, param model.Hyperparameter) {
-		results[name] = sampleOne(param, rand)
+		val := sampleOne(param, rand)
+		if param.ConditionMet(results) {
+			results[name] = val
+		}
 	})
 	return results
 }
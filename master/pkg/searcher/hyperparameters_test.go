@@ -42,3 +42,31 @@ func TestSamplingReproducibility(t *testing.T) {
 		assert.Equal(t, rand1.Bits64(), rand2.Bits64())
 	}
 }
+
+func TestSamplingConditional(t *testing.T) {
+	spec := model.Hyperparameters{
+		"optimizer": {CategoricalHyperparameter: &model.CategoricalHyperparameter{
+			Vals: []interface{}{"adam", "sgd"}}},
+		"momentum": {
+			DoubleHyperparameter: &model.DoubleHyperparameter{Minval: 0, Maxval: 1},
+			Condition:            map[string][]interface{}{"optimizer": {"sgd"}},
+		},
+		"nesterov": {
+			CategoricalHyperparameter: &model.CategoricalHyperparameter{
+				Vals: []interface{}{true, false}},
+			Condition: map[string][]interface{}{"optimizer": {"sgd"}},
+		},
+	}
+
+	seen := make(map[interface{}]bool)
+	for seed := uint32(0); seed < 50; seed++ {
+		sample := sampleAll(spec, nprand.New(seed))
+		optimizer := sample["optimizer"]
+		seen[optimizer] = true
+		_, hasMomentum := sample["momentum"]
+		_, hasNesterov := sample["nesterov"]
+		assert.Equal(t, hasMomentum, optimizer == "sgd")
+		assert.Equal(t, hasNesterov, optimizer == "sgd")
+	}
+	assert.Equal(t, len(seen), 2)
+}
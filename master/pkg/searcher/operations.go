@@ -2,10 +2,12 @@ package searcher
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/nprand"
@@ -225,3 +227,86 @@ func NewShutdown() Shutdown {
 func (shutdown Shutdown) String() string {
 	return "{Shutdown}"
 }
+
+// OperationList is a list of operations that can be serialized to and from JSON. Each operation
+// is represented as an object with a single key, the type of the operation.
+type OperationList []Operation
+
+// MarshalJSON implements the json.Marshaler interface.
+func (l OperationList) MarshalJSON() ([]byte, error) {
+	ops := make([]map[string]Operation, 0, len(l))
+	for _, op := range l {
+		var kind string
+		switch op.(type) {
+		case Create:
+			kind = "Create"
+		case Train:
+			kind = "Train"
+		case Validate:
+			kind = "Validate"
+		case Checkpoint:
+			kind = "Checkpoint"
+		case Close:
+			kind = "Close"
+		case Shutdown:
+			kind = "Shutdown"
+		default:
+			return nil, errors.Errorf("unexpected operation: %T", op)
+		}
+		ops = append(ops, map[string]Operation{kind: op})
+	}
+	return json.Marshal(ops)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (l *OperationList) UnmarshalJSON(data []byte) error {
+	var ops []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return err
+	}
+	*l = make(OperationList, 0, len(ops))
+	for _, op := range ops {
+		if len(op) != 1 {
+			return errors.Errorf("expected a single operation, got %d", len(op))
+		}
+		for kind, raw := range op {
+			parsed, err := unmarshalOperation(kind, raw)
+			if err != nil {
+				return errors.Wrapf(err, "error parsing %s operation", kind)
+			}
+			*l = append(*l, parsed)
+		}
+	}
+	return nil
+}
+
+func unmarshalOperation(kind string, data []byte) (Operation, error) {
+	switch kind {
+	case "Create":
+		var create Create
+		err := json.Unmarshal(data, &create)
+		return create, err
+	case "Train":
+		var train Train
+		err := json.Unmarshal(data, &train)
+		return train, err
+	case "Validate":
+		var validate Validate
+		err := json.Unmarshal(data, &validate)
+		return validate, err
+	case "Checkpoint":
+		var checkpoint Checkpoint
+		err := json.Unmarshal(data, &checkpoint)
+		return checkpoint, err
+	case "Close":
+		var close Close
+		err := json.Unmarshal(data, &close)
+		return close, err
+	case "Shutdown":
+		var shutdown Shutdown
+		err := json.Unmarshal(data, &shutdown)
+		return shutdown, err
+	default:
+		return nil, errors.Errorf("unexpected operation type: %s", kind)
+	}
+}
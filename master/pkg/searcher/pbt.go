@@ -1,6 +1,7 @@
 package searcher
 
 import (
+	"encoding/json"
 	"math"
 	"sort"
 
@@ -161,10 +162,15 @@ func (s *pbtSearch) runNewTrials(ctx context, requestID RequestID) ([]Operation,
 func (s *pbtSearch) exploreParams(ctx context, old hparamSample) hparamSample {
 	params := make(hparamSample)
 	ctx.hparams.Each(func(name string, sampler model.Hyperparameter) {
-		if ctx.rand.UnitInterval() < s.ResampleProbability {
+		// Conditions are evaluated on the new sample, so a hyperparameter can become active after
+		// the value it depends on is resampled; it is then sampled anew.
+		if !sampler.ConditionMet(params) {
+			return
+		}
+		val, ok := old[name]
+		if !ok || ctx.rand.UnitInterval() < s.ResampleProbability {
 			params[name] = sampleOne(sampler, ctx.rand)
 		} else {
-			val := old[name]
 			decrease := ctx.rand.UnitInterval() < .5
 			var multiplier float64
 			if decrease {
@@ -215,3 +221,69 @@ func (s *pbtSearch) trialExitedEarly(
 	s.metrics[requestID] = pbtExitedMetricValue
 	return s.runNewTrials(ctx, requestID)
 }
+
+type pbtSearchSnapshot struct {
+	RoundsCompleted      int                        `json:"rounds_completed"`
+	Metrics              map[RequestID]float64      `json:"metrics"`
+	TrialRoundsCompleted map[RequestID]int          `json:"trial_rounds_completed"`
+	TrialParams          map[RequestID]hparamSample `json:"trial_params"`
+	WaitingOps           []pbtWaitingOpsSnapshot    `json:"waiting_ops"`
+	EarlyExitTrials      map[RequestID]bool         `json:"early_exit_trials"`
+}
+
+// pbtWaitingOpsSnapshot holds the operations that are waiting for a checkpoint to complete.
+type pbtWaitingOpsSnapshot struct {
+	Checkpoint Checkpoint    `json:"checkpoint"`
+	Operations OperationList `json:"operations"`
+}
+
+func (s *pbtSearch) snapshot() (json.RawMessage, error) {
+	snapshot := pbtSearchSnapshot{
+		RoundsCompleted:      s.roundsCompleted,
+		Metrics:              s.metrics,
+		TrialRoundsCompleted: s.trialRoundsCompleted,
+		TrialParams:          s.trialParams,
+		EarlyExitTrials:      s.earlyExitTrials,
+	}
+	for op, ops := range s.waitingOps {
+		checkpoint, ok := op.(Checkpoint)
+		if !ok {
+			return nil, errors.Errorf("unexpected operation waited on: %v", op)
+		}
+		snapshot.WaitingOps = append(snapshot.WaitingOps, pbtWaitingOpsSnapshot{
+			Checkpoint: checkpoint,
+			Operations: ops,
+		})
+	}
+	// Map iteration order is nondeterministic, so sort the waiting operations to make the snapshot
+	// deterministic.
+	sort.Slice(snapshot.WaitingOps, func(i, j int) bool {
+		return snapshot.WaitingOps[i].Checkpoint.RequestID.Before(
+			snapshot.WaitingOps[j].Checkpoint.RequestID)
+	})
+	return json.Marshal(snapshot)
+}
+
+func (s *pbtSearch) restore(ctx context, state json.RawMessage) error {
+	var snapshot pbtSearchSnapshot
+	if err := json.Unmarshal(state, &snapshot); err != nil {
+		return err
+	}
+	s.roundsCompleted = snapshot.RoundsCompleted
+	for requestID, metric := range snapshot.Metrics {
+		s.metrics[requestID] = metric
+	}
+	for requestID, rounds := range snapshot.TrialRoundsCompleted {
+		s.trialRoundsCompleted[requestID] = rounds
+	}
+	for requestID, params := range snapshot.TrialParams {
+		s.trialParams[requestID] = restoreSample(ctx.hparams, params)
+	}
+	for _, waiting := range snapshot.WaitingOps {
+		s.waitingOps[waiting.Checkpoint] = restoreOperations(ctx.hparams, waiting.Operations)
+	}
+	for requestID, exited := range snapshot.EarlyExitTrials {
+		s.earlyExitTrials[requestID] = exited
+	}
+	return nil
+}
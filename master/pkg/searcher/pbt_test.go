@@ -478,3 +478,33 @@ func TestPBTSearchMethod(t *testing.T) {
 
 	runValueSimulationTestCases(t, testCases)
 }
+
+func TestPBTExploreConditional(t *testing.T) {
+	spec := model.Hyperparameters{
+		"optimizer": {CategoricalHyperparameter: &model.CategoricalHyperparameter{
+			Vals: []interface{}{"adam", "sgd"}}},
+		"momentum": {
+			DoubleHyperparameter: &model.DoubleHyperparameter{Minval: 0, Maxval: 1},
+			Condition:            map[string][]interface{}{"optimizer": {"sgd"}},
+		},
+	}
+	config := model.PBTConfig{
+		PopulationSize:   10,
+		NumRounds:        10,
+		LengthPerRound:   model.NewLengthInBatches(1000),
+		PBTExploreConfig: model.PBTExploreConfig{ResampleProbability: 0.5, PerturbFactor: 0.5},
+	}
+
+	for seed := uint32(0); seed < 50; seed++ {
+		ctx := context{rand: nprand.New(seed), hparams: spec}
+		pbt := newPBTSearch(config).(*pbtSearch)
+		for _, old := range []hparamSample{
+			{"optimizer": "adam"},
+			{"optimizer": "sgd", "momentum": 0.5},
+		} {
+			newSample := pbt.exploreParams(ctx, old)
+			_, hasMomentum := newSample["momentum"]
+			assert.Equal(t, hasMomentum, newSample["optimizer"] == "sgd")
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package searcher
 
 import (
+	"encoding/json"
+
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/workload"
 )
@@ -45,3 +47,13 @@ func (s *randomSearch) trialExitedEarly(
 ) ([]Operation, error) {
 	return nil, nil
 }
+
+// snapshot returns no state, since random search does not keep any state besides the random state
+// of the searcher.
+func (s *randomSearch) snapshot() (json.RawMessage, error) {
+	return nil, nil
+}
+
+func (s *randomSearch) restore(context, json.RawMessage) error {
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 type context struct {
 	rand    *nprand.State
 	hparams model.Hyperparameters
+	// unitsCompleted is the total length that the trials of the search have trained for.
+	unitsCompleted float64
 }
 
 // SearchMethod is the interface for hyper-parameter tuning methods. Implementations of this
@@ -71,6 +73,10 @@ func NewSearchMethod(c model.SearcherConfig) SearchMethod {
 		return newAdaptiveASHASearch(*c.AdaptiveASHAConfig)
 	case c.PBTConfig != nil:
 		return newPBTSearch(*c.PBTConfig)
+	case c.TPEConfig != nil:
+		return newTPESearch(*c.TPEConfig)
+	case c.CustomConfig != nil:
+		return newCustomSearch(*c.CustomConfig)
 	default:
 		panic("no searcher type specified")
 	}
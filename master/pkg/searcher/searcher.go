@@ -1,6 +1,7 @@
 package searcher
 
 import (
+	"io"
 	"math"
 
 	"github.com/determined-ai/determined/master/pkg/workload"
@@ -11,6 +12,16 @@ import (
 	"github.com/determined-ai/determined/master/pkg/nprand"
 )
 
+// asyncMethod is implemented by search methods that call external services. While async, such a
+// method queues its calls instead of blocking on them and returns no operations for the event that
+// caused them; the operations are returned by callCompleted once the call has been made.
+type asyncMethod interface {
+	setAsync(async bool)
+	calls() []Call
+	callsPending() bool
+	callCompleted(ctx context, result CallResult) ([]Operation, error)
+}
+
 // Searcher encompasses the state as the searcher progresses using the provided search method.
 type Searcher struct {
 	rand     *nprand.State
@@ -30,7 +41,9 @@ func NewSearcher(seed uint32, method SearchMethod, hparams model.Hyperparameters
 }
 
 func (s *Searcher) context() context {
-	return context{rand: s.rand, hparams: s.hparams}
+	return context{
+		rand: s.rand, hparams: s.hparams, unitsCompleted: s.eventLog.TotalUnitsCompleted,
+	}
 }
 
 // InitialOperations return a set of initial operations that the searcher would like to take.
@@ -122,12 +135,18 @@ func (s *Searcher) TrialClosed(requestID RequestID) ([]Operation, error) {
 		return nil, errors.Wrapf(err, "error while handling a trial closed event: %s", requestID)
 	}
 	s.eventLog.OperationsCreated(operations...)
-	if s.eventLog.TrialsRequested == s.eventLog.TrialsClosed {
-		shutdown := Shutdown{Failure: len(s.eventLog.earlyExits) >= s.eventLog.TrialsRequested}
-		s.eventLog.OperationsCreated(shutdown)
-		operations = append(operations, shutdown)
+	return s.shutdownIfDone(operations), nil
+}
+
+// shutdownIfDone appends a Shutdown to the operations once all of the requested trials have closed,
+// unless the search method may still request more trials from a pending call.
+func (s *Searcher) shutdownIfDone(operations []Operation) []Operation {
+	if s.CallsPending() || s.eventLog.TrialsRequested != s.eventLog.TrialsClosed {
+		return operations
 	}
-	return operations, nil
+	shutdown := Shutdown{Failure: len(s.eventLog.earlyExits) >= s.eventLog.TrialsRequested}
+	s.eventLog.OperationsCreated(shutdown)
+	return append(operations, shutdown)
 }
 
 // Progress returns experiment progress as a float between 0.0 and 1.0.
@@ -155,6 +174,56 @@ func (s *Searcher) RequestID(id int) (RequestID, bool) {
 	return requestID, ok
 }
 
+// SetAsync sets whether the search method queues its calls to external services instead of
+// blocking on them; the queued calls are returned by Calls. It returns false if the search method
+// makes no such calls.
+func (s *Searcher) SetAsync(async bool) bool {
+	method, ok := s.method.(asyncMethod)
+	if ok {
+		method.setAsync(async)
+	}
+	return ok
+}
+
+// Calls returns the calls to external services that the search method queued since the last call
+// to Calls.
+func (s *Searcher) Calls() []Call {
+	if method, ok := s.method.(asyncMethod); ok {
+		return method.calls()
+	}
+	return nil
+}
+
+// CallsPending returns whether any call that the search method queued has not completed yet.
+func (s *Searcher) CallsPending() bool {
+	method, ok := s.method.(asyncMethod)
+	return ok && method.callsPending()
+}
+
+// CallCompleted informs the searcher that a call that the search method queued has completed.
+// Returns the operations that the search method requested with the call.
+func (s *Searcher) CallCompleted(result CallResult) ([]Operation, error) {
+	method, ok := s.method.(asyncMethod)
+	if !ok {
+		return nil, errors.New("search method makes no calls")
+	}
+	operations, err := method.callCompleted(s.context(), result)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while handling a completed call of the search method")
+	}
+	s.eventLog.OperationsCreated(operations...)
+	return s.shutdownIfDone(operations), nil
+}
+
+// Close releases any resources held by the search method, such as connections to external search
+// services.
+func (s *Searcher) Close() error {
+	if closer, ok := s.method.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 // UncommittedEvents returns the searcher events that have occurred since the last call to
 // UncommittedEvents.
 func (s *Searcher) UncommittedEvents() []Event {
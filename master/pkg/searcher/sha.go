@@ -1,6 +1,7 @@
 package searcher
 
 import (
+	"encoding/json"
 	"math"
 	"sort"
 
@@ -231,3 +232,87 @@ func min(initial int, values ...int) int {
 	}
 	return minValue
 }
+
+// rungSnapshot is the serialized state of a rung. The units and trial counts of each rung are
+// derived from the searcher configuration and are not part of the snapshot.
+type rungSnapshot struct {
+	Metrics           []trialMetricSnapshot `json:"metrics"`
+	OutstandingTrials int                   `json:"outstanding_trials"`
+}
+
+type trialMetricSnapshot struct {
+	RequestID RequestID `json:"request_id"`
+	Metric    float64   `json:"metric"`
+	Promoted  bool      `json:"promoted"`
+}
+
+func snapshotRungs(rungs []*rung) []rungSnapshot {
+	snapshots := make([]rungSnapshot, 0, len(rungs))
+	for _, r := range rungs {
+		metrics := make([]trialMetricSnapshot, 0, len(r.metrics))
+		for _, m := range r.metrics {
+			metrics = append(metrics, trialMetricSnapshot{
+				RequestID: m.requestID,
+				Metric:    m.metric,
+				Promoted:  m.promoted,
+			})
+		}
+		snapshots = append(snapshots, rungSnapshot{
+			Metrics:           metrics,
+			OutstandingTrials: r.outstandingTrials,
+		})
+	}
+	return snapshots
+}
+
+func restoreRungs(rungs []*rung, snapshots []rungSnapshot) error {
+	if len(rungs) != len(snapshots) {
+		return errors.Errorf("snapshot has %d rungs, expected %d", len(snapshots), len(rungs))
+	}
+	for i, snapshot := range snapshots {
+		rungs[i].metrics = nil
+		for _, m := range snapshot.Metrics {
+			rungs[i].metrics = append(rungs[i].metrics, trialMetric{
+				requestID: m.RequestID,
+				metric:    m.Metric,
+				promoted:  m.Promoted,
+			})
+		}
+		rungs[i].outstandingTrials = snapshot.OutstandingTrials
+	}
+	return nil
+}
+
+type syncHalvingSearchSnapshot struct {
+	Rungs           []rungSnapshot     `json:"rungs"`
+	TrialRungs      map[RequestID]int  `json:"trial_rungs"`
+	EarlyExitTrials map[RequestID]bool `json:"early_exit_trials"`
+	TrialsCompleted int                `json:"trials_completed"`
+}
+
+func (s *syncHalvingSearch) snapshot() (json.RawMessage, error) {
+	return json.Marshal(syncHalvingSearchSnapshot{
+		Rungs:           snapshotRungs(s.rungs),
+		TrialRungs:      s.trialRungs,
+		EarlyExitTrials: s.earlyExitTrials,
+		TrialsCompleted: s.trialsCompleted,
+	})
+}
+
+func (s *syncHalvingSearch) restore(_ context, state json.RawMessage) error {
+	var snapshot syncHalvingSearchSnapshot
+	if err := json.Unmarshal(state, &snapshot); err != nil {
+		return err
+	}
+	if err := restoreRungs(s.rungs, snapshot.Rungs); err != nil {
+		return err
+	}
+	for requestID, rungIndex := range snapshot.TrialRungs {
+		s.trialRungs[requestID] = rungIndex
+	}
+	for requestID, exited := range snapshot.EarlyExitTrials {
+		s.earlyExitTrials[requestID] = exited
+	}
+	s.trialsCompleted = snapshot.TrialsCompleted
+	return nil
+}
@@ -0,0 +1,146 @@
+package searcher
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/nprand"
+)
+
+// ErrSnapshotUnsupported is returned when the state of a search method cannot be saved; such
+// searchers can only be restored by replaying all of their events. A custom search supports
+// snapshots only once its service has returned the state of the search.
+var ErrSnapshotUnsupported = errors.New("search method does not support snapshots")
+
+// snapshotter is implemented by search methods whose state can be saved and restored. Restoring a
+// snapshot into a newly created search method with the same configuration must leave it in the
+// same state as the search method that the snapshot was taken from.
+type snapshotter interface {
+	snapshot() (json.RawMessage, error)
+	restore(ctx context, state json.RawMessage) error
+}
+
+// searcherSnapshot is the serialized state of a Searcher.
+type searcherSnapshot struct {
+	Rand     *nprand.State    `json:"rand"`
+	EventLog eventLogSnapshot `json:"event_log"`
+	Method   json.RawMessage  `json:"method"`
+}
+
+// eventLogSnapshot is the serialized state of an EventLog. Uncommitted events are not part of
+// the snapshot, since they are saved separately.
+type eventLogSnapshot struct {
+	EarlyExits          map[RequestID]bool `json:"early_exits"`
+	TotalUnitsCompleted float64            `json:"total_units_completed"`
+	Shutdown            bool               `json:"shutdown"`
+	TrialsRequested     int                `json:"trials_requested"`
+	TrialsClosed        int                `json:"trials_closed"`
+	TrialIDs            map[RequestID]int  `json:"trial_ids"`
+	RequestIDs          map[int]RequestID  `json:"request_ids"`
+}
+
+// Snapshot returns the serialized state of the searcher. All events must have been committed, and
+// all calls of the search method completed, before the searcher can be snapshotted.
+func (s *Searcher) Snapshot() (json.RawMessage, error) {
+	method, ok := s.method.(snapshotter)
+	if !ok {
+		return nil, ErrSnapshotUnsupported
+	}
+	if len(s.eventLog.uncommitted) > 0 {
+		return nil, errors.New("cannot snapshot a searcher with uncommitted events")
+	}
+	if s.CallsPending() {
+		return nil, errors.New("cannot snapshot a searcher with pending calls")
+	}
+	methodState, err := method.snapshot()
+	if err == ErrSnapshotUnsupported {
+		return nil, err
+	} else if err != nil {
+		return nil, errors.Wrap(err, "error snapshotting search method")
+	}
+	return json.Marshal(searcherSnapshot{
+		Rand: s.rand,
+		EventLog: eventLogSnapshot{
+			EarlyExits:          s.eventLog.earlyExits,
+			TotalUnitsCompleted: s.eventLog.TotalUnitsCompleted,
+			Shutdown:            s.eventLog.Shutdown,
+			TrialsRequested:     s.eventLog.TrialsRequested,
+			TrialsClosed:        s.eventLog.TrialsClosed,
+			TrialIDs:            s.eventLog.TrialIDs,
+			RequestIDs:          s.eventLog.RequestIDs,
+		},
+		Method: methodState,
+	})
+}
+
+// Restore restores the state of the searcher from a snapshot. It should be called instead of
+// InitialOperations on a newly created searcher.
+func (s *Searcher) Restore(state json.RawMessage) error {
+	method, ok := s.method.(snapshotter)
+	if !ok {
+		return ErrSnapshotUnsupported
+	}
+	var snapshot searcherSnapshot
+	if err := json.Unmarshal(state, &snapshot); err != nil {
+		return errors.Wrap(err, "error parsing searcher snapshot")
+	}
+	if snapshot.Rand == nil {
+		return errors.New("searcher snapshot is missing the random state")
+	}
+
+	s.rand = snapshot.Rand
+	s.eventLog = NewEventLog(s.method.Unit())
+	s.eventLog.TotalUnitsCompleted = snapshot.EventLog.TotalUnitsCompleted
+	s.eventLog.Shutdown = snapshot.EventLog.Shutdown
+	s.eventLog.TrialsRequested = snapshot.EventLog.TrialsRequested
+	s.eventLog.TrialsClosed = snapshot.EventLog.TrialsClosed
+	for requestID := range snapshot.EventLog.EarlyExits {
+		s.eventLog.earlyExits[requestID] = true
+	}
+	for requestID, trialID := range snapshot.EventLog.TrialIDs {
+		s.eventLog.TrialIDs[requestID] = trialID
+	}
+	for trialID, requestID := range snapshot.EventLog.RequestIDs {
+		s.eventLog.RequestIDs[trialID] = requestID
+	}
+
+	if err := method.restore(s.context(), snapshot.Method); err != nil {
+		return errors.Wrap(err, "error restoring search method")
+	}
+	return nil
+}
+
+// restoreSample converts the values of a hyperparameter sample that was read from JSON back into
+// the types that they were sampled as. JSON numbers are always parsed as floats, while integer
+// hyperparameters are sampled as ints.
+func restoreSample(hparams model.Hyperparameters, sample hparamSample) hparamSample {
+	hparams.Each(func(name string, param model.Hyperparameter) {
+		if param.IntHyperparameter == nil {
+			return
+		}
+		if val, ok := sample[name].(float64); ok {
+			sample[name] = int(val)
+		}
+	})
+	return sample
+}
+
+// RestoreCreate converts the hyperparameters of a Create operation that was read from JSON back
+// into the types that they were sampled as.
+func RestoreCreate(hparams model.Hyperparameters, create Create) Create {
+	create.Hparams = restoreSample(hparams, create.Hparams)
+	return create
+}
+
+// restoreOperations converts the hyperparameters of Create operations that were read from JSON
+// back into the types that they were sampled as.
+func restoreOperations(hparams model.Hyperparameters, ops OperationList) []Operation {
+	for i, op := range ops {
+		if create, ok := op.(Create); ok {
+			ops[i] = RestoreCreate(hparams, create)
+		}
+	}
+	return ops
+}
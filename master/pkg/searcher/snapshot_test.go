@@ -0,0 +1,197 @@
+package searcher
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/nprand"
+	"github.com/determined-ai/determined/master/pkg/workload"
+)
+
+// simulateWithSnapshots runs a searcher to completion, handling operations in the order that they
+// were requested. If snapshot is true, the searcher is replaced by a new one that is restored from
+// a snapshot of it after every event. If async is true, the calls that the search method queues
+// are run after every event.
+func simulateWithSnapshots(
+	t *testing.T, methodGen func() SearchMethod, hparams model.Hyperparameters,
+	snapshot, async bool,
+) (map[RequestID][]Runnable, float64) {
+	searcher := NewSearcher(7, methodGen(), hparams)
+	searcher.SetAsync(async)
+	random := rand.New(rand.NewSource(7))
+	trialIDs := make(map[RequestID]int)
+	results := make(map[RequestID][]Runnable)
+
+	runCalls := func(ops []Operation) []Operation {
+		for _, call := range searcher.Calls() {
+			callOps, err := searcher.CallCompleted(call.Run())
+			assert.NilError(t, err)
+			ops = append(ops, callOps...)
+		}
+		return ops
+	}
+
+	queue, err := searcher.InitialOperations()
+	assert.NilError(t, err)
+	queue = runCalls(queue)
+	for len(queue) > 0 {
+		var ops []Operation
+		switch op := queue[0].(type) {
+		case Create:
+			trialIDs[op.RequestID] = len(trialIDs) + 1
+			results[op.RequestID] = []Runnable{}
+			ops, err = searcher.TrialCreated(op, trialIDs[op.RequestID])
+		case Runnable:
+			requestID := op.GetRequestID()
+			var metrics interface{}
+			metrics, err = generateMetrics(random, trialIDs[requestID], len(results[requestID]), op,
+				RandomValidation, defaultMetric)
+			assert.NilError(t, err)
+			results[requestID] = append(results[requestID], op)
+			if train, ok := op.(Train); ok {
+				searcher.WorkloadCompleted(workload.CompletedMessage{Workload: workload.Workload{
+					Kind:    workload.RunStep,
+					TrialID: trialIDs[requestID],
+					StepID:  len(results[requestID]),
+				}}, float64(train.Length.Units))
+			}
+			ops, err = searcher.OperationCompleted(trialIDs[requestID], op, metrics)
+		case Close:
+			ops, err = searcher.TrialClosed(op.RequestID)
+		case Shutdown:
+			assert.Equal(t, len(queue), 1, "shutdown before the last operation")
+		}
+		assert.NilError(t, err)
+		queue = append(queue[1:], runCalls(ops)...)
+		searcher.UncommittedEvents()
+
+		if snapshot {
+			state, err := searcher.Snapshot()
+			assert.NilError(t, err)
+			// The snapshot must survive a round trip through the database.
+			var content map[string]interface{}
+			assert.NilError(t, json.Unmarshal(state, &content))
+			state, err = json.Marshal(content)
+			assert.NilError(t, err)
+
+			searcher = NewSearcher(7, methodGen(), hparams)
+			searcher.SetAsync(async)
+			assert.NilError(t, searcher.Restore(state))
+		}
+	}
+	return results, searcher.Progress()
+}
+
+func TestSearcherSnapshots(t *testing.T) {
+	count := 3
+	hparams := model.Hyperparameters{
+		model.GlobalBatchSize: {ConstHyperparameter: &model.ConstHyperparameter{Val: 64}},
+		"x": {DoubleHyperparameter: &model.DoubleHyperparameter{
+			Minval: 0, Maxval: 1, Count: &count,
+		}},
+		"layers": {IntHyperparameter: &model.IntHyperparameter{
+			Minval: 1, Maxval: 8, Count: &count,
+		}},
+		"optimizer": {CategoricalHyperparameter: &model.CategoricalHyperparameter{
+			Vals: []interface{}{"adam", "sgd"},
+		}},
+	}
+	tpeConfig := defaultTPEConfig()
+	tpeConfig.Metric = defaultMetric
+	tpeConfig.MaxTrials = 10
+	tpeConfig.NumStartupTrials = 3
+	tpeConfig.MaxLength = model.NewLengthInBatches(300)
+
+	testCases := []struct {
+		name      string
+		methodGen func() SearchMethod
+	}{
+		{"random", func() SearchMethod {
+			return newRandomSearch(model.RandomConfig{
+				MaxTrials: 4, MaxLength: model.NewLengthInBatches(300),
+			})
+		}},
+		{"grid", func() SearchMethod {
+			return newGridSearch(model.GridConfig{MaxLength: model.NewLengthInBatches(300)})
+		}},
+		{"sync halving", func() SearchMethod {
+			return newSyncHalvingSearch(model.SyncHalvingConfig{
+				Metric: defaultMetric, NumRungs: 3, SmallerIsBetter: true,
+				MaxLength: model.NewLengthInBatches(900), Budget: model.NewLengthInBatches(9000),
+				Divisor: 3, TrainStragglers: true,
+			})
+		}},
+		{"adaptive", func() SearchMethod {
+			return newAdaptiveSearch(model.AdaptiveConfig{
+				Metric: defaultMetric, SmallerIsBetter: true,
+				MaxLength: model.NewLengthInBatches(6400), Budget: model.NewLengthInBatches(102400),
+				Divisor: 4, TrainStragglers: true, Mode: model.AggressiveMode, MaxRungs: 3,
+			})
+		}},
+		{"adaptive asha", func() SearchMethod {
+			return newAdaptiveASHASearch(model.AdaptiveASHAConfig{
+				Metric: defaultMetric, SmallerIsBetter: true,
+				MaxLength: model.NewLengthInBatches(6400), MaxTrials: 32, Divisor: 4,
+				Mode: model.AggressiveMode, MaxRungs: 3,
+			})
+		}},
+		{"pbt", func() SearchMethod {
+			return newPBTSearch(model.PBTConfig{
+				Metric: defaultMetric, SmallerIsBetter: true,
+				PopulationSize: 6, NumRounds: 4, LengthPerRound: model.NewLengthInBatches(200),
+				PBTReplaceConfig: model.PBTReplaceConfig{TruncateFraction: .5},
+				PBTExploreConfig: model.PBTExploreConfig{
+					ResampleProbability: .5, PerturbFactor: .2,
+				},
+			})
+		}},
+		{"tpe", func() SearchMethod { return newTPESearch(tpeConfig) }},
+		{"custom", newStubCustomSearch(t, model.CustomConfig{
+			Metric: defaultMetric, Endpoint: "stub", MaxLength: model.NewLengthInBatches(300),
+			Config: map[string]interface{}{"max_trials": 3},
+		})},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expected, expectedProgress := simulateWithSnapshots(
+				t, tc.methodGen, hparams, false, false)
+			actual, actualProgress := simulateWithSnapshots(t, tc.methodGen, hparams, true, false)
+			assert.DeepEqual(t, actual, expected)
+			assert.Equal(t, actualProgress, expectedProgress)
+		})
+	}
+}
+
+func TestSearcherSnapshotUnsupported(t *testing.T) {
+	searcher := NewSearcher(0, newCustomSearch(model.CustomConfig{}), nil)
+	_, err := searcher.Snapshot()
+	assert.Equal(t, err, ErrSnapshotUnsupported)
+}
+
+func TestOperationListJSON(t *testing.T) {
+	create := NewCreate(nprand.New(0), hparamSample{"x": 1.5}, model.TrialWorkloadSequencerType)
+	checkpoint := NewCheckpoint(create.RequestID)
+	create.Checkpoint = &checkpoint
+	ops := OperationList{
+		create,
+		NewTrain(create.RequestID, model.NewLengthInEpochs(2)),
+		NewValidate(create.RequestID),
+		checkpoint,
+		NewClose(create.RequestID),
+		Shutdown{Failure: true},
+	}
+
+	bytes, err := json.Marshal(ops)
+	assert.NilError(t, err)
+	var parsed OperationList
+	assert.NilError(t, json.Unmarshal(bytes, &parsed))
+	assert.DeepEqual(t, parsed, ops)
+
+	assert.ErrorContains(t, json.Unmarshal([]byte(`[{"Fly": {}}]`), &parsed),
+		"unexpected operation type: Fly")
+}
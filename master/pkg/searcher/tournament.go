@@ -1,6 +1,10 @@
 package searcher
 
 import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/workload"
 )
@@ -102,3 +106,62 @@ func (s *tournamentSearch) markCreates(subSearch SearchMethod, operations []Oper
 	}
 	return operations
 }
+
+type tournamentSearchSnapshot struct {
+	SubSearches             []json.RawMessage `json:"sub_searches"`
+	SubSearchUnitsCompleted []float64         `json:"sub_search_units_completed"`
+	// TrialTable maps each trial to the index of the sub-search that created it.
+	TrialTable map[RequestID]int `json:"trial_table"`
+}
+
+func (s *tournamentSearch) snapshot() (json.RawMessage, error) {
+	snapshot := tournamentSearchSnapshot{TrialTable: make(map[RequestID]int)}
+	indices := make(map[SearchMethod]int)
+	for i, subSearch := range s.subSearches {
+		indices[subSearch] = i
+		method, ok := subSearch.(snapshotter)
+		if !ok {
+			return nil, ErrSnapshotUnsupported
+		}
+		state, err := method.snapshot()
+		if err != nil {
+			return nil, err
+		}
+		snapshot.SubSearches = append(snapshot.SubSearches, state)
+		snapshot.SubSearchUnitsCompleted = append(
+			snapshot.SubSearchUnitsCompleted, s.subSearchUnitsCompleted[subSearch])
+	}
+	for requestID, subSearch := range s.trialTable {
+		snapshot.TrialTable[requestID] = indices[subSearch]
+	}
+	return json.Marshal(snapshot)
+}
+
+func (s *tournamentSearch) restore(ctx context, state json.RawMessage) error {
+	var snapshot tournamentSearchSnapshot
+	if err := json.Unmarshal(state, &snapshot); err != nil {
+		return err
+	}
+	if len(snapshot.SubSearches) != len(s.subSearches) ||
+		len(snapshot.SubSearchUnitsCompleted) != len(s.subSearches) {
+		return errors.Errorf(
+			"snapshot has %d sub-searches, expected %d", len(snapshot.SubSearches), len(s.subSearches))
+	}
+	for i, subSearch := range s.subSearches {
+		method, ok := subSearch.(snapshotter)
+		if !ok {
+			return ErrSnapshotUnsupported
+		}
+		if err := method.restore(ctx, snapshot.SubSearches[i]); err != nil {
+			return err
+		}
+		s.subSearchUnitsCompleted[subSearch] = snapshot.SubSearchUnitsCompleted[i]
+	}
+	for requestID, i := range snapshot.TrialTable {
+		if i < 0 || i >= len(s.subSearches) {
+			return errors.Errorf("invalid sub-search index %d for trial %s", i, requestID)
+		}
+		s.trialTable[requestID] = s.subSearches[i]
+	}
+	return nil
+}
@@ -0,0 +1,409 @@
+package searcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/nprand"
+	"github.com/determined-ai/determined/master/pkg/workload"
+)
+
+// tpeSearch implements a Bayesian optimization search using the tree-structured Parzen estimator
+// (TPE). See https://papers.nips.cc/paper/4443-algorithms-for-hyper-parameter-optimization.pdf for
+// details. The first trials are sampled at random; afterwards, each new trial is sampled from a
+// model that is fit to the validation metrics of all previously completed trials.
+type tpeSearch struct {
+	defaultSearchMethod
+	model.TPEConfig
+
+	trialParams   map[RequestID]hparamSample
+	observations  []tpeObservation
+	trialsCreated int
+}
+
+// tpeObservation is a hyperparameter sample with the (signed) metric it achieved, such that
+// smaller metrics are always better.
+type tpeObservation struct {
+	requestID RequestID
+	params    hparamSample
+	metric    float64
+}
+
+func newTPESearch(config model.TPEConfig) SearchMethod {
+	return &tpeSearch{
+		TPEConfig:   config,
+		trialParams: make(map[RequestID]hparamSample),
+	}
+}
+
+func (s *tpeSearch) initialOperations(ctx context) ([]Operation, error) {
+	// The number of initial trials controls the degree of parallelism of the search, since every
+	// trial that finishes is replaced by a new one until we reach MaxTrials.
+	maxConcurrentTrials := s.NumStartupTrials
+	if s.MaxConcurrentTrials > 0 {
+		maxConcurrentTrials = s.MaxConcurrentTrials
+	}
+	maxConcurrentTrials = max(min(maxConcurrentTrials, s.MaxTrials), 1)
+
+	var ops []Operation
+	for trial := 0; trial < maxConcurrentTrials; trial++ {
+		ops = append(ops, s.newTrial(ctx)...)
+	}
+	return ops, nil
+}
+
+func (s *tpeSearch) newTrial(ctx context) []Operation {
+	create := NewCreate(ctx.rand, s.sample(ctx), model.TrialWorkloadSequencerType)
+	s.trialParams[create.RequestID] = create.Hparams
+	s.trialsCreated++
+	return []Operation{
+		create,
+		NewTrain(create.RequestID, s.MaxLength),
+		NewValidate(create.RequestID),
+		NewClose(create.RequestID),
+	}
+}
+
+func (s *tpeSearch) validationCompleted(
+	ctx context, requestID RequestID, validate Validate, metrics workload.ValidationMetrics,
+) ([]Operation, error) {
+	metric, err := metrics.Metric(s.Metric)
+	if err != nil {
+		return nil, err
+	}
+	if !s.SmallerIsBetter {
+		metric *= -1
+	}
+	s.observations = append(s.observations, tpeObservation{
+		requestID: requestID,
+		params:    s.trialParams[requestID],
+		metric:    metric,
+	})
+
+	if s.trialsCreated < s.MaxTrials {
+		return s.newTrial(ctx), nil
+	}
+	return nil, nil
+}
+
+func (s *tpeSearch) progress(unitsCompleted float64) float64 {
+	return unitsCompleted / float64(s.MaxLength.MultInt(s.MaxTrials).Units)
+}
+
+// trialExitedEarly replaces the trial that exited with a new one, without adding an observation
+// to the model.
+func (s *tpeSearch) trialExitedEarly(
+	ctx context, requestID RequestID, exitedReason workload.ExitedReason,
+) ([]Operation, error) {
+	if s.trialsCreated < s.MaxTrials {
+		return s.newTrial(ctx), nil
+	}
+	return nil, nil
+}
+
+// sample returns a new hyperparameter sample. Until NumStartupTrials trials have been validated,
+// samples are drawn at random from the configured hyperparameter space.
+func (s *tpeSearch) sample(ctx context) hparamSample {
+	if len(s.observations) < s.NumStartupTrials {
+		return sampleAll(ctx.hparams, ctx.rand)
+	}
+
+	// Split the observations into the best Gamma fraction and the rest. Ties are broken by
+	// request ID so that the split does not depend on the order that validations completed in.
+	sorted := make([]tpeObservation, len(s.observations))
+	copy(sorted, s.observations)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].metric != sorted[j].metric {
+			return sorted[i].metric < sorted[j].metric
+		}
+		return sorted[i].requestID.Before(sorted[j].requestID)
+	})
+	numGood := max(int(math.Ceil(s.Gamma*float64(len(sorted)))), 1)
+	good, bad := sorted[:numGood], sorted[numGood:]
+
+	results := make(hparamSample)
+	ctx.hparams.Each(func(name string, param model.Hyperparameter) {
+		// Each hyperparameter is modeled only on the observations in which it was active, which
+		// gives the tree structure of the estimator.
+		if param.ConditionMet(results) {
+			results[name] = s.sampleOne(ctx.rand, name, param, good, bad)
+		}
+	})
+	return results
+}
+
+// sampleOne draws NumCandidates values for a single hyperparameter from the density of good
+// observations l(x) and returns the one that maximizes l(x) / g(x), where g(x) is the density of
+// the remaining observations; this is equivalent to maximizing the expected improvement.
+func (s *tpeSearch) sampleOne(
+	rand *nprand.State, name string, param model.Hyperparameter, good, bad []tpeObservation,
+) interface{} {
+	switch {
+	case param.ConstHyperparameter != nil:
+		return param.ConstHyperparameter.Val
+	case param.CategoricalHyperparameter != nil:
+		vals := param.CategoricalHyperparameter.Vals
+		l := newCategoricalEstimator(vals, observedIndices(name, vals, good), s.PriorWeight)
+		g := newCategoricalEstimator(vals, observedIndices(name, vals, bad), s.PriorWeight)
+		var best int
+		var bestScore float64
+		for i := 0; i < s.NumCandidates; i++ {
+			candidate := l.sample(rand)
+			score := math.Log(l.probs[candidate]) - math.Log(g.probs[candidate])
+			if i == 0 || score > bestScore {
+				best, bestScore = candidate, score
+			}
+		}
+		return vals[best]
+	}
+
+	var lo, hi float64
+	var toInternal func(interface{}) float64
+	var fromInternal func(float64) interface{}
+	switch {
+	case param.IntHyperparameter != nil:
+		p := param.IntHyperparameter
+		lo, hi = float64(p.Minval), float64(p.Maxval)
+		toInternal = func(v interface{}) float64 { return float64(v.(int)) }
+		fromInternal = func(x float64) interface{} {
+			return intClamp(int(math.Round(x)), p.Minval, p.Maxval)
+		}
+	case param.DoubleHyperparameter != nil:
+		p := param.DoubleHyperparameter
+		lo, hi = p.Minval, p.Maxval
+		toInternal = func(v interface{}) float64 { return v.(float64) }
+		fromInternal = func(x float64) interface{} { return x }
+	case param.LogHyperparameter != nil:
+		// Log hyperparameters are modeled in terms of their exponent.
+		p := param.LogHyperparameter
+		lo, hi = p.Minval, p.Maxval
+		toInternal = func(v interface{}) float64 { return math.Log(v.(float64)) / math.Log(p.Base) }
+		fromInternal = func(x float64) interface{} { return math.Pow(p.Base, x) }
+	default:
+		panic(fmt.Sprintf("unexpected hyperparameter type: %+v", param))
+	}
+
+	observed := func(obs []tpeObservation) []float64 {
+		var xs []float64
+		for _, o := range obs {
+			if v, ok := o.params[name]; ok {
+				xs = append(xs, toInternal(v))
+			}
+		}
+		return xs
+	}
+	l := newParzenEstimator(observed(good), lo, hi, s.PriorWeight)
+	g := newParzenEstimator(observed(bad), lo, hi, s.PriorWeight)
+	var best, bestScore float64
+	for i := 0; i < s.NumCandidates; i++ {
+		candidate := l.sample(rand)
+		score := l.logPDF(candidate) - g.logPDF(candidate)
+		if i == 0 || score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	return fromInternal(best)
+}
+
+// observedIndices returns the index into vals of each observation's value of the named
+// hyperparameter.
+func observedIndices(name string, vals []interface{}, obs []tpeObservation) []int {
+	var indices []int
+	for _, o := range obs {
+		v, ok := o.params[name]
+		if !ok {
+			continue
+		}
+		for i, val := range vals {
+			if reflect.DeepEqual(v, val) {
+				indices = append(indices, i)
+				break
+			}
+		}
+	}
+	return indices
+}
+
+// categoricalEstimator is a smoothed histogram over the levels of a categorical hyperparameter.
+type categoricalEstimator struct {
+	probs []float64
+}
+
+func newCategoricalEstimator(
+	vals []interface{}, observed []int, priorWeight float64,
+) categoricalEstimator {
+	probs := make([]float64, len(vals))
+	total := priorWeight
+	for i := range probs {
+		probs[i] = priorWeight / float64(len(vals))
+	}
+	for _, i := range observed {
+		probs[i]++
+		total++
+	}
+	for i := range probs {
+		probs[i] /= total
+	}
+	return categoricalEstimator{probs: probs}
+}
+
+func (c categoricalEstimator) sample(rand *nprand.State) int {
+	u := rand.UnitInterval()
+	for i, p := range c.probs {
+		if u < p {
+			return i
+		}
+		u -= p
+	}
+	return len(c.probs) - 1
+}
+
+// parzenEstimator is a mixture of Gaussians truncated to [lo, hi], with one component centered on
+// each observation and one wide prior component centered on the middle of the range.
+type parzenEstimator struct {
+	lo, hi  float64
+	mus     []float64
+	sigmas  []float64
+	weights []float64
+}
+
+func newParzenEstimator(observed []float64, lo, hi, priorWeight float64) parzenEstimator {
+	prior := (lo + hi) / 2
+	width := hi - lo
+
+	type component struct {
+		mu     float64
+		weight float64
+		prior  bool
+	}
+	components := []component{{mu: prior, weight: priorWeight, prior: true}}
+	for _, x := range observed {
+		components = append(components, component{mu: x, weight: 1})
+	}
+	sort.SliceStable(components, func(i, j int) bool {
+		return components[i].mu < components[j].mu
+	})
+
+	// Each component's bandwidth is the distance to its farthest neighbor, clipped so that
+	// components are neither too narrow nor wider than the prior.
+	minSigma := width / math.Min(100, float64(len(components)))
+	e := parzenEstimator{lo: lo, hi: hi}
+	var totalWeight float64
+	for i, c := range components {
+		sigma := width
+		if !c.prior {
+			left, right := lo, hi
+			if i > 0 {
+				left = components[i-1].mu
+			}
+			if i < len(components)-1 {
+				right = components[i+1].mu
+			}
+			sigma = math.Min(math.Max(math.Max(c.mu-left, right-c.mu), minSigma), width)
+		}
+		e.mus = append(e.mus, c.mu)
+		e.sigmas = append(e.sigmas, sigma)
+		e.weights = append(e.weights, c.weight)
+		totalWeight += c.weight
+	}
+	for i := range e.weights {
+		e.weights[i] /= totalWeight
+	}
+	return e
+}
+
+// maxTruncatedNormalTries bounds the rejection sampling in parzenEstimator.sample; since every
+// component has a sizeable fraction of its mass within the range, this is very rarely reached.
+const maxTruncatedNormalTries = 100
+
+func (e parzenEstimator) sample(rand *nprand.State) float64 {
+	i := 0
+	u := rand.UnitInterval()
+	for ; i < len(e.weights)-1; i++ {
+		if u < e.weights[i] {
+			break
+		}
+		u -= e.weights[i]
+	}
+	var x float64
+	for try := 0; try < maxTruncatedNormalTries; try++ {
+		x = e.mus[i] + e.sigmas[i]*standardNormal(rand)
+		if x >= e.lo && x <= e.hi {
+			return x
+		}
+	}
+	return doubleClamp(x, e.lo, e.hi)
+}
+
+func (e parzenEstimator) logPDF(x float64) float64 {
+	var p float64
+	for i, mu := range e.mus {
+		sigma := e.sigmas[i]
+		mass := normalCDF((e.hi-mu)/sigma) - normalCDF((e.lo-mu)/sigma)
+		z := (x - mu) / sigma
+		p += e.weights[i] * math.Exp(-z*z/2) / (sigma * math.Sqrt(2*math.Pi) * mass)
+	}
+	return math.Log(p)
+}
+
+// standardNormal samples from a standard normal distribution using the Box-Muller transform.
+func standardNormal(rand *nprand.State) float64 {
+	u1 := 1 - rand.UnitInterval()
+	u2 := rand.UnitInterval()
+	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
+}
+
+func normalCDF(x float64) float64 {
+	return (1 + math.Erf(x/math.Sqrt2)) / 2
+}
+
+type tpeSearchSnapshot struct {
+	TrialParams   map[RequestID]hparamSample `json:"trial_params"`
+	Observations  []tpeObservationSnapshot   `json:"observations"`
+	TrialsCreated int                        `json:"trials_created"`
+}
+
+type tpeObservationSnapshot struct {
+	RequestID RequestID    `json:"request_id"`
+	Params    hparamSample `json:"params"`
+	Metric    float64      `json:"metric"`
+}
+
+func (s *tpeSearch) snapshot() (json.RawMessage, error) {
+	snapshot := tpeSearchSnapshot{
+		TrialParams:   s.trialParams,
+		TrialsCreated: s.trialsCreated,
+	}
+	for _, o := range s.observations {
+		snapshot.Observations = append(snapshot.Observations, tpeObservationSnapshot{
+			RequestID: o.requestID,
+			Params:    o.params,
+			Metric:    o.metric,
+		})
+	}
+	return json.Marshal(snapshot)
+}
+
+func (s *tpeSearch) restore(ctx context, state json.RawMessage) error {
+	var snapshot tpeSearchSnapshot
+	if err := json.Unmarshal(state, &snapshot); err != nil {
+		return err
+	}
+	for requestID, params := range snapshot.TrialParams {
+		s.trialParams[requestID] = restoreSample(ctx.hparams, params)
+	}
+	s.observations = nil
+	for _, o := range snapshot.Observations {
+		s.observations = append(s.observations, tpeObservation{
+			requestID: o.RequestID,
+			params:    restoreSample(ctx.hparams, o.Params),
+			metric:    o.Metric,
+		})
+	}
+	s.trialsCreated = snapshot.TrialsCreated
+	return nil
+}
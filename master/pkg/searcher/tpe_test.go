@@ -0,0 +1,147 @@
+package searcher
+
+import (
+	"math"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/nprand"
+	"github.com/determined-ai/determined/master/pkg/workload"
+)
+
+func defaultTPEConfig() model.TPEConfig {
+	return *model.DefaultExperimentConfig(nil).Searcher.TPEConfig
+}
+
+func tpeTestHyperparameters() model.Hyperparameters {
+	count := 3
+	return model.Hyperparameters{
+		"global_batch_size": {ConstHyperparameter: &model.ConstHyperparameter{Val: 64}},
+		"x":                 {DoubleHyperparameter: &model.DoubleHyperparameter{Minval: 0, Maxval: 1}},
+		"layers": {IntHyperparameter: &model.IntHyperparameter{
+			Minval: 1, Maxval: 8, Count: &count,
+		}},
+		"lr": {LogHyperparameter: &model.LogHyperparameter{Minval: -5, Maxval: -1, Base: 10}},
+		"optimizer": {CategoricalHyperparameter: &model.CategoricalHyperparameter{
+			Vals: []interface{}{"adam", "sgd", "rmsprop"},
+		}},
+	}
+}
+
+func TestTPESearcherRecords(t *testing.T) {
+	conf := defaultTPEConfig()
+	conf.Metric = defaultMetric
+	conf.MaxTrials = 12
+	conf.NumStartupTrials = 4
+	conf.MaxLength = model.NewLengthInRecords(19200)
+	expected := make([][]Runnable, conf.MaxTrials)
+	for i := range expected {
+		expected[i] = toOps("19200R V")
+	}
+	checkSimulation(t, newTPESearch(conf), tpeTestHyperparameters(), RandomValidation, expected)
+}
+
+func TestTPESearcherReproducibility(t *testing.T) {
+	conf := defaultTPEConfig()
+	conf.Metric = defaultMetric
+	conf.MaxTrials = 20
+	conf.NumStartupTrials = 5
+	conf.MaxLength = model.NewLengthInBatches(300)
+	gen := func() SearchMethod { return newTPESearch(conf) }
+	checkReproducibility(t, gen, tpeTestHyperparameters(), defaultMetric)
+}
+
+func TestTPESearchMethod(t *testing.T) {
+	testCases := []valueSimulationTestCase{
+		{
+			name: "test tpe search method",
+			expectedTrials: []predefinedTrial{
+				newConstantPredefinedTrial(toOps("500B V"), .3),
+				newConstantPredefinedTrial(toOps("500B V"), .2),
+				newEarlyExitPredefinedTrial(toOps("500B"), .1),
+				newConstantPredefinedTrial(toOps("500B V"), .1),
+				newConstantPredefinedTrial(toOps("500B V"), .4),
+			},
+			hparams: tpeTestHyperparameters(),
+			config: model.SearcherConfig{
+				TPEConfig: &model.TPEConfig{
+					Metric:              "error",
+					SmallerIsBetter:     true,
+					MaxLength:           model.NewLengthInBatches(500),
+					MaxTrials:           5,
+					MaxConcurrentTrials: 2,
+					NumStartupTrials:    2,
+					NumCandidates:       24,
+					Gamma:               0.25,
+					PriorWeight:         1.0,
+				},
+			},
+		},
+	}
+
+	runValueSimulationTestCases(t, testCases)
+}
+
+// TestTPESearcherConverges checks that, once the model has been fit, the sampled points are
+// concentrated around the optimum of a simple objective.
+func TestTPESearcherConverges(t *testing.T) {
+	conf := defaultTPEConfig()
+	conf.Metric = defaultMetric
+	conf.MaxTrials = 60
+	conf.MaxConcurrentTrials = 1
+	conf.MaxLength = model.NewLengthInBatches(100)
+	hparams := model.Hyperparameters{
+		"x": {DoubleHyperparameter: &model.DoubleHyperparameter{Minval: 0, Maxval: 1}},
+	}
+	objective := func(x float64) float64 { return math.Abs(x - 0.8) }
+
+	method := newTPESearch(conf)
+	ctx := context{rand: nprand.New(0), hparams: hparams}
+	ops, err := method.initialOperations(ctx)
+	assert.NilError(t, err)
+
+	var distances []float64
+	for len(ops) > 0 {
+		create := ops[0].(Create)
+		x := create.Hparams["x"].(float64)
+		distances = append(distances, objective(x))
+		metrics := workload.ValidationMetrics{
+			Metrics: map[string]interface{}{defaultMetric: objective(x)},
+		}
+		ops, err = method.validationCompleted(ctx, create.RequestID, Validate{}, metrics)
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, len(distances), conf.MaxTrials)
+
+	mean := func(xs []float64) float64 {
+		var sum float64
+		for _, x := range xs {
+			sum += x
+		}
+		return sum / float64(len(xs))
+	}
+	random := mean(distances[:conf.NumStartupTrials])
+	modeled := mean(distances[len(distances)-conf.NumStartupTrials:])
+	assert.Assert(t, modeled < random/2, "modeled %f, random %f", modeled, random)
+}
+
+func TestParzenEstimatorDensity(t *testing.T) {
+	e := newParzenEstimator([]float64{0.2, 0.25, 0.3}, 0, 1, 1.0)
+	// The density should integrate to (approximately) one over the range.
+	var integral float64
+	steps := 10000
+	for i := 0; i < steps; i++ {
+		x := (float64(i) + 0.5) / float64(steps)
+		integral += math.Exp(e.logPDF(x)) / float64(steps)
+	}
+	assert.Assert(t, math.Abs(integral-1) < 1e-3, "integral %f", integral)
+	assert.Assert(t, e.logPDF(0.25) > e.logPDF(0.9))
+
+	rand := nprand.New(0)
+	for i := 0; i < 1000; i++ {
+		x := e.sample(rand)
+		assert.Assert(t, x >= 0 && x <= 1, "sample %f out of range", x)
+	}
+}
@@ -65,17 +65,48 @@ func Marshal(v interface{}) ([]byte, error) {
 			jsonTagValue = field.Name
 			fallthrough
 		default:
-			if strings.Contains(jsonTagValue, ",") {
+			name, options := jsonTagValue, ""
+			if idx := strings.Index(jsonTagValue, ","); idx != -1 {
+				name, options = jsonTagValue[:idx], jsonTagValue[idx+1:]
+			}
+			switch {
+			case options == "omitempty":
+				if isEmptyValue(value.Field(i)) {
+					continue
+				}
+			case options != "":
 				return nil, errors.New(
 					"advanced json tag features not support in union type marshaling")
 			}
-			data[jsonTagValue] = value.Field(i).Interface()
+			if name == "" {
+				name = field.Name
+			}
+			data[name] = value.Field(i).Interface()
 		}
 	}
 
 	return json.Marshal(data)
 }
 
+// isEmptyValue returns whether the value is omitted by the omitempty json tag option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
 // marshalToMap returns a map representation of the provided interface.
 func marshalToMap(v interface{}) (map[string]interface{}, error) {
 	bytes, err := json.Marshal(v)
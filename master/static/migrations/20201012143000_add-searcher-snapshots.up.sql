@@ -0,0 +1,7 @@
+-- Each running experiment keeps the latest snapshot of its searcher state, so that restoring the
+-- experiment only has to replay the searcher events that come after event_id.
+CREATE TABLE public.searcher_snapshots (
+    experiment_id integer NOT NULL PRIMARY KEY REFERENCES public.experiments(id),
+    event_id integer NOT NULL,
+    content jsonb NOT NULL
+);
@@ -0,0 +1,6 @@
+DROP TABLE public.role_assignments;
+DROP TYPE public.role;
+DROP TABLE public.user_group_membership;
+DROP TABLE public.groups;
+ALTER TABLE public.experiments DROP COLUMN workspace_id;
+DROP TABLE public.workspaces;
@@ -0,0 +1,37 @@
+-- Workspaces scope the experiments that roles apply to; experiments without a workspace are
+-- governed by the roles of users in the cluster as a whole.
+CREATE TABLE public.workspaces (
+    id SERIAL PRIMARY KEY,
+    name text NOT NULL UNIQUE
+);
+
+ALTER TABLE public.experiments
+    ADD COLUMN workspace_id integer NULL REFERENCES public.workspaces(id);
+
+CREATE TABLE public.groups (
+    id SERIAL PRIMARY KEY,
+    group_name text NOT NULL UNIQUE
+);
+
+CREATE TABLE public.user_group_membership (
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    group_id integer NOT NULL REFERENCES public.groups(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, group_id)
+);
+
+CREATE TYPE public.role AS ENUM (
+    'viewer',
+    'editor',
+    'admin'
+);
+
+-- A role is bound to either a user or a group, in either a workspace or the whole cluster when
+-- workspace_id is NULL.
+CREATE TABLE public.role_assignments (
+    id SERIAL PRIMARY KEY,
+    role public.role NOT NULL,
+    workspace_id integer NULL REFERENCES public.workspaces(id) ON DELETE CASCADE,
+    user_id integer NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    group_id integer NULL REFERENCES public.groups(id) ON DELETE CASCADE,
+    CHECK ((user_id IS NULL) != (group_id IS NULL))
+);
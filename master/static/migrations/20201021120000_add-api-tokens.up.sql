@@ -0,0 +1,13 @@
+-- API tokens authenticate automation such as CI pipelines as a user. Only the SHA-256 hash of
+-- each token is stored.
+CREATE TABLE public.api_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    token_hash text NOT NULL UNIQUE,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    expiry timestamp with time zone NULL,
+    last_used timestamp with time zone NULL,
+    read_only boolean NOT NULL DEFAULT false,
+    workspace_id integer NULL REFERENCES public.workspaces(id) ON DELETE CASCADE
+);
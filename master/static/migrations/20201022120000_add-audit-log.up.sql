@@ -0,0 +1,22 @@
+-- The audit log records every request that changes the state of the cluster, whether it was
+-- allowed or not. The username is kept alongside the user ID so that entries remain readable for
+-- requests that were not authenticated.
+CREATE TABLE public.audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    time timestamp with time zone NOT NULL DEFAULT now(),
+    user_id integer NULL REFERENCES public.users(id) ON DELETE SET NULL,
+    username text NOT NULL DEFAULT '',
+    api_token_id integer NULL REFERENCES public.api_tokens(id) ON DELETE SET NULL,
+    transport text NOT NULL,
+    action text NOT NULL,
+    resource_type text NOT NULL DEFAULT '',
+    resource_id text NOT NULL DEFAULT '',
+    payload_digest text NOT NULL DEFAULT '',
+    source_ip text NOT NULL DEFAULT '',
+    outcome text NOT NULL,
+    status text NOT NULL
+);
+
+CREATE INDEX ix_audit_log_time ON public.audit_log USING btree (time);
+CREATE INDEX ix_audit_log_username ON public.audit_log USING btree (username);
+CREATE INDEX ix_audit_log_resource ON public.audit_log USING btree (resource_type, resource_id);
@@ -0,0 +1,13 @@
+-- Commands, notebooks, shells and TensorBoards are recorded so that the master can account for
+-- them after it restarts. Rows of tasks that have not exited have no end time.
+CREATE TABLE public.commands (
+    task_id text PRIMARY KEY,
+    type text NOT NULL,
+    owner_id integer NOT NULL REFERENCES public.users(id),
+    config jsonb NOT NULL,
+    registered_time timestamp with time zone NOT NULL,
+    end_time timestamp with time zone NULL,
+    exit_status text NULL
+);
+
+CREATE INDEX ix_commands_type_end_time ON public.commands USING btree (type, end_time);
@@ -0,0 +1,20 @@
+-- Slot usage records the slots that the resource managers allocated to each task, so that usage
+-- and cost can be reported per user, experiment, label and resource pool. Rows of allocations that
+-- have not been released have no end time.
+CREATE TABLE public.slot_usage (
+    id BIGSERIAL PRIMARY KEY,
+    task_id text NOT NULL,
+    task_name text NOT NULL DEFAULT '',
+    username text NOT NULL DEFAULT '',
+    experiment_id integer NULL REFERENCES public.experiments(id) ON DELETE SET NULL,
+    labels text[] NOT NULL DEFAULT '{}',
+    resource_pool text NOT NULL DEFAULT '',
+    slots integer NOT NULL,
+    slot_hourly_rate double precision NULL,
+    start_time timestamp with time zone NOT NULL,
+    end_time timestamp with time zone NULL
+);
+
+CREATE INDEX ix_slot_usage_task_id ON public.slot_usage USING btree (task_id);
+CREATE INDEX ix_slot_usage_experiment_id ON public.slot_usage USING btree (experiment_id);
+CREATE INDEX ix_slot_usage_time ON public.slot_usage USING btree (start_time, end_time);
@@ -0,0 +1,10 @@
+-- Users that sign in through an OpenID Connect identity provider are linked to the issuer and
+-- subject of their ID tokens, which, unlike usernames, are assigned by the identity provider and
+-- never reused.
+CREATE TABLE public.oidc_identities (
+    issuer text NOT NULL,
+    subject text NOT NULL,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    PRIMARY KEY (issuer, subject),
+    UNIQUE (user_id, issuer)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE public.trials DROP COLUMN task_id;
+
+DROP TABLE public.task_containers;
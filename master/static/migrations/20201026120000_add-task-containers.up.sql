@@ -0,0 +1,13 @@
+-- The containers of running tasks are recorded so that the master can reattach to them after it
+-- restarts, as long as their agents keep them running. Rows are deleted once the task releases its
+-- resources. Trials record the task ID of their current run to find their containers.
+CREATE TABLE public.task_containers (
+    id text PRIMARY KEY,
+    task_id text NOT NULL,
+    resource_pool text NOT NULL,
+    rank integer NOT NULL
+);
+
+CREATE INDEX ix_task_containers_task_id ON public.task_containers USING btree (task_id);
+
+ALTER TABLE public.trials ADD COLUMN task_id text NULL;
@@ -1,4 +1,5 @@
 SELECT
-    distinct e.config->'labels' AS labels
+    e.id AS id,
+    e.config->'labels' AS labels
 FROM
     experiments e